@@ -69,6 +69,7 @@ var updateTextMap = map[apitype.UpdateKind]struct {
 	apitype.DestroyUpdate:        {"destroy", "Destroying"},
 	apitype.StackImportUpdate:    {"stack import", "Importing"},
 	apitype.ResourceImportUpdate: {"import", "Importing"},
+	apitype.StackRestoreUpdate:   {"stack restore", "Restoring"},
 }
 
 type response string
@@ -120,7 +121,7 @@ func PreviewThenPrompt(ctx context.Context, kind apitype.UpdateKind, stack Stack
 	}
 
 	// If there are no changes, or we're auto-approving or just previewing, we can skip the confirmation prompt.
-	if op.Opts.AutoApprove || kind == apitype.PreviewUpdate {
+	if op.Opts.AutoApprove || op.Opts.PreviewOnly || kind == apitype.PreviewUpdate {
 		close(eventsChannel)
 		// If we're running in experimental mode then return the plan generated, else discard it. The user may
 		// be explicitly setting a plan but that's handled higher up the call stack.
@@ -227,7 +228,7 @@ func PreviewThenPromptThenExecute(ctx context.Context, kind apitype.UpdateKind,
 		}
 
 		plan, changes, res := PreviewThenPrompt(ctx, kind, stack, op, apply)
-		if res != nil || kind == apitype.PreviewUpdate {
+		if res != nil || op.Opts.PreviewOnly || kind == apitype.PreviewUpdate {
 			return changes, res
 		}
 
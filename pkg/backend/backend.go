@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -225,6 +226,41 @@ type SpecificDeploymentExporter interface {
 	ExportDeploymentForVersion(ctx context.Context, stack Stack, version string) (*apitype.UntypedDeployment, error)
 }
 
+// DeploymentRestorer is an interface defining an additional capability of a Backend, specifically the ability to
+// restore a previous version of a stack's deployment while recording the restore in the stack's history. Backends
+// that don't implement it can still restore a version by importing its deployment. This isn't a requirement for all
+// backends and should be checked for dynamically.
+type DeploymentRestorer interface {
+	// RestoreDeployment imports the given deployment, exported from the given version of the stack's history, as the
+	// stack's current state.
+	RestoreDeployment(ctx context.Context, stack Stack, version string, deployment *apitype.UntypedDeployment) error
+}
+
+// SnapshotImporter is an interface defining an additional capability of a Backend, specifically the ability to import
+// a snapshot as a stack's state without first serializing it into a deployment. This keeps memory use down when
+// importing very large stacks. This isn't a requirement for all backends and should be checked for dynamically.
+type SnapshotImporter interface {
+	// ImportSnapshot imports the given snapshot as the stack's current state.
+	ImportSnapshot(ctx context.Context, stack Stack, snap *deploy.Snapshot) error
+}
+
+// DeploymentStreamer is an interface defining an additional capability of a Backend, specifically the ability to write
+// a stack's deployment, as returned by ExportDeployment, to a writer as it is read from storage. This keeps memory use
+// down when exporting very large stacks. This isn't a requirement for all backends and should be checked for
+// dynamically.
+type DeploymentStreamer interface {
+	// StreamDeployment writes the stack's current deployment to w as an untyped deployment.
+	StreamDeployment(ctx context.Context, stack Stack, w io.Writer) error
+}
+
+// HistoryImporter is an interface defining an additional capability of a Backend, specifically the ability to record
+// updates that were made elsewhere, such as in another backend that the stack was migrated from, in a stack's history.
+// This isn't a requirement for all backends and should be checked for dynamically.
+type HistoryImporter interface {
+	// ImportHistory records the given updates, oldest first, in the stack's history.
+	ImportHistory(ctx context.Context, stack Stack, updates []UpdateInfo) error
+}
+
 // UpdateOperation is a complete stack update operation (preview, update, import, refresh, or destroy).
 type UpdateOperation struct {
 	Proj               *workspace.Project
@@ -266,6 +302,8 @@ type UpdateOptions struct {
 	AutoApprove bool
 	// SkipPreview, when true, causes the preview step to be skipped.
 	SkipPreview bool
+	// PreviewOnly, when true, causes the operation to stop after the preview step.
+	PreviewOnly bool
 }
 
 // QueryOptions configures a query to operate against a backend and the engine.
@@ -0,0 +1,67 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// SaveCheckpoint saves the given snapshot as the checkpoint of the given stack, for backends that store checkpoints
+// themselves. write is passed a function that encodes the checkpoint, with its secrets encrypted by the given secrets
+// manager, to a writer, so that the checkpoint can be streamed to where it is stored; it returns a description of
+// that location for error messages.
+//
+// Unless verify is false, the integrity of the snapshot is checked *after* it is written, since it may contain
+// resource state updates that shouldn't be lost; the error then says that the checkpoint was written anyway.
+func SaveCheckpoint(
+	stackName tokens.QName, snap *deploy.Snapshot, sm secrets.Manager, verify bool,
+	write func(encode func(w io.Writer) error) (location string, err error),
+) error {
+	location, err := write(func(w io.Writer) error {
+		if err := stack.EncodeCheckpoint(w, stackName, snap, sm, false /* showSecrets */); err != nil {
+			return fmt.Errorf("serializing checkpoint: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if verify {
+		if verifyerr := snap.VerifyIntegrity(); verifyerr != nil {
+			return fmt.Errorf("%s: snapshot integrity failure; it was already written, but is invalid: %w",
+				location, verifyerr)
+		}
+	}
+	return nil
+}
+
+// VerifyLoadedSnapshot checks the integrity of a snapshot that was loaded from the given location, to catch bugs
+// early, unless verify is false.
+func VerifyLoadedSnapshot(snap *deploy.Snapshot, location string, verify bool) error {
+	if !verify {
+		return nil
+	}
+	if verifyerr := snap.VerifyIntegrity(); verifyerr != nil {
+		return fmt.Errorf("%s: snapshot integrity failure; refusing to use it: %w", location, verifyerr)
+	}
+	return nil
+}
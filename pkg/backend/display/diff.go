@@ -111,6 +111,10 @@ func RenderDiffEvent(event engine.Event, seen map[resource.URN]engine.StepEventM
 		// that the display is appropriate for both.
 	case engine.ResourceOperationFailed:
 		return renderDiffResourceOperationFailedEvent(event.Payload().(engine.ResourceOperationFailedPayload), opts)
+	case engine.ResourceRetryEvent:
+		return renderDiffResourceRetryEvent(event.Payload().(engine.ResourceRetryEventPayload), opts)
+	case engine.ResourceHookEvent:
+		return renderDiffResourceHookEvent(event.Payload().(engine.ResourceHookEventPayload), opts)
 	case engine.ResourceOutputsEvent:
 		return renderDiffResourceOutputsEvent(event.Payload().(engine.ResourceOutputsEventPayload), seen, opts)
 	case engine.ResourcePreEvent:
@@ -299,6 +303,22 @@ func renderDiffResourceOperationFailedEvent(
 	return ""
 }
 
+func renderDiffResourceRetryEvent(payload engine.ResourceRetryEventPayload, opts Options) string {
+	if !shouldShow(payload.Metadata, opts) {
+		return ""
+	}
+	return opts.Color.Colorize(fmt.Sprintf("%s    %s: retrying (%d/%d) after error: %s%s\n", colors.SpecInfo,
+		payload.Metadata.URN, payload.Attempt, payload.MaxAttempts, payload.Message, colors.Reset))
+}
+
+func renderDiffResourceHookEvent(payload engine.ResourceHookEventPayload, opts Options) string {
+	if !shouldShow(payload.Metadata, opts) {
+		return ""
+	}
+	return opts.Color.Colorize(fmt.Sprintf("%s    %s: running %s hook: %s%s\n", colors.SpecInfo,
+		payload.Metadata.URN, payload.Hook, payload.Command, colors.Reset))
+}
+
 func renderDiff(
 	out io.Writer,
 	metadata engine.StepEventMetadata,
@@ -70,7 +70,9 @@ func ShowEvents(
 	streamPreview := cmdutil.IsTruthy(os.Getenv("PULUMI_ENABLE_STREAMING_JSON_PREVIEW"))
 
 	if opts.JSONDisplay {
-		if isPreview && !streamPreview {
+		if isPreview && opts.DriftReport {
+			ShowDriftReport(events, done, opts)
+		} else if isPreview && !streamPreview {
 			ShowPreviewDigest(events, done, opts)
 		} else {
 			ShowJSONEvents(events, done, opts)
@@ -0,0 +1,94 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// ShowDriftReport renders engine events from a refresh preview into a well-formed JSON drift report. Like
+// ShowPreviewDigest, this does not emit anything until the event stream is closed.
+func ShowDriftReport(events <-chan engine.Event, done chan<- bool, opts Options) {
+	// Ensure we close the done channel before exiting.
+	defer func() { close(done) }()
+
+	report := display.DriftReport{Summary: map[display.DriftStatus]int{}}
+	for e := range events {
+		// In the event of cancellation, break out of the loop immediately.
+		if e.Type == engine.CancelEvent {
+			break
+		}
+
+		switch e.Type {
+		case engine.DiagEvent:
+			// Skip any ephemeral or debug messages, and elide all colorization.
+			p := e.Payload().(engine.DiagEventPayload)
+			if !p.Ephemeral && p.Severity != diag.Debug {
+				report.Diagnostics = append(report.Diagnostics, display.PreviewDiagnostic{
+					URN:      p.URN,
+					Message:  colors.Never.Colorize(p.Prefix + p.Message),
+					Severity: p.Severity,
+				})
+			}
+		case engine.ResourceOutputsEvent:
+			if r, ok := driftResource(e.Payload().(engine.ResourceOutputsEventPayload).Metadata); ok {
+				report.Resources = append(report.Resources, r)
+				report.Summary[r.Status]++
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent(&report, "", "    ")
+	contract.Assertf(err == nil, "unexpected JSON error: %v", err)
+	fmt.Println(string(out))
+}
+
+// driftResource classifies the result of refreshing a resource. Only custom resources managed by a provider are
+// classified, as refreshing component and provider resources never changes them.
+func driftResource(m engine.StepEventMetadata) (display.DriftResource, bool) {
+	if m.Old == nil || !m.Old.Custom || providers.IsProviderType(m.Type) {
+		return display.DriftResource{}, false
+	}
+
+	r := display.DriftResource{URN: m.URN}
+	switch m.Op {
+	case deploy.OpSame:
+		r.Status = display.DriftInSync
+	case deploy.OpUpdate:
+		r.Status = display.DriftDrifted
+		if m.New != nil {
+			diff := plugin.NewDetailedDiffFromObjectDiff(m.Old.State.Outputs.Diff(m.New.State.Outputs))
+			r.DetailedDiff = make(map[string]display.PropertyDiff, len(diff))
+			for k, v := range diff {
+				r.DetailedDiff[k] = display.PropertyDiff{Kind: v.Kind.String()}
+			}
+		}
+	case deploy.OpDelete:
+		r.Status = display.DriftDeleted
+	default:
+		return display.DriftResource{}, false
+	}
+	return r, true
+}
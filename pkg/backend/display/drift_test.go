@@ -0,0 +1,116 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+func TestDriftResource(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.URN("urn:pulumi:stack::project::pkgA:m:typA::resA")
+	state := func(typ tokens.Type, custom bool, outputs resource.PropertyMap) *engine.StepEventStateMetadata {
+		return &engine.StepEventStateMetadata{
+			Type:   typ,
+			Custom: custom,
+			State:  &resource.State{Type: typ, Custom: custom, Outputs: outputs},
+		}
+	}
+	old := state("pkgA:m:typA", true, resource.NewPropertyMapFromMap(map[string]interface{}{
+		"foo": "bar",
+		"baz": 1,
+	}))
+
+	tests := []struct {
+		name     string
+		metadata engine.StepEventMetadata
+		expected *display.DriftResource
+	}{
+		{
+			name:     "in sync",
+			metadata: engine.StepEventMetadata{Op: deploy.OpSame, URN: urn, Type: "pkgA:m:typA", Old: old, New: old},
+			expected: &display.DriftResource{URN: urn, Status: display.DriftInSync},
+		},
+		{
+			name: "drifted",
+			metadata: engine.StepEventMetadata{
+				Op:   deploy.OpUpdate,
+				URN:  urn,
+				Type: "pkgA:m:typA",
+				Old:  old,
+				New: state("pkgA:m:typA", true, resource.NewPropertyMapFromMap(map[string]interface{}{
+					"foo": "qux",
+					"bar": true,
+				})),
+			},
+			expected: &display.DriftResource{
+				URN:    urn,
+				Status: display.DriftDrifted,
+				DetailedDiff: map[string]display.PropertyDiff{
+					"foo": {Kind: "update"},
+					"bar": {Kind: "add"},
+					"baz": {Kind: "delete"},
+				},
+			},
+		},
+		{
+			name:     "deleted",
+			metadata: engine.StepEventMetadata{Op: deploy.OpDelete, URN: urn, Type: "pkgA:m:typA", Old: old},
+			expected: &display.DriftResource{URN: urn, Status: display.DriftDeleted},
+		},
+		{
+			name: "component",
+			metadata: engine.StepEventMetadata{
+				Op:   deploy.OpSame,
+				URN:  urn,
+				Type: "my:component:Comp",
+				Old:  state("my:component:Comp", false, nil),
+			},
+		},
+		{
+			name: "provider",
+			metadata: engine.StepEventMetadata{
+				Op:   deploy.OpSame,
+				URN:  urn,
+				Type: "pulumi:providers:pkgA",
+				Old:  state("pulumi:providers:pkgA", true, nil),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, ok := driftResource(tt.metadata)
+			if tt.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, *tt.expected, actual)
+		})
+	}
+}
@@ -143,6 +143,29 @@ func ConvertEngineEvent(e engine.Event, showSecrets bool) (apitype.EngineEvent,
 			Steps:    p.Steps,
 		}
 
+	case engine.ResourceRetryEvent:
+		p, ok := e.Payload().(engine.ResourceRetryEventPayload)
+		if !ok {
+			return apiEvent, eventTypePayloadMismatch
+		}
+		apiEvent.ResourceRetryEvent = &apitype.ResourceRetryEvent{
+			Metadata:    convertStepEventMetadata(p.Metadata, showSecrets),
+			Attempt:     p.Attempt,
+			MaxAttempts: p.MaxAttempts,
+			Message:     p.Message,
+		}
+
+	case engine.ResourceHookEvent:
+		p, ok := e.Payload().(engine.ResourceHookEventPayload)
+		if !ok {
+			return apiEvent, eventTypePayloadMismatch
+		}
+		apiEvent.ResourceHookEvent = &apitype.ResourceHookEvent{
+			Metadata: convertStepEventMetadata(p.Metadata, showSecrets),
+			Hook:     string(p.Hook),
+			Command:  p.Command,
+		}
+
 	default:
 		return apiEvent, fmt.Errorf("unknown event type %q", e.Type)
 	}
@@ -324,6 +347,23 @@ func ConvertJSONEvent(apiEvent apitype.EngineEvent) (engine.Event, error) {
 			Steps:    p.Steps,
 		})
 
+	case apiEvent.ResourceRetryEvent != nil:
+		p := apiEvent.ResourceRetryEvent
+		event = engine.NewEvent(engine.ResourceRetryEvent, engine.ResourceRetryEventPayload{
+			Metadata:    convertJSONStepEventMetadata(p.Metadata),
+			Attempt:     p.Attempt,
+			MaxAttempts: p.MaxAttempts,
+			Message:     p.Message,
+		})
+
+	case apiEvent.ResourceHookEvent != nil:
+		p := apiEvent.ResourceHookEvent
+		event = engine.NewEvent(engine.ResourceHookEvent, engine.ResourceHookEventPayload{
+			Metadata: convertJSONStepEventMetadata(p.Metadata),
+			Hook:     resource.HookType(p.Hook),
+			Command:  p.Command,
+		})
+
 	default:
 		return event, errors.New("unknown event type")
 	}
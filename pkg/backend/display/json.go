@@ -195,7 +195,8 @@ func ShowPreviewDigest(events <-chan engine.Event, done chan<- bool, opts Option
 
 				digest.Steps = append(digest.Steps, step)
 			}
-		case engine.ResourceOutputsEvent, engine.ResourceOperationFailed:
+		case engine.ResourceOutputsEvent, engine.ResourceOperationFailed, engine.ResourceRetryEvent,
+			engine.ResourceHookEvent:
 		// Because we are only JSON serializing previews, we don't need to worry about outputs
 		// resolving or operations failing.
 
@@ -49,6 +49,7 @@ type Options struct {
 	IsInteractive        bool                // true if we should display things interactively.
 	Type                 Type                // type of display (rich diff, progress, or query).
 	JSONDisplay          bool                // true if we should emit the entire diff as JSON.
+	DriftReport          bool                // true if a JSON refresh preview should be emitted as a drift report.
 	EventLogPath         string              // the path to the file to use for logging events, if any.
 	Debug                bool                // true to enable debug output.
 	Stdin                io.Reader           // the reader to use for stdin. Defaults to os.Stdin if unset.
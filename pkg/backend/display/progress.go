@@ -205,6 +205,12 @@ func getEventUrnAndMetadata(event engine.Event) (resource.URN, *engine.StepEvent
 	case engine.ResourceOperationFailed:
 		payload := event.Payload().(engine.ResourceOperationFailedPayload)
 		return payload.Metadata.URN, &payload.Metadata
+	case engine.ResourceRetryEvent:
+		payload := event.Payload().(engine.ResourceRetryEventPayload)
+		return payload.Metadata.URN, &payload.Metadata
+	case engine.ResourceHookEvent:
+		payload := event.Payload().(engine.ResourceHookEventPayload)
+		return payload.Metadata.URN, &payload.Metadata
 	case engine.DiagEvent:
 		return event.Payload().(engine.DiagEventPayload).URN, nil
 	case engine.PolicyViolationEvent:
@@ -891,6 +897,12 @@ func (display *ProgressDisplay) processNormalEvent(event engine.Event) {
 		}
 	} else if event.Type == engine.ResourceOperationFailed {
 		row.SetFailed()
+	} else if event.Type == engine.ResourceRetryEvent {
+		payload := event.Payload().(engine.ResourceRetryEventPayload)
+		row.SetRetrying(payload.Attempt, payload.MaxAttempts)
+	} else if event.Type == engine.ResourceHookEvent {
+		payload := event.Payload().(engine.ResourceHookEventPayload)
+		row.SetRunningHook(payload.Hook)
 	} else if event.Type == engine.DiagEvent {
 		// also record this diagnostic so we print it at the end.
 		row.RecordDiagEvent(event)
@@ -57,6 +57,12 @@ type ResourceRow interface {
 
 	SetFailed()
 
+	// SetRetrying records that the resource's operation is being tried again after a transient failure.
+	SetRetrying(attempt, maxAttempts int)
+
+	// SetRunningHook records that a hook of the resource is running.
+	SetRunningHook(hook resource.HookType)
+
 	DiagInfo() *DiagInfo
 	PolicyPayloads() []engine.PolicyViolationEventPayload
 
@@ -125,6 +131,13 @@ type resourceRowData struct {
 	// If we failed this operation for any reason.
 	failed bool
 
+	// The attempt at this operation that is in progress and the maximum number of attempts, if it is being retried.
+	retryAttempt     int
+	retryMaxAttempts int
+
+	// The hook of this resource that is running, if any.
+	hook resource.HookType
+
 	diagInfo       *DiagInfo
 	policyPayloads []engine.PolicyViolationEventPayload
 
@@ -177,6 +190,14 @@ func (data *resourceRowData) SetFailed() {
 	data.failed = true
 }
 
+func (data *resourceRowData) SetRetrying(attempt, maxAttempts int) {
+	data.retryAttempt, data.retryMaxAttempts = attempt, maxAttempts
+}
+
+func (data *resourceRowData) SetRunningHook(hook resource.HookType) {
+	data.hook = hook
+}
+
 func (data *resourceRowData) DiagInfo() *DiagInfo {
 	return data.diagInfo
 }
@@ -310,6 +331,12 @@ func (data *resourceRowData) ColorizedColumns() []string {
 		columns[statusColumn] = data.display.getStepDoneDescription(step, failed)
 	} else {
 		columns[statusColumn] = data.display.getStepInProgressDescription(step)
+		if data.retryAttempt > 0 {
+			columns[statusColumn] += fmt.Sprintf(" retrying (%d/%d)", data.retryAttempt, data.retryMaxAttempts)
+		}
+		if data.hook != "" {
+			columns[statusColumn] += fmt.Sprintf(" running %s hook", data.hook)
+		}
 	}
 
 	columns[infoColumn] = data.getInfoColumn()
@@ -0,0 +1,227 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// ResourceStateDiff describes how the state of a single resource differs between two snapshots.
+type ResourceStateDiff struct {
+	// Op is deploy.OpCreate if the resource was added, deploy.OpDelete if it was removed and deploy.OpUpdate if its
+	// state changed.
+	Op display.StepOp
+	// Old is the resource's state in the old snapshot, if any.
+	Old *resource.State
+	// New is the resource's state in the new snapshot, if any.
+	New *resource.State
+	// Inputs is the diff between the resource's old and new inputs, if they changed.
+	Inputs *resource.ObjectDiff
+	// Outputs is the diff between the resource's old and new outputs, if they changed.
+	Outputs *resource.ObjectDiff
+	// Fields lists the other fields of the resource's state that changed, such as "id" or "parent".
+	Fields []string
+}
+
+// URN returns the URN of the resource the diff describes.
+func (d ResourceStateDiff) URN() resource.URN {
+	if d.New != nil {
+		return d.New.URN
+	}
+	return d.Old.URN
+}
+
+// State returns the most recent state of the resource the diff describes.
+func (d ResourceStateDiff) State() *resource.State {
+	if d.New != nil {
+		return d.New
+	}
+	return d.Old
+}
+
+// stateKey identifies a resource within a snapshot. A snapshot may contain a live resource and resources pending
+// deletion with the same URN.
+type stateKey struct {
+	urn    resource.URN
+	delete bool
+}
+
+// DiffSnapshots returns the resource-by-resource differences between two snapshots. Resources that are present in
+// both snapshots with the same state are omitted. Resources are returned in the order of the new snapshot, followed by
+// removed resources in the order of the old snapshot. If showSecrets is false, secret values are compared but not
+// revealed in the returned diffs.
+func DiffSnapshots(old, new *deploy.Snapshot, showSecrets bool) []ResourceStateDiff {
+	var oldResources, newResources []*resource.State
+	if old != nil {
+		oldResources = old.Resources
+	}
+	if new != nil {
+		newResources = new.Resources
+	}
+
+	olds := make(map[stateKey]*resource.State, len(oldResources))
+	for _, res := range oldResources {
+		olds[stateKey{res.URN, res.Delete}] = res
+	}
+
+	var diffs []ResourceStateDiff
+	seen := make(map[stateKey]bool, len(newResources))
+	for _, res := range newResources {
+		key := stateKey{res.URN, res.Delete}
+		seen[key] = true
+
+		oldRes, has := olds[key]
+		if !has {
+			diffs = append(diffs, ResourceStateDiff{Op: deploy.OpCreate, New: res})
+			continue
+		}
+
+		diff := ResourceStateDiff{Op: deploy.OpUpdate, Old: oldRes, New: res}
+		diff.Inputs = revealSecrets(oldRes.Inputs, showSecrets).Diff(revealSecrets(res.Inputs, showSecrets))
+		diff.Outputs = revealSecrets(oldRes.Outputs, showSecrets).Diff(revealSecrets(res.Outputs, showSecrets))
+		diff.Fields = diffStateFields(oldRes, res)
+		if diff.Inputs != nil || diff.Outputs != nil || len(diff.Fields) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	for _, res := range oldResources {
+		if !seen[stateKey{res.URN, res.Delete}] {
+			diffs = append(diffs, ResourceStateDiff{Op: deploy.OpDelete, Old: res})
+		}
+	}
+
+	return diffs
+}
+
+// revealSecrets strips the secret annotations from the given properties if showSecrets is true. Otherwise the
+// properties are returned as-is, so that changes to secret values are still detected but printed as "[secret]".
+func revealSecrets(m resource.PropertyMap, showSecrets bool) resource.PropertyMap {
+	if !showSecrets {
+		return m
+	}
+	return MassageSecrets(m, true)
+}
+
+// diffStateFields returns the names of the fields other than inputs and outputs that differ between two states of the
+// same resource.
+func diffStateFields(old, new *resource.State) []string {
+	var fields []string
+	if old.ID != new.ID {
+		fields = append(fields, "id")
+	}
+	if old.Parent != new.Parent {
+		fields = append(fields, "parent")
+	}
+	if old.Provider != new.Provider {
+		fields = append(fields, "provider")
+	}
+	if old.Protect != new.Protect {
+		fields = append(fields, "protect")
+	}
+	if old.External != new.External {
+		fields = append(fields, "external")
+	}
+	if old.PendingReplacement != new.PendingReplacement {
+		fields = append(fields, "pendingReplacement")
+	}
+	if !sameURNSet(old.Dependencies, new.Dependencies) {
+		fields = append(fields, "dependencies")
+	}
+	return fields
+}
+
+func sameURNSet(a, b []resource.URN) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as := append([]resource.URN(nil), a...)
+	bs := append([]resource.URN(nil), b...)
+	sort.Slice(as, func(i, j int) bool { return as[i] < as[j] })
+	sort.Slice(bs, func(i, j int) bool { return bs[i] < bs[j] })
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stateField returns the printable value of one of the fields reported by diffStateFields.
+func stateField(s *resource.State, field string) string {
+	switch field {
+	case "id":
+		return fmt.Sprintf("%q", s.ID)
+	case "parent":
+		return fmt.Sprintf("%q", s.Parent)
+	case "provider":
+		return fmt.Sprintf("%q", s.Provider)
+	case "protect":
+		return fmt.Sprintf("%v", s.Protect)
+	case "external":
+		return fmt.Sprintf("%v", s.External)
+	case "pendingReplacement":
+		return fmt.Sprintf("%v", s.PendingReplacement)
+	case "dependencies":
+		return fmt.Sprintf("%v", s.Dependencies)
+	default:
+		return ""
+	}
+}
+
+// PrintStateDiffs renders the given diffs, as returned by DiffSnapshots, into the buffer. The output contains color
+// tags and must be colorized before being displayed.
+func PrintStateDiffs(b *bytes.Buffer, diffs []ResourceStateDiff, showSecrets bool) {
+	for _, diff := range diffs {
+		res := diff.State()
+		header := fmt.Sprintf("%s%s%s", deploy.Prefix(diff.Op, true), res.URN, colors.Reset)
+		if res.Delete {
+			header += " [pending deletion]"
+		}
+		writeString(b, header+"\n")
+
+		switch diff.Op {
+		case deploy.OpCreate, deploy.OpDelete:
+			if len(res.Inputs) > 0 {
+				writeWithIndentNoPrefix(b, 1, diff.Op, "inputs:\n")
+				PrintObject(b, revealSecrets(res.Inputs, showSecrets), false, 2, diff.Op, true, false, false)
+			}
+			if len(res.Outputs) > 0 {
+				writeWithIndentNoPrefix(b, 1, diff.Op, "outputs:\n")
+				PrintObject(b, revealSecrets(res.Outputs, showSecrets), false, 2, diff.Op, true, false, false)
+			}
+		default:
+			for _, field := range diff.Fields {
+				writeWithIndent(b, 1, deploy.OpUpdate, true, "%s: %s => %s\n",
+					field, stateField(diff.Old, field), stateField(diff.New, field))
+			}
+			if diff.Inputs != nil {
+				writeWithIndentNoPrefix(b, 1, deploy.OpSame, "inputs:\n")
+				PrintObjectDiff(b, *diff.Inputs, nil, false, 2, false, false, false)
+			}
+			if diff.Outputs != nil {
+				writeWithIndentNoPrefix(b, 1, deploy.OpSame, "outputs:\n")
+				PrintObjectDiff(b, *diff.Outputs, nil, false, 2, false, false, false)
+			}
+		}
+		writeString(b, colors.Reset)
+	}
+}
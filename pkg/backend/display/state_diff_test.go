@@ -0,0 +1,131 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+func newDiffTestState(name string, inputs resource.PropertyMap) *resource.State {
+	return &resource.State{
+		Type:    "pkgA:m:typA",
+		URN:     resource.NewURN("dev", "test", "", "pkgA:m:typA", tokens.QName(name)),
+		Custom:  true,
+		ID:      resource.ID(name + "-id"),
+		Inputs:  inputs,
+		Outputs: inputs,
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	t.Parallel()
+
+	same := newDiffTestState("same", resource.PropertyMap{"a": resource.NewStringProperty("a")})
+	changedOld := newDiffTestState("changed", resource.PropertyMap{
+		"a":      resource.NewStringProperty("old"),
+		"secret": resource.MakeSecret(resource.NewStringProperty("s3cr3t")),
+	})
+	changedNew := newDiffTestState("changed", resource.PropertyMap{
+		"a":      resource.NewStringProperty("new"),
+		"secret": resource.MakeSecret(resource.NewStringProperty("s3cr3t")),
+	})
+	changedNew.Protect = true
+	removed := newDiffTestState("removed", resource.PropertyMap{"b": resource.NewNumberProperty(1)})
+	added := newDiffTestState("added", resource.PropertyMap{"c": resource.NewBoolProperty(true)})
+
+	old := deploy.NewSnapshot(deploy.Manifest{}, nil, []*resource.State{same, changedOld, removed}, nil)
+	new := deploy.NewSnapshot(deploy.Manifest{}, nil, []*resource.State{same, changedNew, added}, nil)
+
+	diffs := DiffSnapshots(old, new, false)
+	require.Len(t, diffs, 3)
+
+	assert.Equal(t, deploy.OpUpdate, diffs[0].Op)
+	assert.Equal(t, changedNew.URN, diffs[0].URN())
+	assert.Equal(t, []string{"protect"}, diffs[0].Fields)
+	require.NotNil(t, diffs[0].Inputs)
+	assert.Equal(t, []resource.PropertyKey{"a"}, diffs[0].Inputs.ChangedKeys())
+	require.NotNil(t, diffs[0].Outputs)
+
+	assert.Equal(t, deploy.OpCreate, diffs[1].Op)
+	assert.Equal(t, added.URN, diffs[1].URN())
+	assert.Nil(t, diffs[1].Old)
+
+	assert.Equal(t, deploy.OpDelete, diffs[2].Op)
+	assert.Equal(t, removed.URN, diffs[2].URN())
+	assert.Nil(t, diffs[2].New)
+
+	// Diffing a snapshot against nothing reports every resource.
+	diffs = DiffSnapshots(nil, new, false)
+	assert.Len(t, diffs, 3)
+	diffs = DiffSnapshots(old, old, false)
+	assert.Empty(t, diffs)
+}
+
+func TestDiffSnapshotsPendingDelete(t *testing.T) {
+	t.Parallel()
+
+	live := newDiffTestState("res", resource.PropertyMap{"a": resource.NewStringProperty("new")})
+	pending := newDiffTestState("res", resource.PropertyMap{"a": resource.NewStringProperty("old")})
+	pending.Delete = true
+
+	old := deploy.NewSnapshot(deploy.Manifest{}, nil, []*resource.State{pending}, nil)
+	new := deploy.NewSnapshot(deploy.Manifest{}, nil, []*resource.State{live}, nil)
+
+	diffs := DiffSnapshots(old, new, false)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, deploy.OpCreate, diffs[0].Op)
+	assert.Equal(t, deploy.OpDelete, diffs[1].Op)
+	assert.True(t, diffs[1].Old.Delete)
+}
+
+func TestPrintStateDiffs(t *testing.T) {
+	t.Parallel()
+
+	oldRes := newDiffTestState("res", resource.PropertyMap{
+		"a":      resource.NewStringProperty("old"),
+		"secret": resource.MakeSecret(resource.NewStringProperty("before")),
+	})
+	newRes := newDiffTestState("res", resource.PropertyMap{
+		"a":      resource.NewStringProperty("new"),
+		"secret": resource.MakeSecret(resource.NewStringProperty("after")),
+	})
+	newRes.ID = "other-id"
+	old := deploy.NewSnapshot(deploy.Manifest{}, nil, []*resource.State{oldRes}, nil)
+	new := deploy.NewSnapshot(deploy.Manifest{}, nil, []*resource.State{newRes}, nil)
+
+	var b bytes.Buffer
+	PrintStateDiffs(&b, DiffSnapshots(old, new, false), false)
+	out := colors.Never.Colorize(b.String())
+	assert.Contains(t, out, "~ "+string(newRes.URN))
+	assert.Contains(t, out, `id: "res-id" => "other-id"`)
+	assert.Contains(t, out, `a     : "old" => "new"`)
+	assert.Contains(t, out, `secret: [secret] => [secret]`)
+	assert.NotContains(t, out, "before")
+	assert.NotContains(t, out, "after")
+
+	b.Reset()
+	PrintStateDiffs(&b, DiffSnapshots(old, new, true), true)
+	out = colors.Never.Colorize(b.String())
+	assert.Contains(t, out, `secret: "before" => "after"`)
+}
@@ -79,6 +79,18 @@ func ShowWatchEvents(op string, events <-chan engine.Event, done chan<- bool, op
 				PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
 					"failed %s %s\n", p.Metadata.Op, p.Metadata.URN.Type())
 			}
+		case engine.ResourceRetryEvent:
+			p := e.Payload().(engine.ResourceRetryEventPayload)
+			if shouldShow(p.Metadata, opts) {
+				PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
+					"retrying (%d/%d) %s %s\n", p.Attempt, p.MaxAttempts, p.Metadata.Op, p.Metadata.URN.Type())
+			}
+		case engine.ResourceHookEvent:
+			p := e.Payload().(engine.ResourceHookEventPayload)
+			if shouldShow(p.Metadata, opts) {
+				PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
+					"running %s hook %s\n", p.Hook, p.Command)
+			}
 		default:
 			contract.Failf("unknown event type '%s'", e.Type)
 		}
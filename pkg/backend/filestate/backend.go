@@ -19,11 +19,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -33,15 +35,14 @@ import (
 
 	user "github.com/tweekmonster/luser"
 	"gocloud.dev/blob"
-	_ "gocloud.dev/blob/azureblob" // driver for azblob://
-	_ "gocloud.dev/blob/fileblob"  // driver for file://
-	"gocloud.dev/blob/gcsblob"     // driver for gs://
-	_ "gocloud.dev/blob/s3blob"    // driver for s3://
+	"gocloud.dev/blob/azureblob"  // driver for azblob://
+	_ "gocloud.dev/blob/fileblob" // driver for file://
+	"gocloud.dev/blob/gcsblob"    // driver for gs://
+	_ "gocloud.dev/blob/s3blob"   // driver for s3://
 	"gocloud.dev/gcerrors"
 
 	"github.com/pulumi/pulumi/pkg/v3/authhelpers"
 	"github.com/pulumi/pulumi/pkg/v3/backend"
-	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/operations"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
@@ -88,6 +89,17 @@ var (
 	//
 	// This opt-out is intended to be removed in a future release.
 	PulumiFilestateLegacyLayoutEnvVar = env.SelfManagedStateLegacyLayout.Var().Name()
+
+	// PulumiFilestateLockLeaseEnvVar is the name of an environment variable
+	// that can be set to the number of seconds a stack lock is leased for.
+	// Running operations renew their leases well before they run out;
+	// locks whose leases have run out may be taken over by other processes.
+	PulumiFilestateLockLeaseEnvVar = env.SelfManagedStateLockLease.Var().Name()
+
+	// PulumiFilestateJournalEnvVar is an env var that must be truthy
+	// to record updates as an append-only journal,
+	// which is compacted into the stack's checkpoint when the update completes.
+	PulumiFilestateJournalEnvVar = env.SelfManagedStateJournal.Var().Name()
 )
 
 // Backend extends the base backend interface with specific information about local backends.
@@ -97,6 +109,24 @@ type Backend interface {
 
 	// Upgrade to the latest state store version.
 	Upgrade(ctx context.Context) error
+
+	// ListLocks returns information about the locks currently held on the given stack.
+	ListLocks(ctx context.Context, stackRef backend.StackReference) ([]LockInfo, error)
+
+	// BreakLock deletes the lock with the given ID from the given stack,
+	// or all of the stack's locks if the ID is empty.
+	BreakLock(ctx context.Context, stackRef backend.StackReference, id string) error
+
+	// RetentionPolicy returns the retention policy of the state store, or nil if it doesn't have one.
+	RetentionPolicy() *RetentionPolicy
+
+	// SetRetentionPolicy saves the retention policy of the state store, or removes it if the policy is nil.
+	// The policy is enforced on every update by all users of the state store.
+	SetRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error
+
+	// Prune deletes the history entries and backups of the given stack, or of all stacks if the reference is nil,
+	// that are not retained by the given policy. If dryRun is true, the files are reported but not deleted.
+	Prune(ctx context.Context, policy RetentionPolicy, stackRef backend.StackReference, dryRun bool) ([]PrunedFile, error)
 }
 
 type localBackend struct {
@@ -113,8 +143,18 @@ type localBackend struct {
 
 	lockID string
 
+	// leases tracks the locks held by this backend whose leases are being renewed, keyed by lock path.
+	leases   map[string]*lease
+	leasesMu sync.Mutex
+
 	gzip bool
 
+	// retention is the retention policy of the state store, if any.
+	retention *RetentionPolicy
+
+	// conditionalWrites is true if the bucket driver supports writes that are conditional on an object not existing.
+	conditionalWrites bool
+
 	Getenv func(string) string // == os.Getenv
 
 	// The current project, if any.
@@ -183,6 +223,8 @@ func (r *localBackendReference) Validate() error       { return r.store.Validate
 func (r *localBackendReference) StackBasePath() string { return r.store.StackBasePath(r) }
 func (r *localBackendReference) HistoryDir() string    { return r.store.HistoryDir(r) }
 func (r *localBackendReference) BackupDir() string     { return r.store.BackupDir(r) }
+func (r *localBackendReference) TagsPath() string      { return r.store.TagsPath(r) }
+func (r *localBackendReference) JournalDir() string    { return r.store.JournalDir(r) }
 
 func IsFileStateBackendURL(urlstr string) bool {
 	u, err := url.Parse(urlstr)
@@ -284,6 +326,8 @@ func newLocalBackend(
 		lockID:      lockID.String(),
 		gzip:        gzipCompression,
 		Getenv:      opts.Getenv,
+
+		conditionalWrites: p.Scheme == gcsblob.Scheme || p.Scheme == azureblob.Scheme,
 	}
 	backend.currentProject.Store(project)
 
@@ -307,6 +351,8 @@ func newLocalBackend(
 	//
 	// All actual logic of project mode vs legacy mode is handled by the referenceStore.
 	// This boolean just helps us warn users about unmigrated stacks.
+	backend.retention = meta.Retention
+
 	var projectMode bool
 	switch meta.Version {
 	case 0:
@@ -363,7 +409,7 @@ func (b *localBackend) Upgrade(ctx context.Context) error {
 	// This ensures that if permissions are borked for any reason,
 	// (e.g., we can write to .pulumi/*/*" but not ".pulumi/*.")
 	// we don't leave the bucket in a completely inaccessible state.
-	meta := pulumiMeta{Version: 1}
+	meta := pulumiMeta{Version: 1, Retention: b.retention}
 	if err := meta.WriteTo(ctx, b.bucket); err != nil {
 		var s strings.Builder
 		fmt.Fprintf(&s, "Could not write new state metadata file: %v\n", err)
@@ -561,7 +607,7 @@ func (b *localBackend) ListPolicyPacks(ctx context.Context, orgName string, _ ba
 }
 
 func (b *localBackend) SupportsTags() bool {
-	return false
+	return true
 }
 
 func (b *localBackend) SupportsOrganizations() bool {
@@ -675,7 +721,11 @@ func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackRe
 		return nil, err
 	}
 
-	stack := newStack(localStackRef, file, nil, b)
+	if err := b.saveTags(localStackRef, tags); err != nil {
+		return nil, err
+	}
+
+	stack := newStack(localStackRef, file, nil, tags, b)
 	b.d.Infof(diag.Message("", "Created stack '%s'"), stack.Ref())
 
 	return stack, nil
@@ -694,13 +744,17 @@ func (b *localBackend) GetStack(ctx context.Context, stackRef backend.StackRefer
 		return nil, nil
 	case err != nil:
 		return nil, err
-	default:
-		return newStack(localStackRef, path, snapshot, b), nil
 	}
+
+	tags, err := b.getTags(localStackRef)
+	if err != nil {
+		return nil, err
+	}
+	return newStack(localStackRef, path, snapshot, tags, b), nil
 }
 
 func (b *localBackend) ListStacks(
-	ctx context.Context, _ backend.ListStacksFilter, _ backend.ContinuationToken) (
+	ctx context.Context, filter backend.ListStacksFilter, _ backend.ContinuationToken) (
 	[]backend.StackSummary, backend.ContinuationToken, error,
 ) {
 	stacks, err := b.getLocalStacks()
@@ -708,10 +762,21 @@ func (b *localBackend) ListStacks(
 		return nil, nil, err
 	}
 
-	// Note that the provided stack filter is not honored, since fields like
-	// organizations and tags aren't persisted in the local backend.
+	// Note that only the tag filters are honored, since fields like
+	// organizations aren't persisted in the local backend.
 	results := make([]backend.StackSummary, 0, len(stacks))
 	for _, stackRef := range stacks {
+		if filter.TagName != nil {
+			tags, err := b.getTags(stackRef)
+			if err != nil {
+				return nil, nil, err
+			}
+			value, has := tags[*filter.TagName]
+			if !has || (filter.TagValue != nil && value != *filter.TagValue) {
+				continue
+			}
+		}
+
 		chk, err := b.getCheckpoint(stackRef)
 		if err != nil {
 			return nil, nil, err
@@ -805,15 +870,19 @@ func (b *localBackend) renameStack(ctx context.Context, oldRef *localBackendRefe
 		return err
 	}
 
-	// To remove the old stack, just make a backup of the file and don't write out anything new.
+	// To remove the old stack, just make a backup of the file and don't write out anything new. Any journal has
+	// already been replayed into the snapshot saved above.
 	file := b.stackPath(oldRef)
 	backupTarget(b.bucket, file, false)
+	if err = b.removeJournal(oldRef); err != nil {
+		return err
+	}
 
-	// And rename the history folder as well.
+	// And rename the history folder and tags as well.
 	if err = b.renameHistory(oldRef, newRef); err != nil {
 		return err
 	}
-	return err
+	return b.renameTags(oldRef, newRef)
 }
 
 func (b *localBackend) GetLatestConfiguration(ctx context.Context,
@@ -924,13 +993,11 @@ func (b *localBackend) apply(
 		return nil, nil, result.Errorf("provided project name %q doesn't match Pulumi.yaml", localStackRef.project)
 	}
 
-	stackName := stackRef.FullyQualifiedName()
-	actionLabel := backend.ActionLabel(kind, opts.DryRun)
-
-	if !(op.Opts.Display.JSONDisplay || op.Opts.Display.Type == display.DisplayWatch) {
-		// Print a banner so it's clear this is a local deployment.
-		fmt.Printf(op.Opts.Display.Color.Colorize(
-			colors.SpecHeadline+"%s (%s):"+colors.Reset+"\n"), actionLabel, stackRef)
+	// Save the state left behind by an interrupted update before starting a new one.
+	if !opts.DryRun {
+		if err := b.recoverJournal(ctx, localStackRef); err != nil {
+			return nil, nil, result.FromError(err)
+		}
 	}
 
 	// Start the update.
@@ -939,97 +1006,42 @@ func (b *localBackend) apply(
 		return nil, nil, result.FromError(err)
 	}
 
-	// Spawn a display loop to show events on the CLI.
-	displayEvents := make(chan engine.Event)
-	displayDone := make(chan bool)
-	go display.ShowEvents(
-		strings.ToLower(actionLabel), kind, stackName.Name(), op.Proj.Name, "",
-		displayEvents, displayDone, op.Opts.Display, opts.DryRun)
-
-	// Create a separate event channel for engine events that we'll pipe to both listening streams.
-	engineEvents := make(chan engine.Event)
-
-	scope := op.Scopes.NewScope(engineEvents, opts.DryRun)
-	eventsDone := make(chan bool)
-	go func() {
-		// Pull in all events from the engine and send them to the two listeners.
-		for e := range engineEvents {
-			displayEvents <- e
-
-			// If the caller also wants to see the events, stream them there also.
-			if events != nil {
-				events <- e
-			}
+	// Use this opportunity to refresh the stack's tags, to pick up any metadata changes.
+	if !opts.DryRun {
+		tags := backend.GetMergedStackTags(ctx, stack, op.Root, op.Proj)
+		if err := b.saveTags(localStackRef, tags); err != nil {
+			return nil, nil, result.FromError(err)
 		}
-
-		close(eventsDone)
-	}()
-
-	// Create the management machinery.
-	persister := b.newSnapshotPersister(localStackRef, op.SecretsManager)
-	manager := backend.NewSnapshotManager(persister, update.GetTarget().Snapshot)
-	engineCtx := &engine.Context{
-		Cancel:          scope.Context(),
-		Events:          engineEvents,
-		SnapshotManager: manager,
-		BackendClient:   backend.NewBackendClient(b, op.SecretsProvider),
 	}
 
-	// Perform the update
-	start := time.Now().Unix()
-	var plan *deploy.Plan
-	var changes sdkDisplay.ResourceChanges
-	var updateRes result.Result
-	switch kind {
-	case apitype.PreviewUpdate:
-		plan, changes, updateRes = engine.Update(update, engineCtx, op.Opts.Engine, true)
-	case apitype.UpdateUpdate:
-		_, changes, updateRes = engine.Update(update, engineCtx, op.Opts.Engine, opts.DryRun)
-	case apitype.ResourceImportUpdate:
-		_, changes, updateRes = engine.Import(update, engineCtx, op.Opts.Engine, op.Imports, opts.DryRun)
-	case apitype.RefreshUpdate:
-		_, changes, updateRes = engine.Refresh(update, engineCtx, op.Opts.Engine, opts.DryRun)
-	case apitype.DestroyUpdate:
-		_, changes, updateRes = engine.Destroy(update, engineCtx, op.Opts.Engine, opts.DryRun)
-	default:
-		contract.Failf("Unrecognized update kind: %s", kind)
-	}
-	end := time.Now().Unix()
-
-	// Wait for the display to finish showing all the events.
-	<-displayDone
-	scope.Close() // Don't take any cancellations anymore, we're shutting down.
-	close(engineEvents)
-	contract.IgnoreClose(manager)
-
-	// Make sure the goroutine writing to displayEvents and events has exited before proceeding.
-	<-eventsDone
-	close(displayEvents)
-
-	// Save update results.
-	backendUpdateResult := backend.SucceededResult
-	if updateRes != nil {
-		backendUpdateResult = backend.FailedResult
-	}
-	info := backend.UpdateInfo{
-		Kind:        kind,
-		StartTime:   start,
-		Message:     op.M.Message,
-		Environment: op.M.Environment,
-		Config:      update.GetTarget().Config,
-		Result:      backendUpdateResult,
-		EndTime:     end,
-		// IDEA: it would be nice to populate the *Deployment, so that addToHistory below doesn't need to
-		//     rudely assume it knows where the checkpoint file is on disk as it makes a copy of it.  This isn't
-		//     trivial to achieve today given the event driven nature of plan-walking, however.
-		ResourceChanges: changes,
-	}
+	// Create the management machinery.
+	var manager engine.SnapshotManager
+	if b.journalEnabled() {
+		persister := b.newJournalPersister(localStackRef, op.SecretsManager)
+		manager = backend.NewJournalSnapshotManager(persister, update.GetTarget().Snapshot)
+	} else {
+		persister := b.newSnapshotPersister(localStackRef, op.SecretsManager)
+		manager = backend.NewSnapshotManager(persister, update.GetTarget().Snapshot)
+	}
+
+	plan, changes, info, updateRes := backend.RunLocalUpdate(backend.LocalUpdate{
+		Kind:    kind,
+		Stack:   stack,
+		Op:      op,
+		Opts:    opts,
+		Events:  events,
+		Update:  update,
+		Manager: manager,
+		Client:  backend.NewBackendClient(b, op.SecretsProvider),
+		Broken:  b.leaseBroken(stackRef),
+	})
 
 	var saveErr error
 	var backupErr error
 	if !opts.DryRun {
 		saveErr = b.addToHistory(localStackRef, info)
 		backupErr = b.backupStack(localStackRef)
+		b.enforceRetention(ctx, localStackRef)
 	}
 
 	if updateRes != nil {
@@ -1154,6 +1166,26 @@ func (b *localBackend) ExportDeployment(ctx context.Context,
 		return nil, err
 	}
 
+	// If an update of the stack is running, or was interrupted, export the state recorded in its journal.
+	snap, journaled, err := b.readJournal(ctx, localStackRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journal: %w", err)
+	}
+	if journaled {
+		sdep, err := stack.SerializeDeployment(snap, nil, false /* showSecrets */)
+		if err != nil {
+			return nil, fmt.Errorf("serializing deployment: %w", err)
+		}
+		data, err := encoding.JSON.Marshal(sdep)
+		if err != nil {
+			return nil, err
+		}
+		return &apitype.UntypedDeployment{
+			Version:    3,
+			Deployment: json.RawMessage(data),
+		}, nil
+	}
+
 	chk, err := b.getCheckpoint(localStackRef)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
@@ -1170,6 +1202,62 @@ func (b *localBackend) ExportDeployment(ctx context.Context,
 	}, nil
 }
 
+// StreamDeployment writes the stack's current deployment to w as it is read from the bucket, one resource at a time.
+func (b *localBackend) StreamDeployment(ctx context.Context, stk backend.Stack, w io.Writer) error {
+	localStackRef, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	// If an update of the stack is running, or was interrupted, export the state recorded in its journal.
+	snap, journaled, err := b.readJournal(ctx, localStackRef)
+	if err != nil {
+		return fmt.Errorf("failed to load journal: %w", err)
+	}
+	if journaled {
+		return stack.EncodeDeployment(w, snap, nil, false /* showSecrets */)
+	}
+
+	err = b.readCheckpoint(ctx, b.stackPath(localStackRef), func(r io.Reader) error {
+		return stack.ExportCheckpoint(r, w)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ExportDeploymentForVersion exports the checkpoint saved with the given version of the stack's history. Versions
+// are numbered from the oldest update, starting at 1, as shown by `pulumi stack history`.
+func (b *localBackend) ExportDeploymentForVersion(ctx context.Context,
+	stk backend.Stack, version string,
+) (*apitype.UntypedDeployment, error) {
+	localStackRef, err := b.getReference(stk.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: versions must be integers", version)
+	}
+
+	chk, err := b.getHistoryCheckpoint(localStackRef, v)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encoding.JSON.Marshal(chk.Latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(data),
+	}, nil
+}
+
 func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 	deployment *apitype.UntypedDeployment,
 ) error {
@@ -1190,8 +1278,95 @@ func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 		return err
 	}
 
-	_, _, err = b.saveCheckpoint(localStackRef, chk)
-	return err
+	if _, _, err = b.saveCheckpoint(localStackRef, chk); err != nil {
+		return err
+	}
+	return b.removeJournal(localStackRef)
+}
+
+// ImportHistory records the given updates, oldest first, in the stack's history.
+// The entries don't have copies of their checkpoints, so their versions can't be restored.
+func (b *localBackend) ImportHistory(ctx context.Context, stk backend.Stack, updates []backend.UpdateInfo) error {
+	localStackRef, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	err = b.Lock(ctx, localStackRef)
+	if err != nil {
+		return err
+	}
+	defer b.Unlock(ctx, localStackRef)
+
+	return b.importHistory(localStackRef, updates)
+}
+
+// ImportSnapshot writes the given snapshot as the stack's checkpoint,
+// streaming it to the bucket one resource at a time.
+func (b *localBackend) ImportSnapshot(ctx context.Context, stk backend.Stack, snap *deploy.Snapshot) error {
+	localStackRef, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	err = b.Lock(ctx, localStackRef)
+	if err != nil {
+		return err
+	}
+	defer b.Unlock(ctx, localStackRef)
+
+	_, _, err = b.writeCheckpoint(localStackRef, func(w io.Writer) error {
+		return stack.EncodeCheckpoint(w, localStackRef.FullyQualifiedName(), snap, nil, false /* showSecrets */)
+	})
+	if err != nil {
+		return err
+	}
+	return b.removeJournal(localStackRef)
+}
+
+// RestoreDeployment imports a deployment exported from the given version of the stack's history as its current state,
+// and records the restore as a new entry in the stack's history.
+func (b *localBackend) RestoreDeployment(ctx context.Context, stk backend.Stack, version string,
+	deployment *apitype.UntypedDeployment,
+) error {
+	localStackRef, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	err = b.Lock(ctx, localStackRef)
+	if err != nil {
+		return err
+	}
+	defer b.Unlock(ctx, localStackRef)
+
+	start := time.Now().Unix()
+	stackName := localStackRef.FullyQualifiedName()
+	chk, err := stack.MarshalUntypedDeploymentToVersionedCheckpoint(stackName, deployment)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err = b.saveCheckpoint(localStackRef, chk); err != nil {
+		return err
+	}
+	if err = b.removeJournal(localStackRef); err != nil {
+		return err
+	}
+
+	err = b.addToHistory(localStackRef, backend.UpdateInfo{
+		Kind:        apitype.StackRestoreUpdate,
+		StartTime:   start,
+		Message:     fmt.Sprintf("Restored version %s", version),
+		Environment: map[string]string{},
+		Result:      backend.SucceededResult,
+		EndTime:     time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	b.enforceRetention(ctx, localStackRef)
+	return nil
 }
 
 func (b *localBackend) Logout() error {
@@ -1218,35 +1393,24 @@ func (b *localBackend) getLocalStacks() ([]*localBackendReference, error) {
 func (b *localBackend) UpdateStackTags(ctx context.Context,
 	stack backend.Stack, tags map[apitype.StackTagName]string,
 ) error {
-	// The local backend does not currently persist tags.
-	return errors.New("stack tags not supported in --local mode")
-}
-
-func (b *localBackend) CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error {
-	// Try to delete ALL the lock files
-	allFiles, err := listBucket(b.bucket, stackLockDir(stackRef.FullyQualifiedName()))
+	localStackRef, err := b.getReference(stack.Ref())
 	if err != nil {
-		// Don't error if it just wasn't found
-		if gcerrors.Code(err) == gcerrors.NotFound {
-			return nil
-		}
 		return err
 	}
 
-	for _, file := range allFiles {
-		if file.IsDir {
-			continue
-		}
+	if err := validation.ValidateStackTags(tags); err != nil {
+		return fmt.Errorf("validating tags: %w", err)
+	}
 
-		err := b.bucket.Delete(ctx, file.Key)
-		if err != nil {
-			// Race condition, don't error if the file was delete between us calling list and now
-			if gcerrors.Code(err) == gcerrors.NotFound {
-				return nil
-			}
-			return err
-		}
+	err = b.Lock(ctx, localStackRef)
+	if err != nil {
+		return err
 	}
+	defer b.Unlock(ctx, localStackRef)
 
-	return nil
+	return b.saveTags(localStackRef, tags)
+}
+
+func (b *localBackend) CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error {
+	return b.BreakLock(ctx, stackRef, "")
 }
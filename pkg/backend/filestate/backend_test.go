@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -18,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	user "github.com/tweekmonster/luser"
+	"gocloud.dev/blob"
 	"gocloud.dev/blob/fileblob"
 
 	"github.com/pulumi/pulumi/pkg/v3/backend"
@@ -1138,3 +1141,804 @@ func mapGetenv(m map[string]string) func(string) string {
 		return m[key]
 	}
 }
+
+func TestStackTags(t *testing.T) {
+	t.Parallel()
+
+	// Login to a temp dir filestate backend
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	assert.True(t, b.SupportsTags())
+
+	aStackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+	bStackRef, err := b.ParseStackReference("organization/project/b")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, bStackRef, "", nil)
+	require.NoError(t, err)
+
+	tags := map[apitype.StackTagName]string{"team": "infra"}
+	err = b.UpdateStackTags(ctx, aStack, tags)
+	require.NoError(t, err)
+
+	// Tags are loaded along with the stack.
+	aStack, err = b.GetStack(ctx, aStackRef)
+	require.NoError(t, err)
+	assert.Equal(t, tags, aStack.Tags())
+
+	// Tags can be used to filter stacks.
+	listStacks := func(filter backend.ListStacksFilter) []string {
+		summaries, _, err := b.ListStacks(ctx, filter, nil)
+		require.NoError(t, err)
+		var names []string
+		for _, summary := range summaries {
+			names = append(names, summary.Name().String())
+		}
+		return names
+	}
+	tagName, infra, other := apitype.StackTagName("team"), "infra", "other"
+	assert.ElementsMatch(t, []string{"organization/project/a", "organization/project/b"},
+		listStacks(backend.ListStacksFilter{}))
+	assert.Equal(t, []string{"organization/project/a"}, listStacks(backend.ListStacksFilter{TagName: &tagName}))
+	assert.Equal(t, []string{"organization/project/a"},
+		listStacks(backend.ListStacksFilter{TagName: &tagName, TagValue: &infra}))
+	assert.Empty(t, listStacks(backend.ListStacksFilter{TagName: &tagName, TagValue: &other}))
+
+	// Tags follow the stack when it is renamed.
+	cStackRef, err := b.RenameStack(ctx, aStack, "organization/project/c")
+	require.NoError(t, err)
+	cStack, err := b.GetStack(ctx, cStackRef)
+	require.NoError(t, err)
+	assert.Equal(t, map[apitype.StackTagName]string{
+		"team":                 "infra",
+		apitype.ProjectNameTag: "project",
+	}, cStack.Tags())
+
+	// And are removed along with it.
+	_, err = b.RemoveStack(ctx, cStack, false)
+	require.NoError(t, err)
+	tagsExist, err := b.(*localBackend).bucket.Exists(ctx, cStackRef.(*localBackendReference).TagsPath())
+	require.NoError(t, err)
+	assert.False(t, tagsExist)
+}
+
+func TestLegacyUpgrade_tags(t *testing.T) {
+	t.Parallel()
+
+	// Make a dummy stack file and tags file in the legacy location
+	tmpDir := t.TempDir()
+	err := os.MkdirAll(path.Join(tmpDir, ".pulumi", "stacks"), os.ModePerm)
+	require.NoError(t, err)
+	err = os.WriteFile(path.Join(tmpDir, ".pulumi", "stacks", "a.json"), []byte(`{
+		"latest": {
+			"resources": [
+				{
+					"type": "package:module:resource",
+					"urn": "urn:pulumi:stack::project::package:module:resource::name"
+				}
+			]
+		}
+	}`), os.ModePerm)
+	require.NoError(t, err)
+	err = os.MkdirAll(path.Join(tmpDir, ".pulumi", "tags"), os.ModePerm)
+	require.NoError(t, err)
+	err = os.WriteFile(path.Join(tmpDir, ".pulumi", "tags", "a.json"), []byte(`{"team": "infra"}`), os.ModePerm)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb := b.(*localBackend)
+	assert.IsType(t, &legacyReferenceStore{}, lb.store)
+
+	legacyRef, err := lb.parseStackReference("a")
+	require.NoError(t, err)
+	legacyStack, err := b.GetStack(ctx, legacyRef)
+	require.NoError(t, err)
+	assert.Equal(t, map[apitype.StackTagName]string{"team": "infra"}, legacyStack.Tags())
+
+	err = lb.Upgrade(ctx)
+	require.NoError(t, err)
+
+	// The tags were moved to the project layout, picking up the project name on the way.
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.GetStack(ctx, aStackRef)
+	require.NoError(t, err)
+	assert.Equal(t, map[apitype.StackTagName]string{
+		"team":                 "infra",
+		apitype.ProjectNameTag: "project",
+	}, aStack.Tags())
+
+	legacyTagsExist, err := lb.bucket.Exists(ctx, legacyRef.TagsPath())
+	require.NoError(t, err)
+	assert.False(t, legacyTagsExist)
+}
+
+func TestLockLease(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil,
+		&localBackendOptions{Getenv: func(key string) string {
+			if key == PulumiFilestateLockLeaseEnvVar {
+				return "1"
+			}
+			return ""
+		}})
+	require.NoError(t, err)
+
+	aStackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	err = b.Lock(ctx, aStackRef)
+	require.NoError(t, err)
+
+	locks, err := b.ListLocks(ctx, aStackRef)
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	assert.Equal(t, b.lockID, locks[0].ID)
+	require.NotNil(t, locks[0].Expires)
+	firstExpiry := *locks[0].Expires
+
+	// The lease is renewed while the lock is held.
+	assert.Eventually(t, func() bool {
+		locks, err := b.ListLocks(ctx, aStackRef)
+		require.NoError(t, err)
+		require.Len(t, locks, 1)
+		return locks[0].Expires.After(firstExpiry) && !locks[0].Expired(time.Now())
+	}, 5*time.Second, 100*time.Millisecond)
+
+	b.Unlock(ctx, aStackRef)
+	locks, err = b.ListLocks(ctx, aStackRef)
+	require.NoError(t, err)
+	assert.Empty(t, locks)
+}
+
+func TestLockTakeOverExpired(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb := b.(*localBackend)
+
+	aStackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	writeLock := func(id string, expires *time.Time) {
+		content, err := json.Marshal(lockContent{
+			Pid:       1234,
+			Username:  "someone",
+			Hostname:  "ci-runner",
+			Timestamp: time.Now().Add(-time.Hour),
+			Expires:   expires,
+		})
+		require.NoError(t, err)
+		err = lb.bucket.WriteAll(ctx, path.Join(stackLockDir(aStackRef.FullyQualifiedName()), id+".json"), content, nil)
+		require.NoError(t, err)
+	}
+
+	// A lock whose lease has run out is taken over.
+	expired := time.Now().Add(-time.Minute)
+	writeLock("9c5f3a56-0d6e-4a8e-8f43-3f6a1ad5c2f1", &expired)
+	err = lb.Lock(ctx, aStackRef)
+	require.NoError(t, err)
+	locks, err := lb.ListLocks(ctx, aStackRef)
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	assert.Equal(t, lb.lockID, locks[0].ID)
+	lb.Unlock(ctx, aStackRef)
+
+	// A lock without a lease, as written by older CLIs, never expires.
+	legacyID := "0b7a2f0e-5d4c-4a3b-9e1f-6c2d8a7b4e90"
+	writeLock(legacyID, nil)
+	err = lb.Lock(ctx, aStackRef)
+	assert.ErrorContains(t, err, "created by someone@ci-runner (pid 1234)")
+
+	locks, err = lb.ListLocks(ctx, aStackRef)
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	assert.Equal(t, legacyID, locks[0].ID)
+	assert.False(t, locks[0].Expired(time.Now()))
+
+	// But can be broken explicitly.
+	err = lb.BreakLock(ctx, aStackRef, "5e8d1c2b-7a4f-4c6e-b3d9-1f0a2e4c6b8d")
+	assert.ErrorContains(t, err, "no lock with ID")
+	err = lb.BreakLock(ctx, aStackRef, legacyID)
+	require.NoError(t, err)
+	err = lb.Lock(ctx, aStackRef)
+	require.NoError(t, err)
+	lb.Unlock(ctx, aStackRef)
+}
+
+func TestBreakLockInvalidID(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb := b.(*localBackend)
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	// Lock IDs name objects in the bucket, so anything that could point outside of the lock directory is rejected.
+	for _, id := range []string{
+		"../../stacks/project/a",
+		`..\..\stacks\project\a`,
+		"{" + lb.lockID + "}",
+		"not-a-lock-id",
+	} {
+		err = lb.BreakLock(ctx, aStackRef, id)
+		assert.ErrorContains(t, err, "invalid lock ID", "id %q", id)
+	}
+
+	exists, err := lb.bucket.Exists(ctx, lb.stackPath(aStackRef))
+	require.NoError(t, err)
+	assert.True(t, exists, "the stack's checkpoint was deleted")
+}
+
+// conditionalBucket emulates a bucket that supports writes that are conditional on the object not existing, like
+// those of GCS and Azure, on top of a bucket that doesn't.
+type conditionalBucket struct {
+	Bucket
+}
+
+func (b conditionalBucket) WriteAll(ctx context.Context, key string, p []byte, opts *blob.WriterOptions) error {
+	if opts != nil && opts.BeforeWrite != nil {
+		exists, err := b.Exists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%v already exists", key)
+		}
+		opts = nil
+	}
+	return b.Bucket.WriteAll(ctx, key, p, opts)
+}
+
+func TestBreakLockByIDDeletesGuard(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	newBackend := func() *localBackend {
+		b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+		require.NoError(t, err)
+		lb := b.(*localBackend)
+		lb.bucket = conditionalBucket{lb.bucket}
+		lb.conditionalWrites = true
+		return lb
+	}
+	holder, breaker := newBackend(), newBackend()
+
+	aStackRef, err := holder.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = holder.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	err = holder.Lock(ctx, aStackRef)
+	require.NoError(t, err)
+	exists, err := holder.bucket.Exists(ctx, holder.lockGuardPath(aStackRef))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// The guard of another lock is left alone.
+	err = breaker.BreakLock(ctx, aStackRef, "5e8d1c2b-7a4f-4c6e-b3d9-1f0a2e4c6b8d")
+	assert.ErrorContains(t, err, "no lock with ID")
+	err = breaker.Lock(ctx, aStackRef)
+	assert.ErrorContains(t, err, "the stack is currently locked")
+
+	// Breaking the lock by its ID deletes its guard too, so the stack can be locked again straight away.
+	err = breaker.BreakLock(ctx, aStackRef, holder.lockID)
+	require.NoError(t, err)
+	err = breaker.Lock(ctx, aStackRef)
+	require.NoError(t, err)
+	breaker.Unlock(ctx, aStackRef)
+}
+
+func TestLockLeaseBroken(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil,
+		&localBackendOptions{Getenv: func(key string) string {
+			if key == PulumiFilestateLockLeaseEnvVar {
+				return "1"
+			}
+			return ""
+		}})
+	require.NoError(t, err)
+
+	aStackRef, err := b.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	err = b.Lock(ctx, aStackRef)
+	require.NoError(t, err)
+	defer b.Unlock(ctx, aStackRef)
+	require.NoError(t, b.checkLease(aStackRef))
+
+	// Another process takes over the lock, as if our lease had run out.
+	taken, err := json.Marshal(lockContent{
+		Pid:       1234,
+		Username:  "someone",
+		Hostname:  "ci-runner",
+		Timestamp: time.Now(),
+	})
+	require.NoError(t, err)
+	err = b.bucket.WriteAll(ctx, b.lockPath(aStackRef), taken, nil)
+	require.NoError(t, err)
+
+	select {
+	case <-b.leaseBroken(aStackRef):
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lock was not reported as broken")
+	}
+
+	// The other process's lock is left as it is, and the stack can no longer be written through the lock.
+	content, err := b.bucket.ReadAll(ctx, b.lockPath(aStackRef))
+	require.NoError(t, err)
+	assert.Equal(t, taken, content)
+	assert.ErrorContains(t, b.checkLease(aStackRef), "was broken by another process")
+	err = b.newSnapshotPersister(aStackRef, b64.NewBase64SecretsManager()).Save(&deploy.Snapshot{})
+	assert.ErrorContains(t, err, "was broken by another process")
+}
+
+//nolint:paralleltest // mutates environment variables
+func TestExportDeploymentForVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb, ok := b.(*localBackend)
+	require.True(t, ok)
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE", "abc123")
+	for _, name := range []tokens.QName{"first", "second"} {
+		deployment, err := makeUntypedDeployment(name, "abc123",
+			"v1:4iF78gb0nF0=:v1:Co6IbTWYs/UdrjgY:FSrAWOFZnj9ealCUDdJL7LrUKXX9BA==")
+		require.NoError(t, err)
+		err = b.ImportDeployment(ctx, aStack, deployment)
+		require.NoError(t, err)
+		err = lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate})
+		require.NoError(t, err)
+	}
+
+	history, err := b.GetHistory(ctx, aStackRef, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 2, history[0].Version)
+	assert.Equal(t, 1, history[1].Version)
+
+	for version, name := range map[string]string{"1": "first", "2": "second"} {
+		deployment, err := lb.ExportDeploymentForVersion(ctx, aStack, version)
+		require.NoError(t, err)
+		assert.Contains(t, string(deployment.Deployment), "a:b:c::"+name)
+	}
+
+	_, err = lb.ExportDeploymentForVersion(ctx, aStack, "3")
+	assert.ErrorContains(t, err, "has no version 3")
+	_, err = lb.ExportDeploymentForVersion(ctx, aStack, "latest")
+	assert.ErrorContains(t, err, `invalid version "latest"`)
+}
+
+//nolint:paralleltest // mutates environment variables
+func TestRestoreDeployment(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb, ok := b.(*localBackend)
+	require.True(t, ok)
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE", "abc123")
+	for _, name := range []tokens.QName{"first", "second"} {
+		deployment, err := makeUntypedDeployment(name, "abc123",
+			"v1:4iF78gb0nF0=:v1:Co6IbTWYs/UdrjgY:FSrAWOFZnj9ealCUDdJL7LrUKXX9BA==")
+		require.NoError(t, err)
+		err = b.ImportDeployment(ctx, aStack, deployment)
+		require.NoError(t, err)
+		err = lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate})
+		require.NoError(t, err)
+	}
+
+	deployment, err := lb.ExportDeploymentForVersion(ctx, aStack, "1")
+	require.NoError(t, err)
+	err = lb.RestoreDeployment(ctx, aStack, "1", deployment)
+	require.NoError(t, err)
+
+	// The restored version is now the current state.
+	current, err := lb.ExportDeployment(ctx, aStack)
+	require.NoError(t, err)
+	assert.Contains(t, string(current.Deployment), "a:b:c::first")
+
+	// And the restore is recorded in the history, along with a copy of the restored checkpoint.
+	history, err := b.GetHistory(ctx, aStackRef, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, apitype.StackRestoreUpdate, history[0].Kind)
+	assert.Equal(t, "Restored version 1", history[0].Message)
+	assert.Equal(t, backend.SucceededResult, history[0].Result)
+	assert.Equal(t, 3, history[0].Version)
+
+	restored, err := lb.ExportDeploymentForVersion(ctx, aStack, "3")
+	require.NoError(t, err)
+	assert.Contains(t, string(restored.Deployment), "a:b:c::first")
+}
+
+func TestImportHistory(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb, ok := b.(*localBackend)
+	require.True(t, ok)
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	// Updates that started in the same second, or that have no start time, still get entries of their own.
+	updates := []backend.UpdateInfo{
+		{Kind: apitype.UpdateUpdate, Message: "first", Version: 4, StartTime: 1600000000},
+		{Kind: apitype.RefreshUpdate, Message: "second", Version: 5, StartTime: 1600000000},
+		{Kind: apitype.DestroyUpdate, Message: "third", Version: 5},
+	}
+	err = lb.ImportHistory(ctx, aStack, updates)
+	require.NoError(t, err)
+
+	history, err := b.GetHistory(ctx, aStackRef, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "third", history[0].Message)
+	assert.Equal(t, 6, history[0].Version)
+	assert.Equal(t, "second", history[1].Message)
+	assert.Equal(t, 5, history[1].Version)
+	assert.Equal(t, "first", history[2].Message)
+	assert.Equal(t, 4, history[2].Version)
+
+	// Later updates are numbered after the imported ones.
+	err = lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate})
+	require.NoError(t, err)
+	history, err = b.GetHistory(ctx, aStackRef, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 7, history[0].Version)
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb, ok := b.(*localBackend)
+	require.True(t, ok)
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+		require.NoError(t, lb.backupStack(aStackRef))
+	}
+	// An old backup, as named by backupStack.
+	old := time.Now().Add(-48 * time.Hour).UnixNano()
+	oldBackup := path.Join(aStackRef.BackupDir(), fmt.Sprintf("a.%d.json", old))
+	require.NoError(t, lb.bucket.WriteAll(ctx, oldBackup, []byte("{}"), nil))
+
+	// A dry run reports, but doesn't delete, the old backup.
+	pruned, err := lb.Prune(ctx, RetentionPolicy{MaxAge: 24 * time.Hour}, nil, true /* dryRun */)
+	require.NoError(t, err)
+	require.Len(t, pruned, 1)
+	assert.Equal(t, filepath.ToSlash(oldBackup), pruned[0].Path)
+	assert.Equal(t, int64(2), pruned[0].Size)
+	exists, err := lb.bucket.Exists(ctx, oldBackup)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Keep the last two history entries, with their checkpoints, and the last two backups.
+	pruned, err = lb.Prune(ctx, RetentionPolicy{KeepLast: 2}, aStackRef, false /* dryRun */)
+	require.NoError(t, err)
+	assert.Len(t, pruned, 3*2+4)
+	exists, err = lb.bucket.Exists(ctx, oldBackup)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// The remaining entries keep their versions.
+	history, err := b.GetHistory(ctx, aStackRef, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 5, history[0].Version)
+	assert.Equal(t, 4, history[1].Version)
+	backups, err := listBucket(lb.bucket, aStackRef.BackupDir())
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	_, err = lb.ExportDeploymentForVersion(ctx, aStack, "1")
+	assert.Error(t, err)
+
+	// New entries continue the numbering.
+	require.NoError(t, lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	history, err = b.GetHistory(ctx, aStackRef, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 6, history[0].Version)
+}
+
+func TestPrune_legacyHistory(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb, ok := b.(*localBackend)
+	require.True(t, ok)
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	// Entries written by older versions of the CLI don't record their version.
+	for i := 0; i < 4; i++ {
+		pathPrefix := path.Join(aStackRef.HistoryDir(), fmt.Sprintf("a-%d", time.Now().Add(time.Duration(i)).UnixNano()))
+		require.NoError(t, lb.saveHistoryEntry(pathPrefix, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	}
+
+	_, err = lb.Prune(ctx, RetentionPolicy{KeepLast: 2}, aStackRef, false /* dryRun */)
+	require.NoError(t, err)
+
+	// The remaining entries keep the versions they had before the older ones were pruned.
+	history, err := b.GetHistory(ctx, aStackRef, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 4, history[0].Version)
+	assert.Equal(t, 3, history[1].Version)
+
+	require.NoError(t, lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	history, err = b.GetHistory(ctx, aStackRef, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 5, history[0].Version)
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb, ok := b.(*localBackend)
+	require.True(t, ok)
+	assert.Nil(t, lb.RetentionPolicy())
+
+	policy := &RetentionPolicy{KeepLast: 1, Gzip: true}
+	require.NoError(t, lb.SetRetentionPolicy(ctx, policy))
+
+	// The policy is persisted for other users of the state store.
+	b2, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	assert.Equal(t, policy, b2.RetentionPolicy())
+
+	aStackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	aStack, err := b.CreateStack(ctx, aStackRef, "", nil)
+	require.NoError(t, err)
+
+	// History is compressed even though the checkpoint isn't.
+	require.NoError(t, lb.addToHistory(aStackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	files, err := listBucket(lb.bucket, aStackRef.HistoryDir())
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		assert.True(t, strings.HasSuffix(file.Key, ".json.gz"), file.Key)
+		data, err := lb.bucket.ReadAll(ctx, file.Key)
+		require.NoError(t, err)
+		assert.True(t, encoding.IsCompressed(data), file.Key)
+	}
+	_, err = lb.ExportDeploymentForVersion(ctx, aStack, "1")
+	require.NoError(t, err)
+
+	// The policy is enforced when the stack is restored.
+	deployment, err := lb.ExportDeploymentForVersion(ctx, aStack, "1")
+	require.NoError(t, err)
+	require.NoError(t, lb.RestoreDeployment(ctx, aStack, "1", deployment))
+	history, err := b.GetHistory(ctx, aStackRef, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 2, history[0].Version)
+
+	// Remove the policy.
+	require.NoError(t, lb.SetRetentionPolicy(ctx, nil))
+	b3, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	assert.Nil(t, b3.RetentionPolicy())
+}
+
+func TestRetentionPolicy_legacy(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil,
+		&localBackendOptions{Getenv: func(key string) string {
+			if key == PulumiFilestateLegacyLayoutEnvVar {
+				return "1"
+			}
+			return ""
+		}})
+	require.NoError(t, err)
+
+	err = b.SetRetentionPolicy(ctx, &RetentionPolicy{KeepLast: 1})
+	assert.ErrorContains(t, err, "legacy layout")
+}
+
+func TestImportSnapshot_gzip(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(
+		ctx,
+		diagtest.LogSink(t), "file://"+filepath.ToSlash(stateDir),
+		&workspace.Project{Name: "testproj"},
+		&localBackendOptions{
+			Getenv: mapGetenv(map[string]string{
+				"PULUMI_SELF_MANAGED_STATE_GZIP": "true",
+			}),
+		},
+	)
+	require.NoError(t, err)
+
+	fooRef, err := b.ParseStackReference("foo")
+	require.NoError(t, err)
+	foo, err := b.CreateStack(ctx, fooRef, "", nil)
+	require.NoError(t, err)
+
+	resources := []*resource.State{
+		{
+			URN:    resource.NewURN("foo", "testproj", "", "a:b:c", "name"),
+			Type:   "a:b:c",
+			Custom: true,
+			ID:     "id",
+			Outputs: resource.PropertyMap{
+				"password": resource.MakeSecret(resource.NewStringProperty("hunter2")),
+			},
+		},
+	}
+	manifest := deploy.Manifest{Magic: (&deploy.Manifest{}).NewMagic()}
+	snap := deploy.NewSnapshot(manifest, b64.NewBase64SecretsManager(), resources, nil)
+	require.NoError(t, b.ImportSnapshot(ctx, foo, snap))
+
+	// The checkpoint is compressed, and read back one resource at a time.
+	data, err := os.ReadFile(filepath.Join(stateDir, ".pulumi", "stacks", "testproj", "foo.json.gz"))
+	require.NoError(t, err)
+	assert.True(t, encoding.IsCompressed(data))
+
+	got, _, err := b.getStack(ctx, fooRef.(*localBackendReference))
+	require.NoError(t, err)
+	require.Len(t, got.Resources, 1)
+	assert.Equal(t, resource.ID("id"), got.Resources[0].ID)
+	password := got.Resources[0].Outputs["password"]
+	require.True(t, password.IsSecret())
+	assert.Equal(t, "hunter2", password.SecretValue().Element.StringValue())
+}
+
+func TestStreamDeployment(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(
+		ctx,
+		diagtest.LogSink(t), "file://"+filepath.ToSlash(stateDir),
+		&workspace.Project{Name: "testproj"},
+		&localBackendOptions{
+			Getenv: mapGetenv(map[string]string{
+				"PULUMI_SELF_MANAGED_STATE_GZIP": "true",
+			}),
+		},
+	)
+	require.NoError(t, err)
+
+	fooRef, err := b.ParseStackReference("foo")
+	require.NoError(t, err)
+	foo, err := b.CreateStack(ctx, fooRef, "", nil)
+	require.NoError(t, err)
+
+	resources := []*resource.State{
+		{
+			URN:    resource.NewURN("foo", "testproj", "", "a:b:c", "name"),
+			Type:   "a:b:c",
+			Custom: true,
+			ID:     "id",
+			Outputs: resource.PropertyMap{
+				"password": resource.MakeSecret(resource.NewStringProperty("hunter2")),
+			},
+		},
+	}
+	manifest := deploy.Manifest{Magic: (&deploy.Manifest{}).NewMagic()}
+	snap := deploy.NewSnapshot(manifest, b64.NewBase64SecretsManager(), resources, nil)
+	require.NoError(t, b.ImportSnapshot(ctx, foo, snap))
+
+	// The streamed deployment is the one ExportDeployment returns, with its secrets still encrypted.
+	deployment, err := b.ExportDeployment(ctx, foo)
+	require.NoError(t, err)
+	expected, err := json.Marshal(deployment)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, b.StreamDeployment(ctx, foo, &buf))
+	assert.JSONEq(t, string(expected), buf.String())
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestWriteCheckpoint_encodeError(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(stateDir),
+		&workspace.Project{Name: "testproj"}, nil)
+	require.NoError(t, err)
+
+	fooRef, err := b.ParseStackReference("foo")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, fooRef, "", nil)
+	require.NoError(t, err)
+	path := filepath.Join(stateDir, ".pulumi", "stacks", "testproj", "foo.json")
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// A checkpoint that fails to encode part way through is not retried, and doesn't replace the existing one.
+	calls := 0
+	_, _, err = b.writeCheckpoint(fooRef.(*localBackendReference), func(w io.Writer) error {
+		calls++
+		_, err := w.Write([]byte(`{"version": 3, "checkpoint": {`))
+		require.NoError(t, err)
+		return errors.New("great sadness")
+	})
+	assert.ErrorContains(t, err, "great sadness")
+	assert.Equal(t, 1, calls)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
@@ -21,7 +21,10 @@ type Bucket interface {
 	SignedURL(ctx context.Context, key string, opts *blob.SignedURLOptions) (string, error)
 	ReadAll(ctx context.Context, key string) (_ []byte, err error)
 	WriteAll(ctx context.Context, key string, p []byte, opts *blob.WriterOptions) (err error)
+	NewReader(ctx context.Context, key string, opts *blob.ReaderOptions) (_ *blob.Reader, err error)
+	NewWriter(ctx context.Context, key string, opts *blob.WriterOptions) (_ *blob.Writer, err error)
 	Exists(ctx context.Context, key string) (bool, error)
+	Attributes(ctx context.Context, key string) (*blob.Attributes, error)
 }
 
 // wrappedBucket encapsulates a true gocloud blob.Bucket, but ensures that all paths we send to it
@@ -58,10 +61,22 @@ func (b *wrappedBucket) WriteAll(ctx context.Context, key string, p []byte, opts
 	return b.bucket.WriteAll(ctx, filepath.ToSlash(key), p, opts)
 }
 
+func (b *wrappedBucket) NewReader(ctx context.Context, key string, opts *blob.ReaderOptions) (*blob.Reader, error) {
+	return b.bucket.NewReader(ctx, filepath.ToSlash(key), opts)
+}
+
+func (b *wrappedBucket) NewWriter(ctx context.Context, key string, opts *blob.WriterOptions) (*blob.Writer, error) {
+	return b.bucket.NewWriter(ctx, filepath.ToSlash(key), opts)
+}
+
 func (b *wrappedBucket) Exists(ctx context.Context, key string) (bool, error) {
 	return b.bucket.Exists(ctx, filepath.ToSlash(key))
 }
 
+func (b *wrappedBucket) Attributes(ctx context.Context, key string) (*blob.Attributes, error) {
+	return b.bucket.Attributes(ctx, filepath.ToSlash(key))
+}
+
 // listBucket returns a list of all files in the bucket within a given directory. go-cloud sorts the results by key
 func listBucket(bucket Bucket, dir string) ([]*blob.ListObject, error) {
 	bucketIter := bucket.List(&blob.ListOptions{
@@ -0,0 +1,264 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// The journal of an update is stored in the stack's journal directory as a base checkpoint, "<gen>.base.json", and
+// segments of entries appended after it, "<gen>.<seq>.json". Every time the journal is begun again, it gets a new
+// generation, and the files of older generations are deleted only once the new base has been written. Recovery uses
+// the most recent generation that has a base, so the journal is consistent no matter when an update is interrupted.
+const journalBaseName = "base"
+
+// localJournalPersister is a JournalPersister that writes journals to the backend's bucket.
+type localJournalPersister struct {
+	ref     *localBackendReference
+	backend *localBackend
+	sm      secrets.Manager
+
+	generation int // The generation of the current journal.
+	segment    int // The number of the last segment appended to the current journal.
+}
+
+var _ backend.JournalPersister = (*localJournalPersister)(nil)
+
+func (b *localBackend) newJournalPersister(ref *localBackendReference, sm secrets.Manager) *localJournalPersister {
+	return &localJournalPersister{ref: ref, backend: b, sm: sm}
+}
+
+// journalEnabled returns true if updates should be recorded as journals.
+func (b *localBackend) journalEnabled() bool {
+	return cmdutil.IsTruthy(b.Getenv(PulumiFilestateJournalEnvVar))
+}
+
+func (p *localJournalPersister) SecretsManager() secrets.Manager {
+	return p.sm
+}
+
+func (p *localJournalPersister) Begin(base *deploy.Snapshot) error {
+	if err := p.backend.checkLease(p.ref); err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	b, dir := p.backend, p.ref.JournalDir()
+
+	files, err := b.listJournal(dir)
+	if err != nil {
+		return err
+	}
+	generation := p.generation
+	for _, file := range files {
+		if file.generation > generation {
+			generation = file.generation
+		}
+	}
+	generation++
+
+	key := journalBasePath(dir, generation)
+	encodeErr, err := b.writeObject(ctx, key, false /* compress */, func(w io.Writer) error {
+		return stack.EncodeCheckpoint(w, p.ref.FullyQualifiedName(), base, p.sm, false /* showSecrets */)
+	})
+	if encodeErr != nil {
+		return fmt.Errorf("serializing journal base: %w", encodeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("writing journal base: %w", err)
+	}
+	p.generation, p.segment = generation, 0
+
+	// Now that the new base is in place, older journals are no longer needed.
+	for _, file := range files {
+		if err := b.bucket.Delete(ctx, file.key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("deleting %s: %w", file.key, err)
+		}
+	}
+	return nil
+}
+
+func (p *localJournalPersister) Append(entries []apitype.JournalEntryV1) error {
+	if err := p.backend.checkLease(p.ref); err != nil {
+		return err
+	}
+	byts, err := encoding.JSON.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("serializing journal entries: %w", err)
+	}
+
+	key := journalSegmentPath(p.ref.JournalDir(), p.generation, p.segment+1)
+	if err := p.backend.bucket.WriteAll(context.TODO(), key, byts, nil); err != nil {
+		return fmt.Errorf("writing journal entries: %w", err)
+	}
+	p.segment++
+	return nil
+}
+
+func (p *localJournalPersister) Compact(snapshot *deploy.Snapshot) error {
+	if err := p.backend.checkLease(p.ref); err != nil {
+		return err
+	}
+	if _, err := p.backend.saveStack(p.ref, snapshot, p.sm); err != nil {
+		return err
+	}
+	return p.backend.removeJournal(p.ref)
+}
+
+// journalFile is a file of a journal: either its base or one of its segments.
+type journalFile struct {
+	key        string
+	generation int
+	segment    int // The number of the segment, or 0 for the base.
+}
+
+// journalBasePath returns the path of the base of the given generation of a journal.
+func journalBasePath(dir string, generation int) string {
+	return filepath.Join(dir, fmt.Sprintf("%010d.%s.json", generation, journalBaseName))
+}
+
+// journalSegmentPath returns the path of a segment of the given generation of a journal. Numbers are padded so that
+// segments are listed in order.
+func journalSegmentPath(dir string, generation, segment int) string {
+	return filepath.Join(dir, fmt.Sprintf("%010d.%010d.json", generation, segment))
+}
+
+// listJournal returns the files of the journal in the given directory, in order. Files that don't belong to a journal
+// are ignored.
+func (b *localBackend) listJournal(dir string) ([]journalFile, error) {
+	objs, err := listBucket(b.bucket, dir)
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return nil, err
+	}
+
+	var files []journalFile
+	for _, obj := range objs {
+		parts := strings.Split(path.Base(obj.Key), ".")
+		if len(parts) != 3 || parts[2] != "json" {
+			continue
+		}
+		generation, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		file := journalFile{key: obj.Key, generation: generation}
+		if parts[1] != journalBaseName {
+			if file.segment, err = strconv.Atoi(parts[1]); err != nil || file.segment <= 0 {
+				continue
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// readJournal replays the journal left behind by an update of the given stack, if any. It returns false if the stack
+// has no journal.
+func (b *localBackend) readJournal(ctx context.Context, ref *localBackendReference) (*deploy.Snapshot, bool, error) {
+	files, err := b.listJournal(ref.JournalDir())
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Use the most recent generation that has a base.
+	var base *journalFile
+	for i := range files {
+		if files[i].segment == 0 && (base == nil || files[i].generation > base.generation) {
+			base = &files[i]
+		}
+	}
+	if base == nil {
+		return nil, false, nil
+	}
+
+	snapshot, err := b.readSnapshot(ctx, base.key)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %s: %w", base.key, err)
+	}
+	if snapshot == nil {
+		return nil, false, fmt.Errorf("%s has no snapshot", base.key)
+	}
+
+	var entries []apitype.JournalEntryV1
+	for _, file := range files {
+		if file.generation != base.generation || file.segment == 0 {
+			continue
+		}
+		byts, err := b.bucket.ReadAll(ctx, file.key)
+		if err != nil {
+			return nil, false, fmt.Errorf("reading %s: %w", file.key, err)
+		}
+		var segment []apitype.JournalEntryV1
+		if err := encoding.JSON.Unmarshal(byts, &segment); err != nil {
+			return nil, false, fmt.Errorf("reading %s: %w", file.key, err)
+		}
+		entries = append(entries, segment...)
+	}
+
+	logging.V(7).Infof("Replaying %d journal entries for stack %s", len(entries), ref.FullyQualifiedName())
+	snapshot, err = backend.ReplayJournal(ctx, snapshot, entries)
+	if err != nil {
+		return nil, false, fmt.Errorf("replaying the journal in %s: %w", ref.JournalDir(), err)
+	}
+	return snapshot, true, nil
+}
+
+// recoverJournal saves the state recorded in the journal left behind by an interrupted update of the given stack, if
+// any, as the stack's checkpoint.
+func (b *localBackend) recoverJournal(ctx context.Context, ref *localBackendReference) error {
+	snapshot, ok, err := b.readJournal(ctx, ref)
+	if err != nil || !ok {
+		return err
+	}
+
+	b.d.Warningf(diag.Message("", "recovering the state of stack %s from the journal of an interrupted update"), ref)
+	// Pass a nil secrets manager to re-use the one from the journal.
+	if _, err := b.saveStack(ref, snapshot, nil); err != nil {
+		return fmt.Errorf("saving recovered state: %w", err)
+	}
+	return b.removeJournal(ref)
+}
+
+// removeJournal deletes the journal of the given stack, if any.
+func (b *localBackend) removeJournal(ref *localBackendReference) error {
+	files, err := b.listJournal(ref.JournalDir())
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := b.bucket.Delete(context.TODO(), file.key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("deleting %s: %w", file.key, err)
+		}
+	}
+	return nil
+}
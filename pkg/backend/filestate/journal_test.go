@@ -0,0 +1,162 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+type testRegisterResourceEvent struct {
+	deploy.SourceEvent
+}
+
+func (testRegisterResourceEvent) Goal() *resource.Goal               { return nil }
+func (testRegisterResourceEvent) Done(result *deploy.RegisterResult) {}
+
+func newJournalTestStack(t *testing.T) (*localBackend, *localBackendReference) {
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()),
+		&workspace.Project{Name: "testproj"}, nil)
+	require.NoError(t, err)
+
+	stackRef, err := b.ParseStackReference("foo")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, stackRef, "", nil)
+	require.NoError(t, err)
+	return b, stackRef.(*localBackendReference)
+}
+
+func TestJournal_recovery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, ref := newJournalTestStack(t)
+
+	// Start an update that creates a resource and is interrupted before it completes.
+	res := &resource.State{
+		URN:     resource.NewURN("foo", "testproj", "", "a:b:c", "name"),
+		Type:    "a:b:c",
+		Outputs: resource.PropertyMap{"password": resource.MakeSecret(resource.NewStringProperty("hunter2"))},
+	}
+	manager := backend.NewJournalSnapshotManager(b.newJournalPersister(ref, b64.NewBase64SecretsManager()), nil)
+	step := deploy.NewCreateStep(nil, testRegisterResourceEvent{}, res)
+	mutation, err := manager.BeginMutation(step)
+	require.NoError(t, err)
+	require.NoError(t, mutation.End(step, true))
+
+	// The checkpoint hasn't been written yet, but the state is recovered from the journal.
+	chk, err := b.getCheckpoint(ref)
+	require.NoError(t, err)
+	assert.Nil(t, chk.Latest)
+
+	snap, _, err := b.getStack(ctx, ref)
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 1)
+	assert.Equal(t, res.URN, snap.Resources[0].URN)
+	password := snap.Resources[0].Outputs["password"]
+	require.True(t, password.IsSecret())
+	assert.Equal(t, "hunter2", password.SecretValue().Element.StringValue())
+
+	// The next update saves the recovered state as the stack's checkpoint and discards the journal.
+	require.NoError(t, b.recoverJournal(ctx, ref))
+	files, err := b.listJournal(ref.JournalDir())
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	chk, err = b.getCheckpoint(ref)
+	require.NoError(t, err)
+	require.NotNil(t, chk.Latest)
+	require.Len(t, chk.Latest.Resources, 1)
+	assert.Equal(t, res.URN, chk.Latest.Resources[0].URN)
+}
+
+func TestJournal_compact(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, ref := newJournalTestStack(t)
+
+	res := &resource.State{
+		URN:  resource.NewURN("foo", "testproj", "", "a:b:c", "name"),
+		Type: "a:b:c",
+	}
+	manager := backend.NewJournalSnapshotManager(b.newJournalPersister(ref, b64.NewBase64SecretsManager()), nil)
+	step := deploy.NewCreateStep(nil, testRegisterResourceEvent{}, res)
+	mutation, err := manager.BeginMutation(step)
+	require.NoError(t, err)
+	require.NoError(t, mutation.End(step, true))
+
+	files, err := b.listJournal(ref.JournalDir())
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "expected a base and a segment for each of the two entries")
+
+	// Completing the update compacts the journal into the checkpoint.
+	require.NoError(t, manager.Close())
+	files, err = b.listJournal(ref.JournalDir())
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	chk, err := b.getCheckpoint(ref)
+	require.NoError(t, err)
+	require.NotNil(t, chk.Latest)
+	require.Len(t, chk.Latest.Resources, 1)
+	assert.Equal(t, res.URN, chk.Latest.Resources[0].URN)
+
+	snap, _, err := b.getStack(ctx, ref)
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 1)
+}
+
+func TestJournal_begin(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, ref := newJournalTestStack(t)
+
+	p := b.newJournalPersister(ref, b64.NewBase64SecretsManager())
+	base := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), nil, nil)
+	require.NoError(t, p.Begin(base))
+	require.NoError(t, p.Append(nil))
+
+	// Beginning the journal again replaces the previous one.
+	require.NoError(t, p.Begin(base))
+	files, err := b.listJournal(ref.JournalDir())
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, 2, files[0].generation)
+	assert.Equal(t, 0, files[0].segment)
+
+	// Removing the stack removes its journal.
+	require.NoError(t, b.removeStack(ref))
+	files, err = b.listJournal(ref.JournalDir())
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	_, journaled, err := b.readJournal(ctx, ref)
+	require.NoError(t, err)
+	assert.False(t, journaled)
+}
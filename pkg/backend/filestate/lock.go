@@ -23,24 +23,40 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/gofrs/uuid"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
 	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/fsutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
 type lockContent struct {
+	// ID is the ID of the lock, which names its file in the stack's lock directory. It is recorded so that the lock
+	// guard, which has the same content, can be matched to its lock. Locks written by older versions of the CLI have
+	// no ID.
+	ID        string    `json:"id,omitempty"`
 	Pid       int       `json:"pid"`
 	Username  string    `json:"username"`
 	Hostname  string    `json:"hostname"`
 	Timestamp time.Time `json:"timestamp"`
+	// Expires is the time at which the lease on the lock runs out, unless it is renewed before then.
+	// Locks written by older versions of the CLI have no lease and never expire.
+	Expires *time.Time `json:"expires,omitempty"`
 }
 
-func newLockContent() (*lockContent, error) {
+func newLockContent(id string, lease time.Duration) (*lockContent, error) {
 	u, err := user.Current()
 	if err != nil {
 		return nil, err
@@ -49,15 +65,157 @@ func newLockContent() (*lockContent, error) {
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
+	expires := now.Add(lease)
 	return &lockContent{
+		ID:        id,
 		Pid:       os.Getpid(),
 		Username:  u.Username,
 		Hostname:  hostname,
-		Timestamp: time.Now(),
+		Timestamp: now,
+		Expires:   &expires,
 	}, nil
 }
 
+// sameHolder returns true if both locks were acquired by the same process at the same time, whatever their leases.
+func (l *lockContent) sameHolder(other *lockContent) bool {
+	return l.Pid == other.Pid && l.Username == other.Username && l.Hostname == other.Hostname &&
+		l.Timestamp.Equal(other.Timestamp)
+}
+
+// expired returns true if the lease on the lock ran out before the given time.
+func (l *lockContent) expired(now time.Time) bool {
+	return l.Expires != nil && now.After(*l.Expires)
+}
+
+// LockInfo describes a lock held on a stack.
+type LockInfo struct {
+	// ID uniquely identifies the lock within the stack.
+	ID string
+	// Username, Hostname and Pid identify the process that holds the lock.
+	Username string
+	Hostname string
+	Pid      int
+	// Timestamp is the time at which the lock was acquired.
+	Timestamp time.Time
+	// Expires is the time at which the lease on the lock runs out, or nil if the lock never expires.
+	Expires *time.Time
+}
+
+// Expired returns true if the lease on the lock ran out before the given time.
+func (l LockInfo) Expired(now time.Time) bool {
+	return l.Expires != nil && now.After(*l.Expires)
+}
+
+// defaultLockLease is the default duration of the lease on a stack lock.
+const defaultLockLease = 5 * time.Minute
+
+// lockLease returns the duration of the lease on stack locks taken by this backend.
+func (b *localBackend) lockLease() time.Duration {
+	if v := b.Getenv(PulumiFilestateLockLeaseEnvVar); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		logging.V(5).Infof("ignoring invalid lock lease %q", v)
+	}
+	return defaultLockLease
+}
+
+// lease tracks the renewal of a lock held by this backend.
+type lease struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	broken  chan struct{} // closed if the lock is broken or taken over by another process.
+	guarded bool          // true if the lock guard is held as well.
+}
+
+// errLockBroken is returned by renewLease if the lock was deleted or taken over by another process.
+var errLockBroken = errors.New("lock was broken")
+
+// errConditionalWritesUnsupported is returned by ifNotExists for buckets that don't support conditional writes.
+var errConditionalWritesUnsupported = errors.New("conditional writes are not supported by this bucket")
+
+// ifNotExists is a WriterOptions.BeforeWrite callback that makes the write conditional on the object not existing
+// yet. It returns errConditionalWritesUnsupported, aborting the write, if the bucket doesn't support this.
+func ifNotExists(asFunc func(interface{}) bool) error {
+	var gcsObject **storage.ObjectHandle
+	if asFunc(&gcsObject) {
+		*gcsObject = (*gcsObject).If(storage.Conditions{DoesNotExist: true})
+		return nil
+	}
+
+	var azureOpts **azblob.UploadStreamOptions
+	if asFunc(&azureOpts) {
+		if *azureOpts == nil {
+			*azureOpts = &azblob.UploadStreamOptions{}
+		}
+		etagAny := "*"
+		(*azureOpts).BlobAccessConditions = &azblob.BlobAccessConditions{
+			ModifiedAccessConditions: &azblob.ModifiedAccessConditions{IfNoneMatch: &etagAny},
+		}
+		return nil
+	}
+
+	return errConditionalWritesUnsupported
+}
+
+// ifUnchanged returns a WriterOptions.BeforeWrite callback that makes the write conditional on the object still
+// being the version described by the given attributes. The callback returns errConditionalWritesUnsupported,
+// aborting the write, if the bucket doesn't support this.
+func ifUnchanged(attrs *blob.Attributes) func(asFunc func(interface{}) bool) error {
+	return func(asFunc func(interface{}) bool) error {
+		var gcsObject **storage.ObjectHandle
+		if asFunc(&gcsObject) {
+			var objectAttrs storage.ObjectAttrs
+			if !attrs.As(&objectAttrs) {
+				return errConditionalWritesUnsupported
+			}
+			*gcsObject = (*gcsObject).If(storage.Conditions{GenerationMatch: objectAttrs.Generation})
+			return nil
+		}
+
+		var azureOpts **azblob.UploadStreamOptions
+		if asFunc(&azureOpts) {
+			if *azureOpts == nil {
+				*azureOpts = &azblob.UploadStreamOptions{}
+			}
+			etag := attrs.ETag
+			(*azureOpts).BlobAccessConditions = &azblob.BlobAccessConditions{
+				ModifiedAccessConditions: &azblob.ModifiedAccessConditions{IfMatch: &etag},
+			}
+			return nil
+		}
+
+		return errConditionalWritesUnsupported
+	}
+}
+
+// readLock reads the lock file at the given key.
+func (b *localBackend) readLock(ctx context.Context, key string) (*lockContent, error) {
+	content, err := b.bucket.ReadAll(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	l := &lockContent{}
+	if err := json.Unmarshal(content, &l); err != nil {
+		return nil, fmt.Errorf("reading lock %v: %w", key, err)
+	}
+	return l, nil
+}
+
+// describeLock formats a lock for inclusion in an error message.
+func (b *localBackend) describeLock(key string, l *lockContent) string {
+	return fmt.Sprintf("\n  %v: created by %v@%v (pid %v) at %v",
+		b.url+"/"+key,
+		l.Username,
+		l.Hostname,
+		l.Pid,
+		l.Timestamp.Format(time.RFC3339),
+	)
+}
+
 // checkForLock looks for any existing locks for this stack, and returns a helpful diagnostic if there is one.
+// Locks whose lease has expired are taken over, which deletes them.
 func (b *localBackend) checkForLock(ctx context.Context, stackRef backend.StackReference) error {
 	stackName := stackRef.FullyQualifiedName()
 	allFiles, err := listBucket(b.bucket, stackLockDir(stackName))
@@ -69,52 +227,98 @@ func (b *localBackend) checkForLock(ctx context.Context, stackRef backend.StackR
 	// We need to convert it to a slash path (/) to compare it to
 	// the keys in the bucket which are always slash paths.
 	wantLock := filepath.ToSlash(b.lockPath(stackRef))
-	var lockKeys []string
+	now := time.Now()
+	var errorString string
+	var count int
 	for _, file := range allFiles {
-		if file.IsDir {
+		if file.IsDir || file.Key == wantLock {
 			continue
 		}
-		if file.Key != wantLock {
-			lockKeys = append(lockKeys, file.Key)
-		}
-	}
-
-	if len(lockKeys) > 0 {
-		errorString := fmt.Sprintf("the stack is currently locked by %v lock(s). Either wait for the other "+
-			"process(es) to end or delete the lock file with `pulumi cancel`.", len(lockKeys))
 
-		for _, lock := range lockKeys {
-			content, err := b.bucket.ReadAll(ctx, lock)
-			if err != nil {
-				return err
+		l, err := b.readLock(ctx, file.Key)
+		if err != nil {
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				// The lock was released after we listed it.
+				continue
 			}
-			l := &lockContent{}
-			err = json.Unmarshal(content, &l)
-			if err != nil {
+			return err
+		}
+
+		if l.expired(now) {
+			b.d.Warningf(diag.Message("", "taking over expired lock %v held by %v@%v (pid %v) since %v"),
+				b.url+"/"+file.Key, l.Username, l.Hostname, l.Pid, l.Timestamp.Format(time.RFC3339))
+			if err := b.bucket.Delete(ctx, file.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 				return err
 			}
-
-			errorString += fmt.Sprintf("\n  %v: created by %v@%v (pid %v) at %v",
-				b.url+"/"+lock,
-				l.Username,
-				l.Hostname,
-				l.Pid,
-				l.Timestamp.Format(time.RFC3339),
-			)
+			continue
 		}
 
-		return errors.New(errorString)
+		count++
+		errorString += b.describeLock(file.Key, l)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("the stack is currently locked by %v lock(s). Either wait for the other "+
+			"process(es) to end or delete the lock file with `pulumi cancel`.%s", count, errorString)
 	}
 	return nil
 }
 
+// acquireLockGuard creates the stack's lock guard using a conditional write, so that at most one process can hold it
+// at any time. The guard complements the per-process lock files, which are all that older versions of the CLI know
+// about. It returns false without acquiring anything if the bucket doesn't support conditional writes.
+func (b *localBackend) acquireLockGuard(
+	ctx context.Context, stackRef backend.StackReference, content []byte,
+) (bool, error) {
+	if !b.conditionalWrites {
+		return false, nil
+	}
+	key := b.lockGuardPath(stackRef)
+
+	// If the guard is held under an expired lease we delete it and try again, but only once: if somebody else got in
+	// first they now hold the guard.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := b.bucket.WriteAll(ctx, key, content, &blob.WriterOptions{BeforeWrite: ifNotExists})
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, errConditionalWritesUnsupported):
+			return false, nil
+		case gcerrors.Code(err) != gcerrors.FailedPrecondition && gcerrors.Code(err) != gcerrors.AlreadyExists:
+			return false, err
+		}
+
+		l, err := b.readLock(ctx, key)
+		if err != nil {
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				// The guard was released in the meantime.
+				continue
+			}
+			return false, err
+		}
+		if !l.expired(time.Now()) {
+			return false, fmt.Errorf("the stack is currently locked. Either wait for the other "+
+				"process to end or delete the lock file with `pulumi cancel`.%s", b.describeLock(key, l))
+		}
+
+		b.d.Warningf(diag.Message("", "taking over expired lock %v held by %v@%v (pid %v) since %v"),
+			b.url+"/"+key, l.Username, l.Hostname, l.Pid, l.Timestamp.Format(time.RFC3339))
+		if err := b.bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return false, err
+		}
+	}
+
+	return false, errors.New("the stack is currently locked by another process")
+}
+
 func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference) error {
 	//
 	err := b.checkForLock(ctx, stackRef)
 	if err != nil {
 		return err
 	}
-	lockContent, err := newLockContent()
+	leaseDuration := b.lockLease()
+	lockContent, err := newLockContent(b.lockID, leaseDuration)
 	if err != nil {
 		return err
 	}
@@ -122,10 +326,18 @@ func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference
 	if err != nil {
 		return err
 	}
+	guarded, err := b.acquireLockGuard(ctx, stackRef, content)
+	if err != nil {
+		return err
+	}
 	err = b.bucket.WriteAll(ctx, b.lockPath(stackRef), content, nil)
 	if err != nil {
+		if guarded {
+			b.releaseLockGuard(ctx, stackRef)
+		}
 		return err
 	}
+	b.startLease(stackRef, lockContent, leaseDuration, guarded)
 	err = b.checkForLock(ctx, stackRef)
 	if err != nil {
 		b.Unlock(ctx, stackRef)
@@ -134,7 +346,153 @@ func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference
 	return nil
 }
 
+// startLease starts renewing the lease on a lock held by this backend until it is unlocked.
+func (b *localBackend) startLease(
+	stackRef backend.StackReference, content *lockContent, duration time.Duration, guarded bool,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &lease{cancel: cancel, done: make(chan struct{}), broken: make(chan struct{}), guarded: guarded}
+
+	b.leasesMu.Lock()
+	if b.leases == nil {
+		b.leases = make(map[string]*lease)
+	}
+	b.leases[b.lockPath(stackRef)] = l
+	b.leasesMu.Unlock()
+
+	go func() {
+		defer close(l.done)
+
+		ticker := time.NewTicker(duration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				err := b.renewLease(ctx, stackRef, content, now.Add(duration), guarded)
+				if errors.Is(err, errLockBroken) {
+					// Another process may now be updating the stack, so the operation holding the lock must stop.
+					b.d.Errorf(diag.Message("", "the lock on stack %v was broken by another process"),
+						stackRef.FullyQualifiedName())
+					close(l.broken)
+					return
+				}
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					b.d.Warningf(diag.Message("", "failed to renew the lock on stack %v: %v"),
+						stackRef.FullyQualifiedName(), err)
+				}
+			}
+		}
+	}()
+}
+
+// renewLease extends the lease on a lock held by this backend until the given time.
+func (b *localBackend) renewLease(ctx context.Context, stackRef backend.StackReference,
+	content *lockContent, expires time.Time, guarded bool,
+) error {
+	renewed := *content
+	renewed.Expires = &expires
+	byts, err := json.Marshal(renewed)
+	if err != nil {
+		return err
+	}
+	if err := b.renewLock(ctx, b.lockPath(stackRef), content, byts); err != nil {
+		return err
+	}
+	if guarded {
+		return b.renewLock(ctx, b.lockGuardPath(stackRef), content, byts)
+	}
+	return nil
+}
+
+// renewLock overwrites the lock at the given key with the given content, provided that the lock is still the one
+// described by held. It returns errLockBroken if the lock is gone, which happens when it is broken with
+// `pulumi cancel` or similar, or if it now belongs to another process; in either case it is left as it is.
+func (b *localBackend) renewLock(ctx context.Context, key string, held *lockContent, content []byte) error {
+	attrs, err := b.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return errLockBroken
+		}
+		return err
+	}
+	current, err := b.readLock(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return errLockBroken
+		}
+		return err
+	}
+	if !current.sameHolder(held) {
+		return errLockBroken
+	}
+
+	// Where the bucket supports it, make sure that the lock wasn't replaced since we read it.
+	var opts *blob.WriterOptions
+	if b.conditionalWrites {
+		opts = &blob.WriterOptions{BeforeWrite: ifUnchanged(attrs)}
+	}
+	err = b.bucket.WriteAll(ctx, key, content, opts)
+	if gcerrors.Code(err) == gcerrors.FailedPrecondition {
+		return errLockBroken
+	}
+	return err
+}
+
+// checkLease returns an error if the lock held by this backend on the given stack was broken or taken over by
+// another process since it was acquired. Operations that hold the lock must stop writing to the stack if it was.
+func (b *localBackend) checkLease(stackRef backend.StackReference) error {
+	select {
+	case <-b.leaseBroken(stackRef):
+		return fmt.Errorf("the lock on stack %v was broken by another process", stackRef.FullyQualifiedName())
+	default:
+		return nil
+	}
+}
+
+// leaseBroken returns a channel that is closed if the lock held by this backend on the given stack is broken or
+// taken over by another process, or nil if this backend doesn't hold a lock on the stack.
+func (b *localBackend) leaseBroken(stackRef backend.StackReference) <-chan struct{} {
+	b.leasesMu.Lock()
+	defer b.leasesMu.Unlock()
+	if l, has := b.leases[b.lockPath(stackRef)]; has {
+		return l.broken
+	}
+	return nil
+}
+
+// stopLease stops renewing the lease on a lock held by this backend, returning whether the lock guard is held.
+func (b *localBackend) stopLease(stackRef backend.StackReference) (guarded bool) {
+	b.leasesMu.Lock()
+	l, has := b.leases[b.lockPath(stackRef)]
+	delete(b.leases, b.lockPath(stackRef))
+	b.leasesMu.Unlock()
+
+	if !has {
+		return false
+	}
+	l.cancel()
+	<-l.done
+	return l.guarded
+}
+
+func (b *localBackend) releaseLockGuard(ctx context.Context, stackRef backend.StackReference) {
+	err := b.bucket.Delete(ctx, b.lockGuardPath(stackRef))
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		b.d.Errorf(
+			diag.Message("", "there was a problem deleting the lock at %v, manual clean up may be required: %v"),
+			path.Join(b.url, b.lockGuardPath(stackRef)),
+			err)
+	}
+}
+
 func (b *localBackend) Unlock(ctx context.Context, stackRef backend.StackReference) {
+	guarded := b.stopLease(stackRef)
+
 	err := b.bucket.Delete(ctx, b.lockPath(stackRef))
 	if err != nil {
 		b.d.Errorf(
@@ -142,6 +500,132 @@ func (b *localBackend) Unlock(ctx context.Context, stackRef backend.StackReferen
 			path.Join(b.url, b.lockPath(stackRef)),
 			err)
 	}
+
+	if guarded {
+		b.releaseLockGuard(ctx, stackRef)
+	}
+}
+
+// ListLocks returns information about the locks currently held on the given stack.
+func (b *localBackend) ListLocks(ctx context.Context, stackRef backend.StackReference) ([]LockInfo, error) {
+	allFiles, err := listBucket(b.bucket, stackLockDir(stackRef.FullyQualifiedName()))
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var locks []LockInfo
+	for _, file := range allFiles {
+		if file.IsDir {
+			continue
+		}
+
+		l, err := b.readLock(ctx, file.Key)
+		if err != nil {
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		locks = append(locks, LockInfo{
+			ID:        strings.TrimSuffix(objectName(file), ".json"),
+			Username:  l.Username,
+			Hostname:  l.Hostname,
+			Pid:       l.Pid,
+			Timestamp: l.Timestamp,
+			Expires:   l.Expires,
+		})
+	}
+	return locks, nil
+}
+
+// BreakLock deletes the lock with the given ID from the given stack, or all of the stack's locks if the ID is empty.
+func (b *localBackend) BreakLock(ctx context.Context, stackRef backend.StackReference, id string) error {
+	stackName := stackRef.FullyQualifiedName()
+	if id != "" {
+		if !isLockID(id) {
+			return fmt.Errorf("invalid lock ID %q: lock IDs are UUIDs, as listed by `pulumi stack lock ls`", id)
+		}
+		err := b.bucket.Delete(ctx, path.Join(stackLockDir(stackName), id+".json"))
+		found := err == nil
+		if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return err
+		}
+
+		// The guard of the lock has to go as well, or the next process to lock the stack would wait for its lease.
+		guardBroken, err := b.breakLockGuard(ctx, stackRef, id)
+		if err != nil {
+			return err
+		}
+		if !found && !guardBroken {
+			return fmt.Errorf("no lock with ID %q found for stack %v", id, stackName)
+		}
+		return nil
+	}
+
+	// Try to delete ALL the lock files
+	allFiles, err := listBucket(b.bucket, stackLockDir(stackName))
+	if err != nil {
+		// Don't error if it just wasn't found
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range allFiles {
+		if file.IsDir {
+			continue
+		}
+
+		err := b.bucket.Delete(ctx, file.Key)
+		if err != nil {
+			// Race condition, don't error if the file was delete between us calling list and now
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				continue
+			}
+			return err
+		}
+	}
+
+	// And the guard, for buckets that support it.
+	err = b.bucket.Delete(ctx, b.lockGuardPath(stackRef))
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return err
+	}
+	return nil
+}
+
+// breakLockGuard deletes the stack's lock guard if it belongs to the lock with the given ID, returning whether it did.
+func (b *localBackend) breakLockGuard(ctx context.Context, stackRef backend.StackReference, id string) (bool, error) {
+	key := b.lockGuardPath(stackRef)
+	l, err := b.readLock(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if l.ID != id {
+		return false, nil
+	}
+	if err := b.bucket.Delete(ctx, key); err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// isLockID returns true if the given string is a lock ID, as generated by New. Since lock IDs name objects in the
+// bucket, anything else is rejected so that it can't be used to refer to objects outside of a stack's lock directory.
+func isLockID(id string) bool {
+	u, err := uuid.FromString(id)
+	return err == nil && u.String() == id
 }
 
 func lockDir() string {
@@ -157,3 +641,10 @@ func (b *localBackend) lockPath(stackRef backend.StackReference) string {
 	contract.Requiref(stackRef != nil, "stack", "must not be nil")
 	return path.Join(stackLockDir(stackRef.FullyQualifiedName()), b.lockID+".json")
 }
+
+// lockGuardPath returns the path of the object that is conditionally written to guard the stack's lock. It lives
+// next to the stack's lock directory, rather than inside it, so that older versions of the CLI don't see it.
+func (b *localBackend) lockGuardPath(stackRef backend.StackReference) string {
+	contract.Requiref(stackRef != nil, "stack", "must not be nil")
+	return stackLockDir(stackRef.FullyQualifiedName()) + ".json"
+}
@@ -16,9 +16,11 @@ package filestate
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
@@ -49,6 +51,80 @@ type pulumiMeta struct {
 	// Does not use "omitempty" to differentiate
 	// between a missing field and a zero value.
 	Version int `json:"version" yaml:"version"`
+
+	// Retention is the policy used to prune the history
+	// and backups of stacks in the state store, if any.
+	//
+	// It is stored in the metadata file so that
+	// all users of the state store get the same behavior.
+	Retention *RetentionPolicy `json:"retention,omitempty" yaml:"retention,omitempty"`
+}
+
+// RetentionPolicy limits how much history and how many backups
+// are kept for each stack in a filestate backend.
+//
+// The most recent history entry and backup of a stack
+// are always kept.
+type RetentionPolicy struct {
+	// KeepLast is the number of most recent history entries
+	// and backups to keep for each stack.
+	// Zero means no limit.
+	KeepLast int `json:"keepLast,omitempty" yaml:"keepLast,omitempty"`
+
+	// MaxAge is the age after which history entries
+	// and backups are deleted.
+	// It is stored as a duration string, such as "720h0m0s".
+	// Zero means no limit.
+	MaxAge time.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+
+	// Gzip compresses the checkpoints saved to stack history,
+	// regardless of whether PULUMI_SELF_MANAGED_STATE_GZIP is set.
+	Gzip bool `json:"gzip,omitempty" yaml:"gzip,omitempty"`
+}
+
+// retentionPolicyJSON is the JSON form of a RetentionPolicy, with MaxAge as a duration string such as "720h0m0s",
+// the form in which YAML stores it, rather than a number of nanoseconds.
+type retentionPolicyJSON struct {
+	KeepLast int    `json:"keepLast,omitempty"`
+	MaxAge   string `json:"maxAge,omitempty"`
+	Gzip     bool   `json:"gzip,omitempty"`
+}
+
+// MarshalJSON writes the policy with MaxAge as a duration string.
+func (p RetentionPolicy) MarshalJSON() ([]byte, error) {
+	out := retentionPolicyJSON{KeepLast: p.KeepLast, Gzip: p.Gzip}
+	if p.MaxAge != 0 {
+		out.MaxAge = p.MaxAge.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a policy written by MarshalJSON.
+func (p *RetentionPolicy) UnmarshalJSON(b []byte) error {
+	var in retentionPolicyJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	*p = RetentionPolicy{KeepLast: in.KeepLast, Gzip: in.Gzip}
+	if in.MaxAge != "" {
+		age, err := time.ParseDuration(in.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid maxAge: %w", err)
+		}
+		p.MaxAge = age
+	}
+	return nil
+}
+
+// Validate reports an error if the policy's limits are invalid.
+func (p *RetentionPolicy) Validate() error {
+	if p.KeepLast < 0 {
+		return fmt.Errorf("keepLast must not be negative, got %d", p.KeepLast)
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("maxAge must not be negative, got %v", p.MaxAge)
+	}
+	return nil
 }
 
 // ensurePulumiMeta loads the Pulumi state metadata file from the bucket.
@@ -134,6 +210,8 @@ func readPulumiMeta(ctx context.Context, b Bucket) (*pulumiMeta, error) {
 	var state struct {
 		// Version 0 is valid, so we need to use a pointer.
 		Version *int `yaml:"version"`
+
+		Retention *RetentionPolicy `yaml:"retention"`
 	}
 
 	if err := yaml.Unmarshal(metaBody, &state); err != nil {
@@ -144,8 +222,15 @@ func readPulumiMeta(ctx context.Context, b Bucket) (*pulumiMeta, error) {
 		return nil, fmt.Errorf("corrupt store: missing version in %q", pulumiMetaPath)
 	}
 
+	if state.Retention != nil {
+		if err := state.Retention.Validate(); err != nil {
+			return nil, fmt.Errorf("corrupt store: invalid retention policy in %q: %w", pulumiMetaPath, err)
+		}
+	}
+
 	return &pulumiMeta{
-		Version: *state.Version,
+		Version:   *state.Version,
+		Retention: state.Retention,
 	}, nil
 }
 
@@ -16,8 +16,10 @@ package filestate
 
 import (
 	"context"
+	"encoding/json"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
 	"github.com/stretchr/testify/assert"
@@ -138,6 +140,11 @@ func TestEnsurePulumiMeta_corruption(t *testing.T) {
 			give:    `version: foo`,
 			wantErr: `corrupt store: unmarshal ".pulumi/meta.yaml"`,
 		},
+		{
+			desc:    "negative retention",
+			give:    "version: 1\nretention:\n  keepLast: -1",
+			wantErr: `corrupt store: invalid retention policy in ".pulumi/meta.yaml": keepLast must not be negative`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -166,6 +173,14 @@ func TestMeta_roundTrip(t *testing.T) {
 		{desc: "zero", give: pulumiMeta{Version: 0}},
 		{desc: "one", give: pulumiMeta{Version: 1}},
 		{desc: "future", give: pulumiMeta{Version: 42}},
+		{
+			desc: "retention",
+			give: pulumiMeta{Version: 1, Retention: &RetentionPolicy{
+				KeepLast: 10,
+				MaxAge:   30 * 24 * time.Hour,
+				Gzip:     true,
+			}},
+		},
 	}
 
 	for _, tt := range tests {
@@ -222,3 +237,29 @@ func TestNew_noMetaOnInit(t *testing.T) {
 
 	assert.NoFileExists(t, filepath.Join(tmpDir, ".pulumi", "meta.yaml"))
 }
+
+func TestReadPulumiMeta_retentionDuration(t *testing.T) {
+	t.Parallel()
+
+	b := memblob.OpenBucket(nil)
+	ctx := context.Background()
+	require.NoError(t, b.WriteAll(ctx, ".pulumi/meta.yaml",
+		[]byte("version: 1\nretention:\n  keepLast: 5\n  maxAge: 720h\n"), nil))
+
+	meta, err := readPulumiMeta(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, &RetentionPolicy{KeepLast: 5, MaxAge: 720 * time.Hour}, meta.Retention)
+
+	// The age is written back as a duration string, in YAML and in JSON, rather than a number of nanoseconds.
+	require.NoError(t, meta.WriteTo(ctx, b))
+	body, err := b.ReadAll(ctx, ".pulumi/meta.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "maxAge: 720h0m0s")
+
+	body, err = json.Marshal(meta.Retention)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"keepLast": 5, "maxAge": "720h0m0s"}`, string(body))
+	var policy RetentionPolicy
+	require.NoError(t, json.Unmarshal(body, &policy))
+	assert.Equal(t, *meta.Retention, policy)
+}
@@ -0,0 +1,257 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+)
+
+// PrunedFile describes a file deleted, or that would be deleted, by Prune.
+type PrunedFile struct {
+	// Path is the path of the file inside the bucket.
+	Path string
+	// Size is the size of the file in bytes.
+	Size int64
+}
+
+func (b *localBackend) RetentionPolicy() *RetentionPolicy {
+	return b.retention
+}
+
+func (b *localBackend) SetRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error {
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	meta, err := readPulumiMeta(ctx, b.bucket)
+	if err != nil {
+		return err
+	}
+	if meta == nil || meta.Version == 0 {
+		return errors.New("retention policies can't be saved in state stores using the legacy layout; " +
+			"run 'pulumi state upgrade' first")
+	}
+
+	meta.Retention = policy
+	if err := meta.WriteTo(ctx, b.bucket); err != nil {
+		return err
+	}
+	b.retention = policy
+	return nil
+}
+
+func (b *localBackend) Prune(ctx context.Context, policy RetentionPolicy, stackRef backend.StackReference,
+	dryRun bool,
+) ([]PrunedFile, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	var refs []*localBackendReference
+	if stackRef != nil {
+		ref, err := b.getReference(stackRef)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	} else {
+		var err error
+		if refs, err = b.getLocalStacks(); err != nil {
+			return nil, err
+		}
+	}
+
+	var pruned []PrunedFile
+	for _, ref := range refs {
+		files, err := b.pruneStack(ctx, ref, policy, dryRun)
+		pruned = append(pruned, files...)
+		if err != nil {
+			return pruned, fmt.Errorf("pruning stack %s: %w", ref, err)
+		}
+	}
+	return pruned, nil
+}
+
+// enforceRetention prunes the given stack according to the state store's retention policy, if it has one. Failures
+// are reported as warnings, since they don't affect the stack itself.
+func (b *localBackend) enforceRetention(ctx context.Context, ref *localBackendReference) {
+	if b.retention == nil {
+		return
+	}
+	if _, err := b.pruneStack(ctx, ref, *b.retention, false /* dryRun */); err != nil {
+		b.d.Warningf(diag.Message("", "could not prune the history of stack %s: %v"), ref, err)
+	}
+}
+
+// pruneStack deletes the history entries and backups of the given stack that are not retained by the policy.
+func (b *localBackend) pruneStack(ctx context.Context, ref *localBackendReference, policy RetentionPolicy,
+	dryRun bool,
+) ([]PrunedFile, error) {
+	now := time.Now()
+
+	// History entries are returned most recent first.
+	historyEntries, err := b.historyEntries(ref)
+	if err != nil {
+		return nil, err
+	}
+	historyFiles, err := listBucket(b.bucket, ref.HistoryDir())
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return nil, err
+	}
+	checkpoints := make(map[string]*blob.ListObject, len(historyFiles))
+	for _, file := range historyFiles {
+		checkpoints[file.Key] = file
+	}
+
+	var pruned []*blob.ListObject
+	var retained []int
+	for i, entry := range historyEntries {
+		if policy.retains(i, historyFileTime(entry), now) {
+			retained = append(retained, i)
+			continue
+		}
+		pruned = append(pruned, entry)
+		if checkpoint, has := checkpoints[historyCheckpointPath(entry.Key)]; has {
+			pruned = append(pruned, checkpoint)
+		}
+	}
+	if len(pruned) > 0 && !dryRun {
+		if err := b.pinHistoryVersions(ctx, historyEntries, retained); err != nil {
+			return nil, err
+		}
+	}
+
+	var backups []*blob.ListObject
+	backupFiles, err := listBucket(b.bucket, ref.BackupDir())
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return nil, err
+	}
+	for _, file := range backupFiles {
+		if !file.IsDir {
+			backups = append(backups, file)
+		}
+	}
+	// Backup file names end with a timestamp, so sort them most recent first.
+	backupTimes := make(map[string]time.Time, len(backups))
+	for _, backup := range backups {
+		backupTimes[backup.Key] = backupFileTime(backup)
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backupTimes[backups[i].Key].After(backupTimes[backups[j].Key])
+	})
+	for i, backup := range backups {
+		if !policy.retains(i, backupTimes[backup.Key], now) {
+			pruned = append(pruned, backup)
+		}
+	}
+
+	files := make([]PrunedFile, 0, len(pruned))
+	for _, obj := range pruned {
+		if !dryRun {
+			if err := b.bucket.Delete(ctx, obj.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+				return files, fmt.Errorf("deleting %s: %w", obj.Key, err)
+			}
+		}
+		files = append(files, PrunedFile{Path: obj.Key, Size: obj.Size})
+	}
+	return files, nil
+}
+
+// pinHistoryVersions records their version in the given history entries that were written by older versions of the
+// CLI without one. Those are numbered by their position in the history, which changes when older entries are pruned.
+func (b *localBackend) pinHistoryVersions(ctx context.Context, historyEntries []*blob.ListObject, entries []int) error {
+	for _, i := range entries {
+		key := historyEntries[i].Key
+		byts, err := b.bucket.ReadAll(ctx, key)
+		if err != nil {
+			return fmt.Errorf("reading history file %s: %w", key, err)
+		}
+		m := encoding.JSON
+		if encoding.IsCompressed(byts) {
+			m = encoding.Gzip(m)
+		}
+		var update backend.UpdateInfo
+		if err := m.Unmarshal(byts, &update); err != nil {
+			return fmt.Errorf("reading history file %s: %w", key, err)
+		}
+		if update.Version != 0 {
+			continue
+		}
+
+		update.Version = len(historyEntries) - i
+		if byts, err = m.Marshal(&update); err != nil {
+			return err
+		}
+		if err := b.bucket.WriteAll(ctx, key, byts, nil); err != nil {
+			return fmt.Errorf("writing history file %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// retains reports whether the policy keeps the i'th most recent history entry or backup, created at the given time.
+func (p RetentionPolicy) retains(i int, created, now time.Time) bool {
+	switch {
+	case i == 0:
+		// Always keep the most recent entry.
+		return true
+	case p.KeepLast > 0 && i >= p.KeepLast:
+		return false
+	case p.MaxAge > 0 && now.Sub(created) > p.MaxAge:
+		return false
+	default:
+		return true
+	}
+}
+
+// historyFileTime returns the time a history file was written,
+// as recorded in its name: "<stack>-<unixnano>.history.json".
+func historyFileTime(obj *blob.ListObject) time.Time {
+	name := path.Base(obj.Key)
+	name = name[:strings.LastIndex(name, ".history.")]
+	return parseUnixNano(name[strings.LastIndex(name, "-")+1:], obj.ModTime)
+}
+
+// backupFileTime returns the time a backup file was written,
+// as recorded in its name: "<stack>.<unixnano>.json".
+func backupFileTime(obj *blob.ListObject) time.Time {
+	name := strings.TrimSuffix(path.Base(obj.Key), encoding.GZIPExt)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	return parseUnixNano(name[strings.LastIndex(name, ".")+1:], obj.ModTime)
+}
+
+func parseUnixNano(s string, fallback time.Time) time.Time {
+	nanos, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return time.Unix(0, nanos)
+}
@@ -32,6 +32,9 @@ func (sp *localSnapshotPersister) SecretsManager() secrets.Manager {
 }
 
 func (sp *localSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
+	if err := sp.backend.checkLease(sp.ref); err != nil {
+		return err
+	}
 	_, err := sp.backend.saveStack(sp.ref, snapshot, sp.sm)
 	return err
 }
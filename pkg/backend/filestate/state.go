@@ -15,6 +15,9 @@
 package filestate
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -142,25 +145,59 @@ func (b *localBackend) getStack(
 
 	file := b.stackPath(ref)
 
-	chk, err := b.getCheckpoint(ref)
+	// If an update of the stack is running, or was interrupted, its journal holds more recent state than the
+	// checkpoint.
+	snapshot, journaled, err := b.readJournal(ctx, ref)
 	if err != nil {
-		return nil, file, fmt.Errorf("failed to load checkpoint: %w", err)
+		return nil, file, fmt.Errorf("failed to load journal: %w", err)
 	}
 
-	// Materialize an actual snapshot object.
-	snapshot, err := stack.DeserializeCheckpoint(ctx, stack.DefaultSecretsProvider, chk)
+	// Otherwise materialize an actual snapshot object, one resource at a time.
+	if !journaled {
+		snapshot, err = b.readSnapshot(ctx, file)
+		if err != nil {
+			return nil, file, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+	}
+
+	if err := backend.VerifyLoadedSnapshot(snapshot, file, !DisableIntegrityChecking); err != nil {
+		return nil, file, err
+	}
+	return snapshot, file, nil
+}
+
+// readSnapshot decodes the snapshot stored in the given checkpoint file as it is read from the bucket.
+func (b *localBackend) readSnapshot(ctx context.Context, file string) (*deploy.Snapshot, error) {
+	var snap *deploy.Snapshot
+	err := b.readCheckpoint(ctx, file, func(r io.Reader) error {
+		var err error
+		snap, err = stack.DecodeCheckpoint(ctx, r, stack.DefaultSecretsProvider)
+		return err
+	})
+	return snap, err
+}
+
+// readCheckpoint calls read with the contents of the given checkpoint file as they are read from the bucket,
+// decompressing them if needed.
+func (b *localBackend) readCheckpoint(ctx context.Context, file string, read func(r io.Reader) error) error {
+	r, err := b.bucket.NewReader(ctx, file, nil)
 	if err != nil {
-		return nil, "", err
+		return err
 	}
+	defer contract.IgnoreClose(r)
 
-	// Ensure the snapshot passes verification before returning it, to catch bugs early.
-	if !DisableIntegrityChecking {
-		if verifyerr := snapshot.VerifyIntegrity(); verifyerr != nil {
-			return nil, file, fmt.Errorf("%s: snapshot integrity failure; refusing to use it: %w", file, verifyerr)
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, err := br.Peek(3); err == nil && encoding.IsCompressed(magic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
 		}
+		defer contract.IgnoreClose(zr)
+		src = zr
 	}
 
-	return snapshot, file, nil
+	return read(src)
 }
 
 // GetCheckpoint loads a checkpoint file for the given stack in this project, from the current project workspace.
@@ -180,6 +217,21 @@ func (b *localBackend) getCheckpoint(ref *localBackendReference) (*apitype.Check
 
 func (b *localBackend) saveCheckpoint(
 	ref *localBackendReference, checkpoint *apitype.VersionedCheckpoint,
+) (backupFile string, file string, _ error) {
+	return b.writeCheckpoint(ref, func(w io.Writer) error {
+		byts, err := encoding.JSON.Marshal(checkpoint)
+		if err != nil {
+			return fmt.Errorf("An IO error occurred while marshalling the checkpoint: %w", err)
+		}
+		_, err = w.Write(byts)
+		return err
+	})
+}
+
+// writeCheckpoint writes the checkpoint produced by encode to the stack's checkpoint file. encode may be called more
+// than once if writing to the bucket needs to be retried.
+func (b *localBackend) writeCheckpoint(
+	ref *localBackendReference, encode func(w io.Writer) error,
 ) (backupFile string, file string, _ error) {
 	// Make a serializable stack and then use the encoder to encode it.
 	file = b.stackPath(ref)
@@ -194,17 +246,11 @@ func (b *localBackend) saveCheckpoint(
 		if filepath.Ext(file) != encoding.GZIPExt {
 			file = file + ".gz"
 		}
-		m = encoding.Gzip(m)
 	} else {
 		file = strings.TrimSuffix(file, ".gz")
 	}
 
-	byts, err := m.Marshal(checkpoint)
-	if err != nil {
-		return "", "", fmt.Errorf("An IO error occurred while marshalling the checkpoint: %w", err)
-	}
-
-	// Back up the existing file if it already exists. Don't delete the original, the following WriteAll will
+	// Back up the existing file if it already exists. Don't delete the original, the following write will
 	// atomically replace it anyway and various other bits of the system depend on being able to find the
 	// .json file to know the stack currently exists (see https://github.com/pulumi/pulumi/issues/9033 for
 	// context).
@@ -221,7 +267,11 @@ func (b *localBackend) saveCheckpoint(
 	}
 
 	// And now write out the new snapshot file, overwriting that location.
-	if err = b.bucket.WriteAll(context.TODO(), file, byts, nil); err != nil {
+	encodeErr, err := b.writeObject(context.TODO(), file, b.gzip, encode)
+	if encodeErr != nil {
+		return backupFile, "", encodeErr
+	}
+	if err != nil {
 
 		b.mutex.Lock()
 		defer b.mutex.Unlock()
@@ -238,7 +288,10 @@ func (b *localBackend) saveCheckpoint(
 			Backoff:  &backoff,
 			Accept: func(try int, nextRetryTime time.Duration) (bool, interface{}, error) {
 				// And now write out the new snapshot file, overwriting that location.
-				err := b.bucket.WriteAll(context.TODO(), file, byts, nil)
+				encodeErr, err := b.writeObject(context.TODO(), file, b.gzip, encode)
+				if encodeErr != nil {
+					return false, nil, encodeErr
+				}
 				if err != nil {
 					logging.V(7).Infof("Error while writing snapshot to: %s (attempt=%d, error=%s)", file, try, err)
 					if try > 10 {
@@ -258,7 +311,7 @@ func (b *localBackend) saveCheckpoint(
 
 	// And if we are retaining historical checkpoint information, write it out again
 	if cmdutil.IsTruthy(b.Getenv("PULUMI_RETAIN_CHECKPOINTS")) {
-		if err = b.bucket.WriteAll(context.TODO(), fmt.Sprintf("%v.%v", file, time.Now().UnixNano()), byts, nil); err != nil {
+		if err = b.bucket.Copy(context.TODO(), fmt.Sprintf("%v.%v", file, time.Now().UnixNano()), file, nil); err != nil {
 			return backupFile, "", fmt.Errorf("An IO error occurred while writing the new snapshot file: %w", err)
 		}
 	}
@@ -266,32 +319,81 @@ func (b *localBackend) saveCheckpoint(
 	return backupFile, file, nil
 }
 
+// writeObject writes the object produced by encode to the given key, compressing it if compress is set. The object is
+// only replaced if encode succeeds. Errors returned by encode, as opposed to errors writing to the bucket, are
+// returned separately as encodeErr since retrying the write won't fix them.
+func (b *localBackend) writeObject(
+	ctx context.Context, key string, compress bool, encode func(w io.Writer) error,
+) (encodeErr error, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w, err := b.bucket.NewWriter(ctx, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	bw := &bucketWriter{w: w}
+	var dst io.Writer = bw
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(bw)
+		dst = zw
+	}
+
+	abort := func() {
+		// Cancelling the context before closing the writer discards what was written.
+		cancel()
+		contract.IgnoreClose(w)
+	}
+	if err := encode(dst); err != nil {
+		abort()
+		if bw.err != nil {
+			return nil, bw.err
+		}
+		return err, nil
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			abort()
+			return nil, err
+		}
+	}
+	return nil, w.Close()
+}
+
+// bucketWriter records the first error returned by a bucket writer, to tell it apart from errors encoding the data
+// being written.
+type bucketWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *bucketWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
 func (b *localBackend) saveStack(
 	ref *localBackendReference, snap *deploy.Snapshot,
 	sm secrets.Manager,
 ) (string, error) {
 	contract.Requiref(ref != nil, "ref", "ref was nil")
-	chk, err := stack.SerializeCheckpoint(ref.FullyQualifiedName(), snap, sm, false /* showSecrets */)
-	if err != nil {
-		return "", fmt.Errorf("serializaing checkpoint: %w", err)
-	}
 
-	backup, file, err := b.saveCheckpoint(ref, chk)
+	// Stream the checkpoint straight to the bucket, rather than serializing it in memory first, to keep memory use
+	// flat for large stacks.
+	var file string
+	err := backend.SaveCheckpoint(ref.FullyQualifiedName(), snap, sm, !DisableIntegrityChecking,
+		func(encode func(w io.Writer) error) (string, error) {
+			backup, f, err := b.writeCheckpoint(ref, encode)
+			file = f
+			return fmt.Sprintf("%s (backup available at %s)", f, backup), err
+		})
 	if err != nil {
 		return "", err
 	}
-
-	if !DisableIntegrityChecking {
-		// Finally, *after* writing the checkpoint, check the integrity.  This is done afterwards so that we write
-		// out the checkpoint file since it may contain resource state updates.  But we will warn the user that the
-		// file is already written and might be bad.
-		if verifyerr := snap.VerifyIntegrity(); verifyerr != nil {
-			return "", fmt.Errorf(
-				"%s: snapshot integrity failure; it was already written, but is invalid (backup available at %s): %w",
-				file, backup, verifyerr)
-		}
-	}
-
 	return file, nil
 }
 
@@ -303,6 +405,13 @@ func (b *localBackend) removeStack(ref *localBackendReference) error {
 	file := b.stackPath(ref)
 	backupTarget(b.bucket, file, false)
 
+	if err := b.removeTags(ref); err != nil {
+		return err
+	}
+	if err := b.removeJournal(ref); err != nil {
+		return err
+	}
+
 	historyDir := ref.HistoryDir()
 	return removeAllByPrefix(b.bucket, historyDir)
 }
@@ -406,14 +515,11 @@ func (b *localBackend) stackPath(ref *localBackendReference) string {
 	return plainPath
 }
 
-// getHistory returns locally stored update history. The first element of the result will be
-// the most recent update record.
-func (b *localBackend) getHistory(stack *localBackendReference, pageSize int, page int) ([]backend.UpdateInfo, error) {
+// historyEntries returns the history files of the given stack, most recent first.
+func (b *localBackend) historyEntries(stack *localBackendReference) ([]*blob.ListObject, error) {
 	contract.Requiref(stack != nil, "stack", "must not be nil")
 
 	dir := stack.HistoryDir()
-	// TODO: we could consider optimizing the list operation using `page` and `pageSize`.
-	// Unfortunately, this is mildly invasive given the gocloud List API.
 	allFiles, err := listBucket(b.bucket, dir)
 	if err != nil {
 		// History doesn't exist until a stack has been updated.
@@ -441,6 +547,21 @@ func (b *localBackend) getHistory(stack *localBackendReference, pageSize int, pa
 		historyEntries = append(historyEntries, file)
 	}
 
+	return historyEntries, nil
+}
+
+// getHistory returns locally stored update history. The first element of the result will be
+// the most recent update record.
+func (b *localBackend) getHistory(stack *localBackendReference, pageSize int, page int) ([]backend.UpdateInfo, error) {
+	contract.Requiref(stack != nil, "stack", "must not be nil")
+
+	// TODO: we could consider optimizing the list operation using `page` and `pageSize`.
+	// Unfortunately, this is mildly invasive given the gocloud List API.
+	historyEntries, err := b.historyEntries(stack)
+	if err != nil {
+		return nil, err
+	}
+
 	start := 0
 	end := len(historyEntries) - 1
 	if pageSize > 0 {
@@ -457,27 +578,89 @@ func (b *localBackend) getHistory(stack *localBackendReference, pageSize int, pa
 	var updates []backend.UpdateInfo
 
 	for i := start; i <= end; i++ {
-		file := historyEntries[i]
-		filepath := file.Key
+		update, err := b.readHistoryEntry(historyEntries, i)
+		if err != nil {
+			return nil, err
+		}
+
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// readHistoryEntry reads the i'th of the given history entries, as returned by historyEntries.
+func (b *localBackend) readHistoryEntry(historyEntries []*blob.ListObject, i int) (backend.UpdateInfo, error) {
+	filepath := historyEntries[i].Key
+
+	var update backend.UpdateInfo
+	bytes, err := b.bucket.ReadAll(context.TODO(), filepath)
+	if err != nil {
+		return update, fmt.Errorf("reading history file %s: %w", filepath, err)
+	}
+	m := encoding.JSON
+	if encoding.IsCompressed(bytes) {
+		m = encoding.Gzip(m)
+	}
+	err = m.Unmarshal(bytes, &update)
+	if err != nil {
+		return update, fmt.Errorf("reading history file %s: %w", filepath, err)
+	}
+
+	// Entries written by older versions of the CLI don't record their version.
+	// Those are numbered from the oldest update, starting at 1.
+	if update.Version == 0 {
+		update.Version = len(historyEntries) - i
+	}
+	return update, nil
+}
+
+// historyCheckpointPath returns the path of the checkpoint saved alongside the given history file.
+func historyCheckpointPath(historyFile string) string {
+	// The checkpoint shares its prefix with the history file.
+	idx := strings.LastIndex(historyFile, ".history.")
+	return historyFile[:idx] + ".checkpoint." + historyFile[idx+len(".history."):]
+}
+
+// getHistoryCheckpoint returns the checkpoint saved alongside the given version of the stack's history.
+func (b *localBackend) getHistoryCheckpoint(ref *localBackendReference, version int) (*apitype.CheckpointV3, error) {
+	historyEntries, err := b.historyEntries(ref)
+	if err != nil {
+		return nil, err
+	}
 
-		var update backend.UpdateInfo
-		b, err := b.bucket.ReadAll(context.TODO(), filepath)
+	// Versions decrease from the most recent entry, but may have gaps if old entries were pruned.
+	historyFile := ""
+	for i := range historyEntries {
+		update, err := b.readHistoryEntry(historyEntries, i)
 		if err != nil {
-			return nil, fmt.Errorf("reading history file %s: %w", filepath, err)
+			return nil, err
 		}
-		m := encoding.JSON
-		if encoding.IsCompressed(b) {
-			m = encoding.Gzip(m)
+		if update.Version == version {
+			historyFile = historyEntries[i].Key
 		}
-		err = m.Unmarshal(b, &update)
-		if err != nil {
-			return nil, fmt.Errorf("reading history file %s: %w", filepath, err)
+		if update.Version <= version {
+			break
 		}
+	}
+	if historyFile == "" {
+		return nil, fmt.Errorf("stack %s has no version %d", ref, version)
+	}
 
-		updates = append(updates, update)
+	checkpointFile := historyCheckpointPath(historyFile)
+	bytes, err := b.bucket.ReadAll(context.TODO(), checkpointFile)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, fmt.Errorf("no checkpoint was saved for version %d of stack %s", version, ref)
+		}
+		return nil, fmt.Errorf("reading checkpoint file %s: %w", checkpointFile, err)
+	}
+	m := encoding.JSON
+	if encoding.IsCompressed(bytes) {
+		m = encoding.Gzip(m)
 	}
 
-	return updates, nil
+	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(m, bytes)
 }
 
 func (b *localBackend) renameHistory(oldName *localBackendReference, newName *localBackendReference) error {
@@ -523,35 +706,201 @@ func (b *localBackend) renameHistory(oldName *localBackendReference, newName *lo
 	return nil
 }
 
+// getTags returns the tags stored for the given stack. Stacks without a tags file have no tags.
+func (b *localBackend) getTags(ref *localBackendReference) (map[apitype.StackTagName]string, error) {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	byts, err := b.bucket.ReadAll(context.TODO(), ref.TagsPath())
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading tags for stack %s: %w", ref.FullyQualifiedName(), err)
+	}
+
+	var tags map[apitype.StackTagName]string
+	if err := encoding.JSON.Unmarshal(byts, &tags); err != nil {
+		return nil, fmt.Errorf("reading tags for stack %s: %w", ref.FullyQualifiedName(), err)
+	}
+	return tags, nil
+}
+
+// saveTags replaces the tags stored for the given stack.
+func (b *localBackend) saveTags(ref *localBackendReference, tags map[apitype.StackTagName]string) error {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	if tags == nil {
+		tags = map[apitype.StackTagName]string{}
+	}
+	byts, err := encoding.JSON.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	if err := b.bucket.WriteAll(context.TODO(), ref.TagsPath(), byts, nil); err != nil {
+		return fmt.Errorf("writing tags for stack %s: %w", ref.FullyQualifiedName(), err)
+	}
+	return nil
+}
+
+// removeTags deletes the tags stored for the given stack, if any.
+func (b *localBackend) removeTags(ref *localBackendReference) error {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	if err := b.bucket.Delete(context.TODO(), ref.TagsPath()); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return fmt.Errorf("deleting tags for stack %s: %w", ref.FullyQualifiedName(), err)
+	}
+	return nil
+}
+
+// renameTags moves the tags stored for a stack from its old name to its new one.
+func (b *localBackend) renameTags(oldName *localBackendReference, newName *localBackendReference) error {
+	contract.Requiref(oldName != nil, "oldName", "must not be nil")
+	contract.Requiref(newName != nil, "newName", "must not be nil")
+
+	tags, err := b.getTags(oldName)
+	if err != nil {
+		return err
+	}
+	if tags == nil {
+		// Nothing to rename.
+		return nil
+	}
+
+	// The project tag follows the stack if it moves between projects.
+	if newName.project != "" {
+		tags[apitype.ProjectNameTag] = newName.project.String()
+	}
+
+	if err := b.saveTags(newName, tags); err != nil {
+		return err
+	}
+	return b.removeTags(oldName)
+}
+
 // addToHistory saves the UpdateInfo and makes a copy of the current Checkpoint file.
 func (b *localBackend) addToHistory(ref *localBackendReference, update backend.UpdateInfo) error {
 	contract.Requiref(ref != nil, "ref", "must not be nil")
 
 	dir := ref.HistoryDir()
 
+	// Number the entry after the most recent one,
+	// so that versions don't change when old entries are pruned.
+	historyEntries, err := b.historyEntries(ref)
+	if err != nil {
+		return err
+	}
+	update.Version = 1
+	if len(historyEntries) > 0 {
+		latest, err := b.readHistoryEntry(historyEntries, 0)
+		if err != nil {
+			return err
+		}
+		update.Version = latest.Version + 1
+	}
+
 	// Prefix for the update and checkpoint files.
 	pathPrefix := path.Join(dir, fmt.Sprintf("%s-%d", ref.name, time.Now().UnixNano()))
+	if err := b.saveHistoryEntry(pathPrefix, update); err != nil {
+		return err
+	}
+
+	// Make a copy of the checkpoint file. (Assuming it already exists.)
+	compressed := b.gzip || (b.retention != nil && b.retention.Gzip)
+	ext := "json"
+	if compressed {
+		ext += ".gz"
+	}
+	checkpointFile := fmt.Sprintf("%s.checkpoint.%s", pathPrefix, ext)
+	if !compressed || b.gzip {
+		return b.bucket.Copy(context.TODO(), checkpointFile, b.stackPath(ref), nil)
+	}
+
+	// The retention policy asks for compressed history, but the checkpoint itself isn't compressed.
+	chk, err := b.bucket.ReadAll(context.TODO(), b.stackPath(ref))
+	if err != nil {
+		return err
+	}
+	if !encoding.IsCompressed(chk) {
+		if chk, err = compress(chk); err != nil {
+			return err
+		}
+	}
+	return b.bucket.WriteAll(context.TODO(), checkpointFile, chk, nil)
+}
 
+// saveHistoryEntry writes the given update as the history file with the given prefix.
+func (b *localBackend) saveHistoryEntry(pathPrefix string, update backend.UpdateInfo) error {
 	m, ext := encoding.JSON, "json"
-	if b.gzip {
+	if b.gzip || (b.retention != nil && b.retention.Gzip) {
 		m = encoding.Gzip(m)
 		ext += ".gz"
 	}
 
-	// Save the history file.
 	byts, err := m.Marshal(&update)
 	if err != nil {
 		return err
 	}
 
 	historyFile := fmt.Sprintf("%s.history.%s", pathPrefix, ext)
-	if err = b.bucket.WriteAll(context.TODO(), historyFile, byts, nil); err != nil {
+	return b.bucket.WriteAll(context.TODO(), historyFile, byts, nil)
+}
+
+// importHistory saves the given updates, oldest first, as history entries without copies of their checkpoints. Each
+// entry is named after the time its update started, so that the retention policy ages it by when the update ran, and
+// keeps its version unless that would put it out of order.
+func (b *localBackend) importHistory(ref *localBackendReference, updates []backend.UpdateInfo) error {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	historyEntries, err := b.historyEntries(ref)
+	if err != nil {
 		return err
 	}
+	version := 0
+	if len(historyEntries) > 0 {
+		latest, err := b.readHistoryEntry(historyEntries, 0)
+		if err != nil {
+			return err
+		}
+		version = latest.Version
+	}
 
-	// Make a copy of the checkpoint file. (Assuming it already exists.)
-	checkpointFile := fmt.Sprintf("%s.checkpoint.%s", pathPrefix, ext)
-	return b.bucket.Copy(context.TODO(), checkpointFile, b.stackPath(ref), nil)
+	var written time.Time
+	for _, update := range updates {
+		if update.Version <= version {
+			update.Version = version + 1
+		}
+		version = update.Version
+
+		// History files are ordered by the times in their names, which must therefore be distinct and increasing.
+		started := time.Now()
+		if update.StartTime != 0 {
+			started = time.Unix(update.StartTime, 0)
+		}
+		if !started.After(written) {
+			started = written.Add(time.Nanosecond)
+		}
+		written = started
+
+		pathPrefix := path.Join(ref.HistoryDir(), fmt.Sprintf("%s-%d", ref.name, written.UnixNano()))
+		if err := b.saveHistoryEntry(pathPrefix, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress gzip-compresses the given bytes.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // isPulumiDirEmpty reports whether the .pulumi directory inside the bucket
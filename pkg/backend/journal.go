@@ -0,0 +1,411 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/pkg/v3/version"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// JournalPersister persists the journal of an update. Rather than saving a full snapshot after every step, the
+// journal records the snapshot the update started from once, and then appends a small entry for every step. The
+// journal is compacted into a full snapshot when the update completes. If the update is interrupted, the snapshot it
+// reached can be recovered with ReplayJournal.
+type JournalPersister interface {
+	// Begin starts a new journal on top of the given base snapshot, replacing any journal begun before.
+	Begin(base *deploy.Snapshot) error
+	// Append durably appends the given entries to the current journal.
+	Append(entries []apitype.JournalEntryV1) error
+	// Compact saves the given snapshot as the stack's checkpoint and discards the journal.
+	Compact(snapshot *deploy.Snapshot) error
+	// SecretsManager returns the secrets manager used to encrypt the journal and snapshots.
+	SecretsManager() secrets.Manager
+}
+
+// JournalSnapshotManager is a SnapshotManager that persists the steps of an update to a journal as they happen, and
+// compacts the journal into a full snapshot when it is closed.
+type JournalSnapshotManager struct {
+	persister JournalPersister
+	base      *deploy.Snapshot // The base snapshot for this update.
+
+	m         sync.Mutex
+	entries   engine.JournalEntries   // All of the entries of this update, in order.
+	persisted int                     // The number of entries that have been persisted.
+	begun     bool                    // True if the journal has been begun.
+	rebase    bool                    // True if the base snapshot has been rewritten since the journal was begun.
+	dirty     bool                    // True if the snapshot may have changed.
+	closed    bool                    // True if the manager has been closed.
+	ids       map[*resource.State]int // The journal IDs of the resource states seen so far.
+	sequence  int                     // The sequence number of the last persisted entry.
+	enc       config.Encrypter        // The encrypter used to serialize resource states.
+	unstarted []resource.Operation    // The operations that the update didn't start by its deadline.
+}
+
+var _ engine.SnapshotManager = (*JournalSnapshotManager)(nil)
+
+// NewJournalSnapshotManager creates a new JournalSnapshotManager for an update that starts from the given base
+// snapshot.
+func NewJournalSnapshotManager(persister JournalPersister, base *deploy.Snapshot) *JournalSnapshotManager {
+	return &JournalSnapshotManager{
+		persister: persister,
+		base:      base,
+	}
+}
+
+func (sm *JournalSnapshotManager) BeginMutation(step deploy.Step) (engine.SnapshotMutation, error) {
+	contract.Requiref(step != nil, "step", "cannot be nil")
+	logging.V(9).Infof("JournalSnapshotManager: Beginning mutation for step `%s` on resource `%s`", step.Op(), step.URN())
+
+	if err := sm.record(engine.JournalEntryBegin, step); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+func (sm *JournalSnapshotManager) End(step deploy.Step, successful bool) error {
+	contract.Requiref(step != nil, "step", "cannot be nil")
+	logging.V(9).Infof("JournalSnapshotManager: Ending mutation for step `%s` on resource `%s` (%v)",
+		step.Op(), step.URN(), successful)
+
+	kind := engine.JournalEntryFailure
+	if successful {
+		kind = engine.JournalEntrySuccess
+	}
+	return sm.record(kind, step)
+}
+
+func (sm *JournalSnapshotManager) RegisterResourceOutputs(step deploy.Step) error {
+	contract.Requiref(step != nil, "step", "cannot be nil")
+	return sm.record(engine.JournalEntryOutputs, step)
+}
+
+// RecordUnstartedOperations records the operations that the update didn't start because it reached its deadline.
+// They aren't journaled, but are saved in the snapshot that the journal is compacted into.
+func (sm *JournalSnapshotManager) RecordUnstartedOperations(ops []resource.Operation) error {
+	sm.m.Lock()
+	defer sm.m.Unlock()
+
+	if sm.closed {
+		return errors.New("snapshot manager closed")
+	}
+	sm.unstarted, sm.dirty = ops, true
+	return nil
+}
+
+// record adds an entry for the given step to the journal.
+func (sm *JournalSnapshotManager) record(kind engine.JournalEntryKind, step deploy.Step) error {
+	sm.m.Lock()
+	defer sm.m.Unlock()
+
+	if sm.closed {
+		return errors.New("snapshot manager closed")
+	}
+	sm.dirty = true
+
+	switch step.Op() {
+	case deploy.OpRefresh:
+		// Refreshes aren't journaled. Some other component rewrites the base snapshot in memory once they are done
+		// (see SnapshotManager's refreshSnapshotMutation), so all we need to do is persist the rewritten base
+		// snapshot before the next entry.
+		sm.rebase = sm.begun
+		return nil
+	case deploy.OpSame:
+		// In the case of a 'resource create' in a program that wasn't specified by the user in the --target list, we
+		// *never* want to write this to the checkpoint. We treat it as if it doesn't exist at all.
+		if same, ok := step.(*deploy.SameStep); ok && same.IsSkippedCreate() {
+			return nil
+		}
+
+		// Same steps are by far the most common, and it doesn't matter if they are lost: the next update simply
+		// performs them again. Hold on to them until the next entry that must be persisted.
+		sm.entries = append(sm.entries, engine.JournalEntry{Kind: kind, Step: step})
+		return nil
+	}
+
+	sm.entries = append(sm.entries, engine.JournalEntry{Kind: kind, Step: step})
+	return sm.flush()
+}
+
+// flush persists all of the entries that haven't been persisted yet, beginning the journal first if needed.
+func (sm *JournalSnapshotManager) flush() error {
+	if !sm.begun || sm.rebase {
+		if err := sm.begin(); err != nil {
+			return fmt.Errorf("failed to begin journal: %w", err)
+		}
+	}
+	if sm.persisted == len(sm.entries) {
+		return nil
+	}
+
+	sequence := sm.sequence
+	entries := make([]apitype.JournalEntryV1, 0, len(sm.entries)-sm.persisted)
+	for _, e := range sm.entries[sm.persisted:] {
+		entry, err := sm.serializeEntry(e)
+		if err != nil {
+			sm.sequence = sequence
+			return fmt.Errorf("failed to serialize journal entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := sm.persister.Append(entries); err != nil {
+		// The entries will be appended again with the next entry.
+		sm.sequence = sequence
+		return fmt.Errorf("failed to append to journal: %w", err)
+	}
+	sm.persisted = len(sm.entries)
+	return nil
+}
+
+// begin persists the current base snapshot and starts a new journal on top of it. All of the entries recorded so far
+// are persisted again as part of the new journal.
+func (sm *JournalSnapshotManager) begin() error {
+	base := sm.base
+	if base == nil {
+		// The journal needs a base snapshot to record the secrets manager its entries are encrypted with.
+		manifest := deploy.Manifest{
+			Time:    time.Now(),
+			Version: version.Version,
+		}
+		manifest.Magic = manifest.NewMagic()
+		base = deploy.NewSnapshot(manifest, sm.persister.SecretsManager(), nil, nil)
+	}
+	if err := sm.persister.Begin(base); err != nil {
+		return err
+	}
+
+	sm.ids = make(map[*resource.State]int, len(base.Resources))
+	for i, res := range base.Resources {
+		sm.ids[res] = i
+	}
+	sm.sequence, sm.persisted = 0, 0
+	sm.begun, sm.rebase = true, false
+	return nil
+}
+
+// serializeEntry turns an entry into its persisted form.
+func (sm *JournalSnapshotManager) serializeEntry(e engine.JournalEntry) (apitype.JournalEntryV1, error) {
+	var kind apitype.JournalEntryKind
+	switch e.Kind {
+	case engine.JournalEntryBegin:
+		kind = apitype.JournalEntryBegin
+	case engine.JournalEntrySuccess:
+		kind = apitype.JournalEntrySuccess
+	case engine.JournalEntryFailure:
+		kind = apitype.JournalEntryFailure
+	case engine.JournalEntryOutputs:
+		kind = apitype.JournalEntryOutputs
+	default:
+		contract.Failf("unknown journal entry kind: %v", e.Kind)
+	}
+
+	old, err := sm.serializeState(e.Step.Old())
+	if err != nil {
+		return apitype.JournalEntryV1{}, err
+	}
+	new, err := sm.serializeState(e.Step.New())
+	if err != nil {
+		return apitype.JournalEntryV1{}, err
+	}
+
+	sm.sequence++
+	return apitype.JournalEntryV1{
+		Sequence: sm.sequence,
+		Kind:     kind,
+		Op:       string(e.Step.Op()),
+		URN:      e.Step.URN(),
+		Old:      old,
+		New:      new,
+	}, nil
+}
+
+// serializeState turns a resource state into its persisted form, assigning it a journal ID if it doesn't have one.
+// The full contents of the state are always recorded, since the engine may have modified it since it was last seen.
+func (sm *JournalSnapshotManager) serializeState(state *resource.State) (*apitype.JournalResourceV1, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	if sm.enc == nil {
+		sm.enc = config.NewPanicCrypter()
+		if secretsManager := sm.persister.SecretsManager(); secretsManager != nil {
+			enc, err := secretsManager.Encrypter()
+			if err != nil {
+				return nil, err
+			}
+			sm.enc = enc
+		}
+	}
+
+	id, has := sm.ids[state]
+	if !has {
+		id = len(sm.ids)
+		sm.ids[state] = id
+	}
+	res, err := stack.SerializeResource(state, sm.enc, false /* showSecrets */)
+	if err != nil {
+		return nil, err
+	}
+	return &apitype.JournalResourceV1{ID: id, State: res}, nil
+}
+
+// Close compacts the journal into a full snapshot.
+func (sm *JournalSnapshotManager) Close() error {
+	sm.m.Lock()
+	defer sm.m.Unlock()
+
+	if sm.closed {
+		return nil
+	}
+	sm.closed = true
+	if !sm.dirty {
+		return nil
+	}
+
+	snap, verifyErr := sm.entries.Snap(sm.base)
+	snap.Manifest = deploy.Manifest{
+		Time:    time.Now(),
+		Version: version.Version,
+	}
+	snap.Manifest.Magic = snap.Manifest.NewMagic()
+	snap.SecretsManager = sm.persister.SecretsManager()
+	snap.UnstartedOperations = sm.unstarted
+
+	if err := sm.persister.Compact(snap); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	if verifyErr != nil {
+		return fmt.Errorf("failed to verify snapshot: %w", verifyErr)
+	}
+	return nil
+}
+
+// ReplayJournal replays the given journal entries on top of the given base snapshot, producing the snapshot the update
+// that wrote them had reached. The base snapshot is modified in place.
+func ReplayJournal(
+	ctx context.Context, base *deploy.Snapshot, entries []apitype.JournalEntryV1,
+) (*deploy.Snapshot, error) {
+	contract.Requiref(base != nil, "base", "must not be nil")
+
+	// Decrypt the secrets of all of the entries in one go.
+	var resources []apitype.ResourceV3
+	for _, e := range entries {
+		if e.Old != nil {
+			resources = append(resources, e.Old.State)
+		}
+		if e.New != nil {
+			resources = append(resources, e.New.State)
+		}
+	}
+	dec, enc, err := stack.ResourceCrypters(ctx, base.SecretsManager, resources)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting journal: %w", err)
+	}
+
+	// The steps of the journal refer to resource states by identity, so make sure that entries referring to the same
+	// state get the same *resource.State. The latest contents of a state win.
+	states := make(map[int]*resource.State, len(base.Resources))
+	for i, res := range base.Resources {
+		states[i] = res
+	}
+	deserialize := func(r *apitype.JournalResourceV1) (*resource.State, error) {
+		if r == nil {
+			return nil, nil
+		}
+		state, err := stack.DeserializeResource(r.State, dec, enc)
+		if err != nil {
+			return nil, err
+		}
+		if existing, has := states[r.ID]; has {
+			*existing = *state
+			return existing, nil
+		}
+		states[r.ID] = state
+		return state, nil
+	}
+
+	journal := make(engine.JournalEntries, 0, len(entries))
+	for _, e := range entries {
+		var kind engine.JournalEntryKind
+		switch e.Kind {
+		case apitype.JournalEntryBegin:
+			kind = engine.JournalEntryBegin
+		case apitype.JournalEntrySuccess:
+			kind = engine.JournalEntrySuccess
+		case apitype.JournalEntryFailure:
+			kind = engine.JournalEntryFailure
+		case apitype.JournalEntryOutputs:
+			kind = engine.JournalEntryOutputs
+		default:
+			return nil, fmt.Errorf("journal entry %d has unknown kind %q", e.Sequence, e.Kind)
+		}
+
+		old, err := deserialize(e.Old)
+		if err != nil {
+			return nil, fmt.Errorf("journal entry %d: %w", e.Sequence, err)
+		}
+		new, err := deserialize(e.New)
+		if err != nil {
+			return nil, fmt.Errorf("journal entry %d: %w", e.Sequence, err)
+		}
+		journal = append(journal, engine.JournalEntry{
+			Kind: kind,
+			Step: &replayStep{op: display.StepOp(e.Op), urn: e.URN, old: old, new: new},
+		})
+	}
+
+	snap, err := journal.Snap(base)
+	if err != nil {
+		return nil, err
+	}
+	snap.Manifest = base.Manifest
+	return snap, nil
+}
+
+// replayStep is a step recreated from a journal entry. It can't be applied; it only carries the information needed to
+// replay the journal with JournalEntries.Snap.
+type replayStep struct {
+	deploy.Step
+
+	op  display.StepOp
+	urn resource.URN
+	old *resource.State
+	new *resource.State
+}
+
+func (s *replayStep) Op() display.StepOp   { return s.op }
+func (s *replayStep) URN() resource.URN    { return s.urn }
+func (s *replayStep) Old() *resource.State { return s.old }
+func (s *replayStep) New() *resource.State { return s.new }
+func (s *replayStep) Res() *resource.State {
+	if s.new != nil {
+		return s.new
+	}
+	return s.old
+}
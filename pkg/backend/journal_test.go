@@ -0,0 +1,186 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// MockJournalPersister keeps a journal in serialized form, as a real persister would.
+type MockJournalPersister struct {
+	Base      *apitype.DeploymentV3
+	Entries   []apitype.JournalEntryV1
+	Begins    int
+	Appends   int
+	Compacted *deploy.Snapshot
+}
+
+func (m *MockJournalPersister) Begin(base *deploy.Snapshot) error {
+	dep, err := stack.SerializeDeployment(base, m.SecretsManager(), false /* showSecrets */)
+	if err != nil {
+		return err
+	}
+	m.Base, m.Entries = dep, nil
+	m.Begins++
+	return nil
+}
+
+func (m *MockJournalPersister) Append(entries []apitype.JournalEntryV1) error {
+	bytes, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	var roundTripped []apitype.JournalEntryV1
+	if err := json.Unmarshal(bytes, &roundTripped); err != nil {
+		return err
+	}
+	m.Entries = append(m.Entries, roundTripped...)
+	m.Appends++
+	return nil
+}
+
+func (m *MockJournalPersister) Compact(snap *deploy.Snapshot) error {
+	m.Base, m.Entries, m.Compacted = nil, nil, snap
+	return nil
+}
+
+func (m *MockJournalPersister) SecretsManager() secrets.Manager {
+	return b64.NewBase64SecretsManager()
+}
+
+// Replay recovers the snapshot from the persisted journal, as would happen after a crash.
+func (m *MockJournalPersister) Replay(t *testing.T) *deploy.Snapshot {
+	ctx := context.Background()
+	require.NotNil(t, m.Base, "journal has not begun")
+	base, err := stack.DeserializeDeploymentV3(ctx, *m.Base, stack.DefaultSecretsProvider)
+	require.NoError(t, err)
+	snap, err := ReplayJournal(ctx, base, m.Entries)
+	require.NoError(t, err)
+	return snap
+}
+
+func urns(snap *deploy.Snapshot) []resource.URN {
+	var result []resource.URN
+	for _, res := range snap.Resources {
+		result = append(result, res.URN)
+	}
+	return result
+}
+
+func TestJournalSnapshotManager(t *testing.T) {
+	t.Parallel()
+
+	a := NewResource("a")
+	b := NewResource("b", a.URN)
+	c := NewResource("c", a.URN, b.URN)
+	d := NewResource("d", c.URN)
+	e := NewResource("e", c.URN)
+	snap := NewSnapshot([]*resource.State{a, b, c, d, e})
+
+	// Run the same steps through a regular snapshot manager and a journal, and check that they agree.
+	manager, sp := MockSetup(t, snap)
+	jp := &MockJournalPersister{}
+	journal := NewJournalSnapshotManager(jp, snap)
+
+	applyStep := func(step deploy.Step) {
+		mutation, err := manager.BeginMutation(step)
+		require.NoError(t, err)
+		jmutation, err := journal.BeginMutation(step)
+		require.NoError(t, err)
+		require.NoError(t, mutation.End(step, true))
+		require.NoError(t, jmutation.End(step, true))
+	}
+
+	// b is the same, so nothing is written to the journal yet.
+	bPrime := NewResource(string(b.URN))
+	applyStep(deploy.NewSameStep(nil, MockRegisterResourceEvent{}, b, bPrime))
+	assert.Equal(t, 0, jp.Begins)
+
+	cPrime := NewResource(string(c.URN), bPrime.URN)
+	createReplacement := deploy.NewCreateReplacementStep(nil, MockRegisterResourceEvent{}, c, cPrime, nil, nil, nil, true)
+	replace := deploy.NewReplaceStep(nil, c, cPrime, nil, nil, nil, true)
+	c.Delete = true
+	applyStep(createReplacement)
+	applyStep(replace)
+
+	dPrime := NewResource(string(d.URN), cPrime.URN)
+	dPrime.Outputs["foo"] = resource.MakeSecret(resource.NewStringProperty("bar"))
+	applyStep(deploy.NewUpdateStep(nil, MockRegisterResourceEvent{}, d, dPrime, nil, nil, nil, nil))
+
+	applyStep(deploy.NewDeleteStep(nil, map[resource.URN]bool{}, e))
+
+	// The journal is begun once, and appended to when every step that isn't a same step begins and ends.
+	assert.Equal(t, 1, jp.Begins)
+	assert.Equal(t, 8, jp.Appends)
+
+	expected := sp.LastSnap()
+	recovered := jp.Replay(t)
+	assert.Equal(t, urns(expected), urns(recovered))
+	assert.True(t, recovered.Resources[4].Delete)
+	assert.Equal(t, expected.Resources[4].Dependencies, recovered.Resources[4].Dependencies)
+	assert.Equal(t, dPrime.Outputs, recovered.Resources[2].Outputs)
+
+	require.NoError(t, manager.Close())
+	require.NoError(t, journal.Close())
+	require.NotNil(t, jp.Compacted)
+	assert.Nil(t, jp.Base)
+	assert.Equal(t, urns(expected), urns(jp.Compacted))
+	assert.Equal(t, b64.Type, jp.Compacted.SecretsManager.Type())
+}
+
+func TestJournalSnapshotManager_pendingOperations(t *testing.T) {
+	t.Parallel()
+
+	a := NewResource("a")
+	jp := &MockJournalPersister{}
+	journal := NewJournalSnapshotManager(jp, nil)
+
+	// An update that is interrupted while creating a resource leaves a pending operation behind.
+	step := deploy.NewCreateStep(nil, &MockRegisterResourceEvent{}, a)
+	_, err := journal.BeginMutation(step)
+	require.NoError(t, err)
+
+	recovered := jp.Replay(t)
+	assert.Len(t, recovered.Resources, 0)
+	require.Len(t, recovered.PendingOperations, 1)
+	assert.Equal(t, a.URN, recovered.PendingOperations[0].Resource.URN)
+	assert.Equal(t, resource.OperationTypeCreating, recovered.PendingOperations[0].Type)
+}
+
+func TestJournalSnapshotManager_noMutations(t *testing.T) {
+	t.Parallel()
+
+	// Closing a manager that saw no mutations, e.g. after a preview, must not write anything.
+	jp := &MockJournalPersister{}
+	journal := NewJournalSnapshotManager(jp, NewSnapshot([]*resource.State{NewResource("a")}))
+	require.NoError(t, journal.Close())
+	assert.Equal(t, 0, jp.Begins)
+	assert.Nil(t, jp.Compacted)
+
+	_, err := journal.BeginMutation(deploy.NewCreateStep(nil, &MockRegisterResourceEvent{}, NewResource("b")))
+	assert.Error(t, err)
+}
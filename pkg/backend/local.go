@@ -0,0 +1,157 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/util/cancel"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	sdkDisplay "github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+// LocalUpdate is an update that a self-managed backend runs with the engine in this process, against a stack whose
+// state the backend stores itself.
+type LocalUpdate struct {
+	Kind   apitype.UpdateKind
+	Stack  Stack
+	Op     UpdateOperation
+	Opts   ApplierOptions
+	Events chan<- engine.Event // if not nil, receives the engine's events as well as the display.
+
+	Update engine.UpdateInfo
+	// Manager persists the state of the stack as the update runs. It is closed when the update ends.
+	Manager engine.SnapshotManager
+	Client  deploy.BackendClient
+	// Broken, if not nil, is closed if the update must be canceled, as when the backend's lock on the stack is broken
+	// while the update runs, since another update may then be under way.
+	Broken <-chan struct{}
+}
+
+// RunLocalUpdate runs the given update with the engine, showing its events on the CLI, and returns the engine's
+// results along with the record of the update to add to the stack's history.
+func RunLocalUpdate(u LocalUpdate) (*deploy.Plan, sdkDisplay.ResourceChanges, UpdateInfo, result.Result) {
+	stackRef := u.Stack.Ref()
+	actionLabel := ActionLabel(u.Kind, u.Opts.DryRun)
+	op := u.Op
+
+	if !(op.Opts.Display.JSONDisplay || op.Opts.Display.Type == display.DisplayWatch) {
+		// Print a banner so it's clear this is a self-managed deployment.
+		fmt.Printf(op.Opts.Display.Color.Colorize(
+			colors.SpecHeadline+"%s (%s):"+colors.Reset+"\n"), actionLabel, stackRef)
+	}
+
+	// Spawn a display loop to show events on the CLI.
+	displayEvents := make(chan engine.Event)
+	displayDone := make(chan bool)
+	go display.ShowEvents(
+		strings.ToLower(actionLabel), u.Kind, stackRef.FullyQualifiedName().Name(), op.Proj.Name, "",
+		displayEvents, displayDone, op.Opts.Display, u.Opts.DryRun)
+
+	// Create a separate event channel for engine events that we'll pipe to both listening streams.
+	engineEvents := make(chan engine.Event)
+
+	scope := op.Scopes.NewScope(engineEvents, u.Opts.DryRun)
+	eventsDone := make(chan bool)
+	go func() {
+		// Pull in all events from the engine and send them to the two listeners.
+		for e := range engineEvents {
+			displayEvents <- e
+
+			// If the caller also wants to see the events, stream them there also.
+			if u.Events != nil {
+				u.Events <- e
+			}
+		}
+
+		close(eventsDone)
+	}()
+
+	cancelCtx, cancelSource := cancel.NewChildContext(scope.Context())
+	defer cancelSource.Cancel()
+	if u.Broken != nil {
+		go func() {
+			select {
+			case <-u.Broken:
+				cancelSource.Cancel()
+			case <-cancelCtx.Canceled():
+			}
+		}()
+	}
+	engineCtx := &engine.Context{
+		Cancel:          cancelCtx,
+		Events:          engineEvents,
+		SnapshotManager: u.Manager,
+		BackendClient:   u.Client,
+	}
+
+	// Perform the update
+	start := time.Now().Unix()
+	var plan *deploy.Plan
+	var changes sdkDisplay.ResourceChanges
+	var updateRes result.Result
+	switch u.Kind {
+	case apitype.PreviewUpdate:
+		plan, changes, updateRes = engine.Update(u.Update, engineCtx, op.Opts.Engine, true)
+	case apitype.UpdateUpdate:
+		_, changes, updateRes = engine.Update(u.Update, engineCtx, op.Opts.Engine, u.Opts.DryRun)
+	case apitype.ResourceImportUpdate:
+		_, changes, updateRes = engine.Import(u.Update, engineCtx, op.Opts.Engine, op.Imports, u.Opts.DryRun)
+	case apitype.RefreshUpdate:
+		_, changes, updateRes = engine.Refresh(u.Update, engineCtx, op.Opts.Engine, u.Opts.DryRun)
+	case apitype.DestroyUpdate:
+		_, changes, updateRes = engine.Destroy(u.Update, engineCtx, op.Opts.Engine, u.Opts.DryRun)
+	default:
+		contract.Failf("Unrecognized update kind: %s", u.Kind)
+	}
+	end := time.Now().Unix()
+
+	// Wait for the display to finish showing all the events.
+	<-displayDone
+	scope.Close() // Don't take any cancellations anymore, we're shutting down.
+	close(engineEvents)
+	contract.IgnoreClose(u.Manager)
+
+	// Make sure the goroutine writing to displayEvents and events has exited before proceeding.
+	<-eventsDone
+	close(displayEvents)
+
+	backendUpdateResult := SucceededResult
+	if updateRes != nil {
+		backendUpdateResult = FailedResult
+	}
+	info := UpdateInfo{
+		Kind:        u.Kind,
+		StartTime:   start,
+		Message:     op.M.Message,
+		Environment: op.M.Environment,
+		Config:      u.Update.GetTarget().Config,
+		Result:      backendUpdateResult,
+		EndTime:     end,
+		// IDEA: it would be nice to populate the *Deployment, so that backends don't need to rudely assume they know
+		//     where the checkpoint is as they save it to the history.  This isn't trivial to achieve today given the
+		//     event driven nature of plan-walking, however.
+		ResourceChanges: changes,
+	}
+	return plan, changes, info, updateRes
+}
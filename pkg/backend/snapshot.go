@@ -63,6 +63,7 @@ type SnapshotManager struct {
 	operations       []resource.Operation     // The set of operations known to be outstanding in this plan
 	dones            map[*resource.State]bool // The set of resources that have been operated upon already by this plan
 	completeOps      map[*resource.State]bool // The set of resources that have completed their operation
+	unstartedOps     []resource.Operation     // The operations that the deployment didn't start by its deadline
 	doVerify         bool                     // If true, verify the snapshot before persisting it
 	mutationRequests chan<- mutationRequest   // The queue of mutation requests, to be retired serially by the manager
 	cancel           chan bool                // A channel used to request cancellation of any new mutation requests.
@@ -123,6 +124,15 @@ func (sm *SnapshotManager) RegisterResourceOutputs(step deploy.Step) error {
 	return sm.mutate(func() bool { return true })
 }
 
+// RecordUnstartedOperations records the operations that the deployment didn't start because it reached its deadline,
+// so that they are saved in the snapshot.
+func (sm *SnapshotManager) RecordUnstartedOperations(ops []resource.Operation) error {
+	return sm.mutate(func() bool {
+		sm.unstartedOps = ops
+		return true
+	})
+}
+
 // BeginMutation signals to the SnapshotManager that the engine intends to mutate the global snapshot
 // by performing the given Step. This function gives the SnapshotManager a chance to record the
 // intent to mutate before the mutation occurs.
@@ -237,6 +247,12 @@ func (ssm *sameSnapshotMutation) mustWrite(step *deploy.SameStep) bool {
 		return true
 	}
 
+	// If the hooks of this resource have changed, we must write the checkpoint.
+	if (len(old.Hooks) != 0 || len(new.Hooks) != 0) && !reflect.DeepEqual(old.Hooks, new.Hooks) {
+		logging.V(9).Infof("SnapshotManager: mustWrite() true because of Hooks")
+		return true
+	}
+
 	// If the protection attribute of this resource has changed, we must write the checkpoint.
 	if old.Protect != new.Protect {
 		logging.V(9).Infof("SnapshotManager: mustWrite() true because of Protect")
@@ -619,7 +635,7 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 	// because these must require user intervention to be cleared or resolved.
 	if base := sm.baseSnapshot; base != nil {
 		for _, pendingOperation := range base.PendingOperations {
-			if pendingOperation.Type == resource.OperationTypeCreating {
+			if deploy.IsPendingCreate(pendingOperation) {
 				operations = append(operations, pendingOperation)
 			}
 		}
@@ -632,7 +648,9 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 	}
 
 	manifest.Magic = manifest.NewMagic()
-	return deploy.NewSnapshot(manifest, sm.persister.SecretsManager(), resources, operations)
+	snap := deploy.NewSnapshot(manifest, sm.persister.SecretsManager(), resources, operations)
+	snap.UnstartedOperations = sm.unstartedOps
+	return snap
 }
 
 // saveSnapshot persists the current snapshot and optionally verifies it afterwards.
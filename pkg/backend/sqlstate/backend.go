@@ -0,0 +1,784 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlstate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/uuid"
+	user "github.com/tweekmonster/luser"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/operations"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/pkg/v3/util/validation"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	sdkDisplay "github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// Backend extends the base backend interface with specific information about SQL backends.
+type Backend interface {
+	backend.Backend
+
+	// Close closes the backend's connections to its database.
+	Close() error
+}
+
+type sqlBackend struct {
+	d diag.Sink
+
+	// originalURL is the URL provided when the sqlBackend was initialized. url is a canonicalized version that
+	// should be used when persisting data, with the path of a SQLite database made absolute.
+	originalURL string
+	url         string
+
+	db      *sql.DB
+	dialect dialect
+
+	// lockID identifies the locks taken by this backend.
+	lockID string
+
+	// The current project, if any.
+	currentProject atomic.Pointer[workspace.Project]
+}
+
+// IsSQLStateBackendURL returns true if the given URL is that of a SQL backend.
+func IsSQLStateBackendURL(urlstr string) bool {
+	return strings.HasPrefix(urlstr, SQLitePrefix) || strings.HasPrefix(urlstr, PostgresPrefix) ||
+		strings.HasPrefix(urlstr, PostgreSQLPrefix)
+}
+
+// New constructs a new SQL backend, storing its state in the database at the given URL, which must start with
+// sqlite://, postgres:// or postgresql://. The backend's tables are created if they don't exist yet.
+func New(ctx context.Context, d diag.Sink, originalURL string, project *workspace.Project) (Backend, error) {
+	if !IsSQLStateBackendURL(originalURL) {
+		return nil, fmt.Errorf("sqlstate URL %s has an unsupported scheme", originalURL)
+	}
+
+	u, err := canonicalURL(originalURL)
+	if err != nil {
+		return nil, err
+	}
+	db, dialect, err := openDatabase(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %s: %w", redactURL(originalURL), err)
+	}
+
+	lockID, err := uuid.NewV4()
+	if err != nil {
+		contract.IgnoreClose(db)
+		return nil, err
+	}
+
+	b := &sqlBackend{
+		d:           d,
+		originalURL: originalURL,
+		url:         u,
+		db:          db,
+		dialect:     dialect,
+		lockID:      lockID.String(),
+	}
+	b.currentProject.Store(project)
+	return b, nil
+}
+
+// Login constructs a new SQL backend and makes it the current backend.
+func Login(ctx context.Context, d diag.Sink, url string, project *workspace.Project) (Backend, error) {
+	be, err := New(ctx, d, url, project)
+	if err != nil {
+		return nil, err
+	}
+	return be, workspace.StoreAccount(be.(*sqlBackend).url, workspace.Account{}, true)
+}
+
+// redactURL hides any password in the given URL, so that it can be shown.
+func redactURL(urlstr string) string {
+	u, err := url.Parse(urlstr)
+	if err != nil {
+		return urlstr
+	}
+	return u.Redacted()
+}
+
+func (b *sqlBackend) getReference(ref backend.StackReference) (*sqlBackendReference, error) {
+	stackRef, ok := ref.(*sqlBackendReference)
+	if !ok {
+		return nil, errors.New("bad stack reference type")
+	}
+	return stackRef, nil
+}
+
+func (b *sqlBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *sqlBackend) Name() string {
+	if b.dialect == postgresDialect {
+		if u, err := url.Parse(b.url); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+
+	name, err := os.Hostname()
+	contract.IgnoreError(err)
+	if name == "" {
+		name = "local"
+	}
+	return name
+}
+
+func (b *sqlBackend) URL() string {
+	return redactURL(b.url)
+}
+
+func (b *sqlBackend) SetCurrentProject(project *workspace.Project) {
+	b.currentProject.Store(project)
+}
+
+func (b *sqlBackend) GetPolicyPack(ctx context.Context, policyPack string,
+	d diag.Sink,
+) (backend.PolicyPack, error) {
+	return nil, fmt.Errorf("SQL state backend does not support resource policy")
+}
+
+func (b *sqlBackend) ListPolicyGroups(ctx context.Context, orgName string, _ backend.ContinuationToken) (
+	apitype.ListPolicyGroupsResponse, backend.ContinuationToken, error,
+) {
+	return apitype.ListPolicyGroupsResponse{}, nil, fmt.Errorf("SQL state backend does not support resource policy")
+}
+
+func (b *sqlBackend) ListPolicyPacks(ctx context.Context, orgName string, _ backend.ContinuationToken) (
+	apitype.ListPolicyPacksResponse, backend.ContinuationToken, error,
+) {
+	return apitype.ListPolicyPacksResponse{}, nil, fmt.Errorf("SQL state backend does not support resource policy")
+}
+
+func (b *sqlBackend) SupportsTags() bool {
+	return true
+}
+
+func (b *sqlBackend) SupportsOrganizations() bool {
+	return false
+}
+
+func (b *sqlBackend) ParseStackReference(stackRef string) (backend.StackReference, error) {
+	return b.parseStackReference(stackRef)
+}
+
+// ValidateStackName verifies the stack name is valid for the SQL backend.
+func (b *sqlBackend) ValidateStackName(stackRef string) error {
+	_, err := b.ParseStackReference(stackRef)
+	return err
+}
+
+func (b *sqlBackend) DoesProjectExist(ctx context.Context, projectName string) (bool, error) {
+	var exists int
+	err := b.db.QueryRowContext(ctx, b.dialect.rebind(
+		`SELECT 1 FROM pulumi_stacks WHERE project = ? LIMIT 1`), projectName).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *sqlBackend) CreateStack(ctx context.Context, stackRef backend.StackReference,
+	root string, opts *backend.CreateStackOptions,
+) (backend.Stack, error) {
+	if opts != nil && len(opts.Teams) > 0 {
+		return nil, backend.ErrTeamsNotSupported
+	}
+
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if currentProjectContradictsWorkspace(ref) {
+		return nil, fmt.Errorf("provided project name %q doesn't match Pulumi.yaml", ref.project)
+	}
+
+	stackName := ref.FullyQualifiedName()
+	tags := backend.GetEnvironmentTagsForCurrentStack(root, b.currentProject.Load())
+	if err = validation.ValidateStackProperties(stackName.Name().String(), tags); err != nil {
+		return nil, fmt.Errorf("validating stack properties: %w", err)
+	}
+
+	// The stack, its empty checkpoint and its tags are created together, or not at all if the stack already exists.
+	err = transact(ctx, b.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, b.dialect.rebind(`
+			INSERT INTO pulumi_stacks (project, name, created) VALUES (?, ?, ?)
+			ON CONFLICT (project, name) DO NOTHING`),
+			ref.project, ref.name, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return &backend.StackAlreadyExistsError{StackName: string(stackName)}
+		}
+
+		if err := b.saveStack(ctx, tx, ref, nil, nil); err != nil {
+			return err
+		}
+		return b.saveTags(ctx, tx, ref, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stack := newStack(ref, nil, tags, b)
+	b.d.Infof(diag.Message("", "Created stack '%s'"), stack.Ref())
+
+	return stack, nil
+}
+
+func (b *sqlBackend) GetStack(ctx context.Context, stackRef backend.StackReference) (backend.Stack, error) {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, exists, err := b.getStack(ctx, b.db, ref)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, nil
+	}
+
+	tags, err := b.getTags(ctx, b.db, ref)
+	if err != nil {
+		return nil, err
+	}
+	return newStack(ref, snapshot, tags, b), nil
+}
+
+func (b *sqlBackend) ListStacks(
+	ctx context.Context, filter backend.ListStacksFilter, _ backend.ContinuationToken) (
+	[]backend.StackSummary, backend.ContinuationToken, error,
+) {
+	// Only the project and tag filters are honored, since the backend has no organizations.
+	query := `
+		SELECT s.project, s.name, c.last_update, c.resource_count FROM pulumi_stacks s
+		LEFT JOIN pulumi_checkpoints c ON c.project = s.project AND c.name = s.name
+		WHERE 1 = 1`
+	var args []any
+	if filter.Project != nil {
+		query += ` AND s.project = ?`
+		args = append(args, *filter.Project)
+	}
+	if filter.TagName != nil {
+		query += ` AND EXISTS (SELECT 1 FROM pulumi_tags t
+			WHERE t.project = s.project AND t.name = s.name AND t.tag = ?`
+		args = append(args, *filter.TagName)
+		if filter.TagValue != nil {
+			query += ` AND t.value = ?`
+			args = append(args, *filter.TagValue)
+		}
+		query += `)`
+	}
+	query += ` ORDER BY s.project, s.name`
+
+	rows, err := b.db.QueryContext(ctx, b.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer contract.IgnoreClose(rows)
+
+	var results []backend.StackSummary
+	for rows.Next() {
+		var project, name string
+		var lastUpdate sql.NullInt64
+		var resourceCount sql.NullInt64
+		if err := rows.Scan(&project, &name, &lastUpdate, &resourceCount); err != nil {
+			return nil, nil, err
+		}
+
+		summary := sqlStackSummary{
+			name: &sqlBackendReference{
+				name:           tokens.Name(name),
+				project:        tokens.Name(project),
+				currentProject: b.currentProject.Load,
+			},
+		}
+		if lastUpdate.Valid {
+			t := time.Unix(lastUpdate.Int64, 0)
+			summary.lastUpdate = &t
+		}
+		if resourceCount.Valid {
+			count := int(resourceCount.Int64)
+			summary.resourceCount = &count
+		}
+		results = append(results, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return results, nil, nil
+}
+
+func (b *sqlBackend) RemoveStack(ctx context.Context, stack backend.Stack, force bool) (bool, error) {
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return false, err
+	}
+
+	err = b.Lock(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	defer b.Unlock(ctx, ref)
+
+	snapshot, _, err := b.getStack(ctx, b.db, ref)
+	if err != nil {
+		return false, err
+	}
+
+	// Don't remove stacks that still have resources.
+	if !force && snapshot != nil && len(snapshot.Resources) > 0 {
+		return true, errors.New("refusing to remove stack because it still contains resources")
+	}
+
+	return false, b.removeStack(ctx, ref)
+}
+
+func (b *sqlBackend) RenameStack(ctx context.Context, stack backend.Stack,
+	newName tokens.QName,
+) (backend.StackReference, error) {
+	oldRef, err := b.getReference(stack.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the new stack name is valid.
+	newRef, err := b.parseStackReference(string(newName))
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.Lock(ctx, oldRef)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Unlock(ctx, oldRef)
+
+	// Get the current state from the stack to be renamed.
+	snap, _, err := b.getStack(ctx, b.db, oldRef)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we have a snapshot, we need to rename the URNs inside it to use the new stack name.
+	if snap != nil {
+		if err = edit.RenameStack(snap, newRef.name, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Move everything stored about the stack over to the new name at once, failing if that name is already taken.
+	err = transact(ctx, b.db, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, b.dialect.rebind(
+			`SELECT 1 FROM pulumi_stacks WHERE project = ? AND name = ?`), newRef.project, newRef.name).Scan(&exists)
+		if err == nil {
+			return fmt.Errorf("a stack named %s already exists", newRef.String())
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		for _, table := range stackTables {
+			if _, err := tx.ExecContext(ctx, b.dialect.rebind(
+				`UPDATE `+table+` SET project = ?, name = ? WHERE project = ? AND name = ?`),
+				newRef.project, newRef.name, oldRef.project, oldRef.name); err != nil {
+				return err
+			}
+		}
+
+		// Save the renamed snapshot, re-using its existing secrets manager.
+		return b.saveStack(ctx, tx, newRef, snap, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newRef, nil
+}
+
+func (b *sqlBackend) GetLatestConfiguration(ctx context.Context,
+	stack backend.Stack,
+) (config.Map, error) {
+	hist, err := b.GetHistory(ctx, stack.Ref(), 1 /*pageSize*/, 1 /*page*/)
+	if err != nil {
+		return nil, err
+	}
+	if len(hist) == 0 {
+		return nil, backend.ErrNoPreviousDeployment
+	}
+
+	return hist[0].Config, nil
+}
+
+func (b *sqlBackend) PackPolicies(
+	ctx context.Context, policyPackRef backend.PolicyPackReference,
+	cancellationScopes backend.CancellationScopeSource,
+	callerEventsOpt chan<- engine.Event,
+) result.Result {
+	return result.Error("SQL state backend does not support resource policy")
+}
+
+func (b *sqlBackend) Preview(ctx context.Context, stack backend.Stack,
+	op backend.UpdateOperation,
+) (*deploy.Plan, sdkDisplay.ResourceChanges, result.Result) {
+	// We can skip PreviewThenPromptThenExecute and just go straight to Execute.
+	opts := backend.ApplierOptions{
+		DryRun:   true,
+		ShowLink: true,
+	}
+	return b.apply(ctx, apitype.PreviewUpdate, stack, op, opts, nil /*events*/)
+}
+
+func (b *sqlBackend) Update(ctx context.Context, stack backend.Stack,
+	op backend.UpdateOperation,
+) (sdkDisplay.ResourceChanges, result.Result) {
+	err := b.Lock(ctx, stack.Ref())
+	if err != nil {
+		return nil, result.FromError(err)
+	}
+	defer b.Unlock(ctx, stack.Ref())
+
+	return backend.PreviewThenPromptThenExecute(ctx, apitype.UpdateUpdate, stack, op, b.apply)
+}
+
+func (b *sqlBackend) Import(ctx context.Context, stack backend.Stack,
+	op backend.UpdateOperation, imports []deploy.Import,
+) (sdkDisplay.ResourceChanges, result.Result) {
+	err := b.Lock(ctx, stack.Ref())
+	if err != nil {
+		return nil, result.FromError(err)
+	}
+	defer b.Unlock(ctx, stack.Ref())
+
+	op.Imports = imports
+	return backend.PreviewThenPromptThenExecute(ctx, apitype.ResourceImportUpdate, stack, op, b.apply)
+}
+
+func (b *sqlBackend) Refresh(ctx context.Context, stack backend.Stack,
+	op backend.UpdateOperation,
+) (sdkDisplay.ResourceChanges, result.Result) {
+	err := b.Lock(ctx, stack.Ref())
+	if err != nil {
+		return nil, result.FromError(err)
+	}
+	defer b.Unlock(ctx, stack.Ref())
+
+	return backend.PreviewThenPromptThenExecute(ctx, apitype.RefreshUpdate, stack, op, b.apply)
+}
+
+func (b *sqlBackend) Destroy(ctx context.Context, stack backend.Stack,
+	op backend.UpdateOperation,
+) (sdkDisplay.ResourceChanges, result.Result) {
+	err := b.Lock(ctx, stack.Ref())
+	if err != nil {
+		return nil, result.FromError(err)
+	}
+	defer b.Unlock(ctx, stack.Ref())
+
+	return backend.PreviewThenPromptThenExecute(ctx, apitype.DestroyUpdate, stack, op, b.apply)
+}
+
+func (b *sqlBackend) Query(ctx context.Context, op backend.QueryOperation) result.Result {
+	return backend.RunQuery(ctx, b, op, nil /*events*/, b.newQuery)
+}
+
+func (b *sqlBackend) Watch(ctx context.Context, stk backend.Stack,
+	op backend.UpdateOperation, paths []string,
+) result.Result {
+	return backend.Watch(ctx, stack.DefaultSecretsProvider, b, stk, op, b.apply, paths)
+}
+
+// apply actually performs the provided type of update on a stack stored in the database.
+func (b *sqlBackend) apply(
+	ctx context.Context, kind apitype.UpdateKind, stack backend.Stack,
+	op backend.UpdateOperation, opts backend.ApplierOptions,
+	events chan<- engine.Event,
+) (*deploy.Plan, sdkDisplay.ResourceChanges, result.Result) {
+	stackRef := stack.Ref()
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, nil, result.FromError(err)
+	}
+
+	if currentProjectContradictsWorkspace(ref) {
+		return nil, nil, result.Errorf("provided project name %q doesn't match Pulumi.yaml", ref.project)
+	}
+
+	// Start the update.
+	update, err := b.newUpdate(ctx, ref, op)
+	if err != nil {
+		return nil, nil, result.FromError(err)
+	}
+
+	// Use this opportunity to refresh the stack's tags, to pick up any metadata changes.
+	if !opts.DryRun {
+		tags := backend.GetMergedStackTags(ctx, stack, op.Root, op.Proj)
+		if err := transact(ctx, b.db, func(tx *sql.Tx) error {
+			return b.saveTags(ctx, tx, ref, tags)
+		}); err != nil {
+			return nil, nil, result.FromError(err)
+		}
+	}
+
+	persister := b.newSnapshotPersister(ref, op.SecretsManager)
+	plan, changes, info, updateRes := backend.RunLocalUpdate(backend.LocalUpdate{
+		Kind:    kind,
+		Stack:   stack,
+		Op:      op,
+		Opts:    opts,
+		Events:  events,
+		Update:  update,
+		Manager: backend.NewSnapshotManager(persister, update.GetTarget().Snapshot),
+		Client:  backend.NewBackendClient(b, op.SecretsProvider),
+	})
+
+	var saveErr error
+	if !opts.DryRun {
+		saveErr = b.addToHistory(ctx, ref, info)
+	}
+
+	if updateRes != nil {
+		// We swallow saveErr as it is less important than the updateErr.
+		return plan, changes, updateRes
+	}
+
+	if saveErr != nil {
+		return plan, changes, result.FromError(fmt.Errorf("saving update info: %w", saveErr))
+	}
+
+	return plan, changes, nil
+}
+
+func (b *sqlBackend) GetHistory(
+	ctx context.Context,
+	stackRef backend.StackReference,
+	pageSize int,
+	page int,
+) ([]backend.UpdateInfo, error) {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+	return b.getHistory(ctx, ref, pageSize, page)
+}
+
+func (b *sqlBackend) GetLogs(ctx context.Context,
+	secretsProvider secrets.Provider, stack backend.Stack, cfg backend.StackConfiguration,
+	query operations.LogQuery,
+) ([]operations.LogEntry, error) {
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := b.getTarget(ctx, ref, cfg.Config, cfg.Decrypter)
+	if err != nil {
+		return nil, err
+	}
+
+	return filestate.GetLogsForTarget(target, query)
+}
+
+func (b *sqlBackend) ExportDeployment(ctx context.Context,
+	stk backend.Stack,
+) (*apitype.UntypedDeployment, error) {
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	chk, err := b.getCheckpoint(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	data, err := encoding.JSON.Marshal(chk.Latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(data),
+	}, nil
+}
+
+// ExportDeploymentForVersion exports the checkpoint saved with the given version of the stack's history. Versions
+// are numbered from the oldest update, starting at 1, as shown by `pulumi stack history`.
+func (b *sqlBackend) ExportDeploymentForVersion(ctx context.Context,
+	stk backend.Stack, version string,
+) (*apitype.UntypedDeployment, error) {
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: versions must be integers", version)
+	}
+
+	chk, err := b.getHistoryCheckpoint(ctx, ref, v)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encoding.JSON.Marshal(chk.Latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(data),
+	}, nil
+}
+
+func (b *sqlBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
+	deployment *apitype.UntypedDeployment,
+) error {
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	err = b.Lock(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer b.Unlock(ctx, ref)
+
+	chk, err := stack.MarshalUntypedDeploymentToVersionedCheckpoint(ref.FullyQualifiedName(), deployment)
+	if err != nil {
+		return err
+	}
+	return b.saveCheckpoint(ctx, b.db, ref, chk)
+}
+
+// ImportHistory records the given updates, oldest first, in the stack's history.
+// The entries don't have copies of their checkpoints, so their versions can't be restored.
+func (b *sqlBackend) ImportHistory(ctx context.Context, stk backend.Stack, updates []backend.UpdateInfo) error {
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	err = b.Lock(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer b.Unlock(ctx, ref)
+
+	return b.importHistory(ctx, ref, updates)
+}
+
+// RestoreDeployment imports a deployment exported from the given version of the stack's history as its current state,
+// and records the restore as a new entry in the stack's history.
+func (b *sqlBackend) RestoreDeployment(ctx context.Context, stk backend.Stack, version string,
+	deployment *apitype.UntypedDeployment,
+) error {
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+
+	err = b.Lock(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer b.Unlock(ctx, ref)
+
+	start := time.Now().Unix()
+	chk, err := stack.MarshalUntypedDeploymentToVersionedCheckpoint(ref.FullyQualifiedName(), deployment)
+	if err != nil {
+		return err
+	}
+	if err = b.saveCheckpoint(ctx, b.db, ref, chk); err != nil {
+		return err
+	}
+
+	return b.addToHistory(ctx, ref, backend.UpdateInfo{
+		Kind:        apitype.StackRestoreUpdate,
+		StartTime:   start,
+		Message:     fmt.Sprintf("Restored version %s", version),
+		Environment: map[string]string{},
+		Result:      backend.SucceededResult,
+		EndTime:     time.Now().Unix(),
+	})
+}
+
+func (b *sqlBackend) Logout() error {
+	return workspace.DeleteAccount(b.url)
+}
+
+func (b *sqlBackend) LogoutAll() error {
+	return workspace.DeleteAllAccounts()
+}
+
+func (b *sqlBackend) CurrentUser() (string, []string, error) {
+	user, err := user.Current()
+	if err != nil {
+		return "", nil, err
+	}
+	return user.Username, nil, nil
+}
+
+func (b *sqlBackend) UpdateStackTags(ctx context.Context,
+	stack backend.Stack, tags map[apitype.StackTagName]string,
+) error {
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return err
+	}
+
+	if err := validation.ValidateStackTags(tags); err != nil {
+		return fmt.Errorf("validating tags: %w", err)
+	}
+
+	return transact(ctx, b.db, func(tx *sql.Tx) error {
+		return b.saveTags(ctx, tx, ref, tags)
+	})
+}
@@ -0,0 +1,215 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlstate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"  // driver for postgres:// and postgresql://
+	_ "modernc.org/sqlite" // driver for sqlite://
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+const (
+	// SQLitePrefix is the prefix of the URLs of backends stored in SQLite databases, such as sqlite:///path/to/file.db.
+	SQLitePrefix = "sqlite://"
+	// PostgresPrefix is the prefix of the URLs of backends stored in PostgreSQL databases, such as
+	// postgres://user@host:5432/database.
+	PostgresPrefix = "postgres://"
+	// PostgreSQLPrefix is the other prefix that PostgreSQL accepts for its URLs, such as
+	// postgresql://user@host:5432/database.
+	PostgreSQLPrefix = "postgresql://"
+)
+
+// dialect describes how the statements run by the backend differ between the databases it supports.
+type dialect struct {
+	// driver is the name of the database/sql driver for the database.
+	driver string
+	// numberedParams is true if the database's statements refer to their parameters as $1, $2, ... rather than "?".
+	numberedParams bool
+}
+
+var (
+	sqliteDialect   = dialect{driver: "sqlite"}
+	postgresDialect = dialect{driver: "postgres", numberedParams: true}
+)
+
+// rebind rewrites the "?" parameters of the given statement into the form the database expects.
+func (d dialect) rebind(query string) string {
+	if !d.numberedParams {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// schema creates the tables that hold the backend's state, unless they already exist. Every table is keyed by the
+// stack's project and name, so that renaming or removing a stack is a handful of statements in one transaction.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS pulumi_stacks (
+		project TEXT NOT NULL,
+		name TEXT NOT NULL,
+		created BIGINT NOT NULL,
+		PRIMARY KEY (project, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS pulumi_checkpoints (
+		project TEXT NOT NULL,
+		name TEXT NOT NULL,
+		checkpoint TEXT NOT NULL,
+		last_update BIGINT,
+		resource_count INTEGER,
+		PRIMARY KEY (project, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS pulumi_history (
+		project TEXT NOT NULL,
+		name TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		result TEXT NOT NULL,
+		start_time BIGINT NOT NULL,
+		end_time BIGINT NOT NULL,
+		info TEXT NOT NULL,
+		checkpoint TEXT,
+		PRIMARY KEY (project, name, version)
+	)`,
+	`CREATE TABLE IF NOT EXISTS pulumi_tags (
+		project TEXT NOT NULL,
+		name TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (project, name, tag)
+	)`,
+	`CREATE TABLE IF NOT EXISTS pulumi_locks (
+		project TEXT NOT NULL,
+		name TEXT NOT NULL,
+		id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		hostname TEXT NOT NULL,
+		pid INTEGER NOT NULL,
+		acquired BIGINT NOT NULL,
+		PRIMARY KEY (project, name)
+	)`,
+}
+
+// queryer runs statements, either on their own or as part of a transaction.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// canonicalURL returns the given backend URL with any SQLite path made absolute, so that the backend can be found
+// again from any directory.
+func canonicalURL(originalURL string) (string, error) {
+	if !strings.HasPrefix(originalURL, SQLitePrefix) {
+		return originalURL, nil
+	}
+
+	path, query, _ := strings.Cut(strings.TrimPrefix(originalURL, SQLitePrefix), "?")
+	if path == "" {
+		return "", fmt.Errorf("%s must be followed by the path of the database file", SQLitePrefix)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not determine the home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("could not determine the absolute path of %s: %w", path, err)
+	}
+
+	canonical := SQLitePrefix + filepath.ToSlash(path)
+	if query != "" {
+		canonical += "?" + query
+	}
+	return canonical, nil
+}
+
+// openDatabase connects to the database at the given canonical backend URL, creating the backend's tables if need be.
+func openDatabase(ctx context.Context, backendURL string) (*sql.DB, dialect, error) {
+	var d dialect
+	var dsn string
+	if strings.HasPrefix(backendURL, SQLitePrefix) {
+		// Writers wait for each other rather than failing straight away, and readers aren't blocked by writers.
+		path, query, _ := strings.Cut(strings.TrimPrefix(backendURL, SQLitePrefix), "?")
+		params := url.Values{}
+		params.Add("_pragma", "busy_timeout(10000)")
+		params.Add("_pragma", "journal_mode(WAL)")
+		dsn = "file:" + filepath.FromSlash(path) + "?" + params.Encode()
+		if query != "" {
+			dsn += "&" + query
+		}
+		d = sqliteDialect
+	} else {
+		dsn, d = backendURL, postgresDialect
+	}
+
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, d, err
+	}
+	if d == sqliteDialect {
+		// SQLite allows only one writer at a time, which is simplest to honor by only ever opening one connection.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := transact(ctx, db, func(tx *sql.Tx) error {
+		for _, stmt := range schema {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		contract.IgnoreClose(db)
+		return nil, d, fmt.Errorf("creating the backend's tables: %w", err)
+	}
+	return db, d, nil
+}
+
+// transact runs the given function in a transaction, which is committed if the function succeeds and rolled back
+// otherwise.
+func transact(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		contract.IgnoreError(tx.Rollback())
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,106 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+)
+
+// Lock takes the lock on the given stack. The lock is a row in the locks table, which only one process at a time can
+// insert, so taking it either succeeds outright or fails with a description of the process that holds it.
+func (b *sqlBackend) Lock(ctx context.Context, stackRef backend.StackReference) error {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return err
+	}
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	res, err := b.db.ExecContext(ctx, b.dialect.rebind(`
+		INSERT INTO pulumi_locks (project, name, id, username, hostname, pid, acquired)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (project, name) DO NOTHING`),
+		ref.project, ref.name, b.lockID, u.Username, hostname, os.Getpid(), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("locking stack %s: %w", ref, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 1 {
+		return nil
+	}
+
+	// Somebody else holds the lock, unless it's us.
+	var id, username, holder string
+	var pid int
+	var acquired int64
+	err = b.db.QueryRowContext(ctx, b.dialect.rebind(
+		`SELECT id, username, hostname, pid, acquired FROM pulumi_locks WHERE project = ? AND name = ?`),
+		ref.project, ref.name).Scan(&id, &username, &holder, &pid, &acquired)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// The lock was released in the meantime.
+		return b.Lock(ctx, stackRef)
+	case err != nil:
+		return err
+	case id == b.lockID:
+		return nil
+	}
+	return fmt.Errorf("the stack is currently locked. Either wait for the other process to end or release "+
+		"the lock with `pulumi cancel`.\n  %v: locked by %v@%v (pid %v) at %v",
+		ref, username, holder, pid, time.Unix(acquired, 0).Format(time.RFC3339))
+}
+
+// Unlock releases the lock on the given stack, if this backend holds it.
+func (b *sqlBackend) Unlock(ctx context.Context, stackRef backend.StackReference) {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		b.d.Errorf(diag.Message("", "there was a problem releasing the lock on stack %v: %v"), stackRef, err)
+		return
+	}
+	_, err = b.db.ExecContext(ctx, b.dialect.rebind(
+		`DELETE FROM pulumi_locks WHERE project = ? AND name = ? AND id = ?`), ref.project, ref.name, b.lockID)
+	if err != nil {
+		b.d.Errorf(diag.Message("",
+			"there was a problem releasing the lock on stack %v, manual clean up with `pulumi cancel` may be required: %v"),
+			ref, err)
+	}
+}
+
+// CancelCurrentUpdate releases the lock on the given stack, whoever holds it.
+func (b *sqlBackend) CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.ExecContext(ctx, b.dialect.rebind(
+		`DELETE FROM pulumi_locks WHERE project = ? AND name = ?`), ref.project, ref.name)
+	return err
+}
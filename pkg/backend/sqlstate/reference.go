@@ -0,0 +1,134 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlstate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// sqlBackendReference is a reference to a stack in a SQL backend. Stacks are always scoped to a project.
+type sqlBackendReference struct {
+	name    tokens.Name
+	project tokens.Name
+
+	// A thread-safe way to get the current project.
+	// The function reference or the pointer returned by the function may be nil.
+	currentProject func() *workspace.Project
+}
+
+func (r *sqlBackendReference) String() string {
+	if r.currentProject != nil {
+		proj := r.currentProject()
+		// If the project names match, we can elide them.
+		if proj != nil && string(r.project) == string(proj.Name) {
+			return string(r.name)
+		}
+	}
+	return fmt.Sprintf("organization/%s/%s", r.project, r.name)
+}
+
+func (r *sqlBackendReference) Name() tokens.Name {
+	return r.name
+}
+
+func (r *sqlBackendReference) Project() tokens.Name {
+	return r.project
+}
+
+func (r *sqlBackendReference) FullyQualifiedName() tokens.QName {
+	return tokens.QName(fmt.Sprintf("organization/%s/%s", r.project, r.name))
+}
+
+// parseStackReference parses a stack reference, accepting the same forms as the project-scoped filestate backend:
+// <stack-name>, <org-name>/<stack-name> and <org-name>/<project-name>/<stack-name>, where the organization must always
+// be "organization".
+func (b *sqlBackend) parseStackReference(stackRef string) (*sqlBackendReference, error) {
+	if stackRef == "" {
+		return nil, errors.New("stack name must not be empty")
+	}
+
+	var name, project, org string
+	split := strings.Split(stackRef, "/") // guaranteed to have at least one element
+	switch len(split) {
+	case 1:
+		name = split[0]
+	case 2:
+		org = split[0]
+		name = split[1]
+	case 3:
+		org = split[0]
+		project = split[1]
+		name = split[2]
+	default:
+		return nil, fmt.Errorf("invalid stack reference %q", stackRef)
+	}
+
+	if org != "" && org != "organization" {
+		return nil, errors.New("organization name must be 'organization'")
+	}
+
+	if project == "" {
+		currentProject := b.currentProject.Load()
+		if currentProject == nil {
+			return nil, fmt.Errorf("if you're using the --stack flag, " +
+				"pass the fully qualified name (organization/project/stack)")
+		}
+		project = currentProject.Name.String()
+	}
+
+	if len(project) > 100 {
+		return nil, errors.New("project names are limited to 100 characters")
+	}
+	if !tokens.IsName(project) {
+		return nil, fmt.Errorf(
+			"project names may only contain alphanumerics, hyphens, underscores, and periods: %s",
+			project)
+	}
+	if !tokens.IsName(name) || len(name) > 100 {
+		return nil, fmt.Errorf(
+			"stack names are limited to 100 characters and may only contain alphanumeric, hyphens, underscores, or periods: %s",
+			name)
+	}
+
+	return &sqlBackendReference{
+		name:           tokens.Name(name),
+		project:        tokens.Name(project),
+		currentProject: b.currentProject.Load,
+	}, nil
+}
+
+// Confirm the specified stack's project doesn't contradict the Pulumi.yaml of the current project.
+// If the CWD is not in a Pulumi project, does not contradict.
+func currentProjectContradictsWorkspace(stack *sqlBackendReference) bool {
+	contract.Requiref(stack != nil, "stack", "is nil")
+
+	projPath, err := workspace.DetectProjectPath()
+	if err != nil || projPath == "" {
+		return false
+	}
+
+	proj, err := workspace.LoadProject(projPath)
+	if err != nil {
+		return false
+	}
+
+	return proj.Name.String() != stack.project.String()
+}
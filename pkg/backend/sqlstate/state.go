@@ -0,0 +1,442 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlstate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+type sqlQuery struct {
+	root string
+	proj *workspace.Project
+}
+
+func (q *sqlQuery) GetRoot() string {
+	return q.root
+}
+
+func (q *sqlQuery) GetProject() *workspace.Project {
+	return q.proj
+}
+
+// update is an implementation of engine.Update backed by a SQL database.
+type update struct {
+	root   string
+	proj   *workspace.Project
+	target *deploy.Target
+}
+
+func (u *update) GetRoot() string {
+	return u.root
+}
+
+func (u *update) GetProject() *workspace.Project {
+	return u.proj
+}
+
+func (u *update) GetTarget() *deploy.Target {
+	return u.target
+}
+
+func (b *sqlBackend) newQuery(
+	ctx context.Context,
+	op backend.QueryOperation,
+) (engine.QueryInfo, error) {
+	return &sqlQuery{root: op.Root, proj: op.Proj}, nil
+}
+
+func (b *sqlBackend) newUpdate(
+	ctx context.Context,
+	ref *sqlBackendReference,
+	op backend.UpdateOperation,
+) (*update, error) {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	// Construct the deployment target.
+	target, err := b.getTarget(ctx, ref,
+		op.StackConfiguration.Config, op.StackConfiguration.Decrypter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construct and return a new update.
+	return &update{
+		root:   op.Root,
+		proj:   op.Proj,
+		target: target,
+	}, nil
+}
+
+func (b *sqlBackend) getTarget(
+	ctx context.Context,
+	ref *sqlBackendReference,
+	cfg config.Map,
+	dec config.Decrypter,
+) (*deploy.Target, error) {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+	snapshot, _, err := b.getStack(ctx, b.db, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &deploy.Target{
+		Name:         ref.Name(),
+		Organization: "organization", // like filestate, the backend has no organizations, so we always say "organization"
+		Config:       cfg,
+		Decrypter:    dec,
+		Snapshot:     snapshot,
+	}, nil
+}
+
+// sqlSnapshotPersister is a SnapshotPersister that saves snapshots as the stack's checkpoint.
+type sqlSnapshotPersister struct {
+	ref     *sqlBackendReference
+	backend *sqlBackend
+	sm      secrets.Manager
+}
+
+func (sp *sqlSnapshotPersister) SecretsManager() secrets.Manager {
+	return sp.sm
+}
+
+func (sp *sqlSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
+	return sp.backend.saveStack(context.TODO(), sp.backend.db, sp.ref, snapshot, sp.sm)
+}
+
+func (b *sqlBackend) newSnapshotPersister(ref *sqlBackendReference, sm secrets.Manager) *sqlSnapshotPersister {
+	return &sqlSnapshotPersister{ref: ref, backend: b, sm: sm}
+}
+
+// getStack loads the snapshot of the given stack. It returns false if the stack doesn't exist, and a nil snapshot if
+// the stack has never been deployed.
+func (b *sqlBackend) getStack(
+	ctx context.Context, q queryer, ref *sqlBackendReference,
+) (*deploy.Snapshot, bool, error) {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	var checkpoint sql.NullString
+	err := q.QueryRowContext(ctx, b.dialect.rebind(`
+		SELECT c.checkpoint FROM pulumi_stacks s
+		LEFT JOIN pulumi_checkpoints c ON c.project = s.project AND c.name = s.name
+		WHERE s.project = ? AND s.name = ?`), ref.project, ref.name).Scan(&checkpoint)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to load checkpoint: %w", err)
+	case !checkpoint.Valid:
+		return nil, true, nil
+	}
+
+	snapshot, err := stack.DecodeCheckpoint(ctx, strings.NewReader(checkpoint.String), stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	// The same switch that turns off integrity checking for filestate backends turns it off here.
+	if err := backend.VerifyLoadedSnapshot(snapshot, ref.String(), !filestate.DisableIntegrityChecking); err != nil {
+		return nil, true, err
+	}
+	return snapshot, true, nil
+}
+
+// getCheckpoint loads the checkpoint of the given stack.
+func (b *sqlBackend) getCheckpoint(ctx context.Context, ref *sqlBackendReference) (*apitype.CheckpointV3, error) {
+	var checkpoint string
+	err := b.db.QueryRowContext(ctx, b.dialect.rebind(
+		`SELECT checkpoint FROM pulumi_checkpoints WHERE project = ? AND name = ?`),
+		ref.project, ref.name).Scan(&checkpoint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("stack %s has no checkpoint", ref)
+	} else if err != nil {
+		return nil, err
+	}
+	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, []byte(checkpoint))
+}
+
+// saveStack saves the given snapshot as the stack's checkpoint, encrypting its secrets with the given secrets manager.
+func (b *sqlBackend) saveStack(
+	ctx context.Context, q queryer, ref *sqlBackendReference, snap *deploy.Snapshot, sm secrets.Manager,
+) error {
+	contract.Requiref(ref != nil, "ref", "ref was nil")
+
+	var lastUpdate *time.Time
+	var resourceCount *int
+	if snap != nil {
+		lastUpdate = &snap.Manifest.Time
+		count := len(snap.Resources)
+		resourceCount = &count
+	}
+	return backend.SaveCheckpoint(ref.FullyQualifiedName(), snap, sm, !filestate.DisableIntegrityChecking,
+		func(encode func(w io.Writer) error) (string, error) {
+			var buf bytes.Buffer
+			if err := encode(&buf); err != nil {
+				return "", err
+			}
+			return ref.String(), b.writeCheckpoint(ctx, q, ref, buf.String(), lastUpdate, resourceCount)
+		})
+}
+
+// saveCheckpoint saves the given checkpoint as the stack's checkpoint.
+func (b *sqlBackend) saveCheckpoint(
+	ctx context.Context, q queryer, ref *sqlBackendReference, checkpoint *apitype.VersionedCheckpoint,
+) error {
+	byts, err := encoding.JSON.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("An IO error occurred while marshalling the checkpoint: %w", err)
+	}
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, byts)
+	if err != nil {
+		return err
+	}
+
+	var lastUpdate *time.Time
+	var resourceCount *int
+	if chk.Latest != nil {
+		lastUpdate = &chk.Latest.Manifest.Time
+		count := len(chk.Latest.Resources)
+		resourceCount = &count
+	}
+	return b.writeCheckpoint(ctx, q, ref, string(byts), lastUpdate, resourceCount)
+}
+
+// writeCheckpoint writes the given serialized checkpoint, along with the summary of it shown by `pulumi stack ls`.
+func (b *sqlBackend) writeCheckpoint(ctx context.Context, q queryer, ref *sqlBackendReference,
+	checkpoint string, lastUpdate *time.Time, resourceCount *int,
+) error {
+	var updated *int64
+	if lastUpdate != nil && !lastUpdate.IsZero() {
+		t := lastUpdate.Unix()
+		updated = &t
+	}
+
+	_, err := q.ExecContext(ctx, b.dialect.rebind(`
+		INSERT INTO pulumi_checkpoints (project, name, checkpoint, last_update, resource_count)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (project, name) DO UPDATE SET
+			checkpoint = excluded.checkpoint,
+			last_update = excluded.last_update,
+			resource_count = excluded.resource_count`),
+		ref.project, ref.name, checkpoint, updated, resourceCount)
+	if err != nil {
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	return nil
+}
+
+// getHistory returns a page of the stack's history, most recent first, or all of it if pageSize is not positive.
+func (b *sqlBackend) getHistory(
+	ctx context.Context, ref *sqlBackendReference, pageSize int, page int,
+) ([]backend.UpdateInfo, error) {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	query := `SELECT info FROM pulumi_history WHERE project = ? AND name = ? ORDER BY version DESC`
+	args := []any{ref.project, ref.name}
+	if pageSize > 0 {
+		if page < 1 {
+			page = 1
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, pageSize, (page-1)*pageSize)
+	}
+
+	rows, err := b.db.QueryContext(ctx, b.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(rows)
+
+	var updates []backend.UpdateInfo
+	for rows.Next() {
+		var info string
+		if err := rows.Scan(&info); err != nil {
+			return nil, err
+		}
+		var update backend.UpdateInfo
+		if err := json.Unmarshal([]byte(info), &update); err != nil {
+			return nil, fmt.Errorf("reading history entry: %w", err)
+		}
+		updates = append(updates, update)
+	}
+	return updates, rows.Err()
+}
+
+// getHistoryCheckpoint loads the checkpoint saved with the given version of the stack's history.
+func (b *sqlBackend) getHistoryCheckpoint(
+	ctx context.Context, ref *sqlBackendReference, version int,
+) (*apitype.CheckpointV3, error) {
+	var checkpoint sql.NullString
+	err := b.db.QueryRowContext(ctx, b.dialect.rebind(
+		`SELECT checkpoint FROM pulumi_history WHERE project = ? AND name = ? AND version = ?`),
+		ref.project, ref.name, version).Scan(&checkpoint)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("stack %s has no version %d", ref, version)
+	case err != nil:
+		return nil, err
+	case !checkpoint.Valid:
+		return nil, fmt.Errorf("no checkpoint was saved for version %d of stack %s", version, ref)
+	}
+	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, []byte(checkpoint.String))
+}
+
+// latestVersion returns the version of the most recent entry in the stack's history, or 0 if it has none.
+func (b *sqlBackend) latestVersion(ctx context.Context, q queryer, ref *sqlBackendReference) (int, error) {
+	var version int
+	err := q.QueryRowContext(ctx, b.dialect.rebind(
+		`SELECT COALESCE(MAX(version), 0) FROM pulumi_history WHERE project = ? AND name = ?`),
+		ref.project, ref.name).Scan(&version)
+	return version, err
+}
+
+// insertHistoryEntry inserts the given update into the stack's history, along with the given copy of the stack's
+// checkpoint, if any.
+func (b *sqlBackend) insertHistoryEntry(ctx context.Context, q queryer, ref *sqlBackendReference,
+	update backend.UpdateInfo, checkpoint *string,
+) error {
+	info, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, b.dialect.rebind(`
+		INSERT INTO pulumi_history (project, name, version, kind, result, start_time, end_time, info, checkpoint)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		ref.project, ref.name, update.Version, string(update.Kind), string(update.Result),
+		update.StartTime, update.EndTime, string(info), checkpoint)
+	return err
+}
+
+// addToHistory records the given update as the next version in the stack's history, along with a copy of the
+// stack's current checkpoint.
+func (b *sqlBackend) addToHistory(ctx context.Context, ref *sqlBackendReference, update backend.UpdateInfo) error {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	return transact(ctx, b.db, func(tx *sql.Tx) error {
+		latest, err := b.latestVersion(ctx, tx, ref)
+		if err != nil {
+			return err
+		}
+		update.Version = latest + 1
+
+		var checkpoint *string
+		err = tx.QueryRowContext(ctx, b.dialect.rebind(
+			`SELECT checkpoint FROM pulumi_checkpoints WHERE project = ? AND name = ?`),
+			ref.project, ref.name).Scan(&checkpoint)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return b.insertHistoryEntry(ctx, tx, ref, update, checkpoint)
+	})
+}
+
+// importHistory records the given updates, oldest first, in the stack's history, without copies of their checkpoints.
+// Each update keeps its version unless that would put it out of order.
+func (b *sqlBackend) importHistory(ctx context.Context, ref *sqlBackendReference, updates []backend.UpdateInfo) error {
+	contract.Requiref(ref != nil, "ref", "must not be nil")
+
+	return transact(ctx, b.db, func(tx *sql.Tx) error {
+		version, err := b.latestVersion(ctx, tx, ref)
+		if err != nil {
+			return err
+		}
+		for _, update := range updates {
+			if update.Version <= version {
+				update.Version = version + 1
+			}
+			version = update.Version
+
+			if err := b.insertHistoryEntry(ctx, tx, ref, update, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// getTags returns the stack's tags.
+func (b *sqlBackend) getTags(
+	ctx context.Context, q queryer, ref *sqlBackendReference,
+) (map[apitype.StackTagName]string, error) {
+	rows, err := q.QueryContext(ctx, b.dialect.rebind(
+		`SELECT tag, value FROM pulumi_tags WHERE project = ? AND name = ?`), ref.project, ref.name)
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(rows)
+
+	tags := make(map[apitype.StackTagName]string)
+	for rows.Next() {
+		var tag, value string
+		if err := rows.Scan(&tag, &value); err != nil {
+			return nil, err
+		}
+		tags[tag] = value
+	}
+	return tags, rows.Err()
+}
+
+// saveTags replaces the stack's tags with the given ones.
+func (b *sqlBackend) saveTags(
+	ctx context.Context, q queryer, ref *sqlBackendReference, tags map[apitype.StackTagName]string,
+) error {
+	if _, err := q.ExecContext(ctx, b.dialect.rebind(
+		`DELETE FROM pulumi_tags WHERE project = ? AND name = ?`), ref.project, ref.name); err != nil {
+		return err
+	}
+	for tag, value := range tags {
+		if _, err := q.ExecContext(ctx, b.dialect.rebind(
+			`INSERT INTO pulumi_tags (project, name, tag, value) VALUES (?, ?, ?, ?)`),
+			ref.project, ref.name, tag, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackTables lists the tables that hold the state of a stack.
+var stackTables = []string{"pulumi_stacks", "pulumi_checkpoints", "pulumi_history", "pulumi_tags"}
+
+// removeStack deletes everything stored about the given stack.
+func (b *sqlBackend) removeStack(ctx context.Context, ref *sqlBackendReference) error {
+	return transact(ctx, b.db, func(tx *sql.Tx) error {
+		for _, table := range stackTables {
+			if _, err := tx.ExecContext(ctx, b.dialect.rebind(
+				`DELETE FROM `+table+` WHERE project = ? AND name = ?`), ref.project, ref.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
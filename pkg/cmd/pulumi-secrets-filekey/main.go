@@ -0,0 +1,60 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// pulumi-secrets-filekey is a secrets provider plugin that encrypts with a key kept in a local file. It is used for
+// secrets provider URLs of the form "filekey://<path>".
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/pulumi/pulumi/pkg/v3/secrets/filekey"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+func main() {
+	var tracing string
+	flag.StringVar(&tracing, "tracing", "", "Emit tracing to a Zipkin-compatible tracing endpoint")
+	flag.Parse()
+
+	logging.InitLogging(false, 0, false)
+	cmdutil.InitTracing("pulumi-secrets-filekey", "pulumi-secrets-filekey", tracing)
+
+	// Fire up a gRPC server, letting the kernel choose a free port for us.
+	handle, err := rpcutil.ServeWithOptions(rpcutil.ServeOptions{
+		Init: func(srv *grpc.Server) error {
+			pulumirpc.RegisterSecretsProviderServer(srv, plugin.NewSecretsProviderServer(filekey.NewProvider()))
+			return nil
+		},
+		Options: rpcutil.OpenTracingServerInterceptorOptions(nil),
+	})
+	if err != nil {
+		cmdutil.Exit(err)
+	}
+
+	// The plugin protocol requires that we now write out the port we have chosen to listen on.
+	fmt.Printf("%d\n", handle.Port)
+
+	// Finally, wait for the server to stop serving.
+	if err := <-handle.Done; err != nil {
+		cmdutil.Exit(err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/env"
+)
+
+// deadlineFlag holds the flag that gives a deployment a deadline, after which it stops starting new operations, waits
+// for the ones in flight and saves a clean checkpoint.
+type deadlineFlag struct {
+	timeout time.Duration
+}
+
+func (f *deadlineFlag) register(cmd *cobra.Command) {
+	cmd.PersistentFlags().DurationVar(
+		&f.timeout, "deadline", 0,
+		"The time the update may take, such as 45m, after which no new resource operations are started;"+
+			" operations in flight are allowed to finish and the checkpoint records what was left undone."+
+			" It is counted from the start of the update, after any preview and confirmation prompt."+
+			" Defaults to the PULUMI_DEPLOYMENT_DEADLINE environment variable")
+}
+
+// get returns the time the update may take, from the flag or else the environment, or zero for no deadline.
+func (f *deadlineFlag) get() (time.Duration, error) {
+	if f.timeout != 0 {
+		if f.timeout < 0 {
+			return 0, fmt.Errorf("invalid --deadline %v: must be positive", f.timeout)
+		}
+		return f.timeout, nil
+	}
+
+	v := env.DeploymentDeadline.Value()
+	if v == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a positive duration, such as 45m",
+			env.DeploymentDeadline.Var().Name(), v)
+	}
+	return timeout, nil
+}
+
+// scopes returns the source of the cancellation scopes to run the command's deployments in. The deadline is counted
+// from the start of the update itself, so the preview and confirmation prompt that precede it don't count towards it.
+func (f *deadlineFlag) scopes() (backend.CancellationScopeSource, error) {
+	timeout, err := f.get()
+	if err != nil || timeout == 0 {
+		return cancellationScopes, err
+	}
+	return cancellationScopeSource{timeout: timeout}, nil
+}
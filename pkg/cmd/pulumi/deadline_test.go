@@ -0,0 +1,44 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // installs a signal handler for the process
+func TestDeadlineScopes(t *testing.T) {
+	f := deadlineFlag{timeout: time.Hour}
+	scopes, err := f.scopes()
+	require.NoError(t, err)
+
+	// The preview that runs before the update has no deadline.
+	preview := scopes.NewScope(nil, true /* isPreview */)
+	_, ok := preview.Context().Deadline()
+	preview.Close()
+	assert.False(t, ok)
+
+	// The deadline of the update is counted from its start.
+	start := time.Now()
+	update := scopes.NewScope(nil, false /* isPreview */)
+	deadline, ok := update.Context().Deadline()
+	update.Close()
+	require.True(t, ok)
+	assert.False(t, deadline.Before(start.Add(time.Hour)))
+}
@@ -64,7 +64,13 @@ func newDestroyCmd() *cobra.Command {
 	var yes bool
 	var targets *[]string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
 	var excludeProtected bool
+	var continueOnError bool
+	var runStateHooks bool
+	var retry retryFlags
+	var deadline deadlineFlag
 
 	use, cmdArgs := "destroy", cmdutil.NoArgs
 	if remoteSupported() {
@@ -141,10 +147,16 @@ func newDestroyCmd() *cobra.Command {
 				err = validateUnsupportedRemoteFlags(false, nil, false, "", jsonDisplay, nil,
 					nil, refresh, showConfig, showReplacementSteps, showSames, false,
 					suppressOutputs, "default", targets, nil, nil,
-					targetDependents, "", stackConfigFile)
+					targetDependents, excludes, excludeDependents, "", stackConfigFile)
 				if err != nil {
 					return result.FromError(err)
 				}
+				if retry.isSet() {
+					return result.FromError(errors.New("--retry-attempts is not supported with --remote"))
+				}
+				if deadline.timeout != 0 {
+					return result.FromError(errors.New("--deadline is not supported with --remote"))
+				}
 
 				return runDeployment(ctx, opts.Display, apitype.Destroy, stackName, args[0], remoteArgs)
 			}
@@ -235,12 +247,27 @@ func newDestroyCmd() *cobra.Command {
 				}
 			}
 
+			retryPolicy, resourceRetries, err := retry.policies()
+			if err != nil {
+				return result.FromError(err)
+			}
+			scopes, err := deadline.scopes()
+			if err != nil {
+				return result.FromError(err)
+			}
+
 			opts.Engine = engine.UpdateOptions{
 				Parallel:                  parallel,
 				Debug:                     debug,
 				Refresh:                   refreshOption,
 				DestroyTargets:            deploy.NewUrnTargets(targetUrns),
 				TargetDependents:          targetDependents,
+				Excludes:                  deploy.NewUrnTargets(excludes),
+				ExcludeDependents:         excludeDependents,
+				ContinueOnError:           continueOnError,
+				RunStateHooks:             runStateHooks,
+				Retry:                     retryPolicy,
+				ResourceRetries:           resourceRetries,
 				UseLegacyDiff:             useLegacyDiff(),
 				DisableProviderPreview:    disableProviderPreview(),
 				DisableResourceReferences: disableResourceReferences(),
@@ -256,7 +283,7 @@ func newDestroyCmd() *cobra.Command {
 				StackConfiguration: cfg,
 				SecretsManager:     sm,
 				SecretsProvider:    stack.DefaultSecretsProvider,
-				Scopes:             cancellationScopes,
+				Scopes:             scopes,
 			})
 
 			if res == nil && protectedCount > 0 && !jsonDisplay {
@@ -313,8 +340,26 @@ func newDestroyCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows destroying of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to not destroy. The resources it depends on will not be destroyed either."+
+			" Multiple resources can be specified using: --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also don't destroy the resources that depend on the resources specified in --exclude list")
 	cmd.PersistentFlags().BoolVar(&excludeProtected, "exclude-protected", false, "Do not destroy protected resources."+
 		" Destroy all other resources.")
+	cmd.PersistentFlags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue destroying resources even if an error is encountered"+
+			" (resources that a resource that failed depends on are not destroyed)")
+	cmd.PersistentFlags().BoolVar(
+		&runStateHooks, "run-state-hooks", false,
+		"Run the delete hooks saved in the state of the resources that are destroyed."+
+			" Only use this if you trust the stack's state")
+	retry.register(cmd)
+	deadline.register(cmd)
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().BoolVar(
@@ -27,6 +27,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
 	"github.com/pulumi/pulumi/pkg/v3/backend/httpstate"
+	"github.com/pulumi/pulumi/pkg/v3/backend/sqlstate"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -85,7 +86,18 @@ func newLoginCmd() *cobra.Command {
 			"\n" +
 			"Azure Blob:\n" +
 			"\n" +
-			"    $ pulumi login azblob://my-pulumi-state-bucket\n",
+			"    $ pulumi login azblob://my-pulumi-state-bucket\n" +
+			"\n" +
+			"[PREVIEW] State can also be stored in the tables of a SQLite or PostgreSQL database, which locks stacks\n" +
+			"transactionally and keeps their history queryable. For instance,\n" +
+			"\n" +
+			"SQLite:\n" +
+			"\n" +
+			"    $ pulumi login sqlite://~/pulumi-state.db\n" +
+			"\n" +
+			"PostgreSQL:\n" +
+			"\n" +
+			"    $ pulumi login postgres://user@db.example.com:5432/pulumi\n",
 		Args: cmdutil.MaximumNArgs(1),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			ctx := commandContext()
@@ -146,6 +158,11 @@ func newLoginCmd() *cobra.Command {
 				if defaultOrg != "" {
 					return fmt.Errorf("unable to set default org for this type of backend")
 				}
+			} else if sqlstate.IsSQLStateBackendURL(cloudURL) {
+				be, err = sqlstate.Login(ctx, cmdutil.Diag(), cloudURL, project)
+				if defaultOrg != "" {
+					return fmt.Errorf("unable to set default org for this type of backend")
+				}
 			} else {
 				be, err = httpstate.NewLoginManager().Login(ctx, cmdutil.Diag(), cloudURL, project, insecure, displayOptions)
 				// if the user has specified a default org to associate with the backend
@@ -184,13 +201,13 @@ func newLoginCmd() *cobra.Command {
 
 func validateCloudBackendType(typ string) error {
 	kind := strings.SplitN(typ, ":", 2)[0]
-	supportedKinds := []string{"azblob", "gs", "s3", "file", "https", "http"}
+	supportedKinds := []string{"azblob", "gs", "s3", "file", "sqlite", "postgres", "https", "http"}
 	for _, supportedKind := range supportedKinds {
 		if kind == supportedKind {
 			return nil
 		}
 	}
 	return fmt.Errorf("unknown backend cloudUrl format '%s' (supported Url formats are: "+
-		"azblob://, gs://, s3://, file://, https:// and http://)",
+		"azblob://, gs://, s3://, file://, sqlite://, postgres://, postgresql://, https:// and http://)",
 		kind)
 }
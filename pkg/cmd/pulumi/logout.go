@@ -23,6 +23,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
 	"github.com/pulumi/pulumi/pkg/v3/backend/httpstate"
+	"github.com/pulumi/pulumi/pkg/v3/backend/sqlstate"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -78,7 +79,7 @@ func newLogoutCmd() *cobra.Command {
 
 			var be backend.Backend
 			var err error
-			if filestate.IsFileStateBackendURL(cloudURL) {
+			if filestate.IsFileStateBackendURL(cloudURL) || sqlstate.IsSQLStateBackendURL(cloudURL) {
 				fmt.Printf("Logged out of %s\n", cloudURL)
 				return workspace.DeleteAccount(cloudURL)
 			}
@@ -29,7 +29,6 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
-	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -68,6 +67,9 @@ func newPreviewCmd() *cobra.Command {
 	var replaces []string
 	var targetReplaces []string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
+	var autoAlias bool
 
 	use, cmdArgs := "preview", cmdutil.NoArgs
 	if remoteSupported() {
@@ -128,10 +130,13 @@ func newPreviewCmd() *cobra.Command {
 				err := validateUnsupportedRemoteFlags(expectNop, configArray, configPath, client, jsonDisplay,
 					policyPackPaths, policyPackConfigPaths, refresh, showConfig, showReplacementSteps, showSames,
 					showReads, suppressOutputs, "default", &targets, replaces, targetReplaces,
-					targetDependents, planFilePath, stackConfigFile)
+					targetDependents, excludes, excludeDependents, planFilePath, stackConfigFile)
 				if err != nil {
 					return result.FromError(err)
 				}
+				if autoAlias {
+					return result.FromError(errors.New("--auto-alias is not supported with --remote"))
+				}
 
 				return runDeployment(ctx, displayOpts, apitype.Preview, stackName, args[0], remoteArgs)
 			}
@@ -225,6 +230,9 @@ func newPreviewCmd() *cobra.Command {
 					DisableOutputValues:       disableOutputValues(),
 					UpdateTargets:             deploy.NewUrnTargets(targetURNs),
 					TargetDependents:          targetDependents,
+					Excludes:                  deploy.NewUrnTargets(excludes),
+					ExcludeDependents:         excludeDependents,
+					AutoAlias:                 autoAlias,
 					// If we're trying to save a plan then we _need_ to generate it. We also turn this on in
 					// experimental mode to just get more testing of it.
 					GeneratePlan: hasExperimentalCommands() || planFilePath != "",
@@ -255,7 +263,11 @@ func newPreviewCmd() *cobra.Command {
 					if err != nil {
 						return result.FromError(err)
 					}
-					if err = writePlan(planFilePath, plan, encrypter, showSecrets); err != nil {
+					base, err := getPlanBase(ctx, s, cfg.Config)
+					if err != nil {
+						return result.FromError(err)
+					}
+					if err = writePlan(planFilePath, base, plan, encrypter, showSecrets); err != nil {
 						return result.FromError(err)
 					}
 
@@ -295,10 +307,8 @@ func newPreviewCmd() *cobra.Command {
 		"Config keys contain a path to a property in a map or list to set")
 	cmd.PersistentFlags().StringVar(
 		&planFilePath, "save-plan", "",
-		"[EXPERIMENTAL] Save the operations proposed by the preview to a plan file at the given path")
-	if !hasExperimentalCommands() {
-		contract.AssertNoErrorf(cmd.PersistentFlags().MarkHidden("save-plan"), `Could not mark "save-plan" as hidden`)
-	}
+		"Save the operations proposed by the preview to a plan file at the given path, "+
+			"for use with `pulumi up --plan`")
 	cmd.Flags().BoolVarP(
 		&showSecrets, "show-secrets", "", false, "Emit secrets in plaintext in the plan file. Defaults to `false`")
 
@@ -324,6 +334,18 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows updating of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to leave as it is. Other resources will be updated."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also leave as they are the resources that depend on the resources specified in --exclude list")
+	cmd.PersistentFlags().BoolVar(
+		&autoAlias, "auto-alias", false,
+		"Treat new resources whose inputs match a resource that would be deleted as renames of it"+
+			" instead of replacing it")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().StringSliceVar(
@@ -38,9 +38,13 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
+// driftExitCode is the exit code of `pulumi refresh --detect-drift` when drift is detected.
+const driftExitCode = 2
+
 func newRefreshCmd() *cobra.Command {
 	var debug bool
 	var expectNop bool
+	var detectDrift bool
 	var message string
 	var execKind string
 	var execAgent string
@@ -62,11 +66,14 @@ func newRefreshCmd() *cobra.Command {
 	var suppressPermalink string
 	var yes bool
 	var targets *[]string
+	var excludes []string
+	var excludeDependents bool
 
 	// Flags for handling pending creates
 	var skipPendingCreates bool
 	var clearPendingCreates bool
 	var importPendingCreates *[]string
+	var resolvePendingCreates bool
 
 	use, cmdArgs := "refresh", cmdutil.NoArgs
 	if remoteSupported() {
@@ -83,6 +90,10 @@ func newRefreshCmd() *cobra.Command {
 			"the program text isn't updated accordingly, subsequent updates may still appear to be out of\n" +
 			"synch with respect to the cloud provider's source of truth.\n" +
 			"\n" +
+			"Use `--detect-drift` to only check for drift without changing the stack's state. Each resource\n" +
+			"is reported as in-sync, drifted, or deleted, and the command exits with code 2 if any resource\n" +
+			"has drifted. Combine it with `--json` to emit a machine-readable drift report.\n" +
+			"\n" +
 			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
 			"`--cwd` flag to use a different directory.",
 		Args: cmdArgs,
@@ -91,10 +102,18 @@ func newRefreshCmd() *cobra.Command {
 
 			// Remote implies we're skipping previews.
 			if remoteArgs.remote {
+				if detectDrift {
+					return result.FromError(errors.New("--detect-drift is not supported with --remote"))
+				}
 				skipPreview = true
 			}
 
-			yes = yes || skipPreview || skipConfirmations()
+			if detectDrift && skipPreview {
+				return result.FromError(errors.New("--detect-drift cannot be combined with --skip-preview"))
+			}
+
+			// Drift detection only ever previews the refresh, so there is nothing to confirm.
+			yes = yes || skipPreview || detectDrift || skipConfirmations()
 			interactive := cmdutil.Interactive()
 			if !interactive && !yes {
 				return result.FromError(
@@ -105,9 +124,10 @@ func newRefreshCmd() *cobra.Command {
 			if err != nil {
 				return result.FromError(err)
 			}
+			opts.PreviewOnly = detectDrift
 
 			displayType := display.DisplayProgress
-			if diffDisplay {
+			if diffDisplay || detectDrift {
 				displayType = display.DisplayDiff
 			}
 
@@ -122,6 +142,7 @@ func newRefreshCmd() *cobra.Command {
 				EventLogPath:         eventLogPath,
 				Debug:                debug,
 				JSONDisplay:          jsonDisplay,
+				DriftReport:          detectDrift,
 			}
 
 			// we only suppress permalinks if the user passes true. the default is an empty string
@@ -140,7 +161,7 @@ func newRefreshCmd() *cobra.Command {
 				err = validateUnsupportedRemoteFlags(expectNop, nil, false, "", jsonDisplay, nil,
 					nil, "", showConfig, showReplacementSteps, showSames, false,
 					suppressOutputs, "default", targets, nil, nil,
-					false, "", stackConfigFile)
+					false, excludes, excludeDependents, "", stackConfigFile)
 				if err != nil {
 					return result.FromError(err)
 				}
@@ -199,6 +220,11 @@ func newRefreshCmd() *cobra.Command {
 				return result.FromError(fmt.Errorf(
 					"cannot set both --skip-pending-creates and --clear-pending-creates"))
 			}
+			if detectDrift && (clearPendingCreates || resolvePendingCreates ||
+				importPendingCreates != nil && len(*importPendingCreates) > 0) {
+				return result.FromError(errors.New("--detect-drift cannot be combined with --clear-pending-creates, " +
+					"--import-pending-creates or --resolve-pending-creates"))
+			}
 
 			// First we handle explicit create->imports we were given
 			if importPendingCreates != nil && len(*importPendingCreates) > 0 {
@@ -218,6 +244,9 @@ func newRefreshCmd() *cobra.Command {
 						opts.Display.Color.Colorize(colors.Highlight("warning", "warning", colors.SpecWarning)),
 						unused[0])
 				}
+
+				// The IDs we were given are only of use if we read the resources they identify.
+				resolvePendingCreates = true
 			}
 
 			snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
@@ -226,9 +255,15 @@ func newRefreshCmd() *cobra.Command {
 			}
 
 			// We then allow the user to interactively handle remaining pending creates.
-			if interactive && hasPendingCreates(snap) && !skipPendingCreates {
-				if result := filterMapPendingCreates(ctx, s, opts.Display,
-					yes, interactiveFixPendingCreate); result != nil {
+			if interactive && hasPendingCreates(snap) && !skipPendingCreates && !detectDrift {
+				fix := func(op resource.Operation) (*resource.Operation, error) {
+					fixed, err := interactiveFixPendingCreate(op)
+					if fixed != nil && fixed.Type == resource.OperationTypeImporting {
+						resolvePendingCreates = true
+					}
+					return fixed, err
+				}
+				if result := filterMapPendingCreates(ctx, s, opts.Display, yes, fix); result != nil {
 					return result
 				}
 			}
@@ -256,6 +291,9 @@ func newRefreshCmd() *cobra.Command {
 				DisableResourceReferences: disableResourceReferences(),
 				DisableOutputValues:       disableOutputValues(),
 				RefreshTargets:            deploy.NewUrnTargets(targetUrns),
+				Excludes:                  deploy.NewUrnTargets(excludes),
+				ExcludeDependents:         excludeDependents,
+				ResolvePendingCreates:     resolvePendingCreates,
 				Experimental:              hasExperimentalCommands(),
 			}
 
@@ -275,6 +313,12 @@ func newRefreshCmd() *cobra.Command {
 				return result.FromError(errors.New("refresh cancelled"))
 			case res != nil:
 				return PrintEngineResult(res)
+			case detectDrift && changes != nil && engine.HasChanges(changes):
+				return result.FromError(&cmdutil.ExitCodeError{
+					Code: driftExitCode,
+					Err: fmt.Errorf("drift detected: %d resources changed and %d deleted outside of Pulumi",
+						changes[deploy.OpUpdate], changes[deploy.OpDelete]),
+				})
 			case expectNop && changes != nil && engine.HasChanges(changes):
 				return result.FromError(errors.New("error: no changes were expected but changes occurred"))
 			default:
@@ -289,6 +333,9 @@ func newRefreshCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&expectNop, "expect-no-changes", false,
 		"Return an error if any changes occur during this update")
+	cmd.PersistentFlags().BoolVar(
+		&detectDrift, "detect-drift", false,
+		"Only report the resources that have drifted, without changing the stack's state; exits with code 2 on drift")
 	cmd.PersistentFlags().StringVarP(
 		&stackName, "stack", "s", "",
 		"The name of the stack to operate on. Defaults to the current stack")
@@ -303,6 +350,13 @@ func newRefreshCmd() *cobra.Command {
 	targets = cmd.PersistentFlags().StringArrayP(
 		"target", "t", []string{},
 		"Specify a single resource URN to refresh. Multiple resource can be specified using: --target urn1 --target urn2")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to not refresh. Multiple resources can be specified using:"+
+			" --exclude urn1 --exclude urn2. Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also don't refresh the resources that depend on the resources specified in --exclude list")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().BoolVar(
@@ -344,6 +398,10 @@ func newRefreshCmd() *cobra.Command {
 	importPendingCreates = cmd.PersistentFlags().StringArray(
 		"import-pending-creates", nil,
 		"A list of form [[URN ID]...] describing the provider IDs of pending creates")
+	cmd.PersistentFlags().BoolVar(
+		&resolvePendingCreates, "resolve-pending-creates", false,
+		"Read the resources of the pending creates whose IDs are known from their providers, adding those that exist"+
+			" to the stack's state and dropping those that don't. Implied by --import-pending-creates")
 
 	// Remote flags
 	remoteArgs.applyFlags(cmd)
@@ -378,7 +436,7 @@ func filterMapPendingCreates(
 			if op.Resource == nil {
 				return fmt.Errorf("found operation without resource")
 			}
-			if op.Type != resource.OperationTypeCreating {
+			if !deploy.IsPendingCreate(op) {
 				pending = append(pending, op)
 				continue
 			}
@@ -430,7 +488,7 @@ func hasPendingCreates(snap *deploy.Snapshot) bool {
 		return false
 	}
 	for _, op := range snap.PendingOperations {
-		if op.Type == resource.OperationTypeCreating {
+		if deploy.IsPendingCreate(op) {
 			return true
 		}
 	}
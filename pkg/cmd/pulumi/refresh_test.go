@@ -0,0 +1,87 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+// Pending imports are pending creates too, so --clear-pending-creates clears them.
+func TestClearPendingCreates_import(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	b, err := filestate.New(ctx, cmdutil.Diag(), "file://"+filepath.ToSlash(t.TempDir()), nil)
+	require.NoError(t, err)
+	ref, err := b.ParseStackReference("organization/proj/dev")
+	require.NoError(t, err)
+	s, err := b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	stackURN := resource.NewURN("dev", "proj", "", "pulumi:pulumi:Stack", "proj-dev")
+	stackRes := &resource.State{URN: stackURN, Type: "pulumi:pulumi:Stack"}
+	imported := &resource.State{
+		URN:    resource.NewURN("dev", "proj", "pulumi:pulumi:Stack", "pkgA:m:typA", "imported"),
+		Type:   "pkgA:m:typA",
+		Custom: true,
+		ID:     "some-id",
+		Parent: stackURN,
+	}
+	updated := &resource.State{
+		URN:    resource.NewURN("dev", "proj", "pulumi:pulumi:Stack", "pkgA:m:typA", "updated"),
+		Type:   "pkgA:m:typA",
+		Custom: true,
+		ID:     "other-id",
+		Parent: stackURN,
+	}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(),
+		[]*resource.State{stackRes, updated}, []resource.Operation{
+			resource.NewOperation(imported, resource.OperationTypeImporting),
+			resource.NewOperation(updated, resource.OperationTypeUpdating),
+		})
+	require.NoError(t, saveSnapshot(ctx, s, snap, snap.SecretsManager))
+	assert.True(t, hasPendingCreates(snap))
+
+	s, err = b.GetStack(ctx, ref)
+	require.NoError(t, err)
+	res := filterMapPendingCreates(ctx, s, display.Options{}, false,
+		func(op resource.Operation) (*resource.Operation, error) {
+			return nil, nil
+		})
+	require.Nil(t, res)
+
+	s, err = b.GetStack(ctx, ref)
+	require.NoError(t, err)
+	snap, err = s.Snapshot(ctx, stack.DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	assert.False(t, hasPendingCreates(snap))
+	require.Len(t, snap.PendingOperations, 1)
+	assert.Equal(t, resource.OperationTypeUpdating, snap.PendingOperations[0].Type)
+	assert.Equal(t, updated.URN, snap.PendingOperations[0].Resource.URN)
+}
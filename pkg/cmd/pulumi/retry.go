@@ -0,0 +1,110 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+)
+
+// retryFlags holds the flags that control how resource operations that fail with transient provider errors are
+// retried.
+type retryFlags struct {
+	attempts  int
+	delay     time.Duration
+	maxDelay  time.Duration
+	codes     []string
+	resources []string
+}
+
+func (f *retryFlags) register(cmd *cobra.Command) {
+	cmd.PersistentFlags().IntVar(
+		&f.attempts, "retry-attempts", 0,
+		"Retry resource operations that fail with transient provider errors, such as throttling,"+
+			" up to this many attempts in total")
+	cmd.PersistentFlags().DurationVar(
+		&f.delay, "retry-delay", time.Second,
+		"The delay before retrying a failed resource operation for the first time; it doubles with each retry")
+	cmd.PersistentFlags().DurationVar(
+		&f.maxDelay, "retry-max-delay", 30*time.Second,
+		"The maximum delay between two attempts at a resource operation")
+	cmd.PersistentFlags().StringSliceVar(
+		&f.codes, "retry-codes", nil,
+		"The gRPC status codes of provider errors to retry (defaults to Unavailable, ResourceExhausted and Aborted)")
+	cmd.PersistentFlags().StringArrayVar(
+		&f.resources, "retry-resource", []string{},
+		"Override the number of attempts for specific resources, as 'urn=attempts'."+
+			" Multiple resources can be specified using: --retry-resource urn1=5 --retry-resource urn2=1."+
+			" Wildcards (*, **) are also supported")
+}
+
+// isSet returns true if any retries were requested.
+func (f *retryFlags) isSet() bool {
+	return f.attempts > 0 || len(f.resources) > 0
+}
+
+// policies returns the deployment's retry policy and the policies of specific resources.
+func (f *retryFlags) policies() (deploy.RetryPolicy, []deploy.ResourceRetryPolicy, error) {
+	policy := deploy.RetryPolicy{
+		MaxAttempts:  f.attempts,
+		InitialDelay: f.delay,
+		MaxDelay:     f.maxDelay,
+	}
+	for _, name := range f.codes {
+		code, err := parseRetryCode(name)
+		if err != nil {
+			return deploy.RetryPolicy{}, nil, err
+		}
+		policy.Codes = append(policy.Codes, code)
+	}
+
+	var resources []deploy.ResourceRetryPolicy
+	for _, spec := range f.resources {
+		// URNs contain '=' only in their names, so split on the last one.
+		i := strings.LastIndex(spec, "=")
+		if i <= 0 {
+			return deploy.RetryPolicy{}, nil, fmt.Errorf("invalid --retry-resource %q: expected 'urn=attempts'", spec)
+		}
+		attempts, err := strconv.Atoi(spec[i+1:])
+		if err != nil || attempts < 0 {
+			return deploy.RetryPolicy{}, nil, fmt.Errorf("invalid --retry-resource %q: expected 'urn=attempts'", spec)
+		}
+
+		resourcePolicy := policy
+		resourcePolicy.MaxAttempts = attempts
+		resources = append(resources, deploy.ResourceRetryPolicy{
+			Targets: deploy.NewUrnTargets([]string{spec[:i]}),
+			Policy:  resourcePolicy,
+		})
+	}
+	return policy, resources, nil
+}
+
+// parseRetryCode parses the name of a gRPC status code, such as "Unavailable".
+func parseRetryCode(name string) (codes.Code, error) {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.EqualFold(code.String(), name) {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown gRPC status code %q", name)
+}
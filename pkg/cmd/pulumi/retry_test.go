@@ -0,0 +1,55 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestRetryFlags(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.URN("urn:pulumi:stack::proj::pkg:index:typ::name")
+	f := retryFlags{
+		attempts:  3,
+		delay:     time.Second,
+		maxDelay:  time.Minute,
+		codes:     []string{"unavailable", "Internal"},
+		resources: []string{"urn:pulumi:stack::proj::pkg:index:typ::*=5"},
+	}
+	policy, resources, err := f.policies()
+	require.NoError(t, err)
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.Internal}, policy.Codes)
+	require.Len(t, resources, 1)
+	assert.True(t, resources[0].Targets.Contains(urn))
+	assert.Equal(t, 5, resources[0].Policy.MaxAttempts)
+	assert.Equal(t, time.Minute, resources[0].Policy.MaxDelay)
+
+	f.resources = []string{"urn:pulumi:stack::proj::pkg:index:typ::name"}
+	_, _, err = f.policies()
+	assert.ErrorContains(t, err, "expected 'urn=attempts'")
+
+	f.resources, f.codes = nil, []string{"Flaky"}
+	_, _, err = f.policies()
+	assert.ErrorContains(t, err, `unknown gRPC status code "Flaky"`)
+}
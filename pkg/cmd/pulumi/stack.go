@@ -179,6 +179,7 @@ func newStackCmd() *cobra.Command {
 	cmd.Flags().BoolVar(
 		&showStackName, "show-name", false, "Display only the stack name")
 
+	cmd.AddCommand(newStackDiffCmd())
 	cmd.AddCommand(newStackExportCmd())
 	cmd.AddCommand(newStackGraphCmd())
 	cmd.AddCommand(newStackImportCmd())
@@ -189,8 +190,11 @@ func newStackCmd() *cobra.Command {
 	cmd.AddCommand(newStackSelectCmd())
 	cmd.AddCommand(newStackTagCmd())
 	cmd.AddCommand(newStackRenameCmd())
+	cmd.AddCommand(newStackRestoreCmd())
 	cmd.AddCommand(newStackChangeSecretsProviderCmd())
 	cmd.AddCommand(newStackHistoryCmd())
+	cmd.AddCommand(newStackLockCmd())
+	cmd.AddCommand(newStackMigrateCmd())
 	cmd.AddCommand(newStackUnselectCmd())
 
 	return cmd
@@ -0,0 +1,182 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+func newStackDiffCmd() *cobra.Command {
+	var stackName string
+	var jsonOut bool
+	var showSecrets bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <version> [<version>]",
+		Args:  cmdutil.RangeArgs(1, 2),
+		Short: "Show the differences between two versions of a stack's state",
+		Long: "Show the differences between two versions of a stack's state.\n" +
+			"\n" +
+			"Versions are the ones shown by `pulumi stack history`. If a single version is given, it is\n" +
+			"compared with the stack's current state. Each resource that was added, removed or changed\n" +
+			"between the two versions is listed along with the changes to its inputs and outputs.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			ctx := commandContext()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return err
+			}
+
+			oldVersion, newVersion := args[0], ""
+			if len(args) > 1 {
+				newVersion = args[1]
+			}
+
+			oldSnap, err := loadSnapshotForVersion(ctx, s, oldVersion)
+			if err != nil {
+				return err
+			}
+			newSnap, err := loadSnapshotForVersion(ctx, s, newVersion)
+			if err != nil {
+				return err
+			}
+			if showSecrets {
+				log3rdPartySecretsProviderDecryptionEvent(ctx, s, "", "pulumi stack diff")
+			}
+
+			diffs := display.DiffSnapshots(oldSnap, newSnap, showSecrets)
+			if jsonOut {
+				return printJSON(stateDiffsToJSON(diffs, showSecrets))
+			}
+
+			if len(diffs) == 0 {
+				fmt.Println("No differences")
+				return nil
+			}
+			var b bytes.Buffer
+			display.PrintStateDiffs(&b, diffs, showSecrets)
+			fmt.Print(opts.Color.Colorize(b.String()))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+	cmd.PersistentFlags().BoolVar(
+		&showSecrets, "show-secrets", false, "Display secret values in plaintext. Defaults to `false`")
+
+	return cmd
+}
+
+// loadSnapshotForVersion loads the given version of the stack's state, or its current state if version is empty. The
+// snapshot is nil if the stack had no state at that version.
+func loadSnapshotForVersion(ctx context.Context, s backend.Stack, version string) (*deploy.Snapshot, error) {
+	var deployment *apitype.UntypedDeployment
+	var err error
+	if version == "" {
+		if deployment, err = s.ExportDeployment(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		be := s.Backend()
+		specificExpBE, ok := be.(backend.SpecificDeploymentExporter)
+		if !ok {
+			return nil, fmt.Errorf("the current backend (%s) does not provide the ability to export previous deployments",
+				be.Name())
+		}
+		if deployment, err = specificExpBE.ExportDeploymentForVersion(ctx, s, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if deployment == nil || len(deployment.Deployment) == 0 || string(deployment.Deployment) == "null" {
+		return nil, nil
+	}
+	snap, err := stack.DeserializeUntypedDeployment(ctx, deployment, stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, checkDeploymentVersionError(err, s.Ref().Name().String())
+	}
+	return snap, nil
+}
+
+// resourceStateDiffJSON is the shape of the --json output of `pulumi stack diff`.
+type resourceStateDiffJSON struct {
+	URN             string                 `json:"urn"`
+	Type            string                 `json:"type"`
+	Op              string                 `json:"op"`
+	PendingDeletion bool                   `json:"pendingDeletion,omitempty"`
+	ChangedFields   []string               `json:"changedFields,omitempty"`
+	ChangedInputs   []string               `json:"changedInputs,omitempty"`
+	ChangedOutputs  []string               `json:"changedOutputs,omitempty"`
+	OldInputs       map[string]interface{} `json:"oldInputs,omitempty"`
+	NewInputs       map[string]interface{} `json:"newInputs,omitempty"`
+	OldOutputs      map[string]interface{} `json:"oldOutputs,omitempty"`
+	NewOutputs      map[string]interface{} `json:"newOutputs,omitempty"`
+}
+
+func stateDiffsToJSON(diffs []display.ResourceStateDiff, showSecrets bool) []resourceStateDiffJSON {
+	changedKeys := func(diff *resource.ObjectDiff) []string {
+		if diff == nil {
+			return nil
+		}
+		var keys []string
+		for _, k := range diff.ChangedKeys() {
+			keys = append(keys, string(k))
+		}
+		return keys
+	}
+
+	output := make([]resourceStateDiffJSON, 0, len(diffs))
+	for _, diff := range diffs {
+		res := diff.State()
+		out := resourceStateDiffJSON{
+			URN:             string(res.URN),
+			Type:            string(res.Type),
+			Op:              string(diff.Op),
+			PendingDeletion: res.Delete,
+			ChangedFields:   diff.Fields,
+			ChangedInputs:   changedKeys(diff.Inputs),
+			ChangedOutputs:  changedKeys(diff.Outputs),
+		}
+		if diff.Old != nil {
+			out.OldInputs = display.MassageSecrets(diff.Old.Inputs, showSecrets).Mappable()
+			out.OldOutputs = display.MassageSecrets(diff.Old.Outputs, showSecrets).Mappable()
+		}
+		if diff.New != nil {
+			out.NewInputs = display.MassageSecrets(diff.New.Inputs, showSecrets).Mappable()
+			out.NewOutputs = display.MassageSecrets(diff.New.Outputs, showSecrets).Mappable()
+		}
+		output = append(output, out)
+	}
+	return output
+}
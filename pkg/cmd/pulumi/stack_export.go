@@ -56,6 +56,19 @@ func newStackExportCmd() *cobra.Command {
 				return err
 			}
 
+			// Unless the secrets need decrypting, write the latest deployment straight from the backend's storage
+			// if it supports that, rather than loading it in memory first.
+			if streamer, ok := s.Backend().(backend.DeploymentStreamer); ok && version == "" && !showSecrets {
+				writer, err := openExportFile(file)
+				if err != nil {
+					return err
+				}
+				if err = streamer.StreamDeployment(ctx, s, writer); err != nil {
+					return fmt.Errorf("could not export deployment: %w", err)
+				}
+				return nil
+			}
+
 			var deployment *apitype.UntypedDeployment
 			// Export the latest version of the checkpoint by default. Otherwise, we require that
 			// the backend/stack implements the ability the export previous checkpoints.
@@ -79,13 +92,9 @@ func newStackExportCmd() *cobra.Command {
 				}
 			}
 
-			// Read from stdin or a specified file.
-			writer := os.Stdout
-			if file != "" {
-				writer, err = os.Create(file)
-				if err != nil {
-					return fmt.Errorf("could not open file: %w", err)
-				}
+			writer, err := openExportFile(file)
+			if err != nil {
+				return err
 			}
 
 			if showSecrets {
@@ -95,22 +104,13 @@ func newStackExportCmd() *cobra.Command {
 					return checkDeploymentVersionError(err, stackName)
 				}
 
-				serializedDeployment, err := stack.SerializeDeployment(snap, snap.SecretsManager, true)
-				if err != nil {
-					return err
-				}
-
-				data, err := json.Marshal(serializedDeployment)
-				if err != nil {
-					return err
-				}
+				log3rdPartySecretsProviderDecryptionEvent(ctx, s, "", "pulumi stack export")
 
-				deployment = &apitype.UntypedDeployment{
-					Version:    3,
-					Deployment: data,
+				// Write the decrypted deployment one resource at a time, rather than serializing it in memory first.
+				if err = stack.EncodeDeployment(writer, snap, snap.SecretsManager, true /* showSecrets */); err != nil {
+					return fmt.Errorf("could not export deployment: %w", err)
 				}
-
-				log3rdPartySecretsProviderDecryptionEvent(ctx, s, "", "pulumi stack export")
+				return nil
 			}
 
 			// Write the deployment.
@@ -135,3 +135,15 @@ func newStackExportCmd() *cobra.Command {
 		&showSecrets, "show-secrets", "", false, "Emit secrets in plaintext in exported stack. Defaults to `false`")
 	return cmd
 }
+
+// openExportFile returns the file to write an exported deployment to, which is standard out if no file was given.
+func openExportFile(file string) (*os.File, error) {
+	if file == "" {
+		return os.Stdout, nil
+	}
+	writer, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	return writer, nil
+}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,7 +25,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
@@ -67,17 +70,10 @@ func newStackImportCmd() *cobra.Command {
 				}
 			}
 
-			// Read the checkpoint from stdin.  We decode this into a json.RawMessage so as not to lose any fields
-			// sent by the server that the client CLI does not recognize (enabling round-tripping).
-			var deployment apitype.UntypedDeployment
-			if err = json.NewDecoder(reader).Decode(&deployment); err != nil {
-				return err
-			}
-
-			// We do, however, now want to unmarshal the json.RawMessage into a real, typed deployment.  We do this so
-			// we can check that the deployment doesn't contain resources from a stack other than the selected one. This
-			// catches errors wherein someone imports the wrong stack's deployment (which can seriously hork things).
-			snapshot, err := stack.DeserializeUntypedDeployment(ctx, &deployment, stack.DefaultSecretsProvider)
+			// Read the deployment from stdin, decoding it into a real, typed snapshot one resource at a time.  We do this
+			// so we can check that the deployment doesn't contain resources from a stack other than the selected one.
+			// This catches errors wherein someone imports the wrong stack's deployment (which can seriously hork things).
+			snapshot, err := stack.DecodeDeployment(ctx, reader, stack.DefaultSecretsProvider)
 			if err != nil {
 				return checkDeploymentVersionError(err, stackName.String())
 			}
@@ -122,23 +118,9 @@ func newStackImportCmd() *cobra.Command {
 
 				snapshot.PendingOperations = nil
 			}
-			sdp, err := stack.SerializeDeployment(snapshot, snapshot.SecretsManager, false /* showSecrets */)
-			if err != nil {
-				return fmt.Errorf("constructing deployment for upload: %w", err)
-			}
-
-			bytes, err := json.Marshal(sdp)
-			if err != nil {
-				return err
-			}
-
-			dep := apitype.UntypedDeployment{
-				Version:    apitype.DeploymentSchemaVersionCurrent,
-				Deployment: bytes,
-			}
 
 			// Now perform the deployment.
-			if err = s.ImportDeployment(ctx, &dep); err != nil {
+			if err = importSnapshot(ctx, s, snapshot); err != nil {
 				return fmt.Errorf("could not import deployment: %w", err)
 			}
 			fmt.Printf("Import complete.\n")
@@ -156,3 +138,26 @@ func newStackImportCmd() *cobra.Command {
 
 	return cmd
 }
+
+// importSnapshot imports the given snapshot as the stack's state. Backends that support it write the snapshot
+// directly, one resource at a time; others are sent the snapshot serialized as a deployment.
+func importSnapshot(ctx context.Context, s backend.Stack, snapshot *deploy.Snapshot) error {
+	if importer, ok := s.Backend().(backend.SnapshotImporter); ok {
+		return importer.ImportSnapshot(ctx, s, snapshot)
+	}
+
+	sdp, err := stack.SerializeDeployment(snapshot, snapshot.SecretsManager, false /* showSecrets */)
+	if err != nil {
+		return fmt.Errorf("constructing deployment for upload: %w", err)
+	}
+
+	bytes, err := json.Marshal(sdp)
+	if err != nil {
+		return err
+	}
+
+	return s.ImportDeployment(ctx, &apitype.UntypedDeployment{
+		Version:    apitype.DeploymentSchemaVersionCurrent,
+		Deployment: bytes,
+	})
+}
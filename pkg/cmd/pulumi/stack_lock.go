@@ -0,0 +1,216 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStackLockCmd() *cobra.Command {
+	var stack string
+
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Manage stack locks",
+		Long: "Manage stack locks\n" +
+			"\n" +
+			"Self-managed backends lock a stack while it is being updated. Each lock records the user,\n" +
+			"host and process that holds it, and is leased for a limited time that the running update\n" +
+			"keeps renewing. Locks whose lease has run out are taken over automatically. The `ls` and\n" +
+			"`break` commands can be used to inspect and remove locks.\n",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+
+	cmd.AddCommand(newStackLockLsCmd(&stack))
+	cmd.AddCommand(newStackLockBreakCmd(&stack))
+
+	return cmd
+}
+
+// requireLockingStack returns the given stack along with its backend, if the backend supports managing locks.
+func requireLockingStack(stackName string) (backend.Stack, filestate.Backend, error) {
+	ctx := commandContext()
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+	s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, ok := s.Backend().(filestate.Backend)
+	if !ok {
+		return nil, nil, fmt.Errorf("the current backend (%s) does not support managing stack locks", s.Backend().Name())
+	}
+	return s, b, nil
+}
+
+func newStackLockLsCmd(stack *string) *cobra.Command {
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the locks held on a stack",
+		Args:  cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			ctx := commandContext()
+			s, b, err := requireLockingStack(*stack)
+			if err != nil {
+				return err
+			}
+
+			locks, err := b.ListLocks(ctx, s.Ref())
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				return printStackLocksJSON(locks)
+			}
+
+			if len(locks) == 0 {
+				fmt.Printf("Stack %s is not locked\n", s.Ref())
+				return nil
+			}
+			printStackLocks(locks)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+// stackLockJSON is the shape of the --json output of `pulumi stack lock ls`.
+type stackLockJSON struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Hostname  string `json:"hostname"`
+	Pid       int    `json:"pid"`
+	Timestamp string `json:"timestamp"`
+	Expires   string `json:"expires,omitempty"`
+	Expired   bool   `json:"expired"`
+}
+
+func printStackLocksJSON(locks []filestate.LockInfo) error {
+	now := time.Now()
+	output := make([]stackLockJSON, len(locks))
+	for i, l := range locks {
+		output[i] = stackLockJSON{
+			ID:        l.ID,
+			Username:  l.Username,
+			Hostname:  l.Hostname,
+			Pid:       l.Pid,
+			Timestamp: l.Timestamp.UTC().Format(timeFormat),
+			Expired:   l.Expired(now),
+		}
+		if l.Expires != nil {
+			output[i].Expires = l.Expires.UTC().Format(timeFormat)
+		}
+	}
+	return printJSON(output)
+}
+
+func printStackLocks(locks []filestate.LockInfo) {
+	now := time.Now()
+	rows := make([]cmdutil.TableRow, 0, len(locks))
+	for _, l := range locks {
+		expires := "never"
+		if l.Expires != nil {
+			expires = l.Expires.Format(time.RFC3339)
+			if l.Expired(now) {
+				expires += " (expired)"
+			}
+		}
+		rows = append(rows, cmdutil.TableRow{Columns: []string{
+			l.ID,
+			fmt.Sprintf("%s@%s", l.Username, l.Hostname),
+			strconv.Itoa(l.Pid),
+			l.Timestamp.Format(time.RFC3339),
+			expires,
+		}})
+	}
+
+	cmdutil.PrintTable(cmdutil.Table{
+		Headers: []string{"ID", "HOLDER", "PID", "ACQUIRED", "EXPIRES"},
+		Rows:    rows,
+	})
+}
+
+func newStackLockBreakCmd(stack *string) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "break [<lock-id>]",
+		Short: "Break a lock held on a stack",
+		Long: "Break a lock held on a stack\n" +
+			"\n" +
+			"This command deletes the lock with the given ID, as shown by `pulumi stack lock ls`,\n" +
+			"or all of the stack's locks if no ID is given. Note that this operation is _very dangerous_\n" +
+			"if the process holding the lock is still running, as concurrent updates may corrupt the stack.",
+		Args: cmdutil.MaximumNArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			s, b, err := requireLockingStack(*stack)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			var id string
+			if len(args) > 0 {
+				id = args[0]
+			}
+
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+			stackName := string(s.Ref().Name())
+			prompt := fmt.Sprintf("This will break all locks held on '%s'!", stackName)
+			if id != "" {
+				prompt = fmt.Sprintf("This will break the lock %s held on '%s'!", id, stackName)
+			}
+			if cmdutil.Interactive() && (!yes && !confirmPrompt(prompt, stackName, opts)) {
+				fmt.Println("confirmation declined")
+				return result.Bail()
+			}
+
+			if err := b.BreakLock(ctx, s.Ref(), id); err != nil {
+				return result.FromError(err)
+			}
+			fmt.Println("Lock broken")
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Skip confirmation prompts, and proceed with breaking the lock anyway")
+
+	return cmd
+}
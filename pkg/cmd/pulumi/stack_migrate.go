@@ -0,0 +1,436 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dustin/go-humanize/english"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func newStackMigrateCmd() *cobra.Command {
+	var stackName string
+	var destBackendURL string
+	var destStackName string
+	var secretsProvider string
+	var copyHistory bool
+	var dryRun bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Args:  cmdutil.NoArgs,
+		Short: "Copy a stack to another backend",
+		Long: "Copy a stack to another backend.\n" +
+			"\n" +
+			"This command creates a stack in the backend at --dest-backend with the same state, configuration and\n" +
+			"tags as the current stack. The secrets in the stack's configuration file and in its state are\n" +
+			"re-encrypted with the secrets provider given by --secrets-provider. With --copy-history, the stack's\n" +
+			"update history is copied as well, if the destination backend supports it. The source stack is left\n" +
+			"as it is.\n" +
+			"\n" +
+			"Once the stack is copied, its state is read back from the destination backend and compared with the\n" +
+			"source, to make sure that nothing was lost on the way. Use --dry-run to only show what would be copied.\n" +
+			"\n" +
+			"If the destination stack has the same name as the source, the two share the stack's configuration file.\n" +
+			"The stack can then only be copied if its secrets provider keeps the same key, such as the same\n" +
+			"passphrase or cloud key, so that the source stack can still decrypt its configuration. Otherwise give\n" +
+			"the destination stack another name with --dest-stack.\n" +
+			"\n" +
+			"If copying the stack fails, the destination stack is removed and the configuration file is restored.",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if err := validateSecretsProvider(secretsProvider); err != nil {
+				return err
+			}
+			project, _, err := readProject()
+			if err != nil {
+				return err
+			}
+
+			src, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return err
+			}
+			m, err := loadStackMigration(ctx, project, src)
+			if err != nil {
+				return err
+			}
+			if copyHistory {
+				if m.history, err = src.Backend().GetHistory(ctx, src.Ref(), 0, 0); err != nil {
+					return fmt.Errorf("getting the history of stack %s: %w", src.Ref(), err)
+				}
+			}
+
+			destBackend, err := backendForURL(ctx, destBackendURL, project)
+			if err != nil {
+				return fmt.Errorf("could not get the destination backend: %w", err)
+			}
+			if destStackName == "" {
+				destStackName = string(src.Ref().Name())
+			}
+			destRef, err := destBackend.ParseStackReference(destStackName)
+			if err != nil {
+				return err
+			}
+			if existing, err := destBackend.GetStack(ctx, destRef); err != nil {
+				return err
+			} else if existing != nil {
+				return fmt.Errorf("stack %s already exists in %s", destRef, destBackend.URL())
+			}
+
+			fmt.Printf("Copying stack %s from %s to stack %s in %s:\n",
+				src.Ref(), src.Backend().URL(), destRef, destBackend.URL())
+			m.printSummary(copyHistory)
+			if dryRun {
+				fmt.Println("This was a dry run; no stack was created")
+				return nil
+			}
+			if !yes && !confirmStateEdit("This command will create a copy of your stack. Confirm?", opts) {
+				fmt.Println("confirmation declined")
+				return nil
+			}
+
+			dest, err := migrateStack(ctx, project, m, destBackend, destRef, secretsProvider)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Stack %s was copied to %s\n", dest.Ref(), destBackend.URL())
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().StringVar(
+		&destBackendURL, "dest-backend", "", "The URL of the backend to copy the stack to")
+	if err := cmd.MarkPersistentFlagRequired("dest-backend"); err != nil {
+		panic("failed to mark 'dest-backend' as a required flag")
+	}
+	cmd.PersistentFlags().StringVar(
+		&destStackName, "dest-stack", "",
+		"The name of the stack to create in the destination backend. Defaults to the name of the source stack")
+	cmd.PersistentFlags().StringVar(
+		&secretsProvider, "secrets-provider", "default", possibleSecretsProviderChoices)
+	cmd.PersistentFlags().BoolVar(
+		&copyHistory, "copy-history", false, "Copy the stack's update history as well")
+	cmd.PersistentFlags().BoolVar(
+		&dryRun, "dry-run", false, "Only show what would be copied, without creating the destination stack")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+// stackMigration holds what is copied from the source stack of a migration.
+type stackMigration struct {
+	src          backend.Stack
+	projectStack *workspace.ProjectStack
+	config       config.Map
+	decrypter    config.Decrypter
+	snap         *deploy.Snapshot
+	tags         map[apitype.StackTagName]string
+	history      []backend.UpdateInfo
+}
+
+// loadStackMigration loads the configuration, state and tags of the given stack, with their secrets decrypted.
+func loadStackMigration(ctx context.Context, project *workspace.Project, src backend.Stack) (*stackMigration, error) {
+	ps, err := loadProjectStack(project, src)
+	if err != nil {
+		return nil, err
+	}
+	var decrypter config.Decrypter = config.NewPanicCrypter()
+	if ps.Config.HasSecureValue() {
+		if decrypter, err = getStackDecrypter(src); err != nil {
+			return nil, err
+		}
+	}
+
+	dep, err := src.ExportDeployment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := stack.DeserializeUntypedDeployment(ctx, dep, stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, checkDeploymentVersionError(err, src.Ref().Name().String())
+	}
+
+	return &stackMigration{
+		src:          src,
+		projectStack: ps,
+		config:       ps.Config,
+		decrypter:    decrypter,
+		snap:         snap,
+		tags:         src.Tags(),
+	}, nil
+}
+
+// printSummary lists what will be copied.
+func (m *stackMigration) printSummary(copyHistory bool) {
+	resources := 0
+	if m.snap != nil {
+		resources = len(m.snap.Resources)
+	}
+	secrets := 0
+	for _, v := range m.config {
+		if v.Secure() {
+			secrets++
+		}
+	}
+
+	fmt.Printf("    %s\n", english.Plural(resources, "resource", ""))
+	fmt.Printf("    %s, %s\n", english.Plural(len(m.config), "configuration value", ""),
+		english.Plural(secrets, "secret", ""))
+	fmt.Printf("    %s\n", english.Plural(len(m.tags), "tag", ""))
+	if copyHistory {
+		fmt.Printf("    %s\n", english.Plural(len(m.history), "history entry", "history entries"))
+	}
+}
+
+// migrateStack creates the destination stack of a migration and copies the source stack to it, re-encrypting its
+// secrets with the given secrets provider, then checks that the copied state matches the source. If copying the stack
+// or that check fails, the destination stack is removed again and its configuration file is put back as it was.
+func migrateStack(ctx context.Context, project *workspace.Project, m *stackMigration,
+	b backend.Backend, ref backend.StackReference, secretsProvider string,
+) (backend.Stack, error) {
+	// The destination stack shares the configuration file of the source stack if it has the same name, or if a
+	// configuration file was given explicitly.
+	sharedConfig := stackConfigFile != "" || ref.Name() == m.src.Ref().Name()
+	configPath := stackConfigFile
+	if configPath == "" {
+		_, path, err := workspace.DetectProjectStackPath(ref.Name().Q())
+		if err != nil {
+			return nil, err
+		}
+		configPath = path
+	}
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	dest, err := b.CreateStack(ctx, ref, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create stack: %w", err)
+	}
+	err = copyStack(ctx, project, m, b, dest, secretsProvider, sharedConfig)
+	if err == nil {
+		if verifyErr := verifyMigratedState(ctx, dest, m.snap); verifyErr != nil {
+			err = fmt.Errorf("the state copied to stack %s doesn't match the source: %w", dest.Ref(), verifyErr)
+		}
+	}
+	if err != nil {
+		if cleanupErr := removeMigratedStack(ctx, b, dest, configPath, configBytes); cleanupErr != nil {
+			return nil, fmt.Errorf("%w; %v", err, cleanupErr)
+		}
+		return nil, err
+	}
+	return dest, nil
+}
+
+// removeMigratedStack removes the destination stack of a failed migration, and puts its configuration file back as it
+// was, or removes it if there was none. It reports what couldn't be cleaned up.
+func removeMigratedStack(ctx context.Context, b backend.Backend, dest backend.Stack,
+	configPath string, configBytes []byte,
+) error {
+	var leftovers []string
+	if _, err := b.RemoveStack(ctx, dest, true /*force*/); err != nil {
+		leftovers = append(leftovers, fmt.Sprintf("stack %s was left in %s (%v)", dest.Ref(), b.URL(), err))
+	}
+	if configBytes == nil {
+		if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			leftovers = append(leftovers, fmt.Sprintf("configuration file %s was left behind (%v)", configPath, err))
+		}
+	} else if err := os.WriteFile(configPath, configBytes, 0o644); err != nil { //nolint:gosec // as workspace saves it
+		leftovers = append(leftovers, fmt.Sprintf("configuration file %s could not be restored (%v)", configPath, err))
+	}
+	if len(leftovers) > 0 {
+		return fmt.Errorf("cleaning up the partially copied stack failed: %s", strings.Join(leftovers, "; "))
+	}
+	return nil
+}
+
+// copyStack copies the source stack of a migration to the given, newly created, destination stack.
+func copyStack(ctx context.Context, project *workspace.Project, m *stackMigration,
+	b backend.Backend, dest backend.Stack, secretsProvider string, sharedConfig bool,
+) error {
+	// The stack isn't new as far as its secrets manager is concerned: if it shares its configuration file with the
+	// source stack, the file may hold the settings of the source stack's secrets provider.
+	if err := createSecretsManager(ctx, dest, secretsProvider,
+		false /*rotateSecretsManager*/, false /*creatingStack*/); err != nil {
+		return err
+	}
+	if sharedConfig {
+		ps, err := loadProjectStack(project, dest)
+		if err != nil {
+			return err
+		}
+		if !sameSecretsKey(m.projectStack, ps) {
+			return fmt.Errorf("stack %s shares its configuration file with the source stack, which could no longer "+
+				"decrypt its configuration with the secrets provider %q; use --dest-stack to give the destination "+
+				"stack another name", dest.Ref(), secretsProvider)
+		}
+	}
+	sm, err := getStackSecretsManager(dest)
+	if err != nil {
+		return err
+	}
+
+	// Re-encrypt the configuration.
+	encrypter, err := sm.Encrypter()
+	if err != nil {
+		return err
+	}
+	cfg, err := m.config.Copy(m.decrypter, encrypter)
+	if err != nil {
+		return err
+	}
+	ps, err := loadProjectStack(project, dest)
+	if err != nil {
+		return err
+	}
+	ps.Config = cfg
+	if err := saveProjectStack(dest, ps); err != nil {
+		return err
+	}
+
+	// Re-encrypt the state, renaming the stack in its URNs if need be.
+	if m.snap != nil {
+		if dest.Ref().Name() != m.src.Ref().Name() {
+			if err := edit.RenameStack(m.snap, dest.Ref().Name(), ""); err != nil {
+				return err
+			}
+		}
+		if err := saveSnapshot(ctx, dest, m.snap, sm); err != nil {
+			return err
+		}
+	}
+
+	if len(m.tags) > 0 {
+		if !b.SupportsTags() {
+			cmdutil.Diag().Warningf(diag.Message("", "the destination backend (%s) does not support stack tags, "+
+				"so the stack's tags were not copied"), b.Name())
+		} else {
+			// Tags set by the destination backend itself take precedence.
+			tags := make(map[apitype.StackTagName]string, len(m.tags))
+			for k, v := range m.tags {
+				tags[k] = v
+			}
+			for k, v := range dest.Tags() {
+				tags[k] = v
+			}
+			if err := backend.UpdateStackTags(ctx, dest, tags); err != nil {
+				return fmt.Errorf("copying the stack's tags: %w", err)
+			}
+		}
+	}
+
+	if len(m.history) > 0 {
+		importer, ok := b.(backend.HistoryImporter)
+		if !ok {
+			cmdutil.Diag().Warningf(diag.Message("", "the destination backend (%s) does not support importing "+
+				"history, so the stack's history was not copied"), b.Name())
+		} else {
+			// The history is newest first, but is imported oldest first.
+			history := make([]backend.UpdateInfo, len(m.history))
+			for i, update := range m.history {
+				history[len(history)-1-i] = update
+			}
+			if err := importer.ImportHistory(ctx, dest, history); err != nil {
+				return fmt.Errorf("copying the stack's history: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameSecretsKey returns true if a stack whose configuration file has the secrets settings in new encrypts its
+// configuration with the same key as one whose configuration file had those in old. Passphrase and cloud secrets
+// providers keep what their key derives from in the configuration file, but the service keeps a key for each stack.
+func sameSecretsKey(old, new *workspace.ProjectStack) bool {
+	if old.EncryptedKey != new.EncryptedKey || old.EncryptionSalt != new.EncryptionSalt ||
+		old.SecretsProvider != new.SecretsProvider {
+		return false
+	}
+	return new.EncryptedKey != "" || new.EncryptionSalt != ""
+}
+
+// verifyMigratedState checks that the state of the destination stack of a migration round-trips: that reading it
+// back, and decrypting its secrets, gives the same resources and pending operations as the given source snapshot.
+func verifyMigratedState(ctx context.Context, dest backend.Stack, want *deploy.Snapshot) error {
+	dep, err := dest.ExportDeployment(ctx)
+	if err != nil {
+		return err
+	}
+	got, err := stack.DeserializeUntypedDeployment(ctx, dep, stack.DefaultSecretsProvider)
+	if err != nil {
+		return fmt.Errorf("reading the copied state: %w", err)
+	}
+	if (got == nil) != (want == nil) {
+		return fmt.Errorf("the copied state is missing")
+	}
+	if want == nil {
+		return nil
+	}
+
+	wantJSON, err := plaintextState(want)
+	if err != nil {
+		return err
+	}
+	gotJSON, err := plaintextState(got)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(wantJSON, gotJSON) {
+		return fmt.Errorf("the copied resources or pending operations differ")
+	}
+	return nil
+}
+
+// plaintextState returns the resources and pending operations of the given snapshot as JSON, with their secrets
+// decrypted.
+func plaintextState(snap *deploy.Snapshot) ([]byte, error) {
+	dep, err := stack.SerializeDeployment(snap, snap.SecretsManager, true /* showSecrets */)
+	if err != nil {
+		return nil, fmt.Errorf("serializing deployment: %w", err)
+	}
+	return json.Marshal(struct {
+		Resources         []apitype.ResourceV3  `json:"resources"`
+		PendingOperations []apitype.OperationV2 `json:"pendingOperations"`
+	}{dep.Resources, dep.PendingOperations})
+}
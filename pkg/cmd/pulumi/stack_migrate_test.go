@@ -0,0 +1,187 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+// newMigrateTestStack creates the stack "dev" of a project in a new directory, and a backend to migrate it to.
+func newMigrateTestStack(t *testing.T) (backend.Stack, backend.Backend) {
+	ctx := context.Background()
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE", "how now brown cow")
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Pulumi.yaml"),
+		[]byte("name: proj\nruntime: yaml\n"), 0o600))
+	chdir(t, projectDir)
+	project, _, err := readProject()
+	require.NoError(t, err)
+
+	src, err := filestate.New(ctx, cmdutil.Diag(), "file://"+filepath.ToSlash(t.TempDir()), project)
+	require.NoError(t, err)
+	ref, err := src.ParseStackReference("dev")
+	require.NoError(t, err)
+	s, err := src.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	dest, err := filestate.New(ctx, cmdutil.Diag(), "file://"+filepath.ToSlash(t.TempDir()), project)
+	require.NoError(t, err)
+	return s, dest
+}
+
+// The source stack can still decrypt the configuration file that it shares with a destination stack of the same name
+// when the secrets provider keeps the same key.
+//
+//nolint:paralleltest // changes directory and environment for process
+func TestMigrateStack_sharedConfig(t *testing.T) {
+	ctx := context.Background()
+	src, destBackend := newMigrateTestStack(t)
+
+	require.NoError(t, createSecretsManager(ctx, src, "passphrase", false, true))
+	encrypter, err := getStackEncrypter(src)
+	require.NoError(t, err)
+	ciphertext, err := encrypter.EncryptValue(ctx, "hunter2")
+	require.NoError(t, err)
+	project, _, err := readProject()
+	require.NoError(t, err)
+	ps, err := loadProjectStack(project, src)
+	require.NoError(t, err)
+	ps.Config[config.MustMakeKey("proj", "password")] = config.NewSecureValue(ciphertext)
+	require.NoError(t, saveProjectStack(src, ps))
+
+	m, err := loadStackMigration(ctx, project, src)
+	require.NoError(t, err)
+	ref, err := destBackend.ParseStackReference("dev")
+	require.NoError(t, err)
+	_, err = migrateStack(ctx, project, m, destBackend, ref, "passphrase")
+	require.NoError(t, err)
+
+	decrypter, err := getStackDecrypter(src)
+	require.NoError(t, err)
+	ps, err = loadProjectStack(project, src)
+	require.NoError(t, err)
+	cfg, err := ps.Config.Decrypt(decrypter)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", cfg[config.MustMakeKey("proj", "password")])
+}
+
+// A destination stack of the same name isn't created if its secrets provider would change the configuration file that
+// it shares with the source stack.
+//
+//nolint:paralleltest // changes directory and environment for process
+func TestMigrateStack_sharedConfigOtherSecretsProvider(t *testing.T) {
+	ctx := context.Background()
+	src, destBackend := newMigrateTestStack(t)
+
+	configPath := filepath.Join(".", "Pulumi.dev.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("config:\n  proj:name: dev\n"), 0o600))
+	before, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+
+	project, _, err := readProject()
+	require.NoError(t, err)
+	m, err := loadStackMigration(ctx, project, src)
+	require.NoError(t, err)
+	ref, err := destBackend.ParseStackReference("dev")
+	require.NoError(t, err)
+	_, err = migrateStack(ctx, project, m, destBackend, ref, "passphrase")
+	assert.ErrorContains(t, err, "shares its configuration file with the source stack")
+
+	// The destination stack was removed, and the configuration file was put back.
+	dest, err := destBackend.GetStack(ctx, ref)
+	require.NoError(t, err)
+	assert.Nil(t, dest)
+	after, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+// corruptingBackend is a backend whose new stacks read back a different state from the one written to them.
+type corruptingBackend struct {
+	backend.Backend
+}
+
+func (b *corruptingBackend) CreateStack(ctx context.Context, ref backend.StackReference, root string,
+	opts *backend.CreateStackOptions,
+) (backend.Stack, error) {
+	s, err := b.Backend.CreateStack(ctx, ref, root, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &corruptingStack{Stack: s}, nil
+}
+
+type corruptingStack struct {
+	backend.Stack
+}
+
+func (s *corruptingStack) ExportDeployment(ctx context.Context) (*apitype.UntypedDeployment, error) {
+	res := &resource.State{
+		URN:  resource.NewURN("dev", "proj", "", "a:b:c", "stray"),
+		Type: "a:b:c",
+	}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), []*resource.State{res}, nil)
+	dep, err := stack.SerializeDeployment(snap, nil, false /* showSecrets */)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(dep)
+	if err != nil {
+		return nil, err
+	}
+	return &apitype.UntypedDeployment{Version: apitype.DeploymentSchemaVersionCurrent, Deployment: data}, nil
+}
+
+// A destination stack whose state doesn't match the source stack once copied is removed again.
+//
+//nolint:paralleltest // changes directory and environment for process
+func TestMigrateStack_verifyFails(t *testing.T) {
+	ctx := context.Background()
+	src, destBackend := newMigrateTestStack(t)
+
+	project, _, err := readProject()
+	require.NoError(t, err)
+	m, err := loadStackMigration(ctx, project, src)
+	require.NoError(t, err)
+	ref, err := destBackend.ParseStackReference("other")
+	require.NoError(t, err)
+	_, err = migrateStack(ctx, project, m, &corruptingBackend{Backend: destBackend}, ref, "passphrase")
+	assert.ErrorContains(t, err, "doesn't match the source")
+
+	// The destination stack was removed, along with the configuration file created for it.
+	dest, err := destBackend.GetStack(ctx, ref)
+	require.NoError(t, err)
+	assert.Nil(t, dest)
+	_, err = os.Stat(filepath.Join(".", "Pulumi.other.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
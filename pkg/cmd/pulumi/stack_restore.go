@@ -0,0 +1,122 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStackRestoreCmd() *cobra.Command {
+	var stackName string
+	var version string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Args:  cmdutil.NoArgs,
+		Short: "Restore a previous version of a stack's state",
+		Long: "Restore a previous version of a stack's state\n" +
+			"\n" +
+			"This command replaces the current state of a stack with the state it had after the given\n" +
+			"version, as shown by `pulumi stack history`. The restored state is checked for consistency\n" +
+			"and its secrets are re-encrypted with the stack's current secrets provider. Resources are\n" +
+			"not changed; run `pulumi refresh` afterwards to bring the restored state in line with them.",
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			snap, err := loadSnapshotForVersion(ctx, s, version)
+			if err != nil {
+				return result.FromError(err)
+			}
+			if snap == nil {
+				return result.Errorf("version %s of stack %s has no state to restore", version, s.Ref())
+			}
+			if err := snap.VerifyIntegrity(); err != nil {
+				return result.FromError(fmt.Errorf("version %s of stack %s is not valid: %w", version, s.Ref(), err))
+			}
+
+			// Re-encrypt any secrets with the stack's current secrets manager, which may have changed since.
+			sm, err := getStackSecretsManager(s)
+			if err != nil {
+				return result.FromError(fmt.Errorf("getting secrets manager: %w", err))
+			}
+			sdep, err := stack.SerializeDeployment(snap, sm, false /* showSecrets */)
+			if err != nil {
+				return result.FromError(fmt.Errorf("serializing deployment: %w", err))
+			}
+			bytes, err := json.Marshal(sdep)
+			if err != nil {
+				return result.FromError(err)
+			}
+			deployment := &apitype.UntypedDeployment{
+				Version:    apitype.DeploymentSchemaVersionCurrent,
+				Deployment: bytes,
+			}
+
+			prompt := fmt.Sprintf("This will replace the current state of '%s' with version %s (%d resources)!",
+				s.Ref(), version, len(snap.Resources))
+			if !yes && !confirmPrompt(prompt, s.Ref().String(), opts) {
+				fmt.Println("confirmation declined")
+				return result.Bail()
+			}
+
+			if restorer, ok := s.Backend().(backend.DeploymentRestorer); ok {
+				err = restorer.RestoreDeployment(ctx, s, version, deployment)
+			} else {
+				err = s.ImportDeployment(ctx, deployment)
+			}
+			if err != nil {
+				return result.FromError(fmt.Errorf("restoring version %s: %w", version, err))
+			}
+
+			msg := fmt.Sprintf("%sStack '%s' has been restored to version %s%s",
+				colors.SpecAttention, s.Ref(), version, colors.Reset)
+			fmt.Println(opts.Color.Colorize(msg))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().StringVar(
+		&version, "version", "", "The version of the stack's state to restore, as shown by `pulumi stack history`")
+	contract.AssertNoErrorf(cmd.MarkPersistentFlagRequired("version"), `Could not mark "version" as required`)
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false,
+		"Skip confirmation prompts, and proceed with the restore anyway")
+
+	return cmd
+}
@@ -19,17 +19,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	survey "github.com/AlecAivazis/survey/v2"
 	surveycore "github.com/AlecAivazis/survey/v2/core"
+	"github.com/dustin/go-humanize/english"
 	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
 	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
@@ -48,9 +52,17 @@ troubleshooting a stack or when performing specific edits that otherwise would r
 	}
 
 	cmd.AddCommand(newStateDeleteCommand())
+	cmd.AddCommand(newStateProtectCommand())
 	cmd.AddCommand(newStateUnprotectCommand())
 	cmd.AddCommand(newStateRenameCommand())
 	cmd.AddCommand(newStateUpgradeCommand())
+	cmd.AddCommand(newStateMoveCommand())
+	cmd.AddCommand(newStateGCCommand())
+	cmd.AddCommand(newStatePendingCommand())
+	cmd.AddCommand(newStateRepairCommand())
+	cmd.AddCommand(newStateEditCommand())
+	cmd.AddCommand(newStateShowCommand())
+	cmd.AddCommand(newStateDepsCommand())
 	return cmd
 }
 
@@ -110,6 +122,21 @@ func locateStackResource(opts display.Options, snap *deploy.Snapshot, urn resour
 	return optionMap[option], nil
 }
 
+// isSingleURN reports whether the given arguments name a single resource by its URN, rather than selecting resources
+// with URN globs or types.
+func isSingleURN(urnOrGlobs []string, types []string) bool {
+	return len(urnOrGlobs) == 1 && len(types) == 0 && !strings.ContainsRune(urnOrGlobs[0], '*')
+}
+
+// typeTokens converts the values of a --type flag to type tokens.
+func typeTokens(types []string) []tokens.Type {
+	toks := make([]tokens.Type, len(types))
+	for i, t := range types {
+		toks[i] = tokens.Type(t)
+	}
+	return toks
+}
+
 // runStateEdit runs the given state edit function on a resource with the given URN in a given stack.
 func runStateEdit(
 	ctx context.Context, stackName string, showPrompt bool,
@@ -152,13 +179,7 @@ func totalStateEdit(ctx context.Context, s backend.Stack, showPrompt bool, opts
 	}
 
 	if showPrompt && cmdutil.Interactive() {
-		confirm := false
-		surveycore.DisableColor = true
-		prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
-		prompt += "This command will edit your stack's state directly. Confirm?"
-		if err = survey.AskOne(&survey.Confirm{
-			Message: prompt,
-		}, &confirm, surveyIcons(opts.Color)); err != nil || !confirm {
+		if !confirmStateEdit("This command will edit your stack's state directly. Confirm?", opts) {
 			fmt.Println("confirmation declined")
 			return result.Bail()
 		}
@@ -177,19 +198,89 @@ func totalStateEdit(ctx context.Context, s backend.Stack, showPrompt bool, opts
 		contract.AssertNoErrorf(snap.VerifyIntegrity(), "state edit produced an invalid snapshot")
 	}
 
-	sdep, err := stack.SerializeDeployment(snap, snap.SecretsManager, false /* showSecrets */)
+	// Once we've mutated the snapshot, import it back into the backend so that it can be persisted.
+	return result.WrapIfNonNil(saveSnapshot(ctx, s, snap, snap.SecretsManager))
+}
+
+// runBulkStateEdit runs a snapshot-mutating function on the resources in the given stack that match the given URNs,
+// URN globs and types, as edit.SelectResources matches them, writing the stack's state once for all of them. The
+// resources are listed before the user is asked to confirm the edit, and only listed if dryRun is set. It returns the
+// number of resources that matched.
+func runBulkStateEdit(
+	ctx context.Context, stackName string, showPrompt, dryRun bool,
+	urnOrGlobs []string, types []tokens.Type, verb string,
+	operation func(snap *deploy.Snapshot, resources []*resource.State) error,
+) (int, result.Result) {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+	s, err := requireStack(ctx, stackName, stackOfferNew, opts)
+	if err != nil {
+		return 0, result.FromError(err)
+	}
+	snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
 	if err != nil {
-		return result.FromError(fmt.Errorf("serializing deployment: %w", err))
+		return 0, result.FromError(err)
+	}
+
+	resources, err := edit.SelectResources(snap, urnOrGlobs, types)
+	if err != nil {
+		return 0, result.FromError(err)
+	} else if len(resources) == 0 {
+		return 0, result.Error("no resources in the current state match")
+	}
+
+	fmt.Printf("The following resources will be %s:\n", verb)
+	for _, res := range resources {
+		fmt.Printf("    %s\n", res.URN)
+	}
+	fmt.Println()
+	if dryRun {
+		return len(resources), nil
+	}
+
+	if showPrompt && cmdutil.Interactive() {
+		message := fmt.Sprintf("This command will edit %s in your stack's state directly. Confirm?",
+			english.Plural(len(resources), "resource", ""))
+		if !confirmStateEdit(message, opts) {
+			fmt.Println("confirmation declined")
+			return 0, result.Bail()
+		}
+	}
+
+	// The stack hands out the same snapshot each time it is asked, so the resources selected above are in it.
+	res := totalStateEdit(ctx, s, false /* showPrompt */, opts, func(_ display.Options, snap *deploy.Snapshot) error {
+		return operation(snap, resources)
+	})
+	return len(resources), res
+}
+
+// confirmStateEdit asks the user to confirm an edit to a stack's state, returning whether they did.
+func confirmStateEdit(message string, opts display.Options) bool {
+	confirm := false
+	surveycore.DisableColor = true
+	prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
+	err := survey.AskOne(&survey.Confirm{
+		Message: prompt + message,
+	}, &confirm, surveyIcons(opts.Color))
+	return err == nil && confirm
+}
+
+// saveSnapshot serializes the given snapshot, encrypting its secrets with the given secrets manager, and imports it
+// into the given stack as its new current state.
+func saveSnapshot(ctx context.Context, s backend.Stack, snap *deploy.Snapshot, sm secrets.Manager) error {
+	sdep, err := stack.SerializeDeployment(snap, sm, false /* showSecrets */)
+	if err != nil {
+		return fmt.Errorf("serializing deployment: %w", err)
 	}
 
-	// Once we've mutated the snapshot, import it back into the backend so that it can be persisted.
 	bytes, err := json.Marshal(sdep)
 	if err != nil {
-		return result.FromError(err)
+		return err
 	}
 	dep := apitype.UntypedDeployment{
 		Version:    apitype.DeploymentSchemaVersionCurrent,
 		Deployment: bytes,
 	}
-	return result.WrapIfNonNil(s.ImportDeployment(ctx, &dep))
+	return s.ImportDeployment(ctx, &dep)
 }
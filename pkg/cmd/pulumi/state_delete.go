@@ -24,6 +24,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
 
+	"github.com/dustin/go-humanize/english"
 	"github.com/spf13/cobra"
 )
 
@@ -32,62 +33,70 @@ func newStateDeleteCommand() *cobra.Command {
 	var stack string
 	var yes bool
 	var targetDepenedents bool
+	var types []string
+	var dryRun bool
 
 	cmd := &cobra.Command{
-		Use:   "delete <resource URN>",
-		Short: "Deletes a resource from a stack's state",
-		Long: `Deletes a resource from a stack's state
+		Use:   "delete [resource URN...]",
+		Short: "Deletes resources from a stack's state",
+		Long: `Deletes resources from a stack's state
 
-This command deletes a resource from a stack's state, as long as it is safe to do so. The resource is specified
-by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it).
+This command deletes resources from a stack's state, as long as it is safe to do so. A resource is specified
+by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it). Several resources can be deleted at once by
+passing more than one URN, URN globs, where '*' matches any characters other than ':' and '**' matches any
+characters, or --type; the selected resources are listed before the stack's state is changed.
 
-Resources can't be deleted if there exist other resources that depend on it or are parented to it. Protected resources
-will not be deleted unless it is specifically requested using the --force flag.
+Resources can't be deleted if there exist other resources that depend on it or are parented to it, unless those
+resources are being deleted too. Protected resources will not be deleted unless it is specifically requested using
+the --force flag.
 
 Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
 
 Example:
 pulumi state delete 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:kubernetes::eks-provider'
+pulumi state delete 'urn:pulumi:stage::demo::aws:s3/bucketObject:BucketObject::*'
 `,
-		Args: cmdutil.ExactArgs(1),
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 			yes = yes || skipConfirmations()
-			urn := resource.URN(args[0])
 			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
 			showPrompt := !yes
 
-			res := runStateEdit(ctx, stack, showPrompt, urn, func(snap *deploy.Snapshot, res *resource.State) error {
-				var handleProtected func(*resource.State) error
-				if force {
-					handleProtected = func(res *resource.State) error {
-						cmdutil.Diag().Warningf(diag.Message(res.URN,
-							"deleting protected resource %s due to presence of --force"), res.URN)
-						return edit.UnprotectResource(nil, res)
-					}
+			if len(args) == 0 && len(types) == 0 {
+				return result.Error("must provide the URNs of resources or --type")
+			}
+
+			var handleProtected func(*resource.State) error
+			if force {
+				handleProtected = func(res *resource.State) error {
+					cmdutil.Diag().Warningf(diag.Message(res.URN,
+						"deleting protected resource %s due to presence of --force"), res.URN)
+					return edit.UnprotectResource(nil, res)
 				}
-				return edit.DeleteResource(snap, res, handleProtected, targetDepenedents)
-			})
-			if res != nil {
-				switch e := res.Error().(type) {
-				case edit.ResourceHasDependenciesError:
-					message := string(e.Condemned.URN) + " can't be safely deleted because the following resources depend on it:\n"
-					for _, dependentResource := range e.Dependencies {
-						depUrn := dependentResource.URN
-						message += fmt.Sprintf(" * %-15q (%s)\n", depUrn.Name(), depUrn)
-					}
-
-					message += "\nDelete those resources first or pass --target-dependents."
-					return result.Error(message)
-				case edit.ResourceProtectedError:
-					return result.Errorf(
-						"%s can't be safely deleted because it is protected. "+
-							"Re-run this command with --force to force deletion", string(e.Condemned.URN))
-				default:
-					return res
+			}
+
+			if isSingleURN(args, types) && !dryRun {
+				urn := resource.URN(args[0])
+				res := runStateEdit(ctx, stack, showPrompt, urn, func(snap *deploy.Snapshot, res *resource.State) error {
+					return edit.DeleteResource(snap, res, handleProtected, targetDepenedents)
+				})
+				if res != nil {
+					return stateDeleteError(res)
 				}
+				fmt.Println("Resource deleted")
+				return nil
+			}
+
+			count, res := runBulkStateEdit(ctx, stack, showPrompt, dryRun, args, typeTokens(types), "deleted",
+				func(snap *deploy.Snapshot, resources []*resource.State) error {
+					return edit.DeleteResources(snap, resources, handleProtected, targetDepenedents)
+				})
+			if res != nil {
+				return stateDeleteError(res)
+			}
+			if !dryRun {
+				fmt.Printf("%s deleted\n", english.Plural(count, "resource", ""))
 			}
-			fmt.Println("Resource deleted")
 			return nil
 		}),
 	}
@@ -98,5 +107,28 @@ pulumi state delete 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:
 	cmd.Flags().BoolVar(&force, "force", false, "Force deletion of protected resources")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
 	cmd.Flags().BoolVar(&targetDepenedents, "target-dependents", false, "Delete the URN and all its dependents")
+	cmd.Flags().StringArrayVar(&types, "type", nil, "Only delete resources of the given type; may be repeated")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the resources that would be deleted")
 	return cmd
 }
+
+// stateDeleteError explains why resources couldn't be deleted from a stack's state.
+func stateDeleteError(res result.Result) result.Result {
+	switch e := res.Error().(type) {
+	case edit.ResourceHasDependenciesError:
+		message := string(e.Condemned.URN) + " can't be safely deleted because the following resources depend on it:\n"
+		for _, dependentResource := range e.Dependencies {
+			depUrn := dependentResource.URN
+			message += fmt.Sprintf(" * %-15q (%s)\n", depUrn.Name(), depUrn)
+		}
+
+		message += "\nDelete those resources first or pass --target-dependents."
+		return result.Error(message)
+	case edit.ResourceProtectedError:
+		return result.Errorf(
+			"%s can't be safely deleted because it is protected. "+
+				"Re-run this command with --force to force deletion", string(e.Condemned.URN))
+	default:
+		return res
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+// resourceDependenciesJSON is the shape of a resource in the --json output of `pulumi state deps`.
+type resourceDependenciesJSON struct {
+	URN  resource.URN `json:"urn"`
+	Type tokens.Type  `json:"type"`
+	// DependsOn holds the URNs of the resources that the resource depends on directly.
+	DependsOn []resource.URN `json:"dependsOn"`
+}
+
+// stateDepsJSON is the shape of the --json output of `pulumi state deps`.
+type stateDepsJSON struct {
+	resourceDependenciesJSON
+	// Dependencies holds the resources that the resource depends on, directly or indirectly.
+	Dependencies []resourceDependenciesJSON `json:"dependencies"`
+	// Dependents holds the resources that depend on the resource, directly or indirectly.
+	Dependents []resourceDependenciesJSON `json:"dependents"`
+}
+
+func newStateDepsCommand() *cobra.Command {
+	var stackName string
+	var showURNs bool
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "deps <resource URN>",
+		Short: "Show the resources that a resource depends on and that depend on it",
+		Long: `Show the resources that a resource depends on and that depend on it
+
+This command prints the resources that the given resource depends on, directly or indirectly, and the resources that
+depend on it, which are affected when it is replaced or deleted. A resource depends on its parent, its provider and
+the resources it lists as dependencies. In the trees, each resource is shown once, under the closest resource that
+it is related through.`,
+		Args: cmdutil.ExactArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+			snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+			if err != nil {
+				return result.FromError(err)
+			} else if snap == nil {
+				return result.Error("the stack has no resources")
+			}
+
+			res, err := locateStackResource(opts, snap, resource.URN(args[0]))
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			dg := graph.NewDependencyGraph(snap.Resources)
+			order := snapshotOrder(snap)
+			dependencies, dependenciesTree := walkDependencyGraph(res, order, dg.DependenciesOf)
+			dependents, dependentsTree := walkDependencyGraph(res, order, dg.DependentsOf)
+
+			if jsonOut {
+				toJSON := func(res *resource.State) resourceDependenciesJSON {
+					dependsOn := []resource.URN{}
+					for _, dep := range sortResources(dg.DependenciesOf(res), order) {
+						dependsOn = append(dependsOn, dep.URN)
+					}
+					return resourceDependenciesJSON{URN: res.URN, Type: res.Type, DependsOn: dependsOn}
+				}
+				output := stateDepsJSON{
+					resourceDependenciesJSON: toJSON(res),
+					Dependencies:             []resourceDependenciesJSON{},
+					Dependents:               []resourceDependenciesJSON{},
+				}
+				for _, dep := range dependencies {
+					output.Dependencies = append(output.Dependencies, toJSON(dep))
+				}
+				for _, dep := range dependents {
+					output.Dependents = append(output.Dependents, toJSON(dep))
+				}
+				return result.WrapIfNonNil(printJSON(output))
+			}
+
+			for i, section := range []struct {
+				title string
+				count int
+				tree  *treeNode
+			}{
+				{"Dependencies", len(dependencies), dependenciesTree},
+				{"Dependents", len(dependents), dependentsTree},
+			} {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("%s (%d):\n", section.title, section.count)
+				var rows []cmdutil.TableRow
+				renderNode(section.tree, "", "", showURNs, false /* showIDs */, &rows)
+				cmdutil.PrintTable(cmdutil.Table{
+					Headers: []string{"TYPE", "NAME"},
+					Rows:    rows,
+					Prefix:  "    ",
+				})
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVarP(
+		&showURNs, "show-urns", "u", false, "Display each resource's URN")
+	cmd.Flags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+// snapshotOrder maps each resource in the snapshot to its position in it.
+func snapshotOrder(snap *deploy.Snapshot) map[*resource.State]int {
+	order := make(map[*resource.State]int, len(snap.Resources))
+	for i, res := range snap.Resources {
+		order[res] = i
+	}
+	return order
+}
+
+// sortResources returns the resources in the given set in the order given.
+func sortResources(set graph.ResourceSet, order map[*resource.State]int) []*resource.State {
+	resources := set.ToArray()
+	sort.Slice(resources, func(i, j int) bool {
+		return order[resources[i]] < order[resources[j]]
+	})
+	return resources
+}
+
+// walkDependencyGraph visits the resources reachable from root along the edges that next returns, breadth first. It
+// returns the resources that it reached, in the order that it reached them, and a tree rooted at root in which each of
+// them is a child of the resource that it was first reached from.
+func walkDependencyGraph(root *resource.State, order map[*resource.State]int,
+	next func(*resource.State) graph.ResourceSet,
+) ([]*resource.State, *treeNode) {
+	var reached []*resource.State
+	tree := &treeNode{res: root}
+	seen := map[*resource.State]bool{root: true}
+	queue := []*treeNode{tree}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, res := range sortResources(next(node.res), order) {
+			if seen[res] {
+				continue
+			}
+			seen[res] = true
+			reached = append(reached, res)
+			child := &treeNode{res: res}
+			node.children = append(node.children, child)
+			queue = append(queue, child)
+		}
+	}
+	return reached, tree
+}
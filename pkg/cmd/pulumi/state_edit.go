@@ -0,0 +1,241 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateEditCommand() *cobra.Command {
+	var stackName string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the current stack's state in your editor",
+		Long: `Edit the current stack's state in your editor
+
+This command opens the stack's state, with its secrets decrypted, in the editor named by the VISUAL or EDITOR
+environment variable. Once the editor exits, the edited state is checked against the state's schema, for integrity,
+and for URNs that don't match their stack, project or type. The changes are shown as a diff and, once confirmed, the
+state is written back with its secrets encrypted by the stack's secrets manager.
+
+If the edited state fails the checks, you can go back to the editor to fix it. Nothing is written until the edited
+state passes them.`,
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if !cmdutil.Interactive() {
+				return result.Error("pulumi state edit must be run interactively")
+			}
+
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+			return editState(ctx, s, !yes, opts)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+func editState(ctx context.Context, s backend.Stack, showPrompt bool, opts display.Options) result.Result {
+	dep, err := s.ExportDeployment(ctx)
+	if err != nil {
+		return result.FromError(err)
+	}
+	snap, err := stack.DeserializeUntypedDeployment(ctx, dep, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(err)
+	} else if snap == nil {
+		return result.Error("the stack has no state to edit")
+	}
+
+	sdep, err := stack.SerializeDeployment(snap, snap.SecretsManager, true /* showSecrets */)
+	if err != nil {
+		return result.FromError(fmt.Errorf("serializing deployment: %w", err))
+	}
+	original, err := json.MarshalIndent(sdep, "", "    ")
+	if err != nil {
+		return result.FromError(err)
+	}
+	original = append(original, '\n')
+
+	// The file holds decrypted secrets, so it is only readable by the current user and removed as soon as we're done.
+	file, err := os.CreateTemp("", "pulumi-state-*.json")
+	if err != nil {
+		return result.FromError(err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(original)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	var project tokens.PackageName
+	if len(snap.Resources) > 0 {
+		project = snap.Resources[0].URN.Project()
+	}
+
+	var edited []byte
+	var newSnap *deploy.Snapshot
+	for {
+		if err := runEditor(file.Name()); err != nil {
+			return result.FromError(err)
+		}
+		if edited, err = os.ReadFile(file.Name()); err != nil {
+			return result.FromError(err)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("The state was not changed")
+			return nil
+		}
+
+		newSnap, err = loadEditedState(ctx, edited, s.Ref().Name().Q(), project)
+		if err == nil {
+			break
+		}
+		fmt.Println(opts.Color.Colorize(fmt.Sprintf("%serror%s: %v", colors.SpecError, colors.Reset, err)))
+		if !confirmStateEdit("Edit the state again?", opts) {
+			return result.Bail()
+		}
+	}
+
+	fmt.Println(renderStateDiff(string(original), string(edited), opts))
+	if showPrompt && !confirmStateEdit("This command will edit your stack's state directly. Confirm?", opts) {
+		fmt.Println("confirmation declined")
+		return result.Bail()
+	}
+
+	// Write the state back, encrypting its secrets with the stack's secrets manager whatever the edited state says.
+	if err := saveSnapshot(ctx, s, newSnap, snap.SecretsManager); err != nil {
+		return result.FromError(err)
+	}
+	fmt.Println("State edited")
+	return nil
+}
+
+// loadEditedState validates an edited deployment, returning the snapshot it describes if it is valid.
+func loadEditedState(
+	ctx context.Context, edited []byte, stackName tokens.QName, project tokens.PackageName,
+) (*deploy.Snapshot, error) {
+	dep := apitype.UntypedDeployment{
+		Version:    apitype.DeploymentSchemaVersionCurrent,
+		Deployment: json.RawMessage(edited),
+	}
+	if !json.Valid(edited) {
+		return nil, errors.New("the edited state is not valid JSON")
+	}
+	if err := stack.ValidateUntypedDeployment(&dep); err != nil {
+		return nil, fmt.Errorf("the edited state doesn't match the state's schema: %w", err)
+	}
+
+	snap, err := stack.DeserializeUntypedDeployment(ctx, &dep, stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, fmt.Errorf("the edited state can't be loaded: %w", err)
+	}
+	if err := snap.VerifyIntegrity(); err != nil {
+		return nil, fmt.Errorf("the edited state fails integrity checks: %w", err)
+	}
+	if err := edit.VerifyURNs(snap, stackName, project); err != nil {
+		return nil, fmt.Errorf("the edited state has inconsistent URNs: %w", err)
+	}
+	return snap, nil
+}
+
+// runEditor opens the given file in the user's editor, waiting for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+// renderStateDiff renders a unified diff between the original and edited state.
+func renderStateDiff(original, edited string, opts display.Options) string {
+	edits := myers.ComputeEdits(span.URIFromPath("state.json"), original, edited)
+	diff := fmt.Sprint(gotextdiff.ToUnified("original", "edited", original, edits))
+
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		content := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			b.WriteString(content)
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(colors.SpecCreate + content + colors.Reset)
+		case strings.HasPrefix(line, "-"):
+			b.WriteString(colors.SpecDelete + content + colors.Reset)
+		default:
+			b.WriteString(content)
+		}
+		if content != line {
+			b.WriteString("\n")
+		}
+	}
+	return opts.Color.Colorize(b.String())
+}
@@ -0,0 +1,177 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateGCCommand() *cobra.Command {
+	var stackName string
+	var keepLast int
+	var maxAge string
+	var gzip bool
+	var savePolicy bool
+	var dryRun bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete old history and backups from a self-managed backend",
+		Long: `Delete old history and backups from a self-managed backend
+
+Self-managed backends keep a copy of a stack's state for every update in its history, along with
+backups of the state. This command deletes the history entries and backups that are not retained by
+the given limits: the most recent --keep-last entries and those younger than --max-age. The most
+recent entry of each stack is always kept. If no limits are given, the retention policy saved in
+the backend is used.
+
+With --save-policy the limits are saved in the backend, so that they are enforced after every update
+by everyone using it.
+
+All stacks in the backend are collected unless --stack is given.`,
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			b, err := currentBackend(ctx, nil, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+			lb, ok := b.(filestate.Backend)
+			if !ok {
+				return result.Errorf("the current backend (%s) does not support collecting stack history", b.Name())
+			}
+
+			var policy filestate.RetentionPolicy
+			switch {
+			case keepLast != 0 || maxAge != "":
+				age, err := parseRetentionAge(maxAge)
+				if err != nil {
+					return result.FromError(err)
+				}
+				policy = filestate.RetentionPolicy{KeepLast: keepLast, MaxAge: age, Gzip: gzip}
+			case lb.RetentionPolicy() != nil:
+				policy = *lb.RetentionPolicy()
+			default:
+				return result.FromError(errors.New(
+					"no retention policy is saved in the backend; specify --keep-last or --max-age"))
+			}
+			if cmd.Flags().Changed("gzip") {
+				policy.Gzip = gzip
+			}
+			if err := policy.Validate(); err != nil {
+				return result.FromError(err)
+			}
+
+			var stackRef backend.StackReference
+			if stackName != "" {
+				if stackRef, err = lb.ParseStackReference(stackName); err != nil {
+					return result.FromError(err)
+				}
+			}
+
+			if savePolicy && !dryRun {
+				if err := lb.SetRetentionPolicy(ctx, &policy); err != nil {
+					return result.FromError(fmt.Errorf("saving retention policy: %w", err))
+				}
+			}
+
+			if !dryRun && !yes {
+				prompt := "This will permanently delete the history and backups of all stacks that are not retained!"
+				if stackRef != nil {
+					prompt = fmt.Sprintf("This will permanently delete the history and backups of '%s' "+
+						"that are not retained!", stackRef)
+				}
+				if !confirmPrompt(prompt, "yes", opts) {
+					fmt.Println("confirmation declined")
+					return result.Bail()
+				}
+			}
+
+			pruned, err := lb.Prune(ctx, policy, stackRef, dryRun)
+			var size int64
+			for _, file := range pruned {
+				if dryRun {
+					fmt.Printf("would delete %s\n", file.Path)
+				}
+				size += file.Size
+			}
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %d files (%s)\n", verb, len(pruned), humanize.Bytes(uint64(size)))
+			if err != nil {
+				return result.FromError(err)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "", "The name of the stack to collect. Defaults to all stacks in the backend")
+	cmd.PersistentFlags().IntVar(
+		&keepLast, "keep-last", 0, "Keep the given number of most recent history entries and backups of each stack")
+	cmd.PersistentFlags().StringVar(
+		&maxAge, "max-age", "", "Keep history entries and backups younger than the given age, e.g. `30d` or `12h`")
+	cmd.PersistentFlags().BoolVar(
+		&gzip, "gzip", false, "Compress new history entries, overriding the saved policy; takes effect when saved with --save-policy")
+	cmd.PersistentFlags().BoolVar(
+		&savePolicy, "save-policy", false, "Save the limits in the backend and enforce them after every update")
+	cmd.PersistentFlags().BoolVar(
+		&dryRun, "dry-run", false, "Only list the files that would be deleted")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false, "Skip confirmation prompts, and proceed with the deletion anyway")
+
+	return cmd
+}
+
+// parseRetentionAge parses a duration as accepted by time.ParseDuration, additionally allowing a number of days such
+// as "30d".
+func parseRetentionAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid --max-age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --max-age %q: %w", s, err)
+	}
+	return d, nil
+}
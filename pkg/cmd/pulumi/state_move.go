@@ -0,0 +1,239 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	survey "github.com/AlecAivazis/survey/v2"
+	surveycore "github.com/AlecAivazis/survey/v2/core"
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/version"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+
+	"github.com/spf13/cobra"
+)
+
+func newStateMoveCommand() *cobra.Command {
+	var sourceStackName string
+	var destStackName string
+	var includeDependents bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "move <resource URN>...",
+		Short: "Move resources from one stack's state to another",
+		Long: `Move resources from one stack's state to another
+
+This command moves one or more resources from the state of a source stack into the state of a destination stack.
+The resources are specified by their Pulumi URNs (use ` + "`pulumi stack --show-urns`" + ` to get them).
+
+The URNs of the moved resources, and all references to them, are rewritten for the destination stack and project.
+Resources that are children of the source's root stack resource become children of the destination's root stack
+resource, and default providers used by the moved resources are carried over. Secrets are re-encrypted with the
+destination stack's secrets manager.
+
+Resources can't be moved if resources that stay behind depend on them or are parented to them, unless the
+--include-dependents flag is passed, in which case those resources are moved as well. Dependencies of moved resources
+on resources that stay behind are dropped.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+
+Example:
+pulumi state move --dest prod 'urn:pulumi:dev::demo::aws:s3/bucket:Bucket::my-bucket'
+`,
+		Args: cmdutil.MinimumNArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			urns := make([]resource.URN, 0, len(args))
+			for _, arg := range args {
+				urn := resource.URN(arg)
+				if !urn.IsValid() {
+					return result.Errorf("The provided URN %q is not valid", arg)
+				}
+				urns = append(urns, urn)
+			}
+
+			return runStateMove(ctx, sourceStackName, destStackName, urns, includeDependents, showPrompt)
+		}),
+	}
+
+	cmd.Flags().StringVar(&sourceStackName, "source", "",
+		"The name of the stack to move resources from. Defaults to the current stack")
+	cmd.Flags().StringVar(&destStackName, "dest", "", "The name of the stack to move resources to")
+	contract.AssertNoErrorf(cmd.MarkFlagRequired("dest"), "could not mark \"dest\" as required")
+	cmd.Flags().BoolVar(&includeDependents, "include-dependents", false,
+		"Also move all resources that depend on or are children of the given resources")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	return cmd
+}
+
+func runStateMove(ctx context.Context, sourceStackName, destStackName string, urns []resource.URN,
+	includeDependents, showPrompt bool,
+) result.Result {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+
+	sourceStack, err := requireStack(ctx, sourceStackName, stackLoadOnly, opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+	destStack, err := requireStack(ctx, destStackName, stackOfferNew, opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+	if sourceStack.Ref().FullyQualifiedName() == destStack.Ref().FullyQualifiedName() {
+		return result.Error("the source and destination stacks must be different")
+	}
+
+	sourceSnap, err := sourceStack.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(fmt.Errorf("loading source stack: %w", err))
+	} else if sourceSnap == nil {
+		return result.Errorf("the source stack %s has no resources", sourceStack.Ref())
+	}
+
+	destSnap, err := destStack.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(fmt.Errorf("loading destination stack: %w", err))
+	}
+	if destSnap == nil {
+		manifest := deploy.Manifest{
+			Time:    time.Now(),
+			Version: version.Version,
+		}
+		manifest.Magic = manifest.NewMagic()
+		destSnap = deploy.NewSnapshot(manifest, nil, nil, nil)
+	}
+	if destSnap.SecretsManager == nil {
+		// Any secrets that are moved over need to be encrypted with the destination stack's secrets manager.
+		if destSnap.SecretsManager, err = getStackSecretsManager(destStack); err != nil {
+			return result.FromError(fmt.Errorf("getting secrets manager for destination stack: %w", err))
+		}
+	}
+
+	resources := make([]*resource.State, 0, len(urns))
+	for _, urn := range urns {
+		res, err := locateStackResource(opts, sourceSnap, urn)
+		if err != nil {
+			return result.FromError(err)
+		}
+		resources = append(resources, res)
+	}
+
+	destProject, err := stackProject(destStack, destSnap)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	moved, err := edit.MoveResources(sourceSnap, destSnap, resources,
+		destStack.Ref().Name().Q(), destProject, includeDependents)
+	if err != nil {
+		if e, ok := err.(edit.ResourceHasDependentsError); ok {
+			message := string(e.Resource.URN) + " can't be moved because the following resources depend on it:\n"
+			for _, dependentResource := range e.Dependents {
+				depUrn := dependentResource.URN
+				message += fmt.Sprintf(" * %-15q (%s)\n", depUrn.Name(), depUrn)
+			}
+
+			message += "\nMove those resources as well or pass --include-dependents."
+			return result.Error(message)
+		}
+		return result.FromError(err)
+	}
+
+	fmt.Printf("Moving the following resources from %s to %s:\n", sourceStack.Ref(), destStack.Ref())
+	for _, res := range moved.Resources {
+		fmt.Printf("  - %s\n", res.URN)
+	}
+	if len(moved.Providers) > 0 {
+		fmt.Printf("Copying the following default providers to %s:\n", destStack.Ref())
+		for _, res := range moved.Providers {
+			fmt.Printf("  - %s\n", res.URN)
+		}
+	}
+	brokenURNs := make([]resource.URN, 0, len(moved.BrokenDependencies))
+	for urn := range moved.BrokenDependencies {
+		brokenURNs = append(brokenURNs, urn)
+	}
+	sort.Slice(brokenURNs, func(i, j int) bool { return brokenURNs[i] < brokenURNs[j] })
+	for _, urn := range brokenURNs {
+		for _, dep := range moved.BrokenDependencies[urn] {
+			fmt.Println(opts.Color.Colorize(fmt.Sprintf(
+				"%swarning%s: dropping dependency of %s on %s, which stays in %s",
+				colors.SpecWarning, colors.Reset, urn, dep, sourceStack.Ref())))
+		}
+	}
+
+	if showPrompt && cmdutil.Interactive() {
+		confirm := false
+		surveycore.DisableColor = true
+		prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
+		prompt += "This command will edit the state of both stacks directly. Confirm?"
+		if err = survey.AskOne(&survey.Confirm{
+			Message: prompt,
+		}, &confirm, surveyIcons(opts.Color)); err != nil || !confirm {
+			fmt.Println("confirmation declined")
+			return result.Bail()
+		}
+	}
+
+	// Write the destination first, so that if saving the source fails the resources end up tracked by both stacks
+	// rather than by neither.
+	if err := saveSnapshot(ctx, destStack, destSnap, destSnap.SecretsManager); err != nil {
+		return result.FromError(fmt.Errorf("saving destination stack: %w", err))
+	}
+	if err := saveSnapshot(ctx, sourceStack, sourceSnap, sourceSnap.SecretsManager); err != nil {
+		return result.FromError(fmt.Errorf("saving source stack (the resources are now in both stacks): %w", err))
+	}
+
+	fmt.Printf("Moved %d resources\n", len(moved.Resources))
+	return nil
+}
+
+// stackProject returns the project that resources in the given stack belong to. This is taken from the stack's
+// reference if it is project-scoped, and otherwise from the stack's existing resources or the current project.
+func stackProject(s backend.Stack, snap *deploy.Snapshot) (tokens.PackageName, error) {
+	// Project-scoped references have fully qualified names of the form <organization>/<project>/<stack>.
+	if parts := strings.Split(string(s.Ref().FullyQualifiedName()), "/"); len(parts) == 3 {
+		return tokens.PackageName(parts[1]), nil
+	}
+	if snap != nil && len(snap.Resources) > 0 {
+		return snap.Resources[0].URN.Project(), nil
+	}
+	project, _, err := readProject()
+	if err != nil {
+		return "", fmt.Errorf("determining the project of stack %s: %w", s.Ref(), err)
+	}
+	return tokens.PackageName(project.Name), nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// Resources moved into an empty stack of another project take on that project, not the one they came from.
+//
+//nolint:paralleltest // changes directory and environment for process
+func TestStateMove_emptyStackInOtherProject(t *testing.T) {
+	ctx := context.Background()
+
+	stateDir := t.TempDir()
+	t.Setenv("PULUMI_CONFIG_PASSPHRASE", "how now brown cow")
+	t.Setenv(workspace.PulumiBackendURLEnvVar, "file://"+filepath.ToSlash(stateDir))
+	backendInstance = nil
+	t.Cleanup(func() { backendInstance = nil })
+
+	b, err := filestate.New(ctx, cmdutil.Diag(), "file://"+filepath.ToSlash(stateDir), nil)
+	require.NoError(t, err)
+	srcRef, err := b.ParseStackReference("organization/src/dev")
+	require.NoError(t, err)
+	srcStack, err := b.CreateStack(ctx, srcRef, "", nil)
+	require.NoError(t, err)
+	destRef, err := b.ParseStackReference("organization/dest/prod")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, destRef, "", nil)
+	require.NoError(t, err)
+
+	stackURN := resource.NewURN("dev", "src", "", "pulumi:pulumi:Stack", "src-dev")
+	compURN := resource.NewURN("dev", "src", "pulumi:pulumi:Stack", "my:module:Component", "comp")
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), []*resource.State{
+		{URN: stackURN, Type: "pulumi:pulumi:Stack"},
+		{URN: compURN, Type: "my:module:Component", Parent: stackURN},
+	}, nil)
+	require.NoError(t, saveSnapshot(ctx, srcStack, snap, snap.SecretsManager))
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Pulumi.yaml"),
+		[]byte("name: src\nruntime: yaml\n"), 0o600))
+	chdir(t, projectDir)
+
+	res := runStateMove(ctx, "organization/src/dev", "organization/dest/prod", []resource.URN{compURN},
+		false /* includeDependents */, false /* showPrompt */)
+	require.Nil(t, res)
+
+	destStack, err := requireStack(ctx, "organization/dest/prod", stackLoadOnly, display.Options{})
+	require.NoError(t, err)
+	destSnap, err := destStack.Snapshot(ctx, stack.DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.NotNil(t, destSnap)
+	urns := make([]resource.URN, 0, len(destSnap.Resources))
+	for _, r := range destSnap.Resources {
+		urns = append(urns, r.URN)
+	}
+	assert.Contains(t, urns,
+		resource.NewURN("prod", "dest", "pulumi:pulumi:Stack", "my:module:Component", "comp"))
+	for _, urn := range urns {
+		assert.Equal(t, tokens.PackageName("dest"), urn.Project(), "URN %s", urn)
+	}
+
+	srcStack, err = requireStack(ctx, "organization/src/dev", stackLoadOnly, display.Options{})
+	require.NoError(t, err)
+	srcSnap, err := srcStack.Snapshot(ctx, stack.DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.NotNil(t, srcSnap)
+	require.Len(t, srcSnap.Resources, 1)
+	assert.Equal(t, stackURN, srcSnap.Resources[0].URN)
+}
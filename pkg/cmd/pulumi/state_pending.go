@@ -0,0 +1,230 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	survey "github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStatePendingCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pending",
+		Short: "Manage the pending operations in a stack's state",
+		Long: `Manage the pending operations in a stack's state
+
+An update that is interrupted leaves the operations it had in flight pending in the stack's state. Pending creates
+are not cleared by a refresh, since the resource they were creating may exist: resolve them by supplying the ID of
+the resource they created and running 'pulumi refresh --resolve-pending-creates', or clear them if the create
+never happened.`,
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.AddCommand(newStatePendingLsCommand())
+	cmd.AddCommand(newStatePendingResolveCommand())
+	cmd.AddCommand(newStatePendingClearCommand())
+	return cmd
+}
+
+// pendingOperationJSON is the shape of the --json output of `pulumi state pending ls`.
+type pendingOperationJSON struct {
+	URN       resource.URN           `json:"urn"`
+	Type      string                 `json:"type"`
+	Operation resource.OperationType `json:"operation"`
+	ID        resource.ID            `json:"id,omitempty"`
+}
+
+func newStatePendingLsCommand() *cobra.Command {
+	var stackName string
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the pending operations in a stack's state",
+		Args:  cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+			snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			var ops []resource.Operation
+			if snap != nil {
+				ops = snap.PendingOperations
+			}
+
+			if jsonOut {
+				output := make([]pendingOperationJSON, len(ops))
+				for i, op := range ops {
+					output[i] = pendingOperationJSON{
+						URN:       op.Resource.URN,
+						Type:      string(op.Resource.Type),
+						Operation: op.Type,
+						ID:        op.Resource.ID,
+					}
+				}
+				return result.WrapIfNonNil(printJSON(output))
+			}
+
+			if len(ops) == 0 {
+				fmt.Println("No pending operations")
+				return nil
+			}
+			rows := make([]cmdutil.TableRow, len(ops))
+			for i, op := range ops {
+				rows[i] = cmdutil.TableRow{Columns: []string{string(op.Type), string(op.Resource.URN), string(op.Resource.ID)}}
+			}
+			cmdutil.PrintTable(cmdutil.Table{
+				Headers: []string{"OPERATION", "URN", "ID"},
+				Rows:    rows,
+			})
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+func newStatePendingResolveCommand() *cobra.Command {
+	var stackName string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "resolve <resource URN> [ID]",
+		Short: "Supply the ID of the resource made by a pending create",
+		Long: `Supply the ID of the resource made by a pending create
+
+This command records the ID of the resource that a pending create made, prompting for it if it isn't given. The next
+'pulumi refresh --resolve-pending-creates' reads the resource from its provider, adding it to the stack's state if
+it exists and dropping the pending create if it doesn't.`,
+		Args: cmdutil.RangeArgs(1, 2),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+
+			urn := resource.URN(args[0])
+			var id resource.ID
+			if len(args) == 2 {
+				id = resource.ID(args[1])
+			} else if cmdutil.Interactive() {
+				var answer string
+				if err := survey.AskOne(&survey.Input{
+					Message: fmt.Sprintf("ID of the resource created for %s:", urn),
+				}, &answer, nil); err != nil {
+					return result.FromError(fmt.Errorf("no ID given: %w", err))
+				}
+				id = resource.ID(answer)
+			}
+			if id == "" {
+				return result.FromError(errors.New("must provide the ID of the resource that the create made"))
+			}
+
+			res := runTotalStateEdit(ctx, stackName, !yes, func(_ display.Options, snap *deploy.Snapshot) error {
+				return edit.ResolvePendingCreate(snap, urn, id)
+			})
+			if res != nil {
+				return res
+			}
+			fmt.Println("Pending create resolved; run 'pulumi refresh --resolve-pending-creates' to read the resource")
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+func newStatePendingClearCommand() *cobra.Command {
+	var stackName string
+	var clearAll bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "clear [resource URN...]",
+		Short: "Remove pending operations from a stack's state",
+		Long: `Remove pending operations from a stack's state
+
+This command drops the pending operations on the given resources, or all of them with --all. Only clear a pending
+create once you are sure that the resource it was creating doesn't exist, since it is forgotten otherwise.`,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+
+			if clearAll == (len(args) > 0) {
+				return result.FromError(errors.New("must provide either the URNs of resources or --all"))
+			}
+			urns := make([]resource.URN, len(args))
+			for i, arg := range args {
+				urns[i] = resource.URN(arg)
+			}
+
+			return clearPendingOperations(ctx, stackName, urns, !yes)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVar(&clearAll, "all", false, "Clear all pending operations in the checkpoint")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+func clearPendingOperations(ctx context.Context, stackName string, urns []resource.URN, showPrompt bool) result.Result {
+	var cleared []resource.Operation
+	res := runTotalStateEdit(ctx, stackName, showPrompt, func(_ display.Options, snap *deploy.Snapshot) error {
+		var err error
+		cleared, err = edit.ClearPendingOperations(snap, urns)
+		return err
+	})
+	if res != nil {
+		return res
+	}
+	for _, op := range cleared {
+		fmt.Printf("Cleared pending %s of %s\n", op.Type, op.Resource.URN)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize/english"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateProtectCommand() *cobra.Command {
+	var protectAll bool
+	var stack string
+	var yes bool
+	var types []string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "protect [resource URN...]",
+		Short: "Protect resources in a stack's state",
+		Long: `Protect resources in a stack's state
+
+This command sets the 'protect' bit on one or more resources, preventing those resources from being deleted.
+
+Resources are selected by URN, by URN glob, where '*' matches any characters other than ':' and '**' matches any
+characters, or by type with --type. The selected resources are listed before the stack's state is changed.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+
+Example:
+pulumi state protect 'urn:pulumi:prod::demo::aws:rds/instance:Instance::*'
+pulumi state protect --type aws:s3/bucket:Bucket`,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			if protectAll {
+				return setResourcesProtect(ctx, stack, nil, nil, true /* protect */, showPrompt, dryRun)
+			}
+			if len(args) == 0 && len(types) == 0 {
+				return result.Error("must provide the URNs of resources, --type or --all")
+			}
+			return setResourcesProtect(ctx, stack, args, types, true /* protect */, showPrompt, dryRun)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVar(&protectAll, "all", false, "Protect all resources in the checkpoint")
+	cmd.Flags().StringArrayVar(&types, "type", nil, "Only protect resources of the given type; may be repeated")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the resources that would be protected")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+// setResourcesProtect sets the 'protect' bit of the resources that match the given URNs, URN globs and types to the
+// given value.
+func setResourcesProtect(
+	ctx context.Context, stackName string, urnOrGlobs []string, types []string,
+	protect bool, showPrompt, dryRun bool,
+) result.Result {
+	verb, operation := "protected", edit.ProtectResource
+	if !protect {
+		verb, operation = "unprotected", edit.UnprotectResource
+	}
+
+	count, res := runBulkStateEdit(ctx, stackName, showPrompt, dryRun, urnOrGlobs, typeTokens(types), verb,
+		func(snap *deploy.Snapshot, resources []*resource.State) error {
+			for _, res := range resources {
+				if err := operation(snap, res); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if res != nil || dryRun {
+		return res
+	}
+	fmt.Printf("%s %s\n", english.Plural(count, "resource", ""), verb)
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	survey "github.com/AlecAivazis/survey/v2"
+	surveycore "github.com/AlecAivazis/survey/v2/core"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateRepairCommand() *cobra.Command {
+	var stackName string
+	var dryRun bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Repair a stack's state that fails integrity checks",
+		Long: `Repair a stack's state that fails integrity checks
+
+A stack whose state fails integrity checks can't be updated until the state is fixed. This command repairs the
+problems that can be fixed without losing track of any resources: resources that come before their provider, parent
+or dependencies are moved after them, references to resources that no longer exist are dropped from dependency lists,
+and resources whose parent no longer exists are re-parented to the stack.
+
+The repairs are listed before anything is written. Use --dry-run to only list them. Problems that can't be repaired
+automatically, such as references to missing providers, must be fixed by hand with 'pulumi stack export' and
+'pulumi stack import'.`,
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			// The whole point is to load a state that fails integrity checks, which self-managed backends refuse to
+			// do. We check the integrity of the repaired state ourselves before writing it.
+			filestate.DisableIntegrityChecking = true
+
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+			dep, err := s.ExportDeployment(ctx)
+			if err != nil {
+				return result.FromError(err)
+			}
+			snap, err := stack.DeserializeUntypedDeployment(ctx, dep, stack.DefaultSecretsProvider)
+			if err != nil {
+				return result.FromError(err)
+			}
+			if snap == nil {
+				fmt.Println("The stack has no state to repair")
+				return nil
+			}
+
+			problem := snap.VerifyIntegrity()
+			if problem == nil {
+				fmt.Println("The stack's state has no integrity problems")
+				return nil
+			}
+			fmt.Println(opts.Color.Colorize(fmt.Sprintf(
+				"%serror%s: the stack's state fails integrity checks: %v", colors.SpecError, colors.Reset, problem)))
+
+			repairs, err := edit.RepairSnapshot(snap)
+			if err != nil {
+				return result.FromError(fmt.Errorf("repairing the stack's state: %w", err))
+			}
+			fmt.Println("Repairs:")
+			for _, repair := range repairs {
+				fmt.Println(opts.Color.Colorize(fmt.Sprintf("  %s~ %s%s", colors.SpecUpdate, repair, colors.Reset)))
+			}
+			if err := snap.VerifyIntegrity(); err != nil {
+				return result.FromError(fmt.Errorf("the stack's state has problems that can't be repaired automatically, "+
+					"and must be fixed by hand with 'pulumi stack export' and 'pulumi stack import': %w", err))
+			}
+
+			if dryRun {
+				fmt.Println("This was a dry run; the stack's state was not changed")
+				return nil
+			}
+
+			if !yes && cmdutil.Interactive() {
+				confirm := false
+				surveycore.DisableColor = true
+				prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
+				prompt += "This command will edit your stack's state directly. Confirm?"
+				if err = survey.AskOne(&survey.Confirm{
+					Message: prompt,
+				}, &confirm, surveyIcons(opts.Color)); err != nil || !confirm {
+					fmt.Println("confirmation declined")
+					return result.Bail()
+				}
+			}
+
+			if err := saveSnapshot(ctx, s, snap, snap.SecretsManager); err != nil {
+				return result.FromError(err)
+			}
+			fmt.Printf("Made %d repairs\n", len(repairs))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().BoolVar(
+		&dryRun, "dry-run", false, "Only list the repairs, without changing the stack's state")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
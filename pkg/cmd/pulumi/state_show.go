@@ -0,0 +1,199 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateShowCommand() *cobra.Command {
+	var stackName string
+	var showSecrets bool
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "show <resource URN>...",
+		Short: "Show the recorded state of resources",
+		Long: `Show the recorded state of resources
+
+This command prints what the stack's state records about the resources with the given URNs: their inputs and
+outputs, provider, parent, protect and retain flags and custom timeouts. A URN may be a glob, where '*' matches any
+characters other than ':' and '**' matches any characters. Secret values are masked unless --show-secrets is passed.`,
+		Args: cmdutil.MinimumNArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+			snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+			if err != nil {
+				return result.FromError(err)
+			} else if snap == nil {
+				return result.Error("the stack has no resources")
+			}
+
+			resources, err := edit.SelectResources(snap, args, nil)
+			if err != nil {
+				return result.FromError(err)
+			}
+			if showSecrets {
+				log3rdPartySecretsProviderDecryptionEvent(ctx, s, "", "pulumi state show")
+			}
+
+			if jsonOut {
+				output := make([]apitype.ResourceV3, len(resources))
+				for i, res := range resources {
+					output[i], err = resourceForShow(res, showSecrets)
+					if err != nil {
+						return result.FromError(err)
+					}
+				}
+				return result.WrapIfNonNil(printJSON(output))
+			}
+
+			for i, res := range resources {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Print(opts.Color.Colorize(renderResourceState(res, showSecrets)))
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVar(
+		&showSecrets, "show-secrets", false, "Display secret values in plaintext")
+	cmd.Flags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+// resourceForShow serializes the given resource for the --json output of `pulumi state show`, masking its secrets
+// unless showSecrets is set.
+func resourceForShow(res *resource.State, showSecrets bool) (apitype.ResourceV3, error) {
+	masked := *res
+	masked.Inputs = display.MassageSecrets(res.Inputs, showSecrets)
+	masked.Outputs = display.MassageSecrets(res.Outputs, showSecrets)
+
+	// The secrets have all been removed, so nothing should need to be encrypted.
+	return stack.SerializeResource(&masked, config.NewPanicCrypter(), showSecrets)
+}
+
+// renderResourceState renders the recorded state of the given resource for `pulumi state show`.
+func renderResourceState(res *resource.State, showSecrets bool) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n", res.URN)
+
+	var fields [][2]string
+	field := func(name, value string) {
+		fields = append(fields, [2]string{name, value})
+	}
+	field("Type", string(res.Type))
+	if res.Custom {
+		field("ID", string(res.ID))
+	}
+	if res.Provider != "" {
+		field("Provider", res.Provider)
+	}
+	if res.Parent != "" {
+		field("Parent", string(res.Parent))
+	}
+	field("Protect", fmt.Sprint(res.Protect))
+	field("Retain on delete", fmt.Sprint(res.RetainOnDelete))
+	if res.DeletedWith != "" {
+		field("Deleted with", string(res.DeletedWith))
+	}
+	if res.External {
+		field("External", "true")
+	}
+	if res.Delete {
+		field("Pending deletion", "true")
+	}
+	if res.CustomTimeouts.IsNotEmpty() {
+		field("Custom timeouts", renderCustomTimeouts(res.CustomTimeouts))
+	}
+	if res.Created != nil {
+		field("Created", res.Created.Format(time.RFC3339))
+	}
+	if res.Modified != nil {
+		field("Modified", res.Modified.Format(time.RFC3339))
+	}
+
+	width := 0
+	for _, f := range fields {
+		if len(f[0]) > width {
+			width = len(f[0])
+		}
+	}
+	for _, f := range fields {
+		fmt.Fprintf(&b, "    %-*s  %s\n", width+1, f[0]+":", f[1])
+	}
+
+	for _, props := range []struct {
+		name string
+		m    resource.PropertyMap
+	}{{"Inputs", res.Inputs}, {"Outputs", res.Outputs}} {
+		fmt.Fprintf(&b, "    %s:\n", props.name)
+		if len(props.m) == 0 {
+			fmt.Fprintf(&b, "        (none)\n")
+			continue
+		}
+		// PrintObject masks secrets itself, so they only need unwrapping when they are to be shown.
+		m := props.m
+		if showSecrets {
+			m = display.MassageSecrets(m, true)
+		}
+		display.PrintObject(&b, m, false /* planning */, 2, deploy.OpSame,
+			false /* prefix */, false /* truncateOutput */, false /* debug */)
+	}
+
+	return b.String()
+}
+
+func renderCustomTimeouts(timeouts resource.CustomTimeouts) string {
+	var parts []string
+	for _, t := range []struct {
+		op      string
+		seconds float64
+	}{{"create", timeouts.Create}, {"update", timeouts.Update}, {"delete", timeouts.Delete}} {
+		if t.seconds != 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", t.op, time.Duration(t.seconds*float64(time.Second))))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
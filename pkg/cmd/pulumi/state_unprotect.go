@@ -33,14 +33,19 @@ func newStateUnprotectCommand() *cobra.Command {
 	var unprotectAll bool
 	var stack string
 	var yes bool
+	var types []string
+	var dryRun bool
 
 	cmd := &cobra.Command{
-		Use:   "unprotect <resource URN>",
+		Use:   "unprotect [resource URN...]",
 		Short: "Unprotect resources in a stack's state",
 		Long: `Unprotect resource in a stack's state
 
-This command clears the 'protect' bit on one or more resources, allowing those resources to be deleted.`,
-		Args: cmdutil.MaximumNArgs(1),
+This command clears the 'protect' bit on one or more resources, allowing those resources to be deleted.
+
+Resources are selected by URN, by URN glob, where '*' matches any characters other than ':' and '**' matches any
+characters, or by type with --type. When more than one resource may be selected, the selected resources are listed
+before the stack's state is changed.`,
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 			yes = yes || skipConfirmations()
@@ -51,12 +56,15 @@ This command clears the 'protect' bit on one or more resources, allowing those r
 				return unprotectAllResources(ctx, stack, showPrompt)
 			}
 
-			if len(args) != 1 {
+			if len(args) == 0 && len(types) == 0 {
 				return result.Error("must provide a URN corresponding to a resource")
 			}
 
-			urn := resource.URN(args[0])
-			return unprotectResource(ctx, stack, urn, showPrompt)
+			if isSingleURN(args, types) && !dryRun {
+				urn := resource.URN(args[0])
+				return unprotectResource(ctx, stack, urn, showPrompt)
+			}
+			return setResourcesProtect(ctx, stack, args, types, false /* protect */, showPrompt, dryRun)
 		}),
 	}
 
@@ -64,6 +72,8 @@ This command clears the 'protect' bit on one or more resources, allowing those r
 		&stack, "stack", "s", "",
 		"The name of the stack to operate on. Defaults to the current stack")
 	cmd.Flags().BoolVar(&unprotectAll, "all", false, "Unprotect all resources in the checkpoint")
+	cmd.Flags().StringArrayVar(&types, "type", nil, "Only unprotect resources of the given type; may be repeated")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the resources that would be unprotected")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
 
 	return cmd
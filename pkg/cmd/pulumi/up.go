@@ -29,6 +29,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
@@ -80,7 +81,14 @@ func newUpCmd() *cobra.Command {
 	var replaces []string
 	var targetReplaces []string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
 	var planFilePath string
+	var continueOnError bool
+	var runStateHooks bool
+	var autoAlias bool
+	var retry retryFlags
+	var deadline deadlineFlag
 
 	// up implementation used when the source of the Pulumi program is in the current working directory.
 	upWorkingDirectory := func(ctx context.Context, opts backend.UpdateOptions) result.Result {
@@ -138,6 +146,14 @@ func newUpCmd() *cobra.Command {
 		if err != nil {
 			return result.FromError(err)
 		}
+		retryPolicy, resourceRetries, err := retry.policies()
+		if err != nil {
+			return result.FromError(err)
+		}
+		scopes, err := deadline.scopes()
+		if err != nil {
+			return result.FromError(err)
+		}
 		opts.Engine = engine.UpdateOptions{
 			LocalPolicyPacks:          engine.MakeLocalPolicyPacks(policyPackPaths, policyPackConfigPaths),
 			Parallel:                  parallel,
@@ -151,6 +167,13 @@ func newUpCmd() *cobra.Command {
 			DisableOutputValues:       disableOutputValues(),
 			UpdateTargets:             deploy.NewUrnTargets(targetURNs),
 			TargetDependents:          targetDependents,
+			Excludes:                  deploy.NewUrnTargets(excludes),
+			ExcludeDependents:         excludeDependents,
+			ContinueOnError:           continueOnError,
+			AutoAlias:                 autoAlias,
+			RunStateHooks:             runStateHooks,
+			Retry:                     retryPolicy,
+			ResourceRetries:           resourceRetries,
 			// Trigger a plan to be generated during the preview phase which can be constrained to during the
 			// update phase.
 			GeneratePlan: true,
@@ -166,10 +189,24 @@ func newUpCmd() *cobra.Command {
 			if err != nil {
 				return result.FromError(err)
 			}
-			plan, err := readPlan(planFilePath, dec, enc)
+			plan, planBase, err := readPlan(planFilePath, dec, enc)
 			if err != nil {
 				return result.FromError(err)
 			}
+			if planBase == nil {
+				cmdutil.Diag().Warningf(diag.Message("" /*urn*/, "The plan file '%s' was written by an older "+
+					"version of Pulumi, so it can't be checked for changes to the stack since it was computed"),
+					planFilePath)
+			} else {
+				// Refuse to apply a plan that was computed against a different stack, state or configuration.
+				current, err := getPlanBase(ctx, s, cfg.Config)
+				if err != nil {
+					return result.FromError(err)
+				}
+				if err := planBase.Check(current); err != nil {
+					return result.FromError(err)
+				}
+			}
 			opts.Engine.Plan = plan
 		}
 
@@ -181,7 +218,7 @@ func newUpCmd() *cobra.Command {
 			StackConfiguration: cfg,
 			SecretsManager:     sm,
 			SecretsProvider:    stack.DefaultSecretsProvider,
-			Scopes:             cancellationScopes,
+			Scopes:             scopes,
 		})
 		switch {
 		case res != nil && res.Error() == context.Canceled:
@@ -356,12 +393,25 @@ func newUpCmd() *cobra.Command {
 		if err != nil {
 			return result.FromError(err)
 		}
+		retryPolicy, resourceRetries, err := retry.policies()
+		if err != nil {
+			return result.FromError(err)
+		}
+		scopes, err := deadline.scopes()
+		if err != nil {
+			return result.FromError(err)
+		}
 
 		opts.Engine = engine.UpdateOptions{
 			LocalPolicyPacks: engine.MakeLocalPolicyPacks(policyPackPaths, policyPackConfigPaths),
 			Parallel:         parallel,
 			Debug:            debug,
 			Refresh:          refreshOption,
+			ContinueOnError:  continueOnError,
+			AutoAlias:        autoAlias,
+			RunStateHooks:    runStateHooks,
+			Retry:            retryPolicy,
+			ResourceRetries:  resourceRetries,
 			// If we're in experimental mode then we trigger a plan to be generated during the preview phase
 			// which will be constrained to during the update phase.
 			GeneratePlan: hasExperimentalCommands(),
@@ -381,7 +431,7 @@ func newUpCmd() *cobra.Command {
 			StackConfiguration: cfg,
 			SecretsManager:     sm,
 			SecretsProvider:    stack.DefaultSecretsProvider,
-			Scopes:             cancellationScopes,
+			Scopes:             scopes,
 		})
 		switch {
 		case res != nil && res.Error() == context.Canceled:
@@ -473,10 +523,19 @@ func newUpCmd() *cobra.Command {
 				err = validateUnsupportedRemoteFlags(expectNop, configArray, path, client, jsonDisplay, policyPackPaths,
 					policyPackConfigPaths, refresh, showConfig, showReplacementSteps, showSames, showReads,
 					suppressOutputs, secretsProvider, &targets, replaces, targetReplaces,
-					targetDependents, planFilePath, stackConfigFile)
+					targetDependents, excludes, excludeDependents, planFilePath, stackConfigFile)
 				if err != nil {
 					return result.FromError(err)
 				}
+				if retry.isSet() {
+					return result.FromError(errors.New("--retry-attempts is not supported with --remote"))
+				}
+				if deadline.timeout != 0 {
+					return result.FromError(errors.New("--deadline is not supported with --remote"))
+				}
+				if autoAlias {
+					return result.FromError(errors.New("--auto-alias is not supported with --remote"))
+				}
 
 				return runDeployment(ctx, opts.Display, apitype.Update, stackName, args[0], remoteArgs)
 			}
@@ -547,6 +606,28 @@ func newUpCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows updating of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to leave as it is. Other resources will be updated."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also leave as they are the resources that depend on the resources specified in --exclude list")
+	cmd.PersistentFlags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue updating resources even if an error is encountered"+
+			" (resources that depend on a resource that failed are skipped)")
+	cmd.PersistentFlags().BoolVar(
+		&autoAlias, "auto-alias", false,
+		"Treat new resources whose inputs match a resource that would be deleted as renames of it"+
+			" instead of replacing it")
+	cmd.PersistentFlags().BoolVar(
+		&runStateHooks, "run-state-hooks", false,
+		"Run the delete hooks saved in the state of resources that are removed from the program."+
+			" Only use this if you trust the stack's state")
+	retry.register(cmd)
+	deadline.register(cmd)
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().StringSliceVar(
@@ -601,12 +682,10 @@ func newUpCmd() *cobra.Command {
 
 	cmd.PersistentFlags().StringVar(
 		&planFilePath, "plan", "",
-		"[EXPERIMENTAL] Path to a plan file to use for the update. The update will not "+
-			"perform operations that exceed its plan (e.g. replacements instead of updates, or updates instead"+
-			"of sames).")
-	if !hasExperimentalCommands() {
-		contract.AssertNoErrorf(cmd.PersistentFlags().MarkHidden("plan"), `Could not mark "plan" as hidden`)
-	}
+		"Path to a plan file written by `pulumi preview --save-plan` to use for the update. The update will not "+
+			"perform operations that exceed its plan (e.g. replacements instead of updates, or updates instead "+
+			"of sames), and will refuse to run if the stack's state or configuration has changed since the plan "+
+			"was computed.")
 
 	// Remote flags
 	remoteArgs.applyFlags(cmd)
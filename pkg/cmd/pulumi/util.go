@@ -28,6 +28,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -40,6 +41,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
 	"github.com/pulumi/pulumi/pkg/v3/backend/httpstate"
+	"github.com/pulumi/pulumi/pkg/v3/backend/sqlstate"
 	"github.com/pulumi/pulumi/pkg/v3/backend/state"
 	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
@@ -115,7 +117,7 @@ func isFilestateBackend(opts display.Options) (bool, error) {
 		return false, fmt.Errorf("could not get cloud url: %w", err)
 	}
 
-	return filestate.IsFileStateBackendURL(url), nil
+	return filestate.IsFileStateBackendURL(url) || sqlstate.IsSQLStateBackendURL(url), nil
 }
 
 func nonInteractiveCurrentBackend(ctx context.Context, project *workspace.Project) (backend.Backend, error) {
@@ -131,6 +133,9 @@ func nonInteractiveCurrentBackend(ctx context.Context, project *workspace.Projec
 	if filestate.IsFileStateBackendURL(url) {
 		return filestate.New(ctx, cmdutil.Diag(), url, project)
 	}
+	if sqlstate.IsSQLStateBackendURL(url) {
+		return sqlstate.New(ctx, cmdutil.Diag(), url, project)
+	}
 	return httpstate.NewLoginManager().Current(ctx, cmdutil.Diag(), url, project, workspace.GetCloudInsecure(url))
 }
 
@@ -147,9 +152,31 @@ func currentBackend(ctx context.Context, project *workspace.Project, opts displa
 	if filestate.IsFileStateBackendURL(url) {
 		return filestate.New(ctx, cmdutil.Diag(), url, project)
 	}
+	if sqlstate.IsSQLStateBackendURL(url) {
+		return sqlstate.New(ctx, cmdutil.Diag(), url, project)
+	}
 	return httpstate.NewLoginManager().Login(ctx, cmdutil.Diag(), url, project, workspace.GetCloudInsecure(url), opts)
 }
 
+// backendForURL returns the backend at the given URL without making it the current backend. Unlike with
+// currentBackend, the user must already be logged in to a Pulumi Cloud backend.
+func backendForURL(ctx context.Context, url string, project *workspace.Project) (backend.Backend, error) {
+	if filestate.IsFileStateBackendURL(url) {
+		return filestate.New(ctx, cmdutil.Diag(), url, project)
+	}
+	if sqlstate.IsSQLStateBackendURL(url) {
+		return sqlstate.New(ctx, cmdutil.Diag(), url, project)
+	}
+	account, err := workspace.GetAccount(httpstate.ValueOrDefaultURL(url))
+	if err != nil {
+		return nil, fmt.Errorf("getting stored credentials: %w", err)
+	}
+	if account.AccessToken == "" {
+		return nil, fmt.Errorf("not logged in to %s; run 'pulumi login %s' first", url, url)
+	}
+	return httpstate.New(cmdutil.Diag(), url, project, workspace.GetCloudInsecure(url))
+}
+
 // This is used to control the contents of the tracing header.
 var tracingHeader = os.Getenv("PULUMI_TRACING_HEADER")
 
@@ -821,12 +848,20 @@ func (s *cancellationScope) Close() {
 	<-s.done
 }
 
-type cancellationScopeSource int
+type cancellationScopeSource struct {
+	// timeout is the time that updates run in the scopes may take, counted from when their scope is created, if not
+	// zero. Previews have no deadline.
+	timeout time.Duration
+}
 
-var cancellationScopes = backend.CancellationScopeSource(cancellationScopeSource(0))
+var cancellationScopes = backend.CancellationScopeSource(cancellationScopeSource{})
 
-func (cancellationScopeSource) NewScope(events chan<- engine.Event, isPreview bool) backend.CancellationScope {
-	cancelContext, cancelSource := cancel.NewContext(context.Background())
+func (s cancellationScopeSource) NewScope(events chan<- engine.Event, isPreview bool) backend.CancellationScope {
+	var deadline time.Time
+	if s.timeout != 0 && !isPreview {
+		deadline = time.Now().Add(s.timeout)
+	}
+	cancelContext, cancelSource := cancel.NewContextWithDeadline(context.Background(), deadline)
 
 	c := &cancellationScope{
 		context: cancelContext,
@@ -942,35 +977,70 @@ func getRefreshOption(proj *workspace.Project, refresh string) (bool, error) {
 	return false, nil
 }
 
-func writePlan(path string, plan *deploy.Plan, enc config.Encrypter, showSecrets bool) error {
+// getPlanBase returns the current state of the given stack, for recording in or checking against a plan file.
+func getPlanBase(ctx context.Context, s backend.Stack, cfg config.Map) (stack.PlanBase, error) {
+	deployment, err := s.ExportDeployment(ctx)
+	if err != nil {
+		return stack.PlanBase{}, fmt.Errorf("exporting stack state: %w", err)
+	}
+	checkpointDigest, err := stack.CheckpointDigest(deployment)
+	if err != nil {
+		return stack.PlanBase{}, err
+	}
+	configDigest, err := stack.ConfigDigest(cfg)
+	if err != nil {
+		return stack.PlanBase{}, err
+	}
+	return stack.PlanBase{
+		Stack:            s.Ref().FullyQualifiedName().String(),
+		CheckpointDigest: checkpointDigest,
+		ConfigDigest:     configDigest,
+	}, nil
+}
+
+func writePlan(path string, base stack.PlanBase, plan *deploy.Plan, enc config.Encrypter, showSecrets bool) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer contract.IgnoreClose(f)
 
-	deploymentPlan, err := stack.SerializePlan(plan, enc, showSecrets)
+	planFile, err := stack.SerializePlanFile(base, plan, enc, showSecrets)
 	if err != nil {
 		return err
 	}
 	encoder := json.NewEncoder(f)
 	encoder.SetEscapeHTML(false)
 	encoder.SetIndent("", "    ")
-	return encoder.Encode(deploymentPlan)
+	return encoder.Encode(planFile)
 }
 
-func readPlan(path string, dec config.Decrypter, enc config.Encrypter) (*deploy.Plan, error) {
-	f, err := os.Open(path)
+// readPlan reads a plan file. The returned base is nil if the file was written by an older version of the CLI that
+// didn't record the state the plan was computed against.
+func readPlan(path string, dec config.Decrypter, enc config.Encrypter) (*deploy.Plan, *stack.PlanBase, error) {
+	b, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer contract.IgnoreClose(f)
 
-	var deploymentPlan apitype.DeploymentPlanV1
-	if err := json.NewDecoder(f).Decode(&deploymentPlan); err != nil {
-		return nil, err
+	var planFile apitype.PlanFileV1
+	if err := json.Unmarshal(b, &planFile); err != nil {
+		return nil, nil, err
+	}
+	if planFile.Version == 0 {
+		var deploymentPlan apitype.DeploymentPlanV1
+		if err := json.Unmarshal(b, &deploymentPlan); err != nil {
+			return nil, nil, err
+		}
+		plan, err := stack.DeserializePlan(deploymentPlan, dec, enc)
+		return plan, nil, err
+	}
+
+	plan, base, err := stack.DeserializePlanFile(planFile, dec, enc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading plan file '%s': %w", path, err)
 	}
-	return stack.DeserializePlan(deploymentPlan, dec, enc)
+	return plan, &base, nil
 }
 
 func buildStackName(stackName string) (string, error) {
@@ -75,6 +75,8 @@ func validateUnsupportedRemoteFlags(
 	replaces []string,
 	targetReplaces []string,
 	targetDependents bool,
+	excludes []string,
+	excludeDependents bool,
 	planFilePath string,
 	stackConfigFile string,
 ) error {
@@ -136,6 +138,12 @@ func validateUnsupportedRemoteFlags(
 	if targetDependents {
 		return errors.New("--target-dependents is not supported with --remote")
 	}
+	if len(excludes) > 0 {
+		return errors.New("--exclude is not supported with --remote")
+	}
+	if excludeDependents {
+		return errors.New("--exclude-dependents is not supported with --remote")
+	}
 	if planFilePath != "" {
 		return errors.New("--plan is not supported with --remote")
 	}
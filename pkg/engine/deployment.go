@@ -147,6 +147,9 @@ type deploymentOptions struct {
 	// true if we should trust the dependency graph reported by the language host. Not all Pulumi-supported languages
 	// correctly report their dependencies, in which case this will be false.
 	trustDependencies bool
+
+	// the caps on the number of concurrent operations on the resources of providers, from the project's options.
+	providerConcurrency map[string]int
 }
 
 // deploymentSourceFunc is a callback that will be used to prepare for, and evaluate, the "new" state for a stack.
@@ -176,6 +179,9 @@ func newDeployment(ctx *Context, info *deploymentContext, opts deploymentOptions
 	plugctx = plugctx.WithCancelChannel(ctx.Cancel.Canceled())
 
 	opts.trustDependencies = proj.TrustResourceDependencies()
+	if proj.Options != nil {
+		opts.providerConcurrency = proj.Options.ProviderConcurrency
+	}
 	// Now create the state source.  This may issue an error if it can't create the source.  This entails,
 	// for example, loading any plugins which will be required to execute a program, among other things.
 	source, err := opts.SourceFunc(ctx.BackendClient, opts, proj, pwd, main, target, plugctx, dryRun)
@@ -268,6 +274,7 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 
 	// Execute the deployment.
 	start := time.Now()
+	deadline, _ := cancelCtx.Cancel.Deadline()
 
 	done := make(chan bool)
 	var newPlan *deploy.Plan
@@ -283,11 +290,21 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 			DestroyTargets:            deployment.Options.DestroyTargets,
 			UpdateTargets:             deployment.Options.UpdateTargets,
 			TargetDependents:          deployment.Options.TargetDependents,
+			Excludes:                  deployment.Options.Excludes,
+			ExcludeDependents:         deployment.Options.ExcludeDependents,
 			TrustDependencies:         deployment.Options.trustDependencies,
+			ProviderConcurrency:       deployment.Options.providerConcurrency,
 			UseLegacyDiff:             deployment.Options.UseLegacyDiff,
 			DisableResourceReferences: deployment.Options.DisableResourceReferences,
 			DisableOutputValues:       deployment.Options.DisableOutputValues,
 			GeneratePlan:              deployment.Options.UpdateOptions.GeneratePlan,
+			ContinueOnError:           deployment.Options.ContinueOnError,
+			AutoAlias:                 deployment.Options.AutoAlias,
+			ResolvePendingCreates:     deployment.Options.ResolvePendingCreates,
+			RunStateHooks:             deployment.Options.RunStateHooks,
+			Retry:                     deployment.Options.Retry,
+			ResourceRetries:           deployment.Options.ResourceRetries,
+			Deadline:                  deadline,
 		}
 		newPlan, walkResult = deployment.Deployment.Execute(ctx, opts, preview)
 		close(done)
@@ -58,6 +58,10 @@ func NewEvent(typ EventType, payload interface{}) Event {
 		_, ok = payload.(ResourceOutputsEventPayload)
 	case ResourceOperationFailed:
 		_, ok = payload.(ResourceOperationFailedPayload)
+	case ResourceRetryEvent:
+		_, ok = payload.(ResourceRetryEventPayload)
+	case ResourceHookEvent:
+		_, ok = payload.(ResourceHookEventPayload)
 	case PolicyViolationEvent:
 		_, ok = payload.(PolicyViolationEventPayload)
 	default:
@@ -82,6 +86,8 @@ const (
 	ResourcePreEvent        EventType = "resource-pre"
 	ResourceOutputsEvent    EventType = "resource-outputs"
 	ResourceOperationFailed EventType = "resource-operationfailed"
+	ResourceRetryEvent      EventType = "resource-retry"
+	ResourceHookEvent       EventType = "resource-hook"
 	PolicyViolationEvent    EventType = "policy-violation"
 )
 
@@ -140,6 +146,23 @@ type ResourceOperationFailedPayload struct {
 	Steps    int
 }
 
+// ResourceRetryEventPayload is the payload for an event with type `resource-retry`, which is emitted before a
+// resource operation that failed with a transient error is tried again.
+type ResourceRetryEventPayload struct {
+	Metadata    StepEventMetadata
+	Attempt     int    // the attempt that is about to be made, starting from 2.
+	MaxAttempts int    // the maximum number of attempts that will be made.
+	Message     string // the error that caused the retry.
+}
+
+// ResourceHookEventPayload is the payload for an event with type `resource-hook`, which is emitted before a hook of
+// a resource runs.
+type ResourceHookEventPayload struct {
+	Metadata StepEventMetadata
+	Hook     resource.HookType // the point in the operation at which the hook runs.
+	Command  string            // the command that is run.
+}
+
 type ResourceOutputsEventPayload struct {
 	Metadata StepEventMetadata
 	Planning bool
@@ -365,6 +388,27 @@ func (e *eventEmitter) resourceOperationFailedEvent(
 	}))
 }
 
+func (e *eventEmitter) resourceRetryEvent(step deploy.Step, attempt, maxAttempts int, err error, debug bool) {
+	contract.Requiref(e != nil, "e", "!= nil")
+
+	e.sendEvent(NewEvent(ResourceRetryEvent, ResourceRetryEventPayload{
+		Metadata:    makeStepEventMetadata(step.Op(), step, debug),
+		Attempt:     attempt,
+		MaxAttempts: maxAttempts,
+		Message:     err.Error(),
+	}))
+}
+
+func (e *eventEmitter) resourceHookEvent(step deploy.Step, hook resource.HookType, command string, debug bool) {
+	contract.Requiref(e != nil, "e", "!= nil")
+
+	e.sendEvent(NewEvent(ResourceHookEvent, ResourceHookEventPayload{
+		Metadata: makeStepEventMetadata(step.Op(), step, debug),
+		Hook:     hook,
+		Command:  command,
+	}))
+}
+
 func (e *eventEmitter) resourceOutputsEvent(op display.StepOp, step deploy.Step, planning bool, debug bool) {
 	contract.Requiref(e != nil, "e", "!= nil")
 
@@ -130,7 +130,7 @@ func (entries JournalEntries) Snap(base *deploy.Snapshot) (*deploy.Snapshot, err
 		// and propagate them to the new snapshot: we don't want to clear pending CREATE operations
 		// because these must require user intervention to be cleared or resolved.
 		for _, pendingOperation := range base.PendingOperations {
-			if pendingOperation.Type == resource.OperationTypeCreating {
+			if deploy.IsPendingCreate(pendingOperation) {
 				operations = append(operations, pendingOperation)
 			}
 		}
@@ -154,10 +154,11 @@ func (entries JournalEntries) Snap(base *deploy.Snapshot) (*deploy.Snapshot, err
 }
 
 type Journal struct {
-	entries JournalEntries
-	events  chan JournalEntry
-	cancel  chan bool
-	done    chan bool
+	entries   JournalEntries
+	unstarted []resource.Operation
+	events    chan JournalEntry
+	cancel    chan bool
+	done      chan bool
 }
 
 func (j *Journal) Entries() []JournalEntry {
@@ -204,12 +205,21 @@ func (j *Journal) RegisterResourceOutputs(step deploy.Step) error {
 	}
 }
 
+func (j *Journal) RecordUnstartedOperations(ops []resource.Operation) error {
+	j.unstarted = ops
+	return nil
+}
+
 func (j *Journal) RecordPlugin(plugin workspace.PluginInfo) error {
 	return nil
 }
 
 func (j *Journal) Snap(base *deploy.Snapshot) (*deploy.Snapshot, error) {
-	return j.entries.Snap(base)
+	snap, err := j.entries.Snap(base)
+	if snap != nil {
+		snap.UnstartedOperations = j.unstarted
+	}
+	return snap, err
 }
 
 func NewJournal() *Journal {
@@ -0,0 +1,147 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// createdURNs returns the URNs of the resources in the snapshot that exist. The test journal records skipped creates
+// as resources without IDs, but they aren't written to real checkpoints.
+func createdURNs(snap *deploy.Snapshot) []resource.URN {
+	var urns []resource.URN
+	for _, res := range snap.Resources {
+		if res.ID != "" && res.Type != "pulumi:providers:pkgA" {
+			urns = append(urns, res.URN)
+		}
+	}
+	return urns
+}
+
+func TestContinueOnError_update(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					if urn.Name() == "failing" {
+						return "", nil, resource.StatusOK, errors.New("create failed")
+					}
+					return "created-id", news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		failing, _, _, err := monitor.RegisterResource("pkgA:m:typA", "failing", true)
+		if err != nil {
+			return err
+		}
+		dependent, _, _, err := monitor.RegisterResource("pkgA:m:typA", "dependent", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{failing},
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "transitive", true, deploytest.ResourceOptions{
+			Parent: dependent,
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "independent", true)
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host, ContinueOnError: true}}
+	project := p.GetProject()
+
+	// The update fails, but the resource that doesn't depend on the failing one is still created.
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "independent", "")}, createdURNs(snap))
+
+	// Without the option, the update stops at the first failure.
+	p.Options.ContinueOnError = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Empty(t, createdURNs(snap))
+}
+
+func TestContinueOnError_destroy(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					if urn.Name() == "failing" {
+						return resource.StatusOK, errors.New("delete failed")
+					}
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		base, _, _, err := monitor.RegisterResource("pkgA:m:typA", "base", true)
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "failing", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{base},
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "independent", true)
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host, ContinueOnError: true}}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	require.Len(t, createdURNs(snap), 3)
+
+	// The failing resource and the resource it depends on are left behind, but everything else is deleted.
+	snap, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Equal(t, []resource.URN{
+		p.NewURN("pkgA:m:typA", "base", ""),
+		p.NewURN("pkgA:m:typA", "failing", ""),
+	}, createdURNs(snap))
+}
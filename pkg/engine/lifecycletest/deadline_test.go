@@ -0,0 +1,142 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newDeadlineTestPlan returns a plan whose program creates resA and resB, which depends on resA, unless remove is set,
+// and whose provider takes the given time to delete resB.
+func newDeadlineTestPlan(remove *bool, deleteDelay time.Duration) *TestPlan {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					if urn.Name() == "resB" {
+						time.Sleep(deleteDelay)
+					}
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if *remove {
+			return nil
+		}
+		resA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{resA},
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	return &TestPlan{Options: UpdateOptions{Host: host}}
+}
+
+func TestDeadline(t *testing.T) {
+	t.Parallel()
+
+	remove := false
+	p := newDeadlineTestPlan(&remove, 3*time.Second)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	require.Len(t, createdURNs(snap), 2)
+
+	// resB is deleted first, and is still being deleted at the deadline. Its deletion is allowed to finish, but resA's
+	// is never started.
+	remove = true
+	var warnings []string
+	snap, res = TestOp(Update).RunWithDeadline(time.Now().Add(time.Second), project,
+		p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			warnings = diagMessages(events, diag.Warning)
+			return res
+		})
+	require.NotNil(t, res)
+
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+	assert.Equal(t, []resource.URN{resA}, createdURNs(snap))
+	// Neither resA nor its default provider, which can only be deleted after resA, were deleted.
+	var unstarted []resource.URN
+	for _, op := range snap.UnstartedOperations {
+		assert.Equal(t, resource.OperationTypeDeleting, op.Type)
+		unstarted = append(unstarted, op.Resource.URN)
+	}
+	require.Len(t, unstarted, 2)
+	assert.Equal(t, resA, unstarted[0])
+	assert.True(t, providers.IsDefaultProvider(unstarted[1]))
+	assert.Empty(t, snap.PendingOperations)
+	assert.Contains(t, strings.Join(warnings, "\n"), "reached its deadline")
+
+	// The next update picks up where the last one stopped, and clears the record of what was left undone.
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Empty(t, createdURNs(snap))
+	assert.Empty(t, snap.UnstartedOperations)
+}
+
+func TestDeadline_passed(t *testing.T) {
+	t.Parallel()
+
+	remove := false
+	p := newDeadlineTestPlan(&remove, 0)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).RunWithDeadline(time.Now(), project, p.GetTarget(t, nil), p.Options, false,
+		p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Empty(t, createdURNs(snap))
+}
+
+func TestDeadline_notReached(t *testing.T) {
+	t.Parallel()
+
+	remove := false
+	p := newDeadlineTestPlan(&remove, 0)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).RunWithDeadline(time.Now().Add(time.Hour), project, p.GetTarget(t, nil), p.Options,
+		false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Len(t, createdURNs(snap), 2)
+	assert.Empty(t, snap.UnstartedOperations)
+}
@@ -0,0 +1,176 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newExcludeTestPlan returns a plan for a program that registers resA, resB which depends on resA, and resC, all with
+// the inputs {"foo": *value}.
+func newExcludeTestPlan(value *string) *TestPlan {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		inputs := resource.PropertyMap{"foo": resource.NewStringProperty(*value)}
+		resA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Inputs:       inputs,
+			Dependencies: []resource.URN{resA},
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	return &TestPlan{Options: UpdateOptions{Host: host}}
+}
+
+// resourceInputs returns the value of the "foo" input of each resource in the snapshot, other than providers, by name.
+func resourceInputs(snap *deploy.Snapshot) map[string]string {
+	inputs := make(map[string]string)
+	for _, res := range snap.Resources {
+		if res.Type == "pkgA:m:typA" && res.ID != "" {
+			inputs[string(res.URN.Name())] = res.Inputs["foo"].StringValue()
+		}
+	}
+	return inputs
+}
+
+func TestExcludeUpdate(t *testing.T) {
+	t.Parallel()
+
+	value := "bar"
+	p := newExcludeTestPlan(&value)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// Excluded resources are left as they are.
+	value = "baz"
+	opts := p.Options
+	opts.Excludes = deploy.NewUrnTargets([]string{"**::resA"})
+	updated, res := TestOp(Update).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, map[string]string{"resA": "bar", "resB": "baz", "resC": "baz"}, resourceInputs(updated))
+
+	// And so are the resources that depend on them, if dependents are excluded too.
+	opts.ExcludeDependents = true
+	updated, res = TestOp(Update).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, map[string]string{"resA": "bar", "resB": "bar", "resC": "baz"}, resourceInputs(updated))
+}
+
+func TestExcludeCreate(t *testing.T) {
+	t.Parallel()
+
+	value := "bar"
+	p := newExcludeTestPlan(&value)
+	project := p.GetProject()
+
+	// resB can't be created without resA.
+	p.Options.Excludes = deploy.NewUrnTargets([]string{string(p.NewURN("pkgA:m:typA", "resA", ""))})
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+
+	// Unless it is excluded too.
+	p.Options.ExcludeDependents = true
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, map[string]string{"resC": "bar"}, resourceInputs(snap))
+}
+
+func TestExcludeDestroy(t *testing.T) {
+	t.Parallel()
+
+	value := "bar"
+	p := newExcludeTestPlan(&value)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// An excluded resource is not destroyed, and neither are the resources it depends on.
+	opts := p.Options
+	opts.Excludes = deploy.NewUrnTargets([]string{string(p.NewURN("pkgA:m:typA", "resB", ""))})
+	destroyed, res := TestOp(Destroy).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, map[string]string{"resA": "bar", "resB": "bar"}, resourceInputs(destroyed))
+
+	// Resources that depend on an excluded resource are only kept if dependents are excluded too.
+	opts.Excludes = deploy.NewUrnTargets([]string{string(p.NewURN("pkgA:m:typA", "resA", ""))})
+	destroyed, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, map[string]string{"resA": "bar"}, resourceInputs(destroyed))
+
+	opts.ExcludeDependents = true
+	destroyed, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, map[string]string{"resA": "bar", "resB": "bar"}, resourceInputs(destroyed))
+}
+
+func TestExcludeRefresh(t *testing.T) {
+	t.Parallel()
+
+	value := "bar"
+	p := newExcludeTestPlan(&value)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	opts := p.Options
+	opts.Excludes = deploy.NewUrnTargets([]string{string(p.NewURN("pkgA:m:typA", "resA", ""))})
+	opts.ExcludeDependents = true
+	_, res = TestOp(Refresh).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, entries JournalEntries, _ []Event, res result.Result) result.Result {
+			var refreshed []string
+			for _, entry := range entries {
+				if entry.Kind == JournalEntrySuccess && entry.Step.Type() == "pkgA:m:typA" {
+					refreshed = append(refreshed, string(entry.Step.URN().Name()))
+				}
+			}
+			assert.Equal(t, []string{"resC"}, refreshed)
+			return res
+		})
+	require.Nil(t, res)
+}
@@ -0,0 +1,352 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newHooksTestPlan returns a plan whose program registers a single resource with the given hooks and inputs, unless
+// the inputs are nil.
+func newHooksTestPlan(hooks resource.ResourceHooks, inputs *resource.PropertyMap, creates *int) *TestPlan {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DiffF: func(urn resource.URN, id resource.ID,
+					olds, news resource.PropertyMap, ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if !olds["A"].DeepEquals(news["A"]) {
+						return plugin.DiffResult{ReplaceKeys: []resource.PropertyKey{"A"}}, nil
+					}
+					return plugin.DiffResult{}, nil
+				},
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					if !preview {
+						*creates++
+					}
+					return "created-id", news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if *inputs == nil {
+			return nil
+		}
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: *inputs,
+			Hooks:  hooks,
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	return &TestPlan{Options: UpdateOptions{Host: host}}
+}
+
+// hookEvents returns the payloads of the hook events.
+func hookEvents(events []Event) []ResourceHookEventPayload {
+	var payloads []ResourceHookEventPayload
+	for _, e := range events {
+		if e.Type == ResourceHookEvent {
+			payloads = append(payloads, e.Payload().(ResourceHookEventPayload))
+		}
+	}
+	return payloads
+}
+
+// readHookLog returns the lines that hooks appended to the given file.
+func readHookLog(t *testing.T, path string) []string {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return strings.Fields(string(b))
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := `echo "$PULUMI_HOOK:$PULUMI_RESOURCE_ID" >> ` + log
+	hooks := resource.ResourceHooks{}
+	for _, typ := range []resource.HookType{
+		resource.BeforeCreate, resource.AfterCreate,
+		resource.BeforeUpdate, resource.AfterUpdate,
+		resource.BeforeDelete, resource.AfterDelete,
+	} {
+		hooks[typ] = []string{record}
+	}
+
+	var creates int
+	inputs := resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"})
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	// Hooks don't run during previews.
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, true, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Empty(t, readHookLog(t, log))
+
+	var events []ResourceHookEventPayload
+	validate := func(_ workspace.Project, _ deploy.Target, _ JournalEntries, evts []Event,
+		res result.Result,
+	) result.Result {
+		events = hookEvents(evts)
+		return res
+	}
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, validate)
+	require.Nil(t, res)
+	assert.Equal(t, []string{"before-create:", "after-create:created-id"}, readHookLog(t, log))
+	require.Len(t, events, 2)
+	assert.Equal(t, resource.BeforeCreate, events[0].Hook)
+	assert.Equal(t, record, events[0].Command)
+
+	inputs = resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "baz"})
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []string{
+		"before-create:", "after-create:created-id",
+		"before-update:created-id", "after-update:created-id",
+	}, readHookLog(t, log))
+
+	require.Len(t, snap.Resources, 2)
+	assert.Equal(t, hooks, snap.Resources[1].Hooks)
+
+	// The delete hooks of a resource that is no longer in the program are read from its state.
+	inputs = nil
+	p.Options.RunStateHooks = true
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []string{
+		"before-create:", "after-create:created-id",
+		"before-update:created-id", "after-update:created-id",
+		"before-delete:created-id", "after-delete:created-id",
+	}, readHookLog(t, log))
+	assert.Equal(t, 1, creates)
+}
+
+func TestHooks_replace(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := `echo "$PULUMI_HOOK" >> ` + log
+	hooks := resource.ResourceHooks{
+		resource.BeforeCreate: {record}, resource.AfterCreate: {record},
+		resource.BeforeDelete: {record}, resource.AfterDelete: {record},
+	}
+
+	var creates int
+	inputs := resource.NewPropertyMapFromMap(map[string]interface{}{"A": "foo"})
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// The program registers the resource that replaces the old one, so the delete hooks of the old one run.
+	inputs = resource.NewPropertyMapFromMap(map[string]interface{}{"A": "bar"})
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []string{
+		"before-create", "after-create",
+		"before-create", "after-create", "before-delete", "after-delete",
+	}, readHookLog(t, log))
+	assert.Equal(t, 2, creates)
+}
+
+func TestHooks_destroy(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	log := filepath.Join(t.TempDir(), "hooks.log")
+	record := `echo "$PULUMI_HOOK" >> ` + log
+	hooks := resource.ResourceHooks{resource.BeforeDelete: {record}, resource.AfterDelete: {record}}
+
+	var creates int
+	inputs := resource.PropertyMap{}
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// A destroy doesn't run the program, so the hooks saved in the state are skipped unless they are trusted.
+	var warnings []string
+	destroyed, res := TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			warnings = diagMessages(events, diag.Warning)
+			return res
+		})
+	require.Nil(t, res)
+	assert.Empty(t, destroyed.Resources)
+	assert.Empty(t, readHookLog(t, log))
+	assert.Contains(t, strings.Join(warnings, "\n"), "--run-state-hooks")
+
+	p.Options.RunStateHooks = true
+	destroyed, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Empty(t, destroyed.Resources)
+	assert.Equal(t, []string{"before-delete", "after-delete"}, readHookLog(t, log))
+}
+
+func TestHooks_payload(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	payload := filepath.Join(t.TempDir(), "payload.json")
+	hooks := resource.ResourceHooks{resource.AfterCreate: {"cat > " + payload}}
+
+	var creates int
+	inputs := resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"})
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	b, err := os.ReadFile(payload)
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, map[string]interface{}{
+		"urn":  string(p.NewURN("pkgA:m:typA", "resA", "")),
+		"id":   "created-id",
+		"type": "pkgA:m:typA",
+		"hook": "after-create",
+		"new": map[string]interface{}{
+			"inputs":  map[string]interface{}{"foo": "bar"},
+			"outputs": map[string]interface{}{"foo": "bar"},
+		},
+	}, got)
+}
+
+func TestHooks_secrets(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	payload := filepath.Join(t.TempDir(), "payload.json")
+	hooks := resource.ResourceHooks{resource.AfterCreate: {"cat > " + payload + "; echo the password is hunter2"}}
+
+	var creates int
+	inputs := resource.PropertyMap{"password": resource.MakeSecret(resource.NewStringProperty("hunter2"))}
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	var messages []string
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			messages = diagMessages(events, diag.Info)
+			return res
+		})
+	require.Nil(t, res)
+
+	// Secrets are passed to hooks with the secret signature, and masked in the output of the hooks.
+	b, err := os.ReadFile(payload)
+	require.NoError(t, err)
+	var got struct {
+		New struct {
+			Inputs map[string]interface{} `json:"inputs"`
+		} `json:"new"`
+	}
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, map[string]interface{}{
+		"password": map[string]interface{}{resource.SigKey: resource.SecretSig, "value": "hunter2"},
+	}, got.New.Inputs)
+	output := strings.Join(messages, "\n")
+	assert.Contains(t, output, "the password is [secret]")
+	assert.NotContains(t, output, "hunter2")
+}
+
+func TestHooks_beforeFails(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	hooks := resource.ResourceHooks{resource.BeforeCreate: {"echo not today; exit 1"}}
+
+	var creates int
+	inputs := resource.PropertyMap{}
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Equal(t, 0, creates)
+	assert.Empty(t, createdURNs(snap))
+}
+
+func TestHooks_afterFails(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks in this test are POSIX shell commands")
+	}
+
+	hooks := resource.ResourceHooks{resource.AfterCreate: {"exit 3"}}
+
+	var creates int
+	inputs := resource.PropertyMap{}
+	p := newHooksTestPlan(hooks, &inputs, &creates)
+	project := p.GetProject()
+
+	// The resource has been created, so it is kept in the state even though the deployment fails.
+	var messages []string
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			messages = diagMessages(events, diag.Error)
+			return res
+		})
+	require.NotNil(t, res)
+	assert.Equal(t, 1, creates)
+	assert.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "resA", "")}, createdURNs(snap))
+	require.NotEmpty(t, messages)
+	assert.Contains(t, strings.Join(messages, "\n"), `after-create hook "exit 3" failed`)
+}
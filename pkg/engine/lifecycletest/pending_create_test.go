@@ -0,0 +1,138 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newPendingCreatesTestPlan returns a plan whose program creates resA, and whose provider finds the resources with the
+// IDs "resA" and "found".
+func newPendingCreatesTestPlan(t *testing.T) (*TestPlan, *deploy.Snapshot) {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				ReadF: func(urn resource.URN, id resource.ID,
+					inputs, state resource.PropertyMap,
+				) (plugin.ReadResult, resource.Status, error) {
+					if id != "resA" && id != "found" {
+						return plugin.ReadResult{}, resource.StatusOK, nil
+					}
+					return plugin.ReadResult{
+						ID:      id,
+						Inputs:  inputs,
+						Outputs: resource.NewPropertyMapFromMap(map[string]interface{}{"id": string(id)}),
+					}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host}}
+	snap, res := TestOp(Update).Run(p.GetProject(), p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	return p, snap
+}
+
+// addPendingCreate adds a pending operation on a resource that uses the same provider as resA to the snapshot.
+func addPendingCreate(p *TestPlan, snap *deploy.Snapshot, name string, typ resource.OperationType, id resource.ID) {
+	resA := snap.Resources[1]
+	res := &resource.State{
+		Type:     resA.Type,
+		URN:      p.NewURN(resA.Type, name, ""),
+		Custom:   true,
+		ID:       id,
+		Inputs:   resource.PropertyMap{},
+		Outputs:  resource.PropertyMap{},
+		Provider: resA.Provider,
+	}
+	snap.PendingOperations = append(snap.PendingOperations, resource.NewOperation(res, typ))
+}
+
+func pendingURNs(snap *deploy.Snapshot) []resource.URN {
+	var urns []resource.URN
+	for _, op := range snap.PendingOperations {
+		urns = append(urns, op.Resource.URN)
+	}
+	return urns
+}
+
+func TestResolvePendingCreates(t *testing.T) {
+	t.Parallel()
+
+	p, snap := newPendingCreatesTestPlan(t)
+	project := p.GetProject()
+
+	addPendingCreate(p, snap, "resFound", resource.OperationTypeImporting, "found")
+	addPendingCreate(p, snap, "resMissing", resource.OperationTypeImporting, "missing")
+	addPendingCreate(p, snap, "resUnknown", resource.OperationTypeCreating, "")
+	addPendingCreate(p, snap, "resTaken", resource.OperationTypeImporting, "taken")
+	snap.PendingOperations[3].Resource.URN = snap.Resources[1].URN
+
+	// Without resolving pending creates, a refresh leaves them all pending.
+	refreshed, res := TestOp(Refresh).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Len(t, refreshed.PendingOperations, 4)
+
+	// Resolving them turns the one whose resource exists into a resource and drops the one whose resource doesn't.
+	// The one without an ID, and the one whose URN is taken by another resource, are left pending.
+	opts := p.Options
+	opts.ResolvePendingCreates = true
+	var warnings []string
+	refreshed, res = TestOp(Refresh).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			warnings = diagMessages(events, diag.Warning)
+			return res
+		})
+	require.Nil(t, res)
+
+	resFound := p.NewURN("pkgA:m:typA", "resFound", "")
+	require.Len(t, refreshed.Resources, 3)
+	assert.Equal(t, resFound, refreshed.Resources[2].URN)
+	assert.Equal(t, resource.ID("found"), refreshed.Resources[2].ID)
+	assert.Equal(t, "found", refreshed.Resources[2].Outputs["id"].StringValue())
+	assert.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "resUnknown", ""), snap.Resources[1].URN},
+		pendingURNs(refreshed))
+	assert.Len(t, warnings, 2)
+	require.NoError(t, refreshed.VerifyIntegrity())
+
+	// The resolved resource is managed like any other from now on.
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, refreshed), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+}
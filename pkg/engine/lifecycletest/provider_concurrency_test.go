@@ -0,0 +1,222 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// concurrencyTracker records the maximum number of provider operations that were in flight at once.
+type concurrencyTracker struct {
+	current int32
+	max     int32
+}
+
+func (c *concurrencyTracker) create(urn resource.URN, news resource.PropertyMap, timeout float64,
+	preview bool,
+) (resource.ID, resource.PropertyMap, resource.Status, error) {
+	n := atomic.AddInt32(&c.current, 1)
+	defer atomic.AddInt32(&c.current, -1)
+	for {
+		m := atomic.LoadInt32(&c.max)
+		if n <= m || atomic.CompareAndSwapInt32(&c.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return resource.ID("id-" + urn.Name()), news, resource.StatusOK, nil
+}
+
+// registerConcurrently registers the given number of resources of the given type at once.
+func registerConcurrently(t *testing.T, monitor *deploytest.ResourceMonitor, typ tokens.Type, count int,
+	opts deploytest.ResourceOptions,
+) {
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, _, err := monitor.RegisterResource(typ, fmt.Sprintf("res%d", i), true, opts)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestProviderConcurrency_project(t *testing.T) {
+	t.Parallel()
+
+	tracker := &concurrencyTracker{}
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{CreateF: tracker.create}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		registerConcurrently(t, monitor, "pkgA:m:typA", 8, deploytest.ResourceOptions{})
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host, Parallel: 16}}
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{ProviderConcurrency: map[string]int{"pkgA": 2}}
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Len(t, createdURNs(snap), 8)
+	assert.LessOrEqual(t, atomic.LoadInt32(&tracker.max), int32(2))
+}
+
+func TestProviderConcurrency_resourceOption(t *testing.T) {
+	t.Parallel()
+
+	tracker := &concurrencyTracker{}
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{CreateF: tracker.create}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		provURN, provID, _, err := monitor.RegisterResource(providers.MakeProviderType("pkgA"), "provA", true,
+			deploytest.ResourceOptions{MaxConcurrency: 1})
+		require.NoError(t, err)
+
+		if provID == "" {
+			provID = providers.UnknownID
+		}
+		provRef, err := providers.NewReference(provURN, provID)
+		require.NoError(t, err)
+
+		registerConcurrently(t, monitor, "pkgA:m:typA", 4, deploytest.ResourceOptions{Provider: provRef.String()})
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host, Parallel: 16}}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Len(t, createdURNs(snap), 4)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tracker.max))
+}
+
+func TestProviderConcurrency_invalidResourceOption(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true,
+			deploytest.ResourceOptions{MaxConcurrency: 1})
+		assert.ErrorContains(t, err, "maxConcurrency")
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host}}
+	project := p.GetProject()
+
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+}
+
+func TestProviderConcurrency_noDeadlock(t *testing.T) {
+	t.Parallel()
+
+	// Every pkgA create waits for the pkgB create. With a single pkgA slot and a bounded worker pool, the throttled
+	// steps must not starve the step they are waiting on.
+	unblock := make(chan struct{})
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					select {
+					case <-unblock:
+						return resource.ID("id-" + urn.Name()), news, resource.StatusOK, nil
+					case <-time.After(10 * time.Second):
+						return "", nil, resource.StatusOK, errors.New("deadlock")
+					}
+				},
+			}, nil
+		}),
+		deploytest.NewProviderLoader("pkgB", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					close(unblock)
+					return resource.ID("id-" + urn.Name()), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			registerConcurrently(t, monitor, "pkgA:m:typA", 3, deploytest.ResourceOptions{})
+		}()
+		// Give the pkgA steps a head start so that they occupy the worker pool.
+		time.Sleep(100 * time.Millisecond)
+		_, _, _, err := monitor.RegisterResource("pkgB:m:typB", "resB", true)
+		assert.NoError(t, err)
+		wg.Wait()
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{Options: UpdateOptions{Host: host, Parallel: 2}}
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{ProviderConcurrency: map[string]int{"pkgA": 1}}
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	var created int
+	for _, r := range snap.Resources {
+		if r.ID != "" && !providers.IsProviderType(r.Type) {
+			created++
+		}
+	}
+	assert.Equal(t, 4, created)
+}
@@ -0,0 +1,227 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newRenameTestPlan returns a plan whose program registers a resource with the name in *name, and whose provider
+// counts the resources it creates.
+func newRenameTestPlan(name *string, creates *int) *TestPlan {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					if !preview {
+						*creates++
+					}
+					return resource.ID("id-" + urn.Name()), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", *name, true, deploytest.ResourceOptions{
+			Inputs: resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"}),
+		})
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	return &TestPlan{Options: UpdateOptions{Host: host}}
+}
+
+// diagMessages returns the messages of the diagnostics with the given severity.
+func diagMessages(events []Event, severity diag.Severity) []string {
+	var messages []string
+	for _, e := range events {
+		if e.Type == DiagEvent {
+			if p := e.Payload().(DiagEventPayload); p.Severity == severity {
+				messages = append(messages, p.Message)
+			}
+		}
+	}
+	return messages
+}
+
+func TestRename_suggestAlias(t *testing.T) {
+	t.Parallel()
+
+	name, creates := "resA", 0
+	p := newRenameTestPlan(&name, &creates)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, 1, creates)
+
+	name = "resB"
+	var warnings []string
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			warnings = diagMessages(events, diag.Warning)
+			return res
+		})
+	require.Nil(t, res)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "looks like a rename of '"+string(p.NewURN("pkgA:m:typA", "resA", ""))+"'")
+	assert.Contains(t, warnings[0], `{name: "resA"}`)
+
+	// Without --auto-alias, the resource is still replaced.
+	assert.Equal(t, 2, creates)
+	assert.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "resB", "")}, createdURNs(snap))
+}
+
+func TestRename_autoAlias(t *testing.T) {
+	t.Parallel()
+
+	name, creates := "resA", 0
+	p := newRenameTestPlan(&name, &creates)
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, 1, creates)
+
+	name = "resB"
+	p.Options.AutoAlias = true
+	var warnings []string
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, entries JournalEntries, events []Event,
+			res result.Result,
+		) result.Result {
+			warnings = diagMessages(events, diag.Warning)
+			for _, entry := range entries {
+				if entry.Kind == JournalEntrySuccess && entry.Step.URN() == p.NewURN("pkgA:m:typA", "resB", "") {
+					assert.Equal(t, deploy.OpSame, entry.Step.Op())
+				}
+			}
+			return res
+		})
+	require.Nil(t, res)
+	assert.Empty(t, warnings)
+	assert.Equal(t, 1, creates)
+
+	require.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "resB", "")}, createdURNs(snap))
+	for _, r := range snap.Resources {
+		if r.URN == p.NewURN("pkgA:m:typA", "resB", "") {
+			assert.Equal(t, resource.ID("id-resA"), r.ID)
+		}
+	}
+}
+
+func TestRename_autoAliasAmbiguous(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	names := []string{"resA", "resB"}
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		for _, name := range names {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", name, true, deploytest.ResourceOptions{
+				Inputs: resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"}),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+	p := &TestPlan{Options: UpdateOptions{Host: host}}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// Either old resource could be the new one, so neither is aliased.
+	names = []string{"resC"}
+	p.Options.AutoAlias = true
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "resC", "")}, createdURNs(snap))
+	for _, r := range snap.Resources {
+		assert.Empty(t, r.Aliases)
+	}
+}
+
+func TestRename_autoAliasNewSiblingFirst(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	inputs := map[string]resource.PropertyMap{
+		"resA": resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"}),
+		"resB": {},
+		"resC": resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "baz"}),
+	}
+	names := []string{"resA"}
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		for _, name := range names {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", name, true, deploytest.ResourceOptions{
+				Inputs: inputs[name],
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+	p := &TestPlan{Options: UpdateOptions{Host: host}}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// New siblings that are registered before the old resource don't take it over, as their inputs don't match it.
+	names = []string{"resB", "resC", "resA"}
+	p.Options.AutoAlias = true
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.ElementsMatch(t, []resource.URN{
+		p.NewURN("pkgA:m:typA", "resA", ""),
+		p.NewURN("pkgA:m:typA", "resB", ""),
+		p.NewURN("pkgA:m:typA", "resC", ""),
+	}, createdURNs(snap))
+	for _, r := range snap.Resources {
+		assert.Empty(t, r.Aliases)
+	}
+}
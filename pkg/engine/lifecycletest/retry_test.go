@@ -0,0 +1,145 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine" //nolint:revive
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newRetryTestPlan returns a plan whose program creates a single resource, and whose provider fails to create it with
+// the given code until it has been asked the given number of times.
+func newRetryTestPlan(code codes.Code, failures int32, attempts *int32) *TestPlan {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					if atomic.AddInt32(attempts, 1) <= failures {
+						return "", nil, resource.StatusOK, rpcerror.New(code, "try again later")
+					}
+					return "created-id", news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	return &TestPlan{Options: UpdateOptions{Host: host}}
+}
+
+// retryEvents returns the payloads of the retry events.
+func retryEvents(events []Event) []ResourceRetryEventPayload {
+	var payloads []ResourceRetryEventPayload
+	for _, e := range events {
+		if e.Type == ResourceRetryEvent {
+			payloads = append(payloads, e.Payload().(ResourceRetryEventPayload))
+		}
+	}
+	return payloads
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	p := newRetryTestPlan(codes.Unavailable, 2, &attempts)
+	p.Options.Retry = deploy.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+	project := p.GetProject()
+
+	var retries []ResourceRetryEventPayload
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			retries = retryEvents(events)
+			return res
+		})
+	require.Nil(t, res)
+	assert.Equal(t, int32(3), attempts)
+	assert.Equal(t, []resource.URN{p.NewURN("pkgA:m:typA", "resA", "")}, createdURNs(snap))
+
+	require.Len(t, retries, 2)
+	for i, retry := range retries {
+		assert.Equal(t, p.NewURN("pkgA:m:typA", "resA", ""), retry.Metadata.URN)
+		assert.Equal(t, i+2, retry.Attempt)
+		assert.Equal(t, 3, retry.MaxAttempts)
+		assert.Contains(t, retry.Message, "try again later")
+	}
+}
+
+func TestRetry_exhausted(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	p := newRetryTestPlan(codes.Unavailable, 5, &attempts)
+	p.Options.Retry = deploy.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Equal(t, int32(3), attempts)
+	assert.Empty(t, createdURNs(snap))
+}
+
+func TestRetry_notRetryable(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	p := newRetryTestPlan(codes.InvalidArgument, 1, &attempts)
+	p.Options.Retry = deploy.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+	project := p.GetProject()
+
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+	assert.Equal(t, int32(1), attempts)
+}
+
+func TestRetry_resourcePolicy(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	p := newRetryTestPlan(codes.ResourceExhausted, 3, &attempts)
+	p.Options.Retry = deploy.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond}
+	p.Options.ResourceRetries = []deploy.ResourceRetryPolicy{{
+		Targets: deploy.NewUrnTargets([]string{"**resA"}),
+		Policy:  deploy.RetryPolicy{MaxAttempts: 4, InitialDelay: time.Millisecond},
+	}}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, int32(4), attempts)
+	assert.Len(t, createdURNs(snap), 1)
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/mitchellh/copystructure"
 	"github.com/stretchr/testify/assert"
@@ -57,7 +58,8 @@ type ValidateFunc func(project workspace.Project, target deploy.Target, entries
 func (op TestOp) Plan(project workspace.Project, target deploy.Target, opts UpdateOptions,
 	backendClient deploy.BackendClient, validate ValidateFunc,
 ) (*deploy.Plan, result.Result) {
-	plan, _, res := op.runWithContext(context.Background(), project, target, opts, true, backendClient, validate)
+	plan, _, res := op.runWithContext(context.Background(), time.Time{}, project, target, opts, true, backendClient,
+		validate)
 	return plan, res
 }
 
@@ -72,19 +74,30 @@ func (op TestOp) RunWithContext(
 	target deploy.Target, opts UpdateOptions, dryRun bool,
 	backendClient deploy.BackendClient, validate ValidateFunc,
 ) (*deploy.Snapshot, result.Result) {
-	_, snap, res := op.runWithContext(callerCtx, project, target, opts, dryRun, backendClient, validate)
+	_, snap, res := op.runWithContext(callerCtx, time.Time{}, project, target, opts, dryRun, backendClient, validate)
+	return snap, res
+}
+
+// RunWithDeadline runs the operation with a deadline, after which the deployment stops starting new steps.
+func (op TestOp) RunWithDeadline(
+	deadline time.Time, project workspace.Project,
+	target deploy.Target, opts UpdateOptions, dryRun bool,
+	backendClient deploy.BackendClient, validate ValidateFunc,
+) (*deploy.Snapshot, result.Result) {
+	_, snap, res := op.runWithContext(context.Background(), deadline, project, target, opts, dryRun, backendClient,
+		validate)
 	return snap, res
 }
 
 func (op TestOp) runWithContext(
-	callerCtx context.Context, project workspace.Project,
+	callerCtx context.Context, deadline time.Time, project workspace.Project,
 	target deploy.Target, opts UpdateOptions, dryRun bool,
 	backendClient deploy.BackendClient, validate ValidateFunc,
 ) (*deploy.Plan, *deploy.Snapshot, result.Result) {
 	// Create an appropriate update info and context.
 	info := &updateInfo{project: project, target: target}
 
-	cancelCtx, cancelSrc := cancel.NewContext(context.Background())
+	cancelCtx, cancelSrc := cancel.NewContextWithDeadline(context.Background(), deadline)
 	done := make(chan bool)
 	defer close(done)
 	go func() {
@@ -18,6 +18,7 @@ import (
 	"io"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
 // SnapshotManager manages an in-memory resource graph.
@@ -33,6 +34,10 @@ type SnapshotManager interface {
 	// RegisterResourceOutputs registers the set of resource outputs generated by performing the
 	// given step. These outputs are persisted in the snapshot.
 	RegisterResourceOutputs(step deploy.Step) error
+
+	// RecordUnstartedOperations records the operations that the deployment didn't start because it reached its
+	// deadline. They are persisted in the snapshot.
+	RecordUnstartedOperations(ops []resource.Operation) error
 }
 
 // SnapshotMutation represents an outstanding mutation that is yet to be completed. When the engine completes
@@ -130,6 +130,35 @@ type UpdateOptions struct {
 	// XXXTargets lists.
 	TargetDependents bool
 
+	// Specific resources to leave as they are during an update, preview, refresh or destroy operation.
+	Excludes deploy.UrnTargets
+
+	// true if the resources that depend on the Excludes should be left as they are too.
+	ExcludeDependents bool
+
+	// true if the engine should keep executing the steps whose dependencies succeeded after a step fails, skipping
+	// only the resources that depend on the failed ones.
+	ContinueOnError bool
+
+	// true if the engine should treat new resources that confidently match a deleted resource of the same type as
+	// renames of that resource, as if they had an alias for it.
+	AutoAlias bool
+
+	// true if the delete hooks saved in the state of a resource should run when the program doesn't register the
+	// resource, as when it is removed from the program or destroyed. The state may have been imported or edited, so
+	// its hooks only run if the user asks for it.
+	RunStateHooks bool
+
+	// true if a refresh should resolve the pending creates left by an interrupted update that know the ID of the
+	// resource they were creating, by reading the resource from its provider.
+	ResolvePendingCreates bool
+
+	// How to retry the resource operations that fail with transient provider errors.
+	Retry deploy.RetryPolicy
+
+	// Retry policies for specific resources, overriding Retry.
+	ResourceRetries []deploy.ResourceRetryPolicy
+
 	// true if the engine should use legacy diffing behavior during an update.
 	UseLegacyDiff bool
 
@@ -632,6 +661,24 @@ func (acts *updateActions) OnResourceOutputs(step deploy.Step) error {
 	return acts.Context.SnapshotManager.RegisterResourceOutputs(step)
 }
 
+func (acts *updateActions) OnResourceStepRetry(step deploy.Step, attempt, maxAttempts int, err error) error {
+	if shouldReportStep(step, acts.Opts) {
+		acts.Opts.Events.resourceRetryEvent(step, attempt, maxAttempts, err, acts.Opts.Debug)
+	}
+	return nil
+}
+
+func (acts *updateActions) OnResourceHook(step deploy.Step, hook resource.HookType, command string) error {
+	if shouldReportStep(step, acts.Opts) {
+		acts.Opts.Events.resourceHookEvent(step, hook, command, acts.Opts.Debug)
+	}
+	return nil
+}
+
+func (acts *updateActions) OnDeadlineExceeded(unstarted []resource.Operation) error {
+	return acts.Context.SnapshotManager.RecordUnstartedOperations(unstarted)
+}
+
 func (acts *updateActions) OnPolicyViolation(urn resource.URN, d plugin.AnalyzeDiagnostic) {
 	acts.Opts.Events.policyViolationEvent(urn, d)
 }
@@ -750,6 +797,25 @@ func (acts *previewActions) OnResourceOutputs(step deploy.Step) error {
 	return nil
 }
 
+func (acts *previewActions) OnResourceStepRetry(step deploy.Step, attempt, maxAttempts int, err error) error {
+	if shouldReportStep(step, acts.Opts) {
+		acts.Opts.Events.resourceRetryEvent(step, attempt, maxAttempts, err, acts.Opts.Debug)
+	}
+	return nil
+}
+
+func (acts *previewActions) OnResourceHook(step deploy.Step, hook resource.HookType, command string) error {
+	if shouldReportStep(step, acts.Opts) {
+		acts.Opts.Events.resourceHookEvent(step, hook, command, acts.Opts.Debug)
+	}
+	return nil
+}
+
+func (acts *previewActions) OnDeadlineExceeded(unstarted []resource.Operation) error {
+	// Previews don't save snapshots, so there is nothing to record.
+	return nil
+}
+
 func (acts *previewActions) OnPolicyViolation(urn resource.URN, d plugin.AnalyzeDiagnostic) {
 	acts.Opts.Events.policyViolationEvent(urn, d)
 }
@@ -0,0 +1,104 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// withDeadline derives the context that the steps of a deployment run under from the caller's context. The context is
+// canceled at the deployment's deadline, if it has one, which stops the step executor from starting new steps while
+// letting the steps in flight finish.
+func withDeadline(callerCtx context.Context, opts Options) (context.Context, context.CancelFunc) {
+	if opts.Deadline.IsZero() {
+		return context.WithCancel(callerCtx)
+	}
+	return context.WithDeadline(callerCtx, opts.Deadline)
+}
+
+// deadlineExceeded returns true if the given context, derived by withDeadline, ended because the deadline was reached
+// rather than because it was canceled.
+func deadlineExceeded(callerCtx, ctx context.Context) bool {
+	return callerCtx.Err() == nil && ctx.Err() == context.DeadlineExceeded
+}
+
+// unstartedOperation returns the operation that a step would have performed had it been started, and false if the
+// step wouldn't have changed anything worth recording.
+func unstartedOperation(step Step) (resource.Operation, bool) {
+	switch step.Op() {
+	case OpCreate, OpCreateReplacement:
+		return resource.NewOperation(step.New(), resource.OperationTypeCreating), true
+	case OpUpdate:
+		return resource.NewOperation(step.New(), resource.OperationTypeUpdating), true
+	case OpDelete, OpDeleteReplaced:
+		return resource.NewOperation(step.Old(), resource.OperationTypeDeleting), true
+	case OpRead, OpReadReplacement:
+		return resource.NewOperation(step.New(), resource.OperationTypeReading), true
+	case OpImport, OpImportReplacement:
+		return resource.NewOperation(step.New(), resource.OperationTypeImporting), true
+	default:
+		return resource.Operation{}, false
+	}
+}
+
+// recordUnstarted records steps that the step executor gave up on without starting them.
+func (se *stepExecutor) recordUnstarted(steps ...Step) {
+	se.unstartedLock.Lock()
+	defer se.unstartedLock.Unlock()
+
+	for _, step := range steps {
+		if op, ok := unstartedOperation(step); ok {
+			se.unstarted = append(se.unstarted, op)
+		}
+	}
+}
+
+// Unstarted returns the operations of the steps that the step executor gave up on without starting them.
+func (se *stepExecutor) Unstarted() []resource.Operation {
+	se.unstartedLock.Lock()
+	defer se.unstartedLock.Unlock()
+
+	return append([]resource.Operation(nil), se.unstarted...)
+}
+
+// reportDeadlineExceeded reports that the deployment reached its deadline, listing the operations that it didn't
+// start, and hands those operations to the deployment's events so that they can be recorded in the snapshot.
+func (ex *deploymentExecutor) reportDeadlineExceeded(opts Options, unstarted []resource.Operation) error {
+	msg := fmt.Sprintf("the deployment reached its deadline of %v; no new operations were started after it",
+		opts.Deadline.Format(time.RFC3339))
+	if len(unstarted) > 0 {
+		lines := make([]string, len(unstarted))
+		for i, op := range unstarted {
+			lines[i] = fmt.Sprintf("    %v (%v)", op.Resource.URN, op.Type)
+		}
+		sort.Strings(lines)
+		msg += "\nthe following operations were not started:\n" + strings.Join(lines, "\n")
+	}
+	ex.deployment.Diag().Warningf(diag.RawMessage("", msg))
+
+	if opts.Events != nil {
+		if err := opts.Events.OnDeadlineExceeded(unstarted); err != nil {
+			return fmt.Errorf("deadline event returned an error: %w", err)
+		}
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	uuid "github.com/gofrs/uuid"
 
@@ -61,11 +62,24 @@ type Options struct {
 	DestroyTargets            UrnTargets // Specific resources to destroy.
 	UpdateTargets             UrnTargets // Specific resources to update.
 	TargetDependents          bool       // true if we're allowing things to proceed, even with unspecified targets
+	Excludes                  UrnTargets // Specific resources to leave as they are.
+	ExcludeDependents         bool       // true if we're also excluding the resources that depend on excluded ones.
 	TrustDependencies         bool       // whether or not to trust the resource dependency graph.
 	UseLegacyDiff             bool       // whether or not to use legacy diffing behavior.
 	DisableResourceReferences bool       // true to disable resource reference support.
 	DisableOutputValues       bool       // true to disable output value support.
 	GeneratePlan              bool       // true to enable plan generation.
+	ContinueOnError           bool       // true to keep executing steps whose dependencies succeeded after a failure.
+	AutoAlias                 bool       // true to treat resources that were confidently renamed as aliased.
+	ResolvePendingCreates     bool       // true to have a refresh read pending creates that know their ID.
+	RunStateHooks             bool       // true to run the delete hooks saved in the state of unregistered resources.
+	Deadline                  time.Time  // the time after which no new steps are started (zero for no deadline).
+
+	Retry           RetryPolicy           // how to retry operations that fail with transient errors.
+	ResourceRetries []ResourceRetryPolicy // retry policies for specific resources, overriding Retry.
+
+	// The maximum number of concurrent operations on the resources of providers, by package name or provider URN.
+	ProviderConcurrency map[string]int
 }
 
 // DegreeOfParallelism returns the degree of parallelism that should be used during the
@@ -82,6 +96,24 @@ func (o Options) InfiniteParallelism() bool {
 	return o.Parallel == math.MaxInt32
 }
 
+// isExcluded returns true if the resource with the given URN is excluded from the deployment. Default providers are
+// only excluded when they are named explicitly, since they are registered on behalf of the program and globs that
+// match them are almost certainly meant for the resources that use them.
+func (o Options) isExcluded(urn resource.URN) bool {
+	if !o.Excludes.IsConstrained() {
+		return false
+	}
+	if providers.IsDefaultProvider(urn) {
+		for _, literal := range o.Excludes.Literals() {
+			if literal == urn {
+				return true
+			}
+		}
+		return false
+	}
+	return o.Excludes.Contains(urn)
+}
+
 // An immutable set of urns to target with an operation.
 //
 // The zero value of UrnTargets is the set of all URNs.
@@ -183,6 +215,9 @@ type StepExecutorEvents interface {
 	OnResourceStepPre(step Step) (interface{}, error)
 	OnResourceStepPost(ctx interface{}, step Step, status resource.Status, err error) error
 	OnResourceOutputs(step Step) error
+	OnResourceStepRetry(step Step, attempt, maxAttempts int, err error) error
+	OnResourceHook(step Step, hook resource.HookType, command string) error
+	OnDeadlineExceeded(unstarted []resource.Operation) error
 }
 
 // PolicyEvents is an interface that can be used to hook policy events.
@@ -234,6 +269,37 @@ func (m *resourceMap) mapRange(callback func(urn resource.URN, state *resource.S
 	})
 }
 
+// failureMap records the resources whose steps failed, and the resources that were skipped because a resource they
+// depend on failed, when a deployment continues after errors.
+type failureMap struct {
+	m sync.Map
+}
+
+// fail records that the step of the given resource failed.
+func (m *failureMap) fail(urn resource.URN) {
+	m.m.Store(urn, resource.URN(""))
+}
+
+// skip records that the given resource was skipped because the resource cause failed or was itself skipped.
+func (m *failureMap) skip(urn, cause resource.URN) {
+	m.m.LoadOrStore(urn, cause)
+}
+
+// get returns true if the given resource failed or was skipped, along with the cause if it was skipped.
+func (m *failureMap) get(urn resource.URN) (resource.URN, bool) {
+	cause, ok := m.m.Load(urn)
+	if !ok {
+		return "", false
+	}
+	return cause.(resource.URN), true
+}
+
+func (m *failureMap) mapRange(callback func(urn, cause resource.URN) bool) {
+	m.m.Range(func(k, v interface{}) bool {
+		return callback(k.(resource.URN), v.(resource.URN))
+	})
+}
+
 type resourcePlans struct {
 	m     sync.RWMutex
 	plans Plan
@@ -288,6 +354,7 @@ type Deployment struct {
 	goals                *goalMap                         // the set of resource goals generated by the deployment.
 	news                 *resourceMap                     // the set of new resources generated by the deployment
 	newPlans             *resourcePlans                   // the set of new resource plans.
+	failures             *failureMap                      // the set of resources that failed or were skipped.
 }
 
 // addDefaultProviders adds any necessary default provider definitions and references to the given snapshot. Version
@@ -476,6 +543,7 @@ func NewDeployment(ctx *plugin.Context, target *Target, prev *Snapshot, plan *Pl
 		goals:                newGoals,
 		news:                 newResources,
 		newPlans:             newResourcePlan(target.Config),
+		failures:             &failureMap{},
 	}, nil
 }
 
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
@@ -93,7 +94,9 @@ func (ex *deploymentExecutor) printPendingOperationsWarning() {
 		"using `pulumi refresh` which will refresh the state from the provider you are using and " +
 		"clear the pending operations if there are any.\n" +
 		"\n" +
-		"Note that `pulumi refresh` will need to be run interactively to clear pending CREATE operations."
+		"Note that `pulumi refresh` will need to be run interactively to clear pending CREATE operations, " +
+		"unless you supply the IDs of the resources they created with `pulumi state pending resolve` and run " +
+		"`pulumi refresh --resolve-pending-creates`. Use `pulumi state pending ls` to list them."
 
 	warning := "Attempting to deploy or update resources " +
 		fmt.Sprintf("with %d pending operations from previous deployment.\n", len(ex.deployment.prev.PendingOperations)) +
@@ -187,11 +190,11 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	ex.stepGen = newStepGenerator(ex.deployment, opts, updateTargetsOpt, replaceTargetsOpt)
 
 	// Derive a cancellable context for this deployment. We will only cancel this context if some piece of the
-	// deployment's execution fails.
-	ctx, cancel := context.WithCancel(callerCtx)
+	// deployment's execution fails, or when the deployment reaches its deadline.
+	ctx, cancel := withDeadline(callerCtx, opts)
 
 	// Set up a step generator and executor for this deployment.
-	ex.stepExec = newStepExecutor(ctx, cancel, ex.deployment, opts, preview, false)
+	ex.stepExec = newStepExecutor(ctx, cancel, ex.deployment, opts, preview, opts.ContinueOnError)
 
 	// We iterate the source in its own goroutine because iteration is blocking and we want the main loop to be able to
 	// respond to cancellation requests promptly.
@@ -223,6 +226,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	//     should bail.
 	//  3. The stepExecCancel cancel context gets canceled. This means some error occurred in the step executor
 	//     and we need to bail. This can also happen if the user hits Ctrl-C.
+	finished := false
 	canceled, res := func() (bool, result.Result) {
 		logging.V(4).Infof("deploymentExecutor.Execute(...): waiting for incoming events")
 		for {
@@ -242,6 +246,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 				}
 
 				if event.Event == nil {
+					finished = true
 					res := ex.performDeletes(ctx, updateTargetsOpt, destroyTargetsOpt)
 					if res != nil {
 						if resErr := res.Error(); resErr != nil {
@@ -258,6 +263,9 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 						logging.V(4).Infof("deploymentExecutor.Execute(...): error handling event: %v", resErr)
 						ex.reportError(ex.deployment.generateEventURN(event.Event), resErr)
 					}
+					if opts.ContinueOnError && ex.failEvent(event.Event) {
+						continue
+					}
 					cancel()
 					return false, result.Bail()
 				}
@@ -274,6 +282,17 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	ex.stepExec.WaitForCompletion()
 	logging.V(4).Infof("deploymentExecutor.Execute(...): step executor has completed")
 
+	// Steps that were in flight at the deadline have completed by now. If the deadline kept the deployment from getting
+	// through all of its work, report what was left undone.
+	if unstarted := ex.stepExec.Unstarted(); res == nil && deadlineExceeded(callerCtx, ctx) &&
+		(!finished || len(unstarted) > 0) {
+		if err := ex.reportDeadlineExceeded(opts, unstarted); err != nil {
+			ex.reportError("", err)
+		}
+		ex.reportExecResult("stopped at its deadline", preview)
+		res = result.Bail()
+	}
+
 	// Now that we've performed all steps in the deployment, ensure that the list of targets to update was
 	// valid.  We have to do this *after* performing the steps as the target list may have referred
 	// to a resource that was created in one of the steps.
@@ -286,7 +305,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	// deletes). We skip this check if we already have an error, chances are if the deployment failed lots of
 	// operations wouldn't have got a chance to run so we'll spam errors about all of those failed operations
 	// making it less clear to the user what the root cause error was.
-	if res == nil && ex.deployment.plan != nil {
+	if res == nil && !ex.stepExec.Errored() && ex.deployment.plan != nil {
 		for urn, resourcePlan := range ex.deployment.plan.ResourcePlans {
 			if len(resourcePlan.Ops) != 0 {
 				if len(resourcePlan.Ops) == 1 && resourcePlan.Ops[0] == OpDelete {
@@ -331,6 +350,10 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 		}
 	}
 
+	if opts.ContinueOnError {
+		ex.reportFailures()
+	}
+
 	// Figure out if execution failed and why. Step generation and execution errors trump cancellation.
 	if res != nil || ex.stepExec.Errored() || ex.stepGen.Errored() {
 		// TODO(cyrusn): We seem to be losing any information about the original 'res's errors.  Should
@@ -386,6 +409,10 @@ func (ex *deploymentExecutor) performDeletes(
 	// deleting but we won't until the previous set of deletes fully completes. This approximation
 	// is conservative, but correct.
 	for _, antichain := range deletes {
+		if ex.stepGen.opts.ContinueOnError {
+			antichain = ex.skipFailedDeletes(antichain)
+		}
+
 		logging.V(4).Infof("deploymentExecutor.Execute(...): beginning delete antichain")
 		tok := ex.stepExec.ExecuteParallel(antichain)
 		tok.Wait(ctx)
@@ -406,6 +433,88 @@ func (ex *deploymentExecutor) performDeletes(
 	return nil
 }
 
+// skipFailedDeletes returns the steps of a delete antichain that can still be executed after some steps failed:
+// resources that failed to be created or updated are not deleted, and neither are the resources that a resource that
+// failed, or was skipped, depends on. The steps that are not executed are recorded as skipped.
+func (ex *deploymentExecutor) skipFailedDeletes(steps antichain) antichain {
+	var result antichain
+	for _, step := range steps {
+		if _, failed := ex.deployment.failures.get(step.URN()); failed {
+			logging.V(7).Infof("deploymentExecutor.performDeletes(...): not deleting failed resource %v", step.URN())
+			continue
+		}
+
+		var cause resource.URN
+		for _, dependent := range ex.deployment.depGraph.DependingOn(step.Res(), nil, true) {
+			if _, failed := ex.deployment.failures.get(dependent.URN); failed {
+				cause = dependent.URN
+				break
+			}
+		}
+		if cause != "" {
+			logging.V(7).Infof("deploymentExecutor.performDeletes(...): skipping %v on %v because %v failed",
+				step.Op(), step.URN(), cause)
+			ex.deployment.failures.skip(step.URN(), cause)
+			continue
+		}
+
+		result = append(result, step)
+	}
+	return result
+}
+
+// failEvent records that the source event couldn't be handled when continuing after errors, and completes it so that
+// the program can go on. It returns false if the event can't be completed, in which case the deployment must stop.
+func (ex *deploymentExecutor) failEvent(event SourceEvent) bool {
+	urn := ex.deployment.generateEventURN(event)
+	switch e := event.(type) {
+	case RegisterResourceEvent:
+		goal := e.Goal()
+		ex.stepExec.sawError.Store(true)
+		ex.deployment.failures.fail(urn)
+		e.Done(&RegisterResult{State: resource.NewState(goal.Type, urn, goal.Custom, false, "", goal.Properties, nil,
+			goal.Parent, goal.Protect, false, goal.Dependencies, nil, goal.Provider, goal.PropertyDependencies, false,
+			goal.AdditionalSecretOutputs, nil, &goal.CustomTimeouts, "", goal.RetainOnDelete, goal.DeletedWith,
+			nil, nil)})
+		return true
+	case ReadResourceEvent:
+		ex.stepExec.sawError.Store(true)
+		ex.deployment.failures.fail(urn)
+		e.Done(&ReadResult{State: resource.NewState(e.Type(), urn, true, false, e.ID(), e.Properties(), nil,
+			e.Parent(), false, true, e.Dependencies(), nil, e.Provider(), nil, false,
+			e.AdditionalSecretOutputs(), nil, nil, "", false, "", nil, nil)})
+		return true
+	default:
+		return false
+	}
+}
+
+// reportFailures reports a summary of the resources that failed, or were skipped because of a failure, when
+// continuing after errors.
+func (ex *deploymentExecutor) reportFailures() {
+	var failed, skipped []string
+	ex.deployment.failures.mapRange(func(urn, cause resource.URN) bool {
+		if cause == "" {
+			failed = append(failed, fmt.Sprintf("    %v", urn))
+		} else {
+			skipped = append(skipped, fmt.Sprintf("    %v (depends on %v)", urn, cause))
+		}
+		return true
+	})
+	if len(failed) == 0 {
+		return
+	}
+	sort.Strings(failed)
+	sort.Strings(skipped)
+
+	msg := "the following resources failed:\n" + strings.Join(failed, "\n")
+	if len(skipped) > 0 {
+		msg += "\nthe following resources were skipped because a resource they depend on failed:\n" +
+			strings.Join(skipped, "\n")
+	}
+	ex.reportError("", errors.New(msg))
+}
+
 // handleSingleEvent handles a single source event. For all incoming events, it produces a chain that needs
 // to be executed and schedules the chain for execution.
 func (ex *deploymentExecutor) handleSingleEvent(event SourceEvent) result.Result {
@@ -488,11 +597,19 @@ func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, p
 
 	// If the user did not provide any --target's, create a refresh step for each resource in the
 	// old snapshot.  If they did provider --target's then only create refresh steps for those
-	// specific targets.
+	// specific targets. Excluded resources are never refreshed.
+	excluded := excludedResources(opts, prev.Resources)
+
+	// If asked to, also read the resources of the pending creates that know their ID, to find out whether they exist.
+	var pending *pendingCreates
+	if opts.ResolvePendingCreates {
+		pending = ex.resolvePendingCreates(opts)
+	}
+
 	steps := []Step{}
 	resourceToStep := map[*resource.State]Step{}
 	for _, res := range prev.Resources {
-		if opts.RefreshTargets.Contains(res.URN) {
+		if opts.RefreshTargets.Contains(res.URN) && !excluded[res.URN] {
 			step := NewRefreshStep(ex.deployment, res, nil)
 			steps = append(steps, step)
 			resourceToStep[res] = step
@@ -506,6 +623,9 @@ func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, p
 	stepExec.SignalCompletion()
 	stepExec.WaitForCompletion()
 
+	if pending != nil {
+		pending.settle(ex, resourceToStep)
+	}
 	ex.rebuildBaseState(resourceToStep, true /*refresh*/)
 
 	// NOTE: we use the presence of an error in the caller context in order to distinguish caller-initiated
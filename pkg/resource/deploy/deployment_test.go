@@ -110,3 +110,26 @@ func TestGlobUrn(t *testing.T) {
 		})
 	}
 }
+
+func TestExcludes(t *testing.T) {
+	t.Parallel()
+
+	res := resource.URN("urn:pulumi:stack::test::pkgA:m:typA::resA")
+	other := resource.URN("urn:pulumi:stack::test::pkgA:m:typA::resB")
+	defaultProvider := resource.URN("urn:pulumi:stack::test::pulumi:providers:pkgA::default")
+
+	// Nothing is excluded by default.
+	assert.False(t, Options{}.isExcluded(res))
+
+	opts := Options{Excludes: NewUrnTargets([]string{"**"})}
+	assert.True(t, opts.isExcluded(res))
+	assert.True(t, opts.isExcluded(other))
+	// Globs don't match default providers...
+	assert.False(t, opts.isExcluded(defaultProvider))
+
+	// ...but literals do.
+	opts = Options{Excludes: NewUrnTargets([]string{string(res), string(defaultProvider)})}
+	assert.True(t, opts.isExcluded(res))
+	assert.False(t, opts.isExcluded(other))
+	assert.True(t, opts.isExcluded(defaultProvider))
+}
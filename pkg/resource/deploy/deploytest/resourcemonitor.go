@@ -103,6 +103,8 @@ type ResourceOptions struct {
 	CustomTimeouts          *resource.CustomTimeouts
 	RetainOnDelete          bool
 	DeletedWith             resource.URN
+	MaxConcurrency          int
+	Hooks                   resource.ResourceHooks
 	SupportsPartialValues   *bool
 	Remote                  bool
 	Providers               map[string]string
@@ -225,6 +227,13 @@ func (rm *ResourceMonitor) RegisterResource(t tokens.Type, name string, custom b
 		AdditionalSecretOutputs:    additionalSecretOutputs,
 		Aliases:                    aliasObjects,
 		DeletedWith:                string(opts.DeletedWith),
+		MaxConcurrency:             int32(opts.MaxConcurrency),
+		BeforeCreateHooks:          opts.Hooks[resource.BeforeCreate],
+		AfterCreateHooks:           opts.Hooks[resource.AfterCreate],
+		BeforeUpdateHooks:          opts.Hooks[resource.BeforeUpdate],
+		AfterUpdateHooks:           opts.Hooks[resource.AfterUpdate],
+		BeforeDeleteHooks:          opts.Hooks[resource.BeforeDelete],
+		AfterDeleteHooks:           opts.Hooks[resource.AfterDelete],
 	}
 
 	// submit request
@@ -0,0 +1,222 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+// resourceHooks returns the hooks of a resource registration, or nil if it has none.
+func resourceHooks(req *pulumirpc.RegisterResourceRequest) resource.ResourceHooks {
+	hooks := resource.ResourceHooks{}
+	for typ, commands := range map[resource.HookType][]string{
+		resource.BeforeCreate: req.GetBeforeCreateHooks(),
+		resource.AfterCreate:  req.GetAfterCreateHooks(),
+		resource.BeforeUpdate: req.GetBeforeUpdateHooks(),
+		resource.AfterUpdate:  req.GetAfterUpdateHooks(),
+		resource.BeforeDelete: req.GetBeforeDeleteHooks(),
+		resource.AfterDelete:  req.GetAfterDeleteHooks(),
+	} {
+		if len(commands) > 0 {
+			hooks[typ] = commands
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	return hooks
+}
+
+// stepHooks returns the types of the hooks that run before and after the given step, and the hooks to run. A resource
+// that the program registered runs the hooks of its registration. A resource that is deleted without being registered,
+// as when it was removed from the program or is being destroyed, runs the delete hooks saved in its state, but only if
+// RunStateHooks is set: a state file can be imported or edited, so its commands aren't trusted by default. Steps that
+// don't create, update or delete a resource have no hooks.
+func (se *stepExecutor) stepHooks(step Step) (before, after resource.HookType, hooks resource.ResourceHooks) {
+	switch step.Op() {
+	case OpCreate, OpCreateReplacement:
+		before, after = resource.BeforeCreate, resource.AfterCreate
+	case OpUpdate:
+		before, after = resource.BeforeUpdate, resource.AfterUpdate
+	case OpDelete, OpDeleteReplaced:
+		before, after = resource.BeforeDelete, resource.AfterDelete
+	default:
+		return "", "", nil
+	}
+
+	if goal, ok := se.deployment.goals.get(step.URN()); ok {
+		return before, after, goal.Hooks
+	}
+	if before != resource.BeforeDelete || step.Old() == nil {
+		return "", "", nil
+	}
+
+	hooks = step.Old().Hooks
+	if len(hooks[before]) == 0 && len(hooks[after]) == 0 {
+		return "", "", nil
+	}
+	if !se.opts.RunStateHooks {
+		se.deployment.Diag().Warningf(diag.RawMessage(step.URN(),
+			"not running the delete hooks saved in the state of this resource; pass --run-state-hooks to run them"))
+		return "", "", nil
+	}
+	return before, after, hooks
+}
+
+// hookPayload is the JSON document that a hook command reads from its standard input.
+type hookPayload struct {
+	URN  resource.URN      `json:"urn"`
+	ID   resource.ID       `json:"id,omitempty"`
+	Type string            `json:"type"`
+	Hook resource.HookType `json:"hook"`
+	Old  *hookState        `json:"old,omitempty"`
+	New  *hookState        `json:"new,omitempty"`
+}
+
+// hookState is the state of the resource before or after the step that a hook runs around. Secret values are
+// wrapped in an object with the standard secret signature, as in a state file:
+//
+//	{"4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270", "value": <plaintext>}
+type hookState struct {
+	Inputs  map[string]interface{} `json:"inputs"`
+	Outputs map[string]interface{} `json:"outputs"`
+}
+
+// newHookState returns the hook state of the given resource state, and appends the string values of its secrets to
+// secrets so that they can be masked in the output of the hook.
+func newHookState(state *resource.State, secrets *[]string) *hookState {
+	if state == nil {
+		return nil
+	}
+	return &hookState{Inputs: hookProperties(state.Inputs, secrets), Outputs: hookProperties(state.Outputs, secrets)}
+}
+
+func hookProperties(props resource.PropertyMap, secrets *[]string) map[string]interface{} {
+	var replv func(v resource.PropertyValue) (interface{}, bool)
+	replv = func(v resource.PropertyValue) (interface{}, bool) {
+		if !v.IsSecret() {
+			return nil, false
+		}
+		element := v.SecretValue().Element
+		collectSecretStrings(element, secrets)
+		return map[string]interface{}{
+			resource.SigKey: resource.SecretSig,
+			"value":         element.MapRepl(nil, replv),
+		}, true
+	}
+	return props.MapRepl(nil, replv)
+}
+
+// collectSecretStrings appends the string values found in the given secret value to secrets.
+func collectSecretStrings(v resource.PropertyValue, secrets *[]string) {
+	switch {
+	case v.IsString():
+		*secrets = append(*secrets, v.StringValue())
+	case v.IsArray():
+		for _, e := range v.ArrayValue() {
+			collectSecretStrings(e, secrets)
+		}
+	case v.IsObject():
+		for _, e := range v.ObjectValue() {
+			collectSecretStrings(e, secrets)
+		}
+	case v.IsSecret():
+		collectSecretStrings(v.SecretValue().Element, secrets)
+	}
+}
+
+// runHooks runs the given hooks of the given type for the resource of a step, one after the other, and stops at the
+// first one that fails. Nothing is run during previews.
+func (se *stepExecutor) runHooks(step Step, typ resource.HookType, hooks resource.ResourceHooks) error {
+	if se.preview || typ == "" || len(hooks[typ]) == 0 {
+		return nil
+	}
+
+	var id resource.ID
+	if step.New() != nil {
+		id = step.New().ID
+	}
+	if id == "" && step.Old() != nil {
+		id = step.Old().ID
+	}
+	var secrets []string
+	payload, err := json.Marshal(hookPayload{
+		URN:  step.URN(),
+		ID:   id,
+		Type: string(step.Type()),
+		Hook: typ,
+		Old:  newHookState(step.Old(), &secrets),
+		New:  newHookState(step.New(), &secrets),
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling payload of %s hook: %w", typ, err)
+	}
+	filter := logging.CreateFilter(secrets, "[secret]")
+
+	for _, command := range hooks[typ] {
+		if events := se.opts.Events; events != nil {
+			if err := events.OnResourceHook(step, typ, command); err != nil {
+				return fmt.Errorf("hook event returned an error: %w", err)
+			}
+		}
+
+		output, err := se.runHook(command, typ, step, id, payload)
+		if output != "" {
+			se.deployment.Diag().Infof(diag.RawMessage(step.URN(), filter.Filter(output)))
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", typ, command, err)
+		}
+	}
+	return nil
+}
+
+// runHook runs a single hook command with the system shell and returns its combined output. Like provider operations,
+// a command that has started isn't interrupted if the deployment is canceled.
+func (se *stepExecutor) runHook(command string, typ resource.HookType, step Step, id resource.ID,
+	payload []byte,
+) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	if ctx := se.deployment.Ctx(); ctx != nil {
+		cmd.Dir = ctx.Pwd
+	}
+	cmd.Env = append(os.Environ(),
+		"PULUMI_HOOK="+string(typ),
+		"PULUMI_URN="+string(step.URN()),
+		"PULUMI_RESOURCE_ID="+string(id),
+		"PULUMI_RESOURCE_TYPE="+string(step.Type()))
+	cmd.Stdin = bytes.NewReader(payload)
+
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	err := cmd.Run()
+	return strings.TrimRight(output.String(), "\r\n"), err
+}
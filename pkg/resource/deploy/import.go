@@ -101,6 +101,7 @@ func NewImportDeployment(ctx *plugin.Context, target *Target, projectName tokens
 		preview:      preview,
 		providers:    reg,
 		newPlans:     newResourcePlan(target.Config),
+		failures:     &failureMap{},
 	}, nil
 }
 
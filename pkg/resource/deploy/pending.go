@@ -0,0 +1,115 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// IsPendingCreate returns true if the given pending operation is a create that was interrupted, or an import, either
+// because it was interrupted too or because the ID of the resource that the create made was supplied afterwards.
+// Neither is cleared by a refresh unless it is resolved, as the resource it was making may exist.
+func IsPendingCreate(op resource.Operation) bool {
+	return op.Type == resource.OperationTypeCreating || op.Type == resource.OperationTypeImporting
+}
+
+// pendingCreates tracks the pending creates that a refresh is resolving by reading their resources from their
+// providers.
+type pendingCreates struct {
+	resolving map[*resource.State]resource.Operation // the operations being resolved, by the state read for them.
+	remaining []resource.Operation                   // the operations that are left pending.
+}
+
+// resolvePendingCreates picks out the pending creates in the base snapshot that can be resolved: those that know the
+// ID of the resource they were creating, whose provider is in the snapshot, and whose URN isn't taken by another live
+// resource. Their resources are added to the base snapshot so that the refresh reads them like any other resource.
+func (ex *deploymentExecutor) resolvePendingCreates(opts Options) *pendingCreates {
+	prev := ex.deployment.prev
+
+	providerRefs, live := make(map[string]bool), make(map[resource.URN]bool)
+	for _, res := range prev.Resources {
+		if res.Delete {
+			continue
+		}
+		live[res.URN] = true
+		if providers.IsProviderType(res.Type) {
+			if ref, err := providers.NewReference(res.URN, res.ID); err == nil {
+				providerRefs[ref.String()] = true
+			}
+		}
+	}
+
+	pending := &pendingCreates{resolving: make(map[*resource.State]resource.Operation)}
+	for _, op := range prev.PendingOperations {
+		res := op.Resource
+		if !IsPendingCreate(op) || !res.Custom || res.ID == "" ||
+			!opts.RefreshTargets.Contains(res.URN) || opts.isExcluded(res.URN) {
+			pending.remaining = append(pending.remaining, op)
+			continue
+		}
+		if !providerRefs[res.Provider] || live[res.URN] {
+			ex.deployment.Diag().Warningf(diag.RawMessage(res.URN, fmt.Sprintf(
+				"cannot resolve the pending %s of %s: its provider is missing or another resource has its URN",
+				op.Type, res.ID)))
+			pending.remaining = append(pending.remaining, op)
+			continue
+		}
+
+		prev.Resources = append(prev.Resources, res)
+		live[res.URN] = true
+		pending.resolving[res] = op
+	}
+	return pending
+}
+
+// settle records the outcome of the refresh of each resolving pending create. Those whose resource the provider found
+// become ordinary resources, and those whose resource it didn't are dropped, since the create never happened. Those
+// that couldn't be read are taken back out of the base snapshot and left pending.
+func (pending *pendingCreates) settle(ex *deploymentExecutor, resourceToStep map[*resource.State]Step) {
+	prev := ex.deployment.prev
+
+	failed := make(map[*resource.State]bool)
+	for res, op := range pending.resolving {
+		step := resourceToStep[res]
+		switch {
+		case step.New() == nil:
+			ex.deployment.Diag().Warningf(diag.RawMessage(res.URN, fmt.Sprintf(
+				"dropping the pending %s: no resource with ID %s exists", op.Type, res.ID)))
+		case step.New() == step.Old():
+			// The read failed, which the step executor has reported, so we know no more than we did before.
+			failed[res] = true
+			delete(resourceToStep, res)
+			pending.remaining = append(pending.remaining, op)
+		default:
+			ex.deployment.Diag().Infof(diag.RawMessage(res.URN, fmt.Sprintf(
+				"resolved the pending %s: the resource with ID %s exists", op.Type, step.New().ID)))
+		}
+	}
+
+	if len(failed) > 0 {
+		resources := make([]*resource.State, 0, len(prev.Resources))
+		for _, res := range prev.Resources {
+			if !failed[res] {
+				resources = append(resources, res)
+			}
+		}
+		prev.Resources = resources
+	}
+	prev.PendingOperations = pending.remaining
+}
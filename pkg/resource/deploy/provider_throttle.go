@@ -0,0 +1,111 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// providerThrottle caps the number of concurrent operations on the resources of providers. Every provider package and
+// provider resource that has a limit gets a semaphore, which operations on the resources it manages hold while they
+// run.
+type providerThrottle struct {
+	lock       sync.Mutex
+	packages   map[tokens.Package]int   // the limits of provider packages.
+	instances  []providerInstanceLimit  // the limits of provider resources, by URN.
+	semaphores map[string]chan struct{} // the semaphores of packages and provider resources, by name or URN.
+}
+
+// providerInstanceLimit is the limit of the provider resources whose URNs match a target.
+type providerInstanceLimit struct {
+	targets UrnTargets
+	limit   int
+}
+
+// newProviderThrottle creates a throttle from limits keyed by package name or by provider resource URN (or glob).
+func newProviderThrottle(limits map[string]int) *providerThrottle {
+	t := &providerThrottle{
+		packages:   map[tokens.Package]int{},
+		semaphores: map[string]chan struct{}{},
+	}
+	for key, limit := range limits {
+		if limit <= 0 {
+			continue
+		}
+		if strings.Contains(key, ":") {
+			t.instances = append(t.instances, providerInstanceLimit{NewUrnTargets([]string{key}), limit})
+		} else {
+			t.packages[tokens.Package(key)] = limit
+		}
+	}
+	return t
+}
+
+// semaphoresFor returns the semaphores that an operation on a resource managed by the given provider must hold, in the
+// order in which they must be acquired so that operations never wait on each other in a cycle. resourceLimit is the
+// limit set on the provider resource itself, which takes precedence over the limits of the project.
+func (t *providerThrottle) semaphoresFor(ref providers.Reference, resourceLimit int) []chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var sems []chan struct{}
+	pkg := providers.GetProviderPackage(ref.URN().Type())
+	if limit, ok := t.packages[pkg]; ok {
+		sems = append(sems, t.semaphore(string(pkg), limit))
+	}
+
+	limit := resourceLimit
+	if limit == 0 {
+		// If several limits match the provider, the strictest one applies.
+		for _, instance := range t.instances {
+			if instance.targets.Contains(ref.URN()) && (limit == 0 || instance.limit < limit) {
+				limit = instance.limit
+			}
+		}
+	}
+	if limit > 0 {
+		sems = append(sems, t.semaphore(string(ref.URN()), limit))
+	}
+	return sems
+}
+
+// semaphore returns the semaphore with the given key, creating it with the given limit if it doesn't exist yet. The
+// lock must be held.
+func (t *providerThrottle) semaphore(key string, limit int) chan struct{} {
+	sem, ok := t.semaphores[key]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		t.semaphores[key] = sem
+	}
+	return sem
+}
+
+// isThrottledOp returns true if steps with the given operation call their provider, and are therefore subject to its
+// concurrency limits. Previews only call providers to read resources.
+func isThrottledOp(op display.StepOp, preview bool) bool {
+	switch op {
+	case OpRead, OpReadReplacement, OpRefresh, OpImport, OpImportReplacement:
+		return true
+	case OpCreate, OpCreateReplacement, OpUpdate, OpDelete, OpDeleteReplaced:
+		return !preview
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestProviderThrottle(t *testing.T) {
+	t.Parallel()
+
+	ref := func(name string) providers.Reference {
+		r, err := providers.NewReference(
+			resource.URN("urn:pulumi:stack::proj::pulumi:providers:aws::"+name), "id")
+		require.NoError(t, err)
+		return r
+	}
+
+	throttle := newProviderThrottle(map[string]int{
+		"aws": 4,
+		"urn:pulumi:stack::proj::pulumi:providers:aws::east": 2,
+		"**::pulumi:providers:aws::e*":                       3,
+		"github":                                             0,
+	})
+
+	// The package's semaphore is shared by all of its providers and comes first.
+	west := throttle.semaphoresFor(ref("west"), 0)
+	require.Len(t, west, 1)
+	assert.Equal(t, 4, cap(west[0]))
+
+	// The strictest of the limits that match a provider applies.
+	east := throttle.semaphoresFor(ref("east"), 0)
+	require.Len(t, east, 2)
+	assert.Equal(t, west[0], east[0])
+	assert.Equal(t, 2, cap(east[1]))
+
+	// A limit set on the provider resource takes precedence.
+	europe := throttle.semaphoresFor(ref("europe"), 1)
+	require.Len(t, europe, 2)
+	assert.Equal(t, 1, cap(europe[1]))
+
+	// Semaphores are created once.
+	assert.Equal(t, east, throttle.semaphoresFor(ref("east"), 0))
+
+	github, err := providers.NewReference("urn:pulumi:stack::proj::pulumi:providers:github::default", "id")
+	require.NoError(t, err)
+	assert.Empty(t, throttle.semaphoresFor(github, 0))
+}
+
+func TestIsThrottledOp(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isThrottledOp(OpCreate, false))
+	assert.False(t, isThrottledOp(OpCreate, true))
+	assert.True(t, isThrottledOp(OpRefresh, true))
+	assert.False(t, isThrottledOp(OpSame, false))
+}
@@ -0,0 +1,148 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// renameThreshold is the fraction of a created resource's inputs that must match those of a deleted resource of the
+// same type for the pair to be reported as a possible rename.
+const renameThreshold = 0.5
+
+// inputSimilarity returns the fraction of the given program inputs whose values match the given old inputs. Old inputs
+// may contain values that the provider added when checking the resource, so only the program's inputs are compared.
+// A resource without inputs doesn't resemble anything, so its similarity is 0.
+func inputSimilarity(news, olds resource.PropertyMap) float64 {
+	if len(news) == 0 {
+		return 0
+	}
+
+	matches := 0
+	for k, v := range news {
+		if old, has := olds[k]; has && v.DeepEquals(old) {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(news))
+}
+
+// aliasSuggestion returns the alias that the resource with the given URN and goal needs in order to take the place of
+// the given old resource.
+func aliasSuggestion(urn resource.URN, goal *resource.Goal, old *resource.State) string {
+	var parts []string
+	if old.URN.Name() != urn.Name() {
+		parts = append(parts, fmt.Sprintf("name: %q", old.URN.Name()))
+	}
+	if old.Parent != goal.Parent {
+		if old.Parent == "" || old.Parent.Type() == resource.RootStackType {
+			// Resources without a parent and children of the root stack have the same URN.
+			parts = append(parts, "noParent: true")
+		} else {
+			parts = append(parts, fmt.Sprintf("parent: %q", old.Parent))
+		}
+	}
+	if len(parts) == 0 {
+		// The resource's name and parent are the same, so something further up its parent chain was renamed.
+		return fmt.Sprintf("{urn: %q}", old.URN)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// isRenameCandidate returns true if the old resource could have become the resource with the given URN and goal.
+func isRenameCandidate(urn resource.URN, goal *resource.Goal, old *resource.State) bool {
+	return old.Type == goal.Type && old.Custom == goal.Custom && !providers.IsProviderType(old.Type) &&
+		old.URN != urn
+}
+
+// autoAlias returns the old resource that the resource with the given URN and goal confidently renames, if any. A
+// rename is confident if the old resource has not been registered by the program, exactly one of its name and parent
+// changed, the program passes inputs and all of them match, and no other old resource matches as well. The program
+// may still register the old resource later, so the inputs must match to tell a rename apart from a new sibling.
+func (sg *stepGenerator) autoAlias(urn resource.URN, goal *resource.Goal) (*resource.State, bool) {
+	if sg.deployment.prev == nil {
+		return nil, false
+	}
+
+	var match *resource.State
+	for _, old := range sg.deployment.prev.Resources {
+		if old.Delete || sg.deployment.Olds()[old.URN] != old || !isRenameCandidate(urn, goal, old) {
+			continue
+		}
+		if _, aliased := sg.aliased[old.URN]; aliased || sg.urns[old.URN] {
+			continue
+		}
+		if renamed, moved := old.URN.Name() != urn.Name(), old.Parent != goal.Parent; renamed == moved {
+			continue
+		}
+		if inputSimilarity(goal.Properties, old.Inputs) < 1 {
+			continue
+		}
+		if match != nil {
+			// The rename is ambiguous.
+			return nil, false
+		}
+		match = old
+	}
+	return match, match != nil
+}
+
+// suggestAliases warns about resources that are being created in place of a deleted resource of the same type with
+// similar inputs, as these are usually resources that were renamed or moved without adding an alias.
+func (sg *stepGenerator) suggestAliases(dels []Step) {
+	// Only resources created from scratch can be renames.
+	var creates []resource.URN
+	for urn := range sg.creates {
+		if _, hasOld := sg.deployment.Olds()[urn]; !hasOld {
+			creates = append(creates, urn)
+		}
+	}
+	sort.Slice(creates, func(i, j int) bool { return creates[i] < creates[j] })
+
+	claimed := make(map[resource.URN]bool)
+	for _, step := range dels {
+		if step.Op() != OpDelete {
+			continue
+		}
+		old := step.Old()
+
+		var best resource.URN
+		var bestGoal *resource.Goal
+		bestSimilarity := renameThreshold
+		for _, urn := range creates {
+			goal, ok := sg.deployment.goals.get(urn)
+			if !ok || claimed[urn] || !isRenameCandidate(urn, goal, old) {
+				continue
+			}
+			if similarity := inputSimilarity(goal.Properties, old.Inputs); similarity >= bestSimilarity &&
+				(bestGoal == nil || similarity > bestSimilarity) {
+				best, bestGoal, bestSimilarity = urn, goal, similarity
+			}
+		}
+		if bestGoal == nil {
+			continue
+		}
+
+		claimed[best] = true
+		sg.deployment.Diag().Warningf(diag.GetResourceMayHaveBeenRenamed(best), best, old.URN,
+			aliasSuggestion(best, bestGoal, old))
+	}
+}
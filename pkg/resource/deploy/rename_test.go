@@ -0,0 +1,104 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestInputSimilarity(t *testing.T) {
+	t.Parallel()
+
+	olds := resource.NewPropertyMapFromMap(map[string]interface{}{
+		"foo":  "bar",
+		"baz":  1,
+		"name": "res-1234567",
+	})
+
+	tests := []struct {
+		name     string
+		news     map[string]interface{}
+		expected float64
+	}{
+		{name: "no inputs", news: nil, expected: 0},
+		{name: "all match", news: map[string]interface{}{"foo": "bar", "baz": 1}, expected: 1},
+		{name: "some match", news: map[string]interface{}{"foo": "bar", "baz": 2}, expected: 0.5},
+		{name: "new key", news: map[string]interface{}{"foo": "bar", "qux": true}, expected: 0.5},
+		{name: "none match", news: map[string]interface{}{"foo": "qux"}, expected: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, inputSimilarity(resource.NewPropertyMapFromMap(tt.news), olds))
+		})
+	}
+}
+
+func TestAliasSuggestion(t *testing.T) {
+	t.Parallel()
+
+	parent := resource.URN("urn:pulumi:stack::project::my:component:Comp::comp")
+	root := resource.URN("urn:pulumi:stack::project::pulumi:pulumi:Stack::project-stack")
+	oldURN := resource.URN("urn:pulumi:stack::project::pkgA:m:typA::resA")
+
+	tests := []struct {
+		name      string
+		newURN    resource.URN
+		newParent resource.URN
+		oldParent resource.URN
+		expected  string
+	}{
+		{
+			name:     "renamed",
+			newURN:   "urn:pulumi:stack::project::pkgA:m:typA::resB",
+			expected: `{name: "resA"}`,
+		},
+		{
+			name:      "moved into a component",
+			newURN:    "urn:pulumi:stack::project::my:component:Comp$pkgA:m:typA::resA",
+			newParent: parent,
+			oldParent: root,
+			expected:  `{noParent: true}`,
+		},
+		{
+			name:      "moved out of a component",
+			newURN:    "urn:pulumi:stack::project::pkgA:m:typA::resB",
+			oldParent: parent,
+			expected:  `{name: "resA", parent: "` + string(parent) + `"}`,
+		},
+		{
+			name:      "parent renamed",
+			newURN:    "urn:pulumi:stack::project::my:component:Other$pkgA:m:typA::resA",
+			newParent: parent,
+			oldParent: parent,
+			expected:  `{urn: "` + string(oldURN) + `"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			goal := &resource.Goal{Type: "pkgA:m:typA", Parent: tt.newParent}
+			old := &resource.State{Type: "pkgA:m:typA", URN: oldURN, Parent: tt.oldParent}
+			assert.Equal(t, tt.expected, aliasSuggestion(tt.newURN, goal, old))
+		})
+	}
+}
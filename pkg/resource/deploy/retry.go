@@ -0,0 +1,130 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+)
+
+// DefaultRetryableCodes are the gRPC status codes of provider errors that are retried if a retry policy doesn't list
+// its own. They are the codes that providers use for throttling and other failures that are expected to go away.
+var DefaultRetryableCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
+
+// RetryPolicy controls how resource operations that fail with transient provider errors are retried. The zero value
+// never retries.
+type RetryPolicy struct {
+	MaxAttempts  int               // the maximum number of attempts, including the first (<=1 to never retry).
+	InitialDelay time.Duration     // the delay before the first retry.
+	MaxDelay     time.Duration     // the maximum delay between two attempts (<=0 for no maximum).
+	Multiplier   float64           // the factor by which the delay grows after each retry (<1 for 2).
+	Codes        []codes.Code      // the retryable gRPC status codes (nil for DefaultRetryableCodes).
+	Statuses     []resource.Status // the retryable operation statuses (nil for just StatusOK).
+}
+
+// ResourceRetryPolicy is a retry policy that applies to a specific set of resources.
+type ResourceRetryPolicy struct {
+	Targets UrnTargets  // the resources to which the policy applies.
+	Policy  RetryPolicy // the policy to apply.
+}
+
+// retryPolicy returns the retry policy of the resource with the given URN: the first of the resource-specific policies
+// that targets it, or else the deployment's policy.
+func (o Options) retryPolicy(urn resource.URN) RetryPolicy {
+	for _, p := range o.ResourceRetries {
+		if p.Targets.IsConstrained() && p.Targets.Contains(urn) {
+			return p.Policy
+		}
+	}
+	return o.Retry
+}
+
+// delay returns how long to wait after the given (1-based) failed attempt before trying again.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			return p.MaxDelay
+		}
+	}
+	return time.Duration(delay)
+}
+
+// retryable returns true if an operation that failed with the given status and error should be tried again. Only
+// errors reported by providers over gRPC are retryable: anything else is a bug or a problem with the program that a
+// retry won't fix.
+func (p RetryPolicy) retryable(status resource.Status, err error) bool {
+	if p.MaxAttempts <= 1 || err == nil {
+		return false
+	}
+
+	statuses := p.Statuses
+	if statuses == nil {
+		statuses = []resource.Status{resource.StatusOK}
+	}
+	if !containsStatus(statuses, status) {
+		return false
+	}
+
+	var rpcErr *rpcerror.Error
+	if !errors.As(err, &rpcErr) {
+		if rpcErr, _ = rpcerror.FromError(err); rpcErr == nil {
+			return false
+		}
+	}
+
+	retryableCodes := p.Codes
+	if retryableCodes == nil {
+		retryableCodes = DefaultRetryableCodes
+	}
+	for _, code := range retryableCodes {
+		if rpcErr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(statuses []resource.Status, status resource.Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableOp returns true if steps with the given operation are retried when they fail. Only the operations that
+// create, update or delete resources are retried.
+func isRetryableOp(op display.StepOp) bool {
+	switch op {
+	case OpCreate, OpCreateReplacement, OpUpdate, OpDelete, OpDeleteReplaced:
+		return true
+	default:
+		return false
+	}
+}
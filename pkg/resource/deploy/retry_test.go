@@ -0,0 +1,83 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{MaxAttempts: 10, InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+	assert.Equal(t, time.Second, p.delay(1))
+	assert.Equal(t, 2*time.Second, p.delay(2))
+	assert.Equal(t, 4*time.Second, p.delay(3))
+	assert.Equal(t, 5*time.Second, p.delay(4))
+	assert.Equal(t, 5*time.Second, p.delay(9))
+
+	p = RetryPolicy{MaxAttempts: 10, InitialDelay: time.Second, Multiplier: 3}
+	assert.Equal(t, 9*time.Second, p.delay(3))
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	t.Parallel()
+
+	unavailable := rpcerror.New(codes.Unavailable, "unavailable")
+
+	var zero RetryPolicy
+	assert.False(t, zero.retryable(resource.StatusOK, unavailable))
+
+	p := RetryPolicy{MaxAttempts: 3}
+	assert.True(t, p.retryable(resource.StatusOK, unavailable))
+	assert.True(t, p.retryable(resource.StatusOK, fmt.Errorf("wrapped: %w", rpcerror.Convert(unavailable))))
+	assert.False(t, p.retryable(resource.StatusOK, nil))
+	assert.False(t, p.retryable(resource.StatusOK, errors.New("not from a provider")))
+	assert.False(t, p.retryable(resource.StatusOK, rpcerror.New(codes.InvalidArgument, "invalid")))
+	assert.False(t, p.retryable(resource.StatusPartialFailure, unavailable))
+
+	p = RetryPolicy{
+		MaxAttempts: 3,
+		Codes:       []codes.Code{codes.Internal},
+		Statuses:    []resource.Status{resource.StatusOK, resource.StatusPartialFailure},
+	}
+	assert.False(t, p.retryable(resource.StatusOK, unavailable))
+	assert.True(t, p.retryable(resource.StatusPartialFailure, rpcerror.New(codes.Internal, "internal")))
+}
+
+func TestRetryPolicyForResource(t *testing.T) {
+	t.Parallel()
+
+	urn := resource.URN("urn:pulumi:stack::proj::pkg:index:typ::name")
+	opts := Options{
+		Retry: RetryPolicy{MaxAttempts: 2},
+		ResourceRetries: []ResourceRetryPolicy{
+			{Targets: NewUrnTargets([]string{"**other"}), Policy: RetryPolicy{MaxAttempts: 3}},
+			{Targets: NewUrnTargets([]string{"**::name"}), Policy: RetryPolicy{MaxAttempts: 4}},
+			{Targets: NewUrnTargets([]string{string(urn)}), Policy: RetryPolicy{MaxAttempts: 5}},
+		},
+	}
+	assert.Equal(t, 4, opts.retryPolicy(urn).MaxAttempts)
+	assert.Equal(t, 2, opts.retryPolicy("urn:pulumi:stack::proj::pkg:index:typ::unmatched").MaxAttempts)
+}
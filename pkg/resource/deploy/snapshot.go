@@ -31,6 +31,9 @@ type Snapshot struct {
 	SecretsManager    secrets.Manager      // the manager to use use when seralizing this snapshot.
 	Resources         []*resource.State    // fetches all resources and their associated states.
 	PendingOperations []resource.Operation // all currently pending resource operations.
+
+	// The operations that the deployment didn't start because it reached its deadline.
+	UnstartedOperations []resource.Operation
 }
 
 // NewSnapshot creates a snapshot from the given arguments.  The resources must be in topologically sorted order.
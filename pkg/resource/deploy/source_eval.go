@@ -1010,6 +1010,8 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	customTimeouts := req.GetCustomTimeouts()
 	retainOnDelete := req.GetRetainOnDelete()
 	deletedWith := resource.URN(req.GetDeletedWith())
+	maxConcurrency := int(req.GetMaxConcurrency())
+	hooks := resourceHooks(req)
 
 	// Custom resources must have a three-part type so that we can 1) identify if they are providers and 2) retrieve the
 	// provider responsible for managing a particular resource (based on the type's Package).
@@ -1025,6 +1027,11 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 		t = tokens.Type(req.GetType())
 	}
 
+	if maxConcurrency < 0 || maxConcurrency > 0 && !providers.IsProviderType(t) {
+		return nil, rpcerror.Newf(codes.InvalidArgument,
+			"maxConcurrency must be a positive number and can only be set on provider resources")
+	}
+
 	// We handle updating the providers map to include the providers field of the parent if
 	// both the current resource and its parent is a component resource.
 	func() {
@@ -1246,6 +1253,8 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 				additionalSecretOutputs, aliases, id, &timeouts, replaceOnChanges, retainOnDelete, deletedWith),
 			done: make(chan *RegisterResult),
 		}
+		step.goal.MaxConcurrency = maxConcurrency
+		step.goal.Hooks = hooks
 
 		select {
 		case rm.regChan <- step:
@@ -54,6 +54,11 @@ type Step interface {
 	Res() *resource.State    // the latest state for the resource that is known (worst case, old).
 	Logical() bool           // true if this step represents a logical operation in the program.
 	Deployment() *Deployment // the owning deployment.
+
+	// Fail signals that this step failed, or will not be applied because of a failure, when the deployment continues
+	// after errors. It completes the step's registration, if any, with the resource's state as far as it is known, so
+	// that the program isn't left waiting on it. It must not be called if Apply returned a StepCompleteFunc.
+	Fail()
 }
 
 // SameStep is a mutating step that does nothing.
@@ -147,6 +152,10 @@ func (s *SameStep) Apply(preview bool) (resource.Status, StepCompleteFunc, error
 	return resource.StatusOK, complete, nil
 }
 
+func (s *SameStep) Fail() {
+	s.reg.Done(&RegisterResult{State: s.new})
+}
+
 func (s *SameStep) IsSkippedCreate() bool {
 	return s.skippedCreate
 }
@@ -285,6 +294,10 @@ func (s *CreateStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	return resourceStatus, complete, resourceError
 }
 
+func (s *CreateStep) Fail() {
+	s.reg.Done(&RegisterResult{State: s.new})
+}
+
 // DeleteStep is a mutating step that deletes an existing resource. If `old` is marked "External",
 // DeleteStep is a no-op.
 type DeleteStep struct {
@@ -424,6 +437,8 @@ func (s *DeleteStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	return resource.StatusOK, func() {}, nil
 }
 
+func (s *DeleteStep) Fail() {}
+
 type RemovePendingReplaceStep struct {
 	deployment *Deployment     // the current deployment.
 	old        *resource.State // the state of the existing resource.
@@ -454,6 +469,8 @@ func (s *RemovePendingReplaceStep) Apply(preview bool) (resource.Status, StepCom
 	return resource.StatusOK, nil, nil
 }
 
+func (s *RemovePendingReplaceStep) Fail() {}
+
 // UpdateStep is a mutating step that updates an existing resource's state.
 type UpdateStep struct {
 	deployment    *Deployment                    // the current deployment.
@@ -561,6 +578,10 @@ func (s *UpdateStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	return resourceStatus, complete, resourceError
 }
 
+func (s *UpdateStep) Fail() {
+	s.reg.Done(&RegisterResult{State: s.new})
+}
+
 // ReplaceStep is a logical step indicating a resource will be replaced.  This is comprised of three physical steps:
 // a creation of the new resource, any number of intervening updates of dependents to the new resource, and then
 // a deletion of the now-replaced old resource.  This logical step is primarily here for tools and visualization.
@@ -619,6 +640,8 @@ func (s *ReplaceStep) Apply(preview bool) (resource.Status, StepCompleteFunc, er
 	return resource.StatusOK, func() {}, nil
 }
 
+func (s *ReplaceStep) Fail() {}
+
 // ReadStep is a step indicating that an existing resources will be "read" and projected into the Pulumi object
 // model. Resources that are read are marked with the "External" bit which indicates to the engine that it does
 // not own this resource's lifeycle.
@@ -771,6 +794,10 @@ func (s *ReadStep) Apply(preview bool) (resource.Status, StepCompleteFunc, error
 	return resourceStatus, complete, resourceError
 }
 
+func (s *ReadStep) Fail() {
+	s.event.Done(&ReadResult{State: s.new})
+}
+
 // RefreshStep is a step used to track the progress of a refresh operation. A refresh operation updates the an existing
 // resource by reading its current state from its provider plugin. These steps are not issued by the step generator;
 // instead, they are issued by the deployment executor as the optional first step in deployment execution.
@@ -875,6 +902,7 @@ func (s *RefreshStep) Apply(preview bool) (resource.Status, StepCompleteFunc, er
 			s.old.Parent, s.old.Protect, s.old.External, s.old.Dependencies, initErrors, s.old.Provider,
 			s.old.PropertyDependencies, s.old.PendingReplacement, s.old.AdditionalSecretOutputs, s.old.Aliases,
 			&s.old.CustomTimeouts, s.old.ImportID, s.old.RetainOnDelete, s.old.DeletedWith, s.old.Created, s.old.Modified)
+		s.new.Hooks = s.old.Hooks
 		complete = func() {
 			var inputsChange, outputsChange bool
 			if s.old != nil {
@@ -898,6 +926,8 @@ func (s *RefreshStep) Apply(preview bool) (resource.Status, StepCompleteFunc, er
 	return rst, complete, err
 }
 
+func (s *RefreshStep) Fail() {}
+
 type ImportStep struct {
 	deployment    *Deployment                    // the current deployment.
 	reg           RegisterResourceEvent          // the registration intent to convey a URN back to.
@@ -1160,6 +1190,10 @@ func (s *ImportStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	return rst, complete, err
 }
 
+func (s *ImportStep) Fail() {
+	s.reg.Done(&RegisterResult{State: s.new})
+}
+
 const (
 	OpSame                 display.StepOp = "same"                   // nothing to do.
 	OpCreate               display.StepOp = "create"                 // creating a new resource.
@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -32,6 +34,7 @@ const (
 	// Dummy workerID for synchronous operations.
 	synchronousWorkerID = -1
 	infiniteWorkerID    = -2
+	standInWorkerID     = -3
 
 	// Utility constant for easy debugging.
 	stepExecutorLogLevel = 4
@@ -89,6 +92,12 @@ type stepExecutor struct {
 	preview         bool        // Whether or not we are doing a preview.
 	pendingNews     sync.Map    // Resources that have been created but are pending a RegisterResourceOutputs.
 	continueOnError bool        // True if we want to continue the deployment after a step error.
+	retryLock       sync.Mutex  // Lock guarding lookups of the retry policies of resources.
+
+	unstartedLock sync.Mutex           // Lock guarding unstarted.
+	unstarted     []resource.Operation // The operations of the steps that were given up on without being started.
+
+	throttle *providerThrottle // The concurrency limits of providers.
 
 	workers        sync.WaitGroup     // WaitGroup tracking the worker goroutines that are owned by this step executor.
 	incomingChains chan incomingChain // Incoming chains that we are to execute
@@ -114,6 +123,7 @@ func (se *stepExecutor) ExecuteSerial(chain chain) completionToken {
 	select {
 	case se.incomingChains <- incomingChain{Chain: chain, CompletionChan: completion}:
 	case <-se.ctx.Done():
+		se.recordUnstarted(chain...)
 		close(completion)
 	}
 
@@ -190,8 +200,8 @@ func (se *stepExecutor) ExecuteRegisterResourceOutputs(e RegisterResourceOutputs
 	}
 
 	// If there is an event subscription for finishing the resource, execute them.
-	if e := se.opts.Events; e != nil {
-		if eventerr := e.OnResourceOutputs(reg); eventerr != nil {
+	if events := se.opts.Events; events != nil {
+		if eventerr := events.OnResourceOutputs(reg); eventerr != nil {
 			se.log(synchronousWorkerID, "register resource outputs failed: %s", eventerr.Error())
 
 			// This is a bit of a kludge, but ExecuteRegisterResourceOutputs is an odd duck
@@ -204,6 +214,10 @@ func (se *stepExecutor) ExecuteRegisterResourceOutputs(e RegisterResourceOutputs
 			diagMsg := diag.RawMessage(reg.URN(), outErr.Error())
 			se.deployment.Diag().Errorf(diagMsg)
 			se.cancelDueToError()
+			if se.continueOnError {
+				se.deployment.failures.fail(urn)
+				e.Done()
+			}
 			return nil
 		}
 	}
@@ -239,17 +253,38 @@ func (se *stepExecutor) WaitForCompletion() {
 // executeChain executes a chain, one step at a time. If any step in the chain fails to execute, or if the
 // context is canceled, the chain stops execution.
 func (se *stepExecutor) executeChain(workerID int, chain chain) {
-	for _, step := range chain {
+	for i, step := range chain {
 		select {
 		case <-se.ctx.Done():
 			se.log(workerID, "step %v on %v canceled", step.Op(), step.URN())
+			se.recordUnstarted(chain[i:]...)
 			return
 		default:
 		}
 
-		if err := se.executeStep(workerID, step); err != nil {
+		release, ok := se.acquireProviderSlots(workerID, step)
+		if !ok {
+			se.log(workerID, "step %v on %v canceled while waiting for its provider", step.Op(), step.URN())
+			se.recordUnstarted(chain[i:]...)
+			return
+		}
+		completed, err := se.executeStep(workerID, step)
+		release()
+		if err != nil {
 			se.log(workerID, "step %v on %v failed, signalling cancellation", step.Op(), step.URN())
 			se.cancelDueToError()
+			if se.continueOnError {
+				// Record the failure, so that the resources that depend on this one are skipped, and release the
+				// registrations of this step and the rest of the chain, which won't be applied.
+				se.deployment.failures.fail(step.URN())
+				if !completed {
+					step.Fail()
+				}
+				for _, rest := range chain[i+1:] {
+					se.log(workerID, "step %v on %v not applied due to failure", rest.Op(), rest.URN())
+					rest.Fail()
+				}
+			}
 			if err != errStepApplyFailed {
 				// Step application errors are recorded by the OnResourceStepPost callback. This is confusing,
 				// but it means that at this level we shouldn't be logging any errors that came from there.
@@ -264,6 +299,65 @@ func (se *stepExecutor) executeChain(workerID int, chain chain) {
 	}
 }
 
+// acquireProviderSlots waits until the step can run within the concurrency limits of the provider of its resource,
+// and returns a function that releases the slots it took. It returns false if the deployment is canceled first.
+//
+// While a worker of a bounded pool waits, a stand-in worker takes its place, so that a provider that is at its limit
+// doesn't take up the whole pool and hold up the steps of other providers, including the ones that the steps it
+// throttles may depend on.
+func (se *stepExecutor) acquireProviderSlots(workerID int, step Step) (func(), bool) {
+	state := step.New()
+	if state == nil {
+		state = step.Old()
+	}
+	if state == nil || state.Provider == "" || !isThrottledOp(step.Op(), se.preview) {
+		return func() {}, true
+	}
+	ref, err := providers.ParseReference(state.Provider)
+	if err != nil {
+		return func() {}, true
+	}
+	var resourceLimit int
+	if goal, ok := se.deployment.goals.get(ref.URN()); ok {
+		resourceLimit = goal.MaxConcurrency
+	}
+	sems := se.throttle.semaphoresFor(ref, resourceLimit)
+
+	release := func(held []chan struct{}) {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+	for i, sem := range sems {
+		select {
+		case sem <- struct{}{}:
+			continue
+		default:
+		}
+
+		se.log(workerID, "step %v on %v waiting for provider %v", step.Op(), step.URN(), ref.URN())
+		var stop chan struct{}
+		if !se.opts.InfiniteParallelism() {
+			stop = make(chan struct{})
+			se.workers.Add(1)
+			go se.worker(standInWorkerID, false /*launchAsync*/, stop)
+		}
+		select {
+		case sem <- struct{}{}:
+			if stop != nil {
+				close(stop)
+			}
+		case <-se.ctx.Done():
+			if stop != nil {
+				close(stop)
+			}
+			release(sems[:i])
+			return nil, false
+		}
+	}
+	return func() { release(sems) }, true
+}
+
 func (se *stepExecutor) cancelDueToError() {
 	se.sawError.Store(true)
 	if !se.continueOnError {
@@ -282,9 +376,9 @@ func (se *stepExecutor) cancelDueToError() {
 // verbatim to the post-step event.
 //
 
-// executeStep executes a single step, returning true if the step execution was successful and
-// false if it was not.
-func (se *stepExecutor) executeStep(workerID int, step Step) error {
+// executeStep executes a single step, returning an error if the step execution was not successful. It also returns
+// true if the step's StepCompleteFunc was called, even if the step failed.
+func (se *stepExecutor) executeStep(workerID int, step Step) (bool, error) {
 	var payload interface{}
 	events := se.opts.Events
 	if events != nil {
@@ -292,18 +386,24 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 		payload, err = events.OnResourceStepPre(step)
 		if err != nil {
 			se.log(workerID, "step %v on %v failed pre-resource step: %v", step.Op(), step.URN(), err)
-			return fmt.Errorf("pre-step event returned an error: %w", err)
+			return false, fmt.Errorf("pre-step event returned an error: %w", err)
 		}
 	}
 
-	se.log(workerID, "applying step %v on %v (preview %v)", step.Op(), step.URN(), se.preview)
-	status, stepComplete, err := step.Apply(se.preview)
+	// A failed before hook fails the step as if applying it had failed, so that the resource is left as it is.
+	beforeHook, afterHook, hooks := se.stepHooks(step)
+	status, stepComplete, err := resource.StatusOK, StepCompleteFunc(nil), se.runHooks(step, beforeHook, hooks)
+	if err == nil {
+		se.log(workerID, "applying step %v on %v (preview %v)", step.Op(), step.URN(), se.preview)
+		status, stepComplete, err = se.applyStep(workerID, step)
+	}
 
 	if err == nil {
 		// If we have a state object, and this is a create or update, remember it, as we may need to update it later.
 		if step.Logical() && step.New() != nil {
 			if prior, has := se.pendingNews.Load(step.URN()); has {
-				return fmt.Errorf("resource '%s' registered twice (%s and %s)", step.URN(), prior.(Step).Op(), step.Op())
+				return false, fmt.Errorf("resource '%s' registered twice (%s and %s)",
+					step.URN(), prior.(Step).Op(), step.Op())
 			}
 
 			se.pendingNews.Store(step.URN(), step)
@@ -361,7 +461,16 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 	if events != nil {
 		if postErr := events.OnResourceStepPost(payload, step, status, err); postErr != nil {
 			se.log(workerID, "step %v on %v failed post-resource step: %v", step.Op(), step.URN(), postErr)
-			return fmt.Errorf("post-step event returned an error: %w", postErr)
+			return false, fmt.Errorf("post-step event returned an error: %w", postErr)
+		}
+	}
+
+	// The after hooks run once the results of the step are saved, but before the resources that depend on this one
+	// can go ahead.
+	if err == nil {
+		if hookErr := se.runHooks(step, afterHook, hooks); hookErr != nil {
+			se.log(workerID, "step %v on %v failed after hook: %v", step.Op(), step.URN(), hookErr)
+			return false, hookErr
 		}
 	}
 
@@ -374,10 +483,45 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 
 	if err != nil {
 		se.log(workerID, "step %v on %v failed with an error: %v", step.Op(), step.URN(), err)
-		return errStepApplyFailed
+		return stepComplete != nil, errStepApplyFailed
 	}
 
-	return nil
+	return true, nil
+}
+
+// applyStep applies a step, retrying it with backoff for as long as it fails with errors that the retry policy of its
+// resource considers transient. Retries are abandoned if the deployment is canceled, in which case the last error is
+// returned.
+func (se *stepExecutor) applyStep(workerID int, step Step) (resource.Status, StepCompleteFunc, error) {
+	var policy RetryPolicy
+	if isRetryableOp(step.Op()) {
+		// URN globs compile lazily into a shared cache, so lookups must not race with each other.
+		se.retryLock.Lock()
+		policy = se.opts.retryPolicy(step.URN())
+		se.retryLock.Unlock()
+	}
+
+	for attempt := 1; ; attempt++ {
+		status, stepComplete, err := step.Apply(se.preview)
+		if attempt >= policy.MaxAttempts || !policy.retryable(status, err) {
+			return status, stepComplete, err
+		}
+
+		delay := policy.delay(attempt)
+		se.log(workerID, "step %v on %v failed with a transient error, retrying in %v (%d/%d): %v",
+			step.Op(), step.URN(), delay, attempt+1, policy.MaxAttempts, err)
+		if events := se.opts.Events; events != nil {
+			if retryErr := events.OnResourceStepRetry(step, attempt+1, policy.MaxAttempts, err); retryErr != nil {
+				return status, stepComplete, fmt.Errorf("retry event returned an error: %w", retryErr)
+			}
+		}
+
+		select {
+		case <-se.ctx.Done():
+			return status, stepComplete, err
+		case <-time.After(delay):
+		}
+	}
 }
 
 // log is a simple logging helper for the step executor.
@@ -406,7 +550,7 @@ func (se *stepExecutor) log(workerID int, msg string, args ...interface{}) {
 // and executes any that it gets from the channel. If `launchAsync` is true, worker launches a new goroutine
 // that will execute the chain so that the execution continues asynchronously and this worker can proceed to
 // the next chain.
-func (se *stepExecutor) worker(workerID int, launchAsync bool) {
+func (se *stepExecutor) worker(workerID int, launchAsync bool, stop <-chan struct{}) {
 	se.log(workerID, "worker coming online")
 	defer se.workers.Done()
 
@@ -442,6 +586,9 @@ func (se *stepExecutor) worker(workerID int, launchAsync bool) {
 		case <-se.ctx.Done():
 			se.log(workerID, "worker exiting due to cancellation")
 			return
+		case <-stop:
+			se.log(workerID, "stand-in worker exiting")
+			return
 		}
 	}
 }
@@ -455,6 +602,7 @@ func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment
 		preview:         preview,
 		continueOnError: continueOnError,
 		incomingChains:  make(chan incomingChain),
+		throttle:        newProviderThrottle(opts.ProviderConcurrency),
 		ctx:             ctx,
 		cancel:          cancel,
 	}
@@ -465,7 +613,7 @@ func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment
 	// asynchronously.
 	if opts.InfiniteParallelism() {
 		exec.workers.Add(1)
-		go exec.worker(infiniteWorkerID, true /*launchAsync*/)
+		go exec.worker(infiniteWorkerID, true /*launchAsync*/, nil /*stop*/)
 		return exec
 	}
 
@@ -473,7 +621,7 @@ func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment
 	fanout := opts.DegreeOfParallelism()
 	for i := 0; i < fanout; i++ {
 		exec.workers.Add(1)
-		go exec.worker(i, false /*launchAsync*/)
+		go exec.worker(i, false /*launchAsync*/, nil /*stop*/)
 	}
 
 	return exec
@@ -59,6 +59,8 @@ type stepGenerator struct {
 	// specify them with --target
 	skippedCreates map[resource.URN]bool
 
+	excludes map[resource.URN]bool // set of URNs that were left as they are because they were excluded
+
 	pendingDeletes map[*resource.State]bool         // set of resources (not URNs!) that are pending deletion
 	providers      map[resource.URN]*resource.State // URN map of providers that we have seen so far.
 
@@ -114,6 +116,67 @@ func (sg *stepGenerator) Errored() bool {
 	return sg.sawError
 }
 
+// findDependency returns the first resource the given resource depends on, either as its parent, its provider, or as
+// a dependency of its properties, for which the given predicate returns true.
+func findDependency(res *resource.State, pred func(resource.URN) bool) (resource.URN, bool) {
+	deps := []resource.URN{res.Parent}
+	if res.Provider != "" {
+		ref, err := providers.ParseReference(res.Provider)
+		contract.AssertNoErrorf(err, "failed to parse provider reference: %v", res.Provider)
+		deps = append(deps, ref.URN())
+	}
+	deps = append(deps, res.Dependencies...)
+	for _, propDeps := range res.PropertyDependencies {
+		deps = append(deps, propDeps...)
+	}
+
+	for _, dep := range deps {
+		if dep != "" && pred(dep) {
+			return dep, true
+		}
+	}
+	return "", false
+}
+
+// failedDependency returns the first resource the given resource depends on that failed or was skipped during this
+// deployment.
+func (sg *stepGenerator) failedDependency(res *resource.State) (resource.URN, bool) {
+	return findDependency(res, func(dep resource.URN) bool {
+		_, failed := sg.deployment.failures.get(dep)
+		return failed
+	})
+}
+
+// isExcluded returns true if the given resource is excluded from this deployment, either because it was excluded
+// explicitly or because it depends on an excluded resource and dependents are excluded too.
+func (sg *stepGenerator) isExcluded(res *resource.State) bool {
+	if sg.opts.isExcluded(res.URN) {
+		return true
+	}
+	if !sg.opts.ExcludeDependents {
+		return false
+	}
+	_, excluded := findDependency(res, func(dep resource.URN) bool { return sg.excludes[dep] })
+	return excluded
+}
+
+// skipResource returns the steps for a resource that is left as it is. A resource that already exists keeps its old
+// state, and one that doesn't isn't created.
+func (sg *stepGenerator) skipResource(event RegisterResourceEvent, old, new *resource.State) []Step {
+	urn := new.URN
+	sg.sames[urn] = true
+
+	if old == nil {
+		sg.skippedCreates[urn] = true
+		return []Step{NewSkippedCreateStep(sg.deployment, event, new)}
+	}
+
+	// Keep the old state rather than the new goal, so that the resource is updated next time.
+	skipped := *old
+	skipped.URN, skipped.ID, skipped.Aliases = urn, "", new.Aliases
+	return []Step{NewSameStep(sg.deployment, event, old, &skipped)}
+}
+
 // checkParent checks that the parent given is valid for the given resource type, and returns a default parent
 // if there is one.
 func (sg *stepGenerator) checkParent(parent resource.URN, resourceType tokens.Type) (resource.URN, result.Result) {
@@ -511,6 +574,21 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 		}
 	}
 
+	// If the resource is new, it may be an old resource that was renamed without an alias.
+	if !hasOld && sg.opts.AutoAlias {
+		if renamed, ok := sg.autoAlias(urn, goal); ok {
+			old, hasOld = renamed, true
+			oldInputs, oldOutputs = old.Inputs, old.Outputs
+			createdAt, modifiedAt = old.Created, old.Modified
+
+			sg.aliased[old.URN] = urn
+			sg.deployment.providers.RegisterAlias(urn, old.URN)
+			alias = []resource.Alias{{URN: old.URN}}
+			sg.aliases[urn] = old.URN
+			sg.deployment.Diag().Infof(diag.GetResourceAutoAliased(urn), urn, old.URN)
+		}
+	}
+
 	// Create the desired inputs from the goal state
 	inputs := goal.Properties
 	if hasOld {
@@ -533,6 +611,7 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 		goal.Dependencies, goal.InitErrors, goal.Provider, goal.PropertyDependencies, false,
 		goal.AdditionalSecretOutputs, aliasUrns, &goal.CustomTimeouts, "", goal.RetainOnDelete, goal.DeletedWith,
 		createdAt, modifiedAt)
+	new.Hooks = goal.Hooks
 
 	// Mark the URN/resource as having been seen. So we can run analyzers on all resources seen, as well as
 	// lookup providers for calculating replacement of resources that use the provider.
@@ -541,6 +620,40 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 		sg.providers[urn] = new
 	}
 
+	// If we're continuing after errors and a resource this one depends on failed, leave this one as it is.
+	if sg.opts.ContinueOnError {
+		if cause, failed := sg.failedDependency(new); failed {
+			logging.V(7).Infof("Planner decided to skip '%v' because '%v' failed", urn, cause)
+			sg.deployment.failures.skip(urn, cause)
+			return sg.skipResource(event, old, new), nil
+		}
+	}
+
+	// If the user excluded this resource, leave it as it is too.
+	if sg.opts.Excludes.IsConstrained() {
+		if sg.isExcluded(new) {
+			logging.V(7).Infof("Planner decided not to update '%v' due to being excluded (same)", urn)
+			sg.excludes[urn] = true
+			return sg.skipResource(event, old, new), nil
+		}
+
+		// A resource can't be created or updated if it depends on an excluded resource that doesn't exist yet.
+		missing, has := findDependency(new, func(dep resource.URN) bool {
+			return sg.excludes[dep] && sg.skippedCreates[dep]
+		})
+		if has {
+			sg.deployment.Diag().Errorf(diag.GetResourceDependsOnExcludedResource(urn), urn, missing)
+			sg.sawError = true
+			if !sg.deployment.preview {
+				return nil, result.Bail()
+			}
+
+			// In preview we keep going, so that the user hears about all of the problems at once.
+			sg.excludes[urn] = true
+			return sg.skipResource(event, old, new), nil
+		}
+	}
+
 	// Fetch the provider for this resource.
 	prov, res := sg.loadResourceProvider(urn, goal.Custom, goal.Provider, goal.Type)
 	if res != nil {
@@ -1165,6 +1278,21 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt UrnTargets) ([]Step, result.
 		dels = filtered
 	}
 
+	// Never delete excluded resources, nor the resources they depend on.
+	if sg.opts.Excludes.IsConstrained() {
+		kept := sg.getExcludedResources()
+		filtered := []Step{}
+		for _, step := range dels {
+			if kept[step.URN()] {
+				logging.V(7).Infof("Planner decided not to delete '%v' due to being excluded", step.URN())
+				continue
+			}
+			filtered = append(filtered, step)
+		}
+
+		dels = filtered
+	}
+
 	deletingUnspecifiedTarget := false
 	for _, step := range dels {
 		urn := step.URN()
@@ -1193,6 +1321,8 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt UrnTargets) ([]Step, result.
 		return nil, result.Bail()
 	}
 
+	sg.suggestAliases(dels)
+
 	return dels, nil
 }
 
@@ -1230,6 +1360,57 @@ func (sg *stepGenerator) getTargetDependents(targetsOpt UrnTargets) map[resource
 	return targets
 }
 
+// excludedResources returns the set of the given resources that are excluded by the options, including the resources
+// that depend on excluded ones, either implicitly, explicitly, or as children, if dependents are excluded too.
+func excludedResources(opts Options, resources []*resource.State) map[resource.URN]bool {
+	excluded := make(map[resource.URN]bool)
+	if !opts.Excludes.IsConstrained() {
+		return excluded
+	}
+
+	var frontier []*resource.State
+	for _, res := range resources {
+		if opts.isExcluded(res.URN) {
+			frontier = append(frontier, res)
+		}
+	}
+
+	dg := graph.NewDependencyGraph(resources)
+	for len(frontier) > 0 {
+		next := frontier[0]
+		frontier = frontier[1:]
+		if excluded[next.URN] {
+			continue
+		}
+		excluded[next.URN] = true
+
+		if opts.ExcludeDependents {
+			frontier = append(frontier, dg.DependingOn(next, excluded, true)...)
+		}
+	}
+
+	return excluded
+}
+
+// getExcludedResources returns the set of old resources that must not be deleted because they are excluded, or
+// because an excluded resource depends on them.
+func (sg *stepGenerator) getExcludedResources() map[resource.URN]bool {
+	prev := sg.deployment.prev.Resources
+	excluded := excludedResources(sg.opts, prev)
+
+	dg := graph.NewDependencyGraph(prev)
+	kept := make(map[resource.URN]bool)
+	for _, res := range prev {
+		if excluded[res.URN] || sg.excludes[res.URN] {
+			kept[res.URN] = true
+			for dep := range dg.TransitiveDependenciesOf(res) {
+				kept[dep.URN] = true
+			}
+		}
+	}
+	return kept
+}
+
 // determineAllowedResourcesToDeleteFromTargets computes the full (transitive) closure of resources
 // that need to be deleted to permit the full list of targetsOpt resources to be deleted. This list
 // will include the targetsOpt resources, but may contain more than just that, if there are dependent
@@ -1936,6 +2117,7 @@ func newStepGenerator(
 		updates:              make(map[resource.URN]bool),
 		deletes:              make(map[resource.URN]bool),
 		skippedCreates:       make(map[resource.URN]bool),
+		excludes:             make(map[resource.URN]bool),
 		pendingDeletes:       make(map[*resource.State]bool),
 		providers:            make(map[resource.URN]*resource.State),
 		dependentReplaceKeys: make(map[resource.URN][]resource.PropertyKey),
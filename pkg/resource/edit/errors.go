@@ -39,3 +39,14 @@ type ResourceProtectedError struct {
 func (ResourceProtectedError) Error() string {
 	return "Can't delete protected resource"
 }
+
+// ResourceHasDependentsError is returned by MoveResources if a resource can't be moved because resources that are
+// staying behind in the source snapshot depend on it.
+type ResourceHasDependentsError struct {
+	Resource   *resource.State
+	Dependents []*resource.State
+}
+
+func (r ResourceHasDependentsError) Error() string {
+	return fmt.Sprintf("Can't move resource %q due to dependent resources", r.Resource.URN)
+}
@@ -16,6 +16,7 @@ package edit
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
@@ -129,12 +130,87 @@ func DeleteResource(
 	return nil
 }
 
+// DeleteResources deletes the given resources from the snapshot, if it is possible to do so, as DeleteResource does
+// for each of them. Resources that depend on each other can be deleted together; the resources are deleted from the
+// last in the snapshot to the first, so each resource's dependents among them are gone by the time it is deleted.
+func DeleteResources(
+	snapshot *deploy.Snapshot, condemned []*resource.State,
+	onProtected func(*resource.State) error, targetDependents bool,
+) error {
+	contract.Requiref(snapshot != nil, "snapshot", "must not be nil")
+
+	condemnedSet := make(map[*resource.State]bool, len(condemned))
+	for _, res := range condemned {
+		condemnedSet[res] = true
+	}
+	for i := len(snapshot.Resources) - 1; i >= 0; i-- {
+		// Deleting a resource's dependents can remove more than one resource, so the index may be out of range.
+		if i >= len(snapshot.Resources) {
+			continue
+		}
+		if res := snapshot.Resources[i]; condemnedSet[res] {
+			if err := DeleteResource(snapshot, res, onProtected, targetDependents); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ProtectResource protects a resource.
+func ProtectResource(_ *deploy.Snapshot, res *resource.State) error {
+	res.Protect = true
+	return nil
+}
+
 // UnprotectResource unprotects a resource.
 func UnprotectResource(_ *deploy.Snapshot, res *resource.State) error {
 	res.Protect = false
 	return nil
 }
 
+// SelectResources returns the resources in the given snapshot whose URNs match any of the given URNs or URN globs, as
+// deploy.UrnTargets matches them, and whose type is one of the given types. No URNs match every resource and no types
+// match every type. The resources are returned in the order that they appear in the snapshot. It is an error for any
+// URN or glob to match no resources at all.
+func SelectResources(snap *deploy.Snapshot, urnOrGlobs []string, types []tokens.Type) ([]*resource.State, error) {
+	if snap == nil {
+		if len(urnOrGlobs) > 0 {
+			return nil, fmt.Errorf("no resources in the current state match %q", urnOrGlobs[0])
+		}
+		return nil, nil
+	}
+
+	targets := make([]deploy.UrnTargets, len(urnOrGlobs))
+	for i, urnOrGlob := range urnOrGlobs {
+		targets[i] = deploy.NewUrnTargets([]string{urnOrGlob})
+	}
+	typeSet := make(map[tokens.Type]bool, len(types))
+	for _, t := range types {
+		typeSet[t] = true
+	}
+
+	var resources []*resource.State
+	matched := make([]bool, len(urnOrGlobs))
+	for _, res := range snap.Resources {
+		found := len(targets) == 0
+		for i, target := range targets {
+			if target.Contains(res.URN) {
+				matched[i], found = true, true
+			}
+		}
+		if found && (len(typeSet) == 0 || typeSet[res.Type]) {
+			resources = append(resources, res)
+		}
+	}
+	for i, urnOrGlob := range urnOrGlobs {
+		if !matched[i] {
+			return nil, fmt.Errorf("no resources in the current state match %q", urnOrGlob)
+		}
+	}
+	return resources, nil
+}
+
 // LocateResource returns all resources in the given snapshot that have the given URN.
 func LocateResource(snap *deploy.Snapshot, urn resource.URN) []*resource.State {
 	// If there is no snapshot then return no resources
@@ -152,6 +228,93 @@ func LocateResource(snap *deploy.Snapshot, urn resource.URN) []*resource.State {
 	return resources
 }
 
+// ResolvePendingCreate records the ID of the resource made by the pending create of the resource with the given URN.
+// The create becomes a pending import, which a refresh that resolves pending creates reads from the resource's
+// provider.
+func ResolvePendingCreate(snap *deploy.Snapshot, urn resource.URN, id resource.ID) error {
+	contract.Requiref(snap != nil, "snap", "must not be nil")
+	contract.Requiref(id != "", "id", "must not be empty")
+
+	for i, op := range snap.PendingOperations {
+		if op.Resource.URN != urn {
+			continue
+		}
+		if !deploy.IsPendingCreate(op) {
+			return fmt.Errorf("the pending operation on %q is not a create, but %s", urn, op.Type)
+		}
+
+		res := *op.Resource
+		res.ID = id
+		snap.PendingOperations[i] = resource.NewOperation(&res, resource.OperationTypeImporting)
+		return nil
+	}
+	return fmt.Errorf("no pending create of %q exists in the current state", urn)
+}
+
+// ClearPendingOperations removes the pending operations on the resources with the given URNs from the snapshot, or all
+// of its pending operations if no URNs are given. It returns the operations that were removed.
+func ClearPendingOperations(snap *deploy.Snapshot, urns []resource.URN) ([]resource.Operation, error) {
+	contract.Requiref(snap != nil, "snap", "must not be nil")
+
+	toClear := make(map[resource.URN]bool, len(urns))
+	for _, urn := range urns {
+		toClear[urn] = true
+	}
+
+	var kept, cleared []resource.Operation
+	found := make(map[resource.URN]bool, len(urns))
+	for _, op := range snap.PendingOperations {
+		if len(urns) == 0 || toClear[op.Resource.URN] {
+			cleared = append(cleared, op)
+			found[op.Resource.URN] = true
+		} else {
+			kept = append(kept, op)
+		}
+	}
+	for _, urn := range urns {
+		if !found[urn] {
+			return nil, fmt.Errorf("no pending operation on %q exists in the current state", urn)
+		}
+	}
+
+	snap.PendingOperations = kept
+	return cleared, nil
+}
+
+// VerifyURNs checks that the URNs in the given snapshot are consistent with the stack and project that it belongs to,
+// and with the resources that they name. This catches mistakes that are easy to make when editing a snapshot by hand,
+// which VerifyIntegrity doesn't look for. An empty project matches any project.
+func VerifyURNs(snap *deploy.Snapshot, stackName tokens.QName, project tokens.PackageName) error {
+	contract.Requiref(snap != nil, "snap", "must not be nil")
+
+	verify := func(res *resource.State) error {
+		urn := res.URN
+		switch {
+		case !urn.IsValid():
+			return fmt.Errorf("resource %q has a malformed URN", urn)
+		case urn.Stack() != stackName:
+			return fmt.Errorf("resource %s belongs to a different stack (%s != %s)", urn, urn.Stack(), stackName)
+		case project != "" && urn.Project() != project:
+			return fmt.Errorf("resource %s belongs to a different project (%s != %s)", urn, urn.Project(), project)
+		case urn.Type() != res.Type:
+			return fmt.Errorf("resource %s has a type that doesn't match its URN (%s)", urn, res.Type)
+		}
+		return nil
+	}
+
+	for _, res := range snap.Resources {
+		if err := verify(res); err != nil {
+			return err
+		}
+	}
+	for _, op := range snap.PendingOperations {
+		if err := verify(op.Resource); err != nil {
+			return fmt.Errorf("pending %s: %w", op.Type, err)
+		}
+	}
+	return nil
+}
+
 // RenameStack changes the `stackName` component of every URN in a snapshot. In addition, it rewrites the name of
 // the root Stack resource itself. May optionally change the project/package name as well.
 func RenameStack(snap *deploy.Snapshot, newName tokens.Name, newProject tokens.PackageName) error {
@@ -216,3 +379,253 @@ func RenameStack(snap *deploy.Snapshot, newName tokens.Name, newProject tokens.P
 
 	return nil
 }
+
+// MoveResult describes the resources that were transferred by a call to MoveResources.
+type MoveResult struct {
+	// Resources are the resources that were moved, with their URNs rewritten for the destination stack.
+	Resources []*resource.State
+	// Providers are the default providers that were copied into the destination snapshot.
+	Providers []*resource.State
+	// BrokenDependencies maps the original URN of a moved resource to the dependencies it had on resources that were
+	// not moved. These dependencies can't be represented in the destination snapshot and are dropped.
+	BrokenDependencies map[resource.URN][]resource.URN
+}
+
+// MoveResources moves the given resources out of the source snapshot and into the destination snapshot, rewriting
+// their URNs (and all references to them) to belong to the given stack and project. Both snapshots are edited
+// in-place.
+//
+// If includeDependents is true, every resource that depends on or is a child of one of the given resources is moved
+// as well. Otherwise an error instance of `ResourceHasDependentsError` is returned if any such resource exists.
+//
+// Children of the source's root stack resource are re-parented to the destination's root stack resource, or left
+// without a parent if the destination doesn't have one. Default
+// providers used by moved resources are copied into the destination snapshot unless it already has a default
+// provider with the same URN, in which case that provider is used instead.
+func MoveResources(
+	source, dest *deploy.Snapshot, resources []*resource.State,
+	destStack tokens.QName, destProject tokens.PackageName, includeDependents bool,
+) (*MoveResult, error) {
+	contract.Requiref(source != nil, "source", "must not be nil")
+	contract.Requiref(dest != nil, "dest", "must not be nil")
+
+	if err := source.VerifyIntegrity(); err != nil {
+		return nil, fmt.Errorf("source checkpoint is invalid: %w", err)
+	}
+	if err := dest.VerifyIntegrity(); err != nil {
+		return nil, fmt.Errorf("destination checkpoint is invalid: %w", err)
+	}
+
+	// Work out the full set of resources to move.
+	dg := graph.NewDependencyGraph(source.Resources)
+	moving := make(map[resource.URN]bool)
+	for _, res := range resources {
+		if res.Type == resource.RootStackType {
+			return nil, fmt.Errorf("the root stack resource %s can't be moved", res.URN)
+		}
+		moving[res.URN] = true
+		if includeDependents {
+			for _, dep := range dg.DependingOn(res, nil, true) {
+				moving[dep.URN] = true
+			}
+		}
+	}
+
+	var sourceRoot, destRoot resource.URN
+	for _, res := range source.Resources {
+		if res.Type == resource.RootStackType && res.Parent == "" {
+			sourceRoot = res.URN
+			break
+		}
+	}
+	destURNs := make(map[resource.URN]bool)
+	destProviders := make(map[resource.URN]*resource.State)
+	for _, res := range dest.Resources {
+		destURNs[res.URN] = true
+		if res.Type == resource.RootStackType && res.Parent == "" && destRoot == "" {
+			destRoot = res.URN
+		}
+		if providers.IsProviderType(res.Type) && !res.Delete {
+			destProviders[res.URN] = res
+		}
+	}
+
+	// Without a root stack in the destination, the children of the source's root stack are left without a parent, so
+	// the root stack's type is dropped from the qualified types in their URNs and those of their descendants.
+	rootPrefix := string(resource.RootStackType) + resource.URNTypeDelimiter
+	rewriteURN := func(u resource.URN) resource.URN {
+		typ := u.QualifiedType()
+		if sourceRoot != "" && destRoot == "" {
+			typ = tokens.Type(strings.TrimPrefix(string(typ), rootPrefix))
+		}
+		return resource.NewURN(destStack, destProject, "", typ, u.Name())
+	}
+
+	// Validate the move before touching either snapshot so that a failure leaves them both intact.
+	var moved []*resource.State
+	movedSet := make(map[*resource.State]bool)
+	for _, res := range source.Resources {
+		if !moving[res.URN] {
+			continue
+		}
+		moved = append(moved, res)
+		movedSet[res] = true
+
+		var staying []*resource.State
+		for _, dep := range dg.DependingOn(res, nil, true) {
+			if !moving[dep.URN] {
+				staying = append(staying, dep)
+			}
+		}
+		if len(staying) != 0 {
+			return nil, ResourceHasDependentsError{Resource: res, Dependents: staying}
+		}
+
+		if res.Parent != "" && !moving[res.Parent] && res.Parent != sourceRoot {
+			return nil, fmt.Errorf("resource %s has parent %s, which is not being moved", res.URN, res.Parent)
+		}
+
+		if res.Provider != "" {
+			ref, err := providers.ParseReference(res.Provider)
+			contract.AssertNoErrorf(err, "failed to parse provider reference from validated checkpoint")
+			if !moving[ref.URN()] && !providers.IsDefaultProvider(ref.URN()) {
+				return nil, fmt.Errorf("resource %s uses provider %s, which is not being moved", res.URN, ref.URN())
+			}
+		}
+
+		if destURNs[rewriteURN(res.URN)] {
+			return nil, fmt.Errorf("resource %s already exists in the destination stack", rewriteURN(res.URN))
+		}
+	}
+
+	result := &MoveResult{BrokenDependencies: make(map[resource.URN][]resource.URN)}
+
+	// Default providers are shared between resources, so only copy each of them once.
+	copiedProviders := make(map[providers.Reference]providers.Reference)
+	copiedSources := make(map[providers.Reference]bool)
+	rewriteProvider := func(provider string) string {
+		ref, err := providers.ParseReference(provider)
+		contract.AssertNoErrorf(err, "failed to parse provider reference from validated checkpoint")
+
+		if moving[ref.URN()] {
+			newRef, err := providers.NewReference(rewriteURN(ref.URN()), ref.ID())
+			contract.AssertNoErrorf(err, "failed to generate provider reference from valid reference")
+			return newRef.String()
+		}
+
+		if newRef, has := copiedProviders[ref]; has {
+			return newRef.String()
+		}
+
+		newURN := rewriteURN(ref.URN())
+		if existing, has := destProviders[newURN]; has {
+			newRef, err := providers.NewReference(existing.URN, existing.ID)
+			contract.AssertNoErrorf(err, "failed to generate provider reference from valid reference")
+			copiedProviders[ref] = newRef
+			return newRef.String()
+		}
+
+		for _, res := range source.Resources {
+			if res.URN != ref.URN() || res.ID != ref.ID() {
+				continue
+			}
+			copied := *res
+			copied.URN = newURN
+			result.Providers = append(result.Providers, &copied)
+			destProviders[newURN] = &copied
+			break
+		}
+		contract.Assertf(destProviders[newURN] != nil, "provider %s not found in validated checkpoint", ref)
+
+		newRef, err := providers.NewReference(newURN, ref.ID())
+		contract.AssertNoErrorf(err, "failed to generate provider reference from valid reference")
+		copiedProviders[ref] = newRef
+		copiedSources[ref] = true
+		return newRef.String()
+	}
+
+	rewriteDependencies := func(res *resource.State, deps []resource.URN, record bool) []resource.URN {
+		var kept []resource.URN
+		for _, dep := range deps {
+			if moving[dep] {
+				kept = append(kept, rewriteURN(dep))
+			} else if record {
+				result.BrokenDependencies[res.URN] = append(result.BrokenDependencies[res.URN], dep)
+			}
+		}
+		return kept
+	}
+
+	for _, res := range moved {
+		res.Dependencies = rewriteDependencies(res, res.Dependencies, true)
+		for key, deps := range res.PropertyDependencies {
+			res.PropertyDependencies[key] = rewriteDependencies(res, deps, false)
+		}
+
+		switch {
+		case res.Parent == "":
+		case moving[res.Parent]:
+			res.Parent = rewriteURN(res.Parent)
+		default:
+			res.Parent = destRoot
+		}
+
+		if res.Provider != "" {
+			res.Provider = rewriteProvider(res.Provider)
+		}
+
+		if res.DeletedWith != "" {
+			if moving[res.DeletedWith] {
+				res.DeletedWith = rewriteURN(res.DeletedWith)
+			} else {
+				res.DeletedWith = ""
+			}
+		}
+
+		for i, alias := range res.Aliases {
+			res.Aliases[i] = rewriteURN(alias)
+		}
+
+		res.URN = rewriteURN(res.URN)
+	}
+	result.Resources = moved
+
+	// Remove the moved resources from the source snapshot, along with any default providers that were copied and are
+	// no longer used by the resources that stay behind.
+	inUse := make(map[providers.Reference]bool)
+	remaining := make([]*resource.State, 0, len(source.Resources)-len(moved))
+	for _, res := range source.Resources {
+		if movedSet[res] {
+			continue
+		}
+		remaining = append(remaining, res)
+		if res.Provider != "" {
+			ref, err := providers.ParseReference(res.Provider)
+			contract.AssertNoErrorf(err, "failed to parse provider reference from validated checkpoint")
+			inUse[ref] = true
+		}
+	}
+	source.Resources = remaining[:0]
+	for _, res := range remaining {
+		if providers.IsProviderType(res.Type) {
+			ref, err := providers.NewReference(res.URN, res.ID)
+			contract.AssertNoErrorf(err, "failed to generate provider reference from valid reference")
+			if copiedSources[ref] && !inUse[ref] {
+				continue
+			}
+		}
+		source.Resources = append(source.Resources, res)
+	}
+
+	dest.Resources = append(dest.Resources, result.Providers...)
+	dest.Resources = append(dest.Resources, moved...)
+
+	if err := source.VerifyIntegrity(); err != nil {
+		return nil, fmt.Errorf("moving resources produced an invalid source checkpoint: %w", err)
+	}
+	if err := dest.VerifyIntegrity(); err != nil {
+		return nil, fmt.Errorf("moving resources produced an invalid destination checkpoint: %w", err)
+	}
+
+	return result, nil
+}
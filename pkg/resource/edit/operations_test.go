@@ -400,6 +400,118 @@ func TestUnprotectResource(t *testing.T) {
 	assert.False(t, a.Protect)
 }
 
+func TestProtectResource(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+	})
+
+	err := ProtectResource(snap, a)
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.State{pA, a}, snap.Resources)
+	assert.True(t, a.Protect)
+}
+
+func TestDeleteResources(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA, b.URN)
+	d := NewResource("d", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+		c,
+		d,
+	})
+
+	// b can't be deleted without c, which depends on it.
+	err := DeleteResources(snap, []*resource.State{a, b}, nil, false)
+	var depErr ResourceHasDependenciesError
+	require.ErrorAs(t, err, &depErr)
+	assert.Equal(t, b, depErr.Condemned)
+	assert.Equal(t, []*resource.State{c}, depErr.Dependencies)
+
+	snap = NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+		c,
+		d,
+	})
+	err = DeleteResources(snap, []*resource.State{a, b, c}, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []*resource.State{pA, d}, snap.Resources)
+}
+
+func TestDeleteResourcesTargetDependents(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA, b.URN)
+	d := NewResource("d", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+		c,
+		d,
+	})
+
+	err := DeleteResources(snap, []*resource.State{a, c, d}, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []*resource.State{pA}, snap.Resources)
+}
+
+func TestSelectResources(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	b.Type = "a:b:d"
+	b.URN = resource.NewURN("test", "test", "", b.Type, "b")
+	c := NewResource("c", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+		c,
+	})
+
+	all, err := SelectResources(snap, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, snap.Resources, all)
+
+	globbed, err := SelectResources(snap, []string{"urn:pulumi:test::test::a:b:*::*"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*resource.State{a, b, c}, globbed)
+
+	typed, err := SelectResources(snap, []string{"urn:pulumi:test::test::**"}, []tokens.Type{"a:b:c"})
+	require.NoError(t, err)
+	assert.Equal(t, []*resource.State{a, c}, typed)
+
+	ofType, err := SelectResources(snap, nil, []tokens.Type{"a:b:d"})
+	require.NoError(t, err)
+	assert.Equal(t, []*resource.State{b}, ofType)
+
+	literals, err := SelectResources(snap, []string{string(c.URN), string(a.URN)}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*resource.State{a, c}, literals)
+
+	_, err = SelectResources(snap, []string{string(a.URN), "urn:pulumi:test::test::x:y:z::*"}, nil)
+	assert.ErrorContains(t, err, `no resources in the current state match "urn:pulumi:test::test::x:y:z::*"`)
+}
+
 func TestLocateResourceNotFound(t *testing.T) {
 	t.Parallel()
 
@@ -526,3 +638,344 @@ func TestRenameStack(t *testing.T) {
 		assert.Len(t, LocateResource(snap, updatedResourceURN), 1)
 	})
 }
+
+func TestMoveResources(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "default", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA)
+	source := NewSnapshot([]*resource.State{pA, a, b, c})
+	dest := NewSnapshot(nil)
+
+	res, err := MoveResources(source, dest, []*resource.State{b}, "dest", "proj", false)
+	require.NoError(t, err)
+
+	// b depended on a, which stays behind, so that dependency is dropped.
+	assert.Equal(t, map[resource.URN][]resource.URN{
+		resource.NewURN("test", "test", "", "a:b:c", "b"): {a.URN},
+	}, res.BrokenDependencies)
+
+	// The default provider is copied over, and kept in the source since a and c still use it.
+	require.Len(t, res.Providers, 1)
+	assert.Equal(t, resource.NewURN("dest", "proj", "", pA.Type, "default"), res.Providers[0].URN)
+	assert.Equal(t, []*resource.State{pA, a, c}, source.Resources)
+	assert.Equal(t, []*resource.State{res.Providers[0], b}, dest.Resources)
+
+	assert.Equal(t, resource.NewURN("dest", "proj", "", "a:b:c", "b"), b.URN)
+	assert.Empty(t, b.Dependencies)
+	ref, err := providers.ParseReference(b.Provider)
+	require.NoError(t, err)
+	assert.Equal(t, res.Providers[0].URN, ref.URN())
+	assert.Equal(t, pA.ID, ref.ID())
+}
+
+func TestMoveResourcesWithDependents(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "default", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA)
+	c.Parent = b.URN
+
+	t.Run("Rejected", func(t *testing.T) {
+		t.Parallel()
+
+		a, b, c := *a, *b, *c
+		source := NewSnapshot([]*resource.State{pA, &a, &b, &c})
+		dest := NewSnapshot(nil)
+
+		_, err := MoveResources(source, dest, []*resource.State{&a}, "dest", "proj", false)
+		var depErr ResourceHasDependentsError
+		require.ErrorAs(t, err, &depErr)
+		assert.Equal(t, &a, depErr.Resource)
+		assert.Equal(t, []*resource.State{&b, &c}, depErr.Dependents)
+
+		// Neither snapshot is touched.
+		assert.Equal(t, []*resource.State{pA, &a, &b, &c}, source.Resources)
+		assert.Empty(t, dest.Resources)
+	})
+
+	t.Run("Included", func(t *testing.T) {
+		t.Parallel()
+
+		a, b, c := *a, *b, *c
+		source := NewSnapshot([]*resource.State{pA, &a, &b, &c})
+		dest := NewSnapshot(nil)
+
+		res, err := MoveResources(source, dest, []*resource.State{&a}, "dest", "proj", true)
+		require.NoError(t, err)
+		assert.Empty(t, res.BrokenDependencies)
+
+		// Nothing is left using the default provider, so it is removed from the source.
+		assert.Empty(t, source.Resources)
+		assert.Equal(t, []*resource.State{res.Providers[0], &a, &b, &c}, dest.Resources)
+		assert.Equal(t, []resource.URN{a.URN}, b.Dependencies)
+		assert.Equal(t, b.URN, c.Parent)
+		assert.Equal(t, resource.NewURN("dest", "proj", "", "a:b:c", "c"), c.URN)
+	})
+}
+
+func TestMoveResourcesReusesDestinationProvider(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "default", "0")
+	a := NewResource("a", pA)
+	source := NewSnapshot([]*resource.State{pA, a})
+
+	pB := NewProviderResource("a", "default", "1")
+	pB.URN = resource.NewURN("dest", "proj", "", pB.Type, "default")
+	dest := NewSnapshot([]*resource.State{pB})
+
+	res, err := MoveResources(source, dest, []*resource.State{a}, "dest", "proj", false)
+	require.NoError(t, err)
+	assert.Empty(t, res.Providers)
+	assert.Equal(t, []*resource.State{pB, a}, dest.Resources)
+
+	ref, err := providers.ParseReference(a.Provider)
+	require.NoError(t, err)
+	assert.Equal(t, pB.URN, ref.URN())
+	assert.Equal(t, pB.ID, ref.ID())
+}
+
+func TestMoveResourcesRootStackChildren(t *testing.T) {
+	t.Parallel()
+
+	stackType := resource.RootStackType
+	newStack := func(stack tokens.QName, project tokens.PackageName) *resource.State {
+		return &resource.State{
+			Type: stackType,
+			URN:  resource.NewURN(stack, project, "", stackType, tokens.QName(string(project)+"-"+string(stack))),
+		}
+	}
+	newSource := func() (*deploy.Snapshot, *resource.State, *resource.State) {
+		root := newStack("test", "test")
+		a := NewResource("a", nil)
+		a.Parent = root.URN
+		a.URN = resource.NewURN("test", "test", stackType, a.Type, "a")
+		b := NewResource("b", nil)
+		b.Parent = a.URN
+		b.URN = resource.NewURN("test", "test", a.URN.QualifiedType(), b.Type, "b")
+		return NewSnapshot([]*resource.State{root, a, b}), a, b
+	}
+
+	t.Run("DestinationRoot", func(t *testing.T) {
+		t.Parallel()
+
+		source, a, b := newSource()
+		destRoot := newStack("dest", "proj")
+		dest := NewSnapshot([]*resource.State{destRoot})
+
+		_, err := MoveResources(source, dest, []*resource.State{a}, "dest", "proj", true)
+		require.NoError(t, err)
+		assert.Equal(t, destRoot.URN, a.Parent)
+		assert.Equal(t, resource.NewURN("dest", "proj", stackType, "a:b:c", "a"), a.URN)
+		assert.Equal(t, a.URN, b.Parent)
+		assert.Equal(t, resource.NewURN("dest", "proj", stackType+"$a:b:c", "a:b:c", "b"), b.URN)
+	})
+
+	t.Run("NoDestinationRoot", func(t *testing.T) {
+		t.Parallel()
+
+		// Without a root stack to re-parent to, the root stack's type is dropped from the URNs.
+		source, a, b := newSource()
+		dest := NewSnapshot(nil)
+
+		_, err := MoveResources(source, dest, []*resource.State{a}, "dest", "proj", true)
+		require.NoError(t, err)
+		assert.Empty(t, a.Parent)
+		assert.Equal(t, resource.NewURN("dest", "proj", "", "a:b:c", "a"), a.URN)
+		assert.Equal(t, a.URN, b.Parent)
+		assert.Equal(t, resource.NewURN("dest", "proj", "a:b:c", "a:b:c", "b"), b.URN)
+		assert.NoError(t, VerifyURNs(dest, "dest", "proj"))
+	})
+}
+
+func TestMoveResourcesFailures(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ExplicitProvider", func(t *testing.T) {
+		t.Parallel()
+
+		pA := NewProviderResource("a", "p1", "0")
+		a := NewResource("a", pA)
+		source := NewSnapshot([]*resource.State{pA, a})
+
+		_, err := MoveResources(source, NewSnapshot(nil), []*resource.State{a}, "dest", "proj", false)
+		assert.ErrorContains(t, err, "which is not being moved")
+	})
+
+	t.Run("Parent", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewResource("a", nil)
+		b := NewResource("b", nil)
+		b.Parent = a.URN
+		source := NewSnapshot([]*resource.State{a, b})
+
+		_, err := MoveResources(source, NewSnapshot(nil), []*resource.State{b}, "dest", "proj", false)
+		assert.ErrorContains(t, err, "which is not being moved")
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewResource("a", nil)
+		source := NewSnapshot([]*resource.State{a})
+		existing := NewResource("a", nil)
+		existing.URN = resource.NewURN("dest", "proj", "", existing.Type, "a")
+
+		_, err := MoveResources(source, NewSnapshot([]*resource.State{existing}), []*resource.State{a},
+			"dest", "proj", false)
+		assert.ErrorContains(t, err, "already exists in the destination stack")
+	})
+}
+
+func TestResolvePendingCreate(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	snap := NewSnapshot([]*resource.State{pA})
+	snap.PendingOperations = []resource.Operation{
+		resource.NewOperation(a, resource.OperationTypeCreating),
+		resource.NewOperation(b, resource.OperationTypeUpdating),
+	}
+
+	require.NoError(t, ResolvePendingCreate(snap, a.URN, "a-id"))
+	assert.Equal(t, resource.OperationTypeImporting, snap.PendingOperations[0].Type)
+	assert.Equal(t, resource.ID("a-id"), snap.PendingOperations[0].Resource.ID)
+	assert.Equal(t, resource.ID(""), a.ID)
+
+	// The ID can be corrected.
+	require.NoError(t, ResolvePendingCreate(snap, a.URN, "other-id"))
+	assert.Equal(t, resource.ID("other-id"), snap.PendingOperations[0].Resource.ID)
+
+	assert.ErrorContains(t, ResolvePendingCreate(snap, b.URN, "b-id"), "not a create")
+	assert.ErrorContains(t, ResolvePendingCreate(snap, pA.URN, "p-id"), "no pending create")
+}
+
+func TestClearPendingOperations(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	c := NewResource("c", pA)
+	newSnap := func() *deploy.Snapshot {
+		snap := NewSnapshot([]*resource.State{pA, b})
+		snap.PendingOperations = []resource.Operation{
+			resource.NewOperation(a, resource.OperationTypeCreating),
+			resource.NewOperation(b, resource.OperationTypeUpdating),
+		}
+		return snap
+	}
+
+	snap := newSnap()
+	cleared, err := ClearPendingOperations(snap, []resource.URN{b.URN})
+	require.NoError(t, err)
+	require.Len(t, cleared, 1)
+	assert.Equal(t, b, cleared[0].Resource)
+	require.Len(t, snap.PendingOperations, 1)
+	assert.Equal(t, a, snap.PendingOperations[0].Resource)
+
+	snap = newSnap()
+	cleared, err = ClearPendingOperations(snap, nil)
+	require.NoError(t, err)
+	assert.Len(t, cleared, 2)
+	assert.Empty(t, snap.PendingOperations)
+
+	// Nothing is cleared if any of the URNs has no pending operation.
+	snap = newSnap()
+	_, err = ClearPendingOperations(snap, []resource.URN{a.URN, c.URN})
+	assert.ErrorContains(t, err, "no pending operation")
+	assert.Len(t, snap.PendingOperations, 2)
+}
+
+func TestRepairSnapshot(t *testing.T) {
+	t.Parallel()
+
+	stackType := resource.RootStackType
+	stack := &resource.State{
+		Type: stackType,
+		URN:  resource.NewURN("test", "test", "", stackType, "test-test"),
+	}
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN, "urn:pulumi:test::test::a:b:c::missing")
+	b.Parent = "urn:pulumi:test::test::a:b:c::gone"
+	b.PropertyDependencies = map[resource.PropertyKey][]resource.URN{
+		"foo": {a.URN, "urn:pulumi:test::test::a:b:c::missing"},
+	}
+	c := NewResource("c", pA, b.URN)
+
+	snap := NewSnapshot([]*resource.State{stack, c, b, pA, a})
+	snap.Manifest.Magic = "tampered"
+	require.Error(t, snap.VerifyIntegrity())
+
+	repairs, err := RepairSnapshot(snap)
+	require.NoError(t, err)
+	require.NoError(t, snap.VerifyIntegrity())
+
+	assert.Equal(t, []*resource.State{stack, pA, a, b, c}, snap.Resources)
+	assert.Equal(t, stack.URN, b.Parent)
+	assert.Equal(t, []resource.URN{a.URN}, b.Dependencies)
+	assert.Equal(t, []resource.URN{a.URN}, b.PropertyDependencies["foo"])
+
+	var descriptions []string
+	for _, repair := range repairs {
+		descriptions = append(descriptions, repair.String())
+	}
+	assert.Equal(t, []string{
+		string(b.URN) + ": re-parented from missing parent urn:pulumi:test::test::a:b:c::gone to the stack",
+		string(b.URN) + ": dropped dependency on missing resource urn:pulumi:test::test::a:b:c::missing",
+		string(b.URN) + ": dropped dependency of property \"foo\" on missing resource " +
+			"urn:pulumi:test::test::a:b:c::missing",
+		string(c.URN) + ": moved after " + string(b.URN) + ", which it refers to",
+		string(b.URN) + ": moved after " + string(a.URN) + ", which it refers to",
+		"recomputed the manifest's magic cookie",
+	}, descriptions)
+
+	// A valid snapshot needs no repairs.
+	repairs, err = RepairSnapshot(snap)
+	require.NoError(t, err)
+	assert.Empty(t, repairs)
+}
+
+func TestRepairSnapshotCycle(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	a.Dependencies = []resource.URN{b.URN}
+
+	_, err := RepairSnapshot(NewSnapshot([]*resource.State{pA, a, b}))
+	assert.ErrorContains(t, err, "dependency cycle")
+}
+
+func TestVerifyURNs(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	snap := NewSnapshot([]*resource.State{pA, a})
+
+	assert.NoError(t, VerifyURNs(snap, "test", "test"))
+	assert.NoError(t, VerifyURNs(snap, "test", ""))
+	assert.ErrorContains(t, VerifyURNs(snap, "other", "test"), "different stack")
+	assert.ErrorContains(t, VerifyURNs(snap, "test", "other"), "different project")
+
+	a.Type = "a:b:d"
+	assert.ErrorContains(t, VerifyURNs(snap, "test", "test"), "doesn't match its URN")
+
+	a.Type, a.URN = "a:b:c", "not-a-urn"
+	assert.ErrorContains(t, VerifyURNs(snap, "test", "test"), "malformed URN")
+
+	snap.Resources = []*resource.State{pA}
+	snap.PendingOperations = []resource.Operation{resource.NewOperation(a, resource.OperationTypeCreating)}
+	assert.ErrorContains(t, VerifyURNs(snap, "test", "test"), "pending creating")
+}
@@ -0,0 +1,147 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// Repair describes a change that RepairSnapshot made to a snapshot to fix one of its integrity problems.
+type Repair struct {
+	URN         resource.URN // the resource that was changed, if any.
+	Description string       // what was wrong, and how it was fixed.
+}
+
+func (r Repair) String() string {
+	if r.URN == "" {
+		return r.Description
+	}
+	return fmt.Sprintf("%s: %s", r.URN, r.Description)
+}
+
+// RepairSnapshot fixes the integrity problems of the given snapshot that can be fixed without losing track of any of
+// its resources, editing the snapshot in place and returning the repairs that it made:
+//
+//   - references to resources that aren't in the snapshot are dropped from dependency lists;
+//   - resources whose parent isn't in the snapshot are re-parented to the stack resource;
+//   - resources are reordered so that each comes after its provider, its parent and its dependencies;
+//   - the manifest's magic cookie is recomputed.
+//
+// Other problems, such as references to missing providers or duplicate URNs, need a human to decide what to do and are
+// left for VerifyIntegrity to report.
+func RepairSnapshot(snap *deploy.Snapshot) ([]Repair, error) {
+	contract.Requiref(snap != nil, "snap", "must not be nil")
+
+	var repairs []Repair
+
+	exists := make(map[resource.URN]bool, len(snap.Resources))
+	var stackURN resource.URN
+	for _, res := range snap.Resources {
+		exists[res.URN] = true
+		if res.Type == resource.RootStackType && res.Parent == "" && !res.Delete {
+			stackURN = res.URN
+		}
+	}
+
+	for _, res := range snap.Resources {
+		if res.Parent != "" && !exists[res.Parent] {
+			parent := stackURN
+			if res.URN == stackURN {
+				parent = ""
+			}
+			description := fmt.Sprintf("re-parented from missing parent %s to the stack", res.Parent)
+			if parent == "" {
+				description = fmt.Sprintf("removed missing parent %s", res.Parent)
+			}
+			repairs = append(repairs, Repair{URN: res.URN, Description: description})
+			res.Parent = parent
+		}
+
+		if len(res.Dependencies) != 0 {
+			deps := make([]resource.URN, 0, len(res.Dependencies))
+			for _, dep := range res.Dependencies {
+				if !exists[dep] {
+					repairs = append(repairs, Repair{
+						URN:         res.URN,
+						Description: fmt.Sprintf("dropped dependency on missing resource %s", dep),
+					})
+					continue
+				}
+				deps = append(deps, dep)
+			}
+			res.Dependencies = deps
+		}
+
+		for key, propDeps := range res.PropertyDependencies {
+			deps := make([]resource.URN, 0, len(propDeps))
+			for _, dep := range propDeps {
+				if !exists[dep] {
+					repairs = append(repairs, Repair{
+						URN:         res.URN,
+						Description: fmt.Sprintf("dropped dependency of property %q on missing resource %s", key, dep),
+					})
+					continue
+				}
+				deps = append(deps, dep)
+			}
+			res.PropertyDependencies[key] = deps
+		}
+
+		if res.DeletedWith != "" && !exists[res.DeletedWith] {
+			repairs = append(repairs, Repair{
+				URN:         res.URN,
+				Description: fmt.Sprintf("dropped deletion with missing resource %s", res.DeletedWith),
+			})
+			res.DeletedWith = ""
+		}
+	}
+
+	sorted, err := graph.TopologicalSort(snap.Resources)
+	if err != nil {
+		return repairs, err
+	}
+	// Report the resources that came before something that they refer to, which are the ones that had to move.
+	seen := make(map[resource.URN]bool, len(snap.Resources))
+	for _, res := range snap.Resources {
+		refs := append([]resource.URN{res.Parent}, res.Dependencies...)
+		if ref, err := providers.ParseReference(res.Provider); err == nil {
+			refs = append(refs, ref.URN())
+		}
+		for _, ref := range refs {
+			if ref != "" && ref != res.URN && exists[ref] && !seen[ref] {
+				repairs = append(repairs, Repair{
+					URN:         res.URN,
+					Description: fmt.Sprintf("moved after %s, which it refers to", ref),
+				})
+				break
+			}
+		}
+		seen[res.URN] = true
+	}
+	snap.Resources = sorted
+
+	if snap.Manifest.Magic != snap.Manifest.NewMagic() {
+		repairs = append(repairs, Repair{Description: "recomputed the manifest's magic cookie"})
+		snap.Manifest.Magic = snap.Manifest.NewMagic()
+	}
+
+	return repairs, nil
+}
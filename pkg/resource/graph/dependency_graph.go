@@ -126,6 +126,57 @@ func (dg *DependencyGraph) DependenciesOf(res *resource.State) ResourceSet {
 	return set
 }
 
+// DependentsOf returns a ResourceSet of resources that directly depend on the given resource. It is the inverse of
+// DependenciesOf: a resource is in DependentsOf(res) exactly when res is in its DependenciesOf, so the resource's
+// children are included, as are resources that depend on a component that res is a transitive child of.
+func (dg *DependencyGraph) DependentsOf(res *resource.State) ResourceSet {
+	set := make(ResourceSet)
+
+	cursorIndex, ok := dg.index[res]
+	contract.Assertf(ok, "could not determine index for resource %s", res.URN)
+
+	// Resources that depend on a component also depend on those of its transitive children that come before them in
+	// the topological sort; see DependenciesOf. Parents always come before their children, so the ancestors of the
+	// resource are all before it.
+	before := make(map[resource.URN]*resource.State, cursorIndex)
+	for _, candidate := range dg.resources[:cursorIndex] {
+		before[candidate.URN] = candidate
+	}
+	dependedOn := map[resource.URN]bool{res.URN: true}
+	for parent := before[res.Parent]; parent != nil; parent = before[parent.Parent] {
+		if !parent.Custom {
+			dependedOn[parent.URN] = true
+		}
+	}
+
+	isDependent := func(candidate *resource.State) bool {
+		if candidate.Parent == res.URN {
+			return true
+		}
+		for _, dependency := range candidate.Dependencies {
+			if dependedOn[dependency] {
+				return true
+			}
+		}
+		if candidate.Provider != "" {
+			ref, err := providers.ParseReference(candidate.Provider)
+			contract.AssertNoErrorf(err, "cannot parse provider reference %q", candidate.Provider)
+			if ref.URN() == res.URN {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, candidate := range dg.resources[cursorIndex+1:] {
+		if isDependent(candidate) {
+			set[candidate] = true
+		}
+	}
+
+	return set
+}
+
 // `TransitiveDependenciesOf` calculates the set of resources that `r` depends
 // on, directly or indirectly. This includes as a `Parent`, a member of r's
 // `Dependencies` list or as a provider.
@@ -240,6 +240,57 @@ func TestDependenciesOfRemoteComponentsNoCycle(t *testing.T) {
 	assert.False(t, rDependencies[child])
 }
 
+func TestDependentsOf(t *testing.T) {
+	t.Parallel()
+
+	aws := NewProviderResource("aws", "default", "0")
+	xyz := NewProviderResource("xyz", "default", "0")
+	first := NewResource("first", xyz)
+	firstNested := NewResource("firstNested", xyz)
+	firstNested.Parent = first.URN
+	sg := NewResource("sg", aws)
+	sg.Parent = firstNested.URN
+	second := NewResource("second", xyz)
+	rule := NewResource("rule", aws, first.URN)
+	rule.Parent = second.URN
+	late := NewResource("late", aws)
+	late.Parent = first.URN
+
+	resources := []*resource.State{
+		aws,
+		xyz,
+		first,
+		firstNested,
+		sg,
+		second,
+		rule,
+		late,
+	}
+	dg := NewDependencyGraph(resources)
+
+	sgDependents := dg.DependentsOf(sg)
+	assert.True(t, sgDependents[rule], "depends on an ancestor component")
+	assert.False(t, sgDependents[late], "unrelated")
+
+	lateDependents := dg.DependentsOf(late)
+	assert.False(t, lateDependents[rule], "child of dependency after the dependent")
+
+	awsDependents := dg.DependentsOf(aws)
+	assert.True(t, awsDependents[sg], "provider")
+	assert.True(t, awsDependents[rule], "provider")
+	assert.False(t, awsDependents[first], "unrelated")
+
+	secondDependents := dg.DependentsOf(second)
+	assert.True(t, secondDependents[rule], "child")
+
+	// DependentsOf is the inverse of DependenciesOf.
+	for _, res := range resources {
+		for _, other := range resources {
+			assert.Equal(t, dg.DependenciesOf(other)[res], dg.DependentsOf(res)[other], "%s -> %s", other.URN, res.URN)
+		}
+	}
+}
+
 func TestTransitiveDependenciesOf(t *testing.T) {
 	t.Parallel()
 
@@ -267,3 +318,29 @@ func TestTransitiveDependenciesOf(t *testing.T) {
 	assert.True(t, set[aws], "everything should depend on the provider")
 	assert.True(t, set[greatUncle], "child depends on greatUncle")
 }
+
+func TestTopologicalSort(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("test", "pA", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA)
+	c.Parent = b.URN
+	d := NewResource("d", pA, "urn:pulumi:test::test::test:test:test::missing")
+
+	// Resources that are already in order keep it.
+	sorted, err := TopologicalSort([]*resource.State{pA, a, d, b, c})
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.State{pA, a, d, b, c}, sorted)
+
+	// Others are moved after their providers, parents and dependencies, but otherwise keep their relative positions.
+	sorted, err = TopologicalSort([]*resource.State{c, d, b, a, pA})
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.State{pA, a, b, c, d}, sorted)
+
+	// Cycles can't be sorted.
+	a.Dependencies = []resource.URN{c.URN}
+	_, err = TopologicalSort([]*resource.State{pA, a, b, c})
+	assert.ErrorContains(t, err, "dependency cycle")
+}
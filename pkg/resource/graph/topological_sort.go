@@ -0,0 +1,99 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// TopologicalSort orders the given resources so that every resource comes after its provider, its parent and its
+// dependencies, as a snapshot requires. Unlike NewDependencyGraph, it doesn't assume the resources are in any order
+// already, but resources that are already in order keep their relative positions. References to resources that aren't
+// in the list are ignored. It returns an error if the references form a cycle.
+func TopologicalSort(resources []*resource.State) ([]*resource.State, error) {
+	byURN := make(map[resource.URN][]int)
+	for i, res := range resources {
+		byURN[res.URN] = append(byURN[res.URN], i)
+	}
+
+	// dependencies returns the indexes of the resources that the resource at the given index must come after, in
+	// their order in the list.
+	dependencies := func(i int) []int {
+		res := resources[i]
+
+		deps := make(map[int]bool)
+		for _, urn := range append([]resource.URN{res.Parent}, res.Dependencies...) {
+			for _, j := range byURN[urn] {
+				deps[j] = true
+			}
+		}
+		if res.Provider != "" {
+			if ref, err := providers.ParseReference(res.Provider); err == nil {
+				for _, j := range byURN[ref.URN()] {
+					if resources[j].ID == ref.ID() {
+						deps[j] = true
+					}
+				}
+			}
+		}
+		delete(deps, i)
+
+		indexes := make([]int, 0, len(deps))
+		for j := range deps {
+			indexes = append(indexes, j)
+		}
+		sort.Ints(indexes)
+		return indexes
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(resources))
+	sorted := make([]*resource.State, 0, len(resources))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("resource %s is part of a dependency cycle", resources[i].URN)
+		}
+
+		state[i] = visiting
+		for _, j := range dependencies(i) {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		sorted = append(sorted, resources[i])
+		return nil
+	}
+
+	for i := range resources {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -109,15 +109,9 @@ func SerializeDeployment(snap *deploy.Snapshot, sm secrets.Manager, showSecrets
 		sm = snap.SecretsManager
 	}
 
-	var enc config.Encrypter
-	if sm != nil {
-		e, err := sm.Encrypter()
-		if err != nil {
-			return nil, fmt.Errorf("getting encrypter for deployment: %w", err)
-		}
-		enc = e
-	} else {
-		enc = config.NewPanicCrypter()
+	enc, err := deploymentEncrypter(sm)
+	if err != nil {
+		return nil, err
 	}
 
 	// Serialize all vertices and only include a vertex section if non-empty.
@@ -139,28 +133,59 @@ func SerializeDeployment(snap *deploy.Snapshot, sm secrets.Manager, showSecrets
 		operations = append(operations, sop)
 	}
 
-	var secretsProvider *apitype.SecretsProvidersV1
-	if sm != nil {
-		secretsProvider = &apitype.SecretsProvidersV1{
-			Type: sm.Type(),
-		}
-		if state := sm.State(); state != nil {
-			rm, err := json.Marshal(state)
-			if err != nil {
-				return nil, err
-			}
-			secretsProvider.State = rm
+	var unstarted []apitype.OperationV2
+	for _, op := range snap.UnstartedOperations {
+		sop, err := SerializeOperation(op, enc, showSecrets)
+		if err != nil {
+			return nil, err
 		}
+		unstarted = append(unstarted, sop)
+	}
+
+	secretsProvider, err := serializeSecretsProviders(sm)
+	if err != nil {
+		return nil, err
 	}
 
 	return &apitype.DeploymentV3{
-		Manifest:          manifest,
-		Resources:         resources,
-		SecretsProviders:  secretsProvider,
-		PendingOperations: operations,
+		Manifest:            manifest,
+		Resources:           resources,
+		SecretsProviders:    secretsProvider,
+		PendingOperations:   operations,
+		UnstartedOperations: unstarted,
 	}, nil
 }
 
+// deploymentEncrypter returns the encrypter to use to serialize a deployment using the given secrets manager.
+func deploymentEncrypter(sm secrets.Manager) (config.Encrypter, error) {
+	if sm == nil {
+		return config.NewPanicCrypter(), nil
+	}
+	enc, err := sm.Encrypter()
+	if err != nil {
+		return nil, fmt.Errorf("getting encrypter for deployment: %w", err)
+	}
+	return enc, nil
+}
+
+// serializeSecretsProviders returns the secrets provider section of a deployment using the given secrets manager.
+func serializeSecretsProviders(sm secrets.Manager) (*apitype.SecretsProvidersV1, error) {
+	if sm == nil {
+		return nil, nil
+	}
+	secretsProvider := &apitype.SecretsProvidersV1{
+		Type: sm.Type(),
+	}
+	if state := sm.State(); state != nil {
+		rm, err := json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		secretsProvider.State = rm
+	}
+	return secretsProvider, nil
+}
+
 // DeserializeUntypedDeployment deserializes an untyped deployment and produces a `deploy.Snapshot`
 // from it. DeserializeDeployment will return an error if the untyped deployment's version is
 // not within the range `DeploymentSchemaVersionCurrent` and `DeploymentSchemaVersionOldestSupported`.
@@ -215,52 +240,13 @@ func DeserializeDeploymentV3(
 		return nil, err
 	}
 
-	var secretsManager secrets.Manager
-	if deployment.SecretsProviders != nil && deployment.SecretsProviders.Type != "" {
-		if secretsProv == nil {
-			return nil, errors.New("deployment uses a SecretsProvider but no SecretsProvider was provided")
-		}
-
-		sm, err := secretsProv.OfType(deployment.SecretsProviders.Type, deployment.SecretsProviders.State)
-		if err != nil {
-			return nil, err
-		}
-		secretsManager = sm
+	secretsManager, err := deserializeSecretsProviders(deployment.SecretsProviders, secretsProv)
+	if err != nil {
+		return nil, err
 	}
-
-	var dec config.Decrypter
-	var enc config.Encrypter
-	if secretsManager == nil {
-		dec = config.NewPanicCrypter()
-		enc = config.NewPanicCrypter()
-	} else {
-		d, err := secretsManager.Decrypter()
-		if err != nil {
-			return nil, err
-		}
-
-		// Do a first pass through state and collect all of the secrets that need decrypting.
-		// We will collect all secrets and decrypt them all at once, rather than just-in-time.
-		// We do this to avoid serial calls to the decryption endpoint which can result in long
-		// wait times in stacks with a large number of secrets.
-		var ciphertexts []string
-		for _, res := range deployment.Resources {
-			collectCiphertexts(&ciphertexts, res.Inputs)
-			collectCiphertexts(&ciphertexts, res.Outputs)
-		}
-
-		// Decrypt the collected secrets and create a decrypter that will use the result as a cache.
-		cache, err := d.BulkDecrypt(ctx, ciphertexts)
-		if err != nil {
-			return nil, err
-		}
-		dec = newMapDecrypter(d, cache)
-
-		e, err := secretsManager.Encrypter()
-		if err != nil {
-			return nil, err
-		}
-		enc = e
+	dec, enc, err := ResourceCrypters(ctx, secretsManager, deployment.Resources)
+	if err != nil {
+		return nil, err
 	}
 
 	// For every serialized resource vertex, create a ResourceDeployment out of it.
@@ -282,7 +268,66 @@ func DeserializeDeploymentV3(
 		ops = append(ops, desop)
 	}
 
-	return deploy.NewSnapshot(*manifest, secretsManager, resources, ops), nil
+	snap := deploy.NewSnapshot(*manifest, secretsManager, resources, ops)
+	for _, op := range deployment.UnstartedOperations {
+		desop, err := DeserializeOperation(op, dec, enc)
+		if err != nil {
+			return nil, err
+		}
+		snap.UnstartedOperations = append(snap.UnstartedOperations, desop)
+	}
+	return snap, nil
+}
+
+// deserializeSecretsProviders returns the secrets manager described by the secrets provider section of a deployment,
+// or nil if the deployment has no secrets provider.
+func deserializeSecretsProviders(
+	providers *apitype.SecretsProvidersV1, secretsProv secrets.Provider,
+) (secrets.Manager, error) {
+	if providers == nil || providers.Type == "" {
+		return nil, nil
+	}
+	if secretsProv == nil {
+		return nil, errors.New("deployment uses a SecretsProvider but no SecretsProvider was provided")
+	}
+	return secretsProv.OfType(providers.Type, providers.State)
+}
+
+// ResourceCrypters returns the decrypter and encrypter to use to deserialize the given resources of a deployment
+// that uses the given secrets manager.
+func ResourceCrypters(
+	ctx context.Context, secretsManager secrets.Manager, resources []apitype.ResourceV3,
+) (config.Decrypter, config.Encrypter, error) {
+	if secretsManager == nil {
+		return config.NewPanicCrypter(), config.NewPanicCrypter(), nil
+	}
+
+	d, err := secretsManager.Decrypter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Do a first pass through state and collect all of the secrets that need decrypting.
+	// We will collect all secrets and decrypt them all at once, rather than just-in-time.
+	// We do this to avoid serial calls to the decryption endpoint which can result in long
+	// wait times in stacks with a large number of secrets.
+	var ciphertexts []string
+	for _, res := range resources {
+		collectCiphertexts(&ciphertexts, res.Inputs)
+		collectCiphertexts(&ciphertexts, res.Outputs)
+	}
+
+	// Decrypt the collected secrets and create a decrypter that will use the result as a cache.
+	cache, err := d.BulkDecrypt(ctx, ciphertexts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enc, err := secretsManager.Encrypter()
+	if err != nil {
+		return nil, nil, err
+	}
+	return newMapDecrypter(d, cache), enc, nil
 }
 
 // SerializeResource turns a resource into a structure suitable for serialization.
@@ -331,6 +376,7 @@ func SerializeResource(res *resource.State, enc config.Encrypter, showSecrets bo
 		DeletedWith:             res.DeletedWith,
 		Created:                 res.Created,
 		Modified:                res.Modified,
+		Hooks:                   res.Hooks.Copy(),
 	}
 
 	if res.CustomTimeouts.IsNotEmpty() {
@@ -513,11 +559,17 @@ func DeserializeResource(res apitype.ResourceV3, dec config.Decrypter, enc confi
 		return nil, fmt.Errorf("resource '%s' has 'custom' false but non-empty ID", res.URN)
 	}
 
-	return resource.NewState(
+	if err := res.Hooks.Validate(); err != nil {
+		return nil, fmt.Errorf("resource '%s' has invalid hooks: %w", res.URN, err)
+	}
+
+	state := resource.NewState(
 		res.Type, res.URN, res.Custom, res.Delete, res.ID,
 		inputs, outputs, res.Parent, res.Protect, res.External, res.Dependencies, res.InitErrors, res.Provider,
 		res.PropertyDependencies, res.PendingReplacement, res.AdditionalSecretOutputs, res.Aliases, res.CustomTimeouts,
-		res.ImportID, res.RetainOnDelete, res.DeletedWith, res.Created, res.Modified), nil
+		res.ImportID, res.RetainOnDelete, res.DeletedWith, res.Created, res.Modified)
+	state.Hooks = res.Hooks.Copy()
+	return state, nil
 }
 
 // DeserializeOperation hydrates a pending resource/operation pair.
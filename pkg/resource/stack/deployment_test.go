@@ -487,6 +487,21 @@ func TestDeserializeInvalidResourceErrors(t *testing.T) {
 	assert.Nil(t, deployment)
 	assert.Error(t, err)
 	assert.Equal(t, fmt.Sprintf("resource '%s' has 'custom' false but non-empty ID", urn), err.Error())
+
+	deployment, err = DeserializeDeploymentV3(ctx, apitype.DeploymentV3{
+		Resources: []apitype.ResourceV3{
+			{
+				URN:    resource.URN(urn),
+				Type:   "aws:ebs/volume:Volume",
+				Custom: true,
+				ID:     "vol-044ba5ad2bd959bc1",
+				Hooks:  resource.ResourceHooks{"before-explode": {"echo boom"}},
+			},
+		},
+	}, DefaultSecretsProvider)
+	assert.Nil(t, deployment)
+	assert.Error(t, err)
+	assert.Equal(t, fmt.Sprintf(`resource '%s' has invalid hooks: unknown hook type "before-explode"`, urn), err.Error())
 }
 
 func TestSerializePropertyValue(t *testing.T) {
@@ -1,6 +1,13 @@
 package stack
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
@@ -213,3 +220,136 @@ func DeserializePlan(plan apitype.DeploymentPlanV1, dec config.Decrypter, enc co
 	}
 	return deserializedPlan, nil
 }
+
+// PlanBase describes the state of a stack that a plan was computed against.
+type PlanBase struct {
+	// Stack is the fully qualified name of the stack.
+	Stack string
+	// CheckpointDigest is the digest of the stack's checkpoint, as returned by CheckpointDigest.
+	CheckpointDigest string
+	// ConfigDigest is the digest of the stack's configuration, as returned by ConfigDigest.
+	ConfigDigest string
+}
+
+// Check returns an error if the current state of a stack differs from the state a plan was computed against.
+func (base PlanBase) Check(current PlanBase) error {
+	switch {
+	case base.Stack != current.Stack:
+		return fmt.Errorf("the plan was computed for stack %s, not %s", base.Stack, current.Stack)
+	case base.CheckpointDigest != current.CheckpointDigest:
+		return fmt.Errorf("the state of stack %s has changed since the plan was computed; "+
+			"run `pulumi preview --save-plan` again to compute a new plan", current.Stack)
+	case base.ConfigDigest != current.ConfigDigest:
+		return fmt.Errorf("the configuration of stack %s has changed since the plan was computed; "+
+			"run `pulumi preview --save-plan` again to compute a new plan", current.Stack)
+	default:
+		return nil
+	}
+}
+
+// CheckpointDigest returns a digest identifying the given exported deployment. A nil deployment, as exported for a
+// stack that has never been updated, has a digest too.
+func CheckpointDigest(deployment *apitype.UntypedDeployment) (string, error) {
+	var buf bytes.Buffer
+	if deployment != nil && len(deployment.Deployment) > 0 {
+		if err := json.Compact(&buf, deployment.Deployment); err != nil {
+			return "", fmt.Errorf("compacting deployment: %w", err)
+		}
+	}
+	return digest(buf.Bytes()), nil
+}
+
+// ConfigDigest returns a digest identifying the given configuration. Secret values are digested in their encrypted
+// form.
+func ConfigDigest(cfg config.Map) (string, error) {
+	if cfg == nil {
+		cfg = config.Map{}
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("marshalling config: %w", err)
+	}
+	return digest(b), nil
+}
+
+// SerializePlanFile serializes a plan, along with the state it was computed against, into a plan file.
+func SerializePlanFile(
+	base PlanBase,
+	plan *deploy.Plan,
+	enc config.Encrypter,
+	showSecrets bool,
+) (apitype.PlanFileV1, error) {
+	deploymentPlan, err := SerializePlan(plan, enc, showSecrets)
+	if err != nil {
+		return apitype.PlanFileV1{}, err
+	}
+	rawPlan, err := json.Marshal(deploymentPlan)
+	if err != nil {
+		return apitype.PlanFileV1{}, fmt.Errorf("marshalling plan: %w", err)
+	}
+
+	file := apitype.PlanFileV1{
+		Version:          apitype.PlanFileVersionCurrent,
+		Stack:            base.Stack,
+		CheckpointDigest: base.CheckpointDigest,
+		ConfigDigest:     base.ConfigDigest,
+		Plan:             rawPlan,
+	}
+	if file.Digest, err = planFileDigest(file); err != nil {
+		return apitype.PlanFileV1{}, err
+	}
+	return file, nil
+}
+
+// DeserializePlanFile verifies the integrity of a plan file and deserializes the plan it contains, along with the
+// state the plan was computed against.
+func DeserializePlanFile(
+	file apitype.PlanFileV1,
+	dec config.Decrypter,
+	enc config.Encrypter,
+) (*deploy.Plan, PlanBase, error) {
+	if file.Version != apitype.PlanFileVersionCurrent {
+		return nil, PlanBase{}, fmt.Errorf("unsupported plan file version %d", file.Version)
+	}
+	actual, err := planFileDigest(file)
+	if err != nil {
+		return nil, PlanBase{}, err
+	}
+	if actual != file.Digest {
+		return nil, PlanBase{}, errors.New("the plan file's digest does not match its contents; it may have been modified")
+	}
+
+	var deploymentPlan apitype.DeploymentPlanV1
+	if err := json.Unmarshal(file.Plan, &deploymentPlan); err != nil {
+		return nil, PlanBase{}, fmt.Errorf("unmarshalling plan: %w", err)
+	}
+	plan, err := DeserializePlan(deploymentPlan, dec, enc)
+	if err != nil {
+		return nil, PlanBase{}, err
+	}
+
+	return plan, PlanBase{
+		Stack:            file.Stack,
+		CheckpointDigest: file.CheckpointDigest,
+		ConfigDigest:     file.ConfigDigest,
+	}, nil
+}
+
+// planFileDigest computes the digest of a plan file's contents, ignoring its Digest field. The plan is compacted
+// first so that the digest doesn't depend on how the file was indented.
+func planFileDigest(file apitype.PlanFileV1) (string, error) {
+	var plan bytes.Buffer
+	if err := json.Compact(&plan, file.Plan); err != nil {
+		return "", fmt.Errorf("compacting plan: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d\n%s\n%s\n%s\n", file.Version, file.Stack, file.CheckpointDigest, file.ConfigDigest)
+	buf.Write(plan.Bytes())
+	return digest(buf.Bytes()), nil
+}
+
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,176 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+func testPlan() *deploy.Plan {
+	plan := deploy.NewPlan(config.Map{
+		config.MustMakeKey("test", "name"): config.NewValue("<value>"),
+	})
+	urn := resource.URN("urn:pulumi:dev::test::pkgA:m:typA::resA")
+	plan.ResourcePlans[urn] = &deploy.ResourcePlan{
+		Goal: &deploy.GoalPlan{
+			Type:   "pkgA:m:typA",
+			Name:   "resA",
+			Custom: true,
+		},
+		Ops: []display.StepOp{deploy.OpCreate},
+	}
+	return &plan
+}
+
+func TestPlanFileRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	base := PlanBase{
+		Stack:            "organization/test/dev",
+		CheckpointDigest: "sha256:abc",
+		ConfigDigest:     "sha256:def",
+	}
+	file, err := SerializePlanFile(base, testPlan(), config.NopEncrypter, false)
+	require.NoError(t, err)
+	assert.Equal(t, apitype.PlanFileVersionCurrent, file.Version)
+	assert.NotEmpty(t, file.Digest)
+
+	// Re-encode the file with indentation, as the CLI does, to check that the digest survives it.
+	b, err := json.MarshalIndent(file, "", "    ")
+	require.NoError(t, err)
+	var read apitype.PlanFileV1
+	require.NoError(t, json.Unmarshal(b, &read))
+
+	plan, readBase, err := DeserializePlanFile(read, config.NopDecrypter, config.NopEncrypter)
+	require.NoError(t, err)
+	assert.Equal(t, base, readBase)
+	assert.Len(t, plan.ResourcePlans, 1)
+	assert.Equal(t, config.NewValue("<value>"), plan.Config[config.MustMakeKey("test", "name")])
+}
+
+func TestPlanFileTampered(t *testing.T) {
+	t.Parallel()
+
+	base := PlanBase{Stack: "organization/test/dev"}
+	file, err := SerializePlanFile(base, testPlan(), config.NopEncrypter, false)
+	require.NoError(t, err)
+
+	modified := file
+	modified.CheckpointDigest = "sha256:other"
+	_, _, err = DeserializePlanFile(modified, config.NopDecrypter, config.NopEncrypter)
+	assert.ErrorContains(t, err, "digest does not match")
+
+	modified = file
+	modified.Plan = json.RawMessage(`{"manifest":{"time":"0001-01-01T00:00:00Z","magic":"","version":""}}`)
+	_, _, err = DeserializePlanFile(modified, config.NopDecrypter, config.NopEncrypter)
+	assert.ErrorContains(t, err, "digest does not match")
+
+	modified = file
+	modified.Version = 2
+	_, _, err = DeserializePlanFile(modified, config.NopDecrypter, config.NopEncrypter)
+	assert.ErrorContains(t, err, "unsupported plan file version 2")
+}
+
+func TestPlanBaseCheck(t *testing.T) {
+	t.Parallel()
+
+	base := PlanBase{
+		Stack:            "organization/test/dev",
+		CheckpointDigest: "sha256:abc",
+		ConfigDigest:     "sha256:def",
+	}
+	assert.NoError(t, base.Check(base))
+
+	other := base
+	other.Stack = "organization/test/prod"
+	assert.ErrorContains(t, base.Check(other), "computed for stack organization/test/dev")
+
+	other = base
+	other.CheckpointDigest = "sha256:123"
+	assert.ErrorContains(t, base.Check(other), "state of stack organization/test/dev has changed")
+
+	other = base
+	other.ConfigDigest = "sha256:456"
+	assert.ErrorContains(t, base.Check(other), "configuration of stack organization/test/dev has changed")
+}
+
+func TestCheckpointDigest(t *testing.T) {
+	t.Parallel()
+
+	empty, err := CheckpointDigest(nil)
+	require.NoError(t, err)
+	alsoEmpty, err := CheckpointDigest(&apitype.UntypedDeployment{Version: 3})
+	require.NoError(t, err)
+	assert.Equal(t, empty, alsoEmpty)
+
+	a, err := CheckpointDigest(&apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(`{"manifest": {"time": "2023-04-05T00:00:00Z"}}`),
+	})
+	require.NoError(t, err)
+	// Whitespace doesn't affect the digest.
+	b, err := CheckpointDigest(&apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(`{"manifest":{"time":"2023-04-05T00:00:00Z"}}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	c, err := CheckpointDigest(&apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(`{"manifest":{"time":"2023-04-06T00:00:00Z"}}`),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, a, c)
+	assert.NotEqual(t, empty, a)
+}
+
+func TestConfigDigest(t *testing.T) {
+	t.Parallel()
+
+	a, err := ConfigDigest(config.Map{
+		config.MustMakeKey("test", "a"): config.NewValue("1"),
+		config.MustMakeKey("test", "b"): config.NewSecureValue("c2VjcmV0"),
+	})
+	require.NoError(t, err)
+	b, err := ConfigDigest(config.Map{
+		config.MustMakeKey("test", "b"): config.NewSecureValue("c2VjcmV0"),
+		config.MustMakeKey("test", "a"): config.NewValue("1"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	c, err := ConfigDigest(config.Map{
+		config.MustMakeKey("test", "a"): config.NewValue("2"),
+		config.MustMakeKey("test", "b"): config.NewSecureValue("c2VjcmV0"),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, a, c)
+
+	empty, err := ConfigDigest(nil)
+	require.NoError(t, err)
+	alsoEmpty, err := ConfigDigest(config.Map{})
+	require.NoError(t, err)
+	assert.Equal(t, empty, alsoEmpty)
+}
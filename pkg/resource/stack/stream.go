@@ -0,0 +1,635 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// The streaming encoders and decoders in this file read and write the same documents as encoding.JSON does with the
+// structures returned by SerializeCheckpoint and SerializeDeployment, but serialize resources one at a time. This
+// keeps memory use flat for very large stacks, where the serialized form of the whole deployment would otherwise be
+// held in memory alongside the snapshot.
+
+// decodeBatchSize is the number of resources that are decoded before their secrets are decrypted in bulk.
+const decodeBatchSize = 1000
+
+// EncodeCheckpoint writes the snapshot of the given stack to w as a versioned checkpoint. The output is identical to
+// marshalling the result of SerializeCheckpoint with encoding.JSON.
+func EncodeCheckpoint(w io.Writer, stack tokens.QName, snap *deploy.Snapshot,
+	sm secrets.Manager, showSecrets bool,
+) error {
+	sw := newJSONStreamWriter(w)
+	sw.open('{')
+	sw.key("version")
+	sw.value(apitype.DeploymentSchemaVersionCurrent)
+	sw.key("checkpoint")
+	sw.open('{')
+	sw.key("stack")
+	sw.value(stack)
+	if snap != nil {
+		sw.key("latest")
+		if err := encodeDeployment(sw, snap, sm, showSecrets); err != nil {
+			return fmt.Errorf("serializing deployment: %w", err)
+		}
+	}
+	sw.close('}')
+	sw.close('}')
+	return sw.flush()
+}
+
+// EncodeDeployment writes the given snapshot to w as an untyped deployment, as exported by `pulumi stack export`. The
+// output is identical to marshalling an apitype.UntypedDeployment holding the result of SerializeDeployment with
+// encoding.JSON.
+func EncodeDeployment(w io.Writer, snap *deploy.Snapshot, sm secrets.Manager, showSecrets bool) error {
+	sw := newJSONStreamWriter(w)
+	sw.open('{')
+	sw.key("version")
+	sw.value(apitype.DeploymentSchemaVersionCurrent)
+	sw.key("deployment")
+	if err := encodeDeployment(sw, snap, sm, showSecrets); err != nil {
+		return err
+	}
+	sw.close('}')
+	return sw.flush()
+}
+
+func encodeDeployment(sw *jsonStreamWriter, snap *deploy.Snapshot, sm secrets.Manager, showSecrets bool) error {
+	if sm == nil {
+		sm = snap.SecretsManager
+	}
+	enc, err := deploymentEncrypter(sm)
+	if err != nil {
+		return err
+	}
+	secretsProvider, err := serializeSecretsProviders(sm)
+	if err != nil {
+		return err
+	}
+
+	sw.open('{')
+	sw.key("manifest")
+	sw.value(snap.Manifest.Serialize())
+	if secretsProvider != nil {
+		sw.key("secrets_providers")
+		sw.value(secretsProvider)
+	}
+	if len(snap.Resources) > 0 {
+		sw.key("resources")
+		sw.open('[')
+		for _, res := range snap.Resources {
+			sres, err := SerializeResource(res, enc, showSecrets)
+			if err != nil {
+				return fmt.Errorf("serializing resources: %w", err)
+			}
+			sw.elem()
+			sw.value(sres)
+			if sw.err != nil {
+				return sw.err
+			}
+		}
+		sw.close(']')
+	}
+	if len(snap.PendingOperations) > 0 {
+		sw.key("pending_operations")
+		sw.open('[')
+		for _, op := range snap.PendingOperations {
+			sop, err := SerializeOperation(op, enc, showSecrets)
+			if err != nil {
+				return err
+			}
+			sw.elem()
+			sw.value(sop)
+		}
+		sw.close(']')
+	}
+	if len(snap.UnstartedOperations) > 0 {
+		sw.key("unstarted_operations")
+		sw.open('[')
+		for _, op := range snap.UnstartedOperations {
+			sop, err := SerializeOperation(op, enc, showSecrets)
+			if err != nil {
+				return err
+			}
+			sw.elem()
+			sw.value(sop)
+		}
+		sw.close(']')
+	}
+	sw.close('}')
+	return sw.err
+}
+
+// jsonStreamWriter writes a JSON document piece by piece, indented in the same way as encoding.JSON.
+type jsonStreamWriter struct {
+	w *bufio.Writer
+	// empty records, for each object or array currently open, whether it has no members yet.
+	empty []bool
+	err   error
+}
+
+func newJSONStreamWriter(w io.Writer) *jsonStreamWriter {
+	return &jsonStreamWriter{w: bufio.NewWriter(w)}
+}
+
+func (sw *jsonStreamWriter) write(s string) {
+	if sw.err == nil {
+		_, sw.err = sw.w.WriteString(s)
+	}
+}
+
+func (sw *jsonStreamWriter) indent() string {
+	return strings.Repeat("    ", len(sw.empty))
+}
+
+// open starts a new object or array.
+func (sw *jsonStreamWriter) open(delim byte) {
+	sw.write(string(delim))
+	sw.empty = append(sw.empty, true)
+}
+
+// close ends the current object or array.
+func (sw *jsonStreamWriter) close(delim byte) {
+	empty := sw.empty[len(sw.empty)-1]
+	sw.empty = sw.empty[:len(sw.empty)-1]
+	if !empty {
+		sw.write("\n" + sw.indent())
+	}
+	sw.write(string(delim))
+	if len(sw.empty) == 0 {
+		sw.write("\n")
+	}
+}
+
+// elem starts a new member of the current object or array.
+func (sw *jsonStreamWriter) elem() {
+	if !sw.empty[len(sw.empty)-1] {
+		sw.write(",")
+	}
+	sw.empty[len(sw.empty)-1] = false
+	sw.write("\n" + sw.indent())
+}
+
+// key starts a new member of the current object with the given key.
+func (sw *jsonStreamWriter) key(k string) {
+	sw.elem()
+	sw.value(k)
+	sw.write(": ")
+}
+
+// value writes a complete value.
+func (sw *jsonStreamWriter) value(v interface{}) {
+	if sw.err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(sw.indent(), "    ")
+	if sw.err = enc.Encode(v); sw.err != nil {
+		return
+	}
+	_, sw.err = sw.w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func (sw *jsonStreamWriter) flush() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.w.Flush()
+}
+
+// DecodeCheckpoint reads a versioned checkpoint, as written by EncodeCheckpoint or by marshalling the result of
+// SerializeCheckpoint, and returns its snapshot. The snapshot is nil if the checkpoint has no deployment. Checkpoints
+// using the current schema are decoded one resource at a time; older checkpoints are read in full and migrated.
+func DecodeCheckpoint(
+	ctx context.Context, r io.Reader, secretsProv secrets.Provider,
+) (*deploy.Snapshot, error) {
+	dec := json.NewDecoder(r)
+	fields := map[string]json.RawMessage{}
+	var version int
+	var snap *deploy.Snapshot
+	streamed := false
+	_, err := decodeObject(dec, func(key string) error {
+		switch {
+		case key == "version":
+			raw, err := decodeRaw(dec, fields, key)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(raw, &version)
+		case key == "checkpoint" && version == apitype.DeploymentSchemaVersionCurrent:
+			streamed = true
+			_, err := decodeObject(dec, func(key string) error {
+				if key != "latest" {
+					_, err := decodeRaw(dec, nil, key)
+					return err
+				}
+				var err error
+				snap, err = decodeDeployment(ctx, dec, secretsProv)
+				return err
+			})
+			return err
+		default:
+			_, err := decodeRaw(dec, fields, key)
+			return err
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if streamed {
+		return snap, nil
+	}
+
+	// Fall back to migrating the checkpoint from its full contents.
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	chk, err := UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, data)
+	if err != nil {
+		return nil, err
+	}
+	return DeserializeCheckpoint(ctx, secretsProv, chk)
+}
+
+// ExportCheckpoint reads a versioned checkpoint, as written by EncodeCheckpoint or by marshalling the result of
+// SerializeCheckpoint, and writes its deployment to w as an untyped deployment, as exported by `pulumi stack export`.
+// Secrets are copied as they are stored, without being decrypted. Checkpoints using the current schema are copied one
+// resource at a time; older checkpoints are read in full and migrated.
+func ExportCheckpoint(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	sw := newJSONStreamWriter(w)
+	fields := map[string]json.RawMessage{}
+	var version int
+	streamed := false
+	_, err := decodeObject(dec, func(key string) error {
+		switch {
+		case key == "version":
+			raw, err := decodeRaw(dec, fields, key)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(raw, &version)
+		case key == "checkpoint" && version == apitype.DeploymentSchemaVersionCurrent:
+			streamed = true
+			sw.open('{')
+			sw.key("version")
+			sw.value(apitype.DeploymentSchemaVersionCurrent)
+			sw.key("deployment")
+			found := false
+			_, err := decodeObject(dec, func(key string) error {
+				if key != "latest" || found {
+					_, err := decodeRaw(dec, nil, key)
+					return err
+				}
+				found = true
+				return copyDeployment(sw, dec)
+			})
+			if err != nil {
+				return err
+			}
+			if !found {
+				sw.value(nil)
+			}
+			sw.close('}')
+			return nil
+		default:
+			_, err := decodeRaw(dec, fields, key)
+			return err
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if streamed {
+		return sw.flush()
+	}
+
+	// Fall back to migrating the checkpoint from its full contents.
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	chk, err := UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, data)
+	if err != nil {
+		return err
+	}
+	deployment, err := encoding.JSON.Marshal(chk.Latest)
+	if err != nil {
+		return err
+	}
+	sw.open('{')
+	sw.key("version")
+	sw.value(apitype.DeploymentSchemaVersionCurrent)
+	sw.key("deployment")
+	sw.value(json.RawMessage(deployment))
+	sw.close('}')
+	return sw.flush()
+}
+
+// copyDeployment copies a deployment from dec to sw, copying its resources and operations one at a time.
+func copyDeployment(sw *jsonStreamWriter, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		sw.value(nil)
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected an object, got %v", tok)
+	}
+	sw.open('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected an object key, got %v", tok)
+		}
+		key = strings.ToLower(key)
+		sw.key(key)
+		switch key {
+		case "resources", "pending_operations", "unstarted_operations":
+			err = copyArray(sw, dec)
+		default:
+			var raw json.RawMessage
+			raw, err = decodeRaw(dec, nil, key)
+			sw.value(raw)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	sw.close('}')
+	return nil
+}
+
+// copyArray copies an array from dec to sw one element at a time.
+func copyArray(sw *jsonStreamWriter, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		sw.value(nil)
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array, got %v", tok)
+	}
+	sw.open('[')
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		sw.elem()
+		sw.value(raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	sw.close(']')
+	return nil
+}
+
+// DecodeDeployment reads an untyped deployment, as written by EncodeDeployment or by `pulumi stack export`, and
+// returns its snapshot. Deployments using the current schema are decoded one resource at a time; older deployments are
+// read in full and migrated.
+func DecodeDeployment(
+	ctx context.Context, r io.Reader, secretsProv secrets.Provider,
+) (*deploy.Snapshot, error) {
+	dec := json.NewDecoder(r)
+	var deployment apitype.UntypedDeployment
+	var snap *deploy.Snapshot
+	streamed := false
+	_, err := decodeObject(dec, func(key string) error {
+		switch {
+		case key == "version":
+			return dec.Decode(&deployment.Version)
+		case key == "deployment" && deployment.Version == apitype.DeploymentSchemaVersionCurrent:
+			streamed = true
+			var err error
+			snap, err = decodeDeployment(ctx, dec, secretsProv)
+			return err
+		case key == "deployment":
+			return dec.Decode(&deployment.Deployment)
+		default:
+			_, err := decodeRaw(dec, nil, key)
+			return err
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if streamed && snap != nil {
+		return snap, nil
+	}
+
+	// Fall back to migrating the deployment from its full contents.
+	return DeserializeUntypedDeployment(ctx, &deployment, secretsProv)
+}
+
+// decodeDeployment decodes a DeploymentV3 into a snapshot, one resource at a time. It returns nil if the deployment is
+// null.
+func decodeDeployment(
+	ctx context.Context, dec *json.Decoder, secretsProv secrets.Provider,
+) (*deploy.Snapshot, error) {
+	var manifest apitype.ManifestV1
+	var secretsManager secrets.Manager
+	var haveSecretsManager bool
+	var operations, unstarted []apitype.OperationV2
+	var pending []apitype.ResourceV3
+	resources := []*resource.State{}
+	var decrypter config.Decrypter
+	var enc config.Encrypter
+
+	// flush deserializes the pending resources, decrypting their secrets in bulk.
+	flush := func() error {
+		var err error
+		if decrypter, enc, err = ResourceCrypters(ctx, secretsManager, pending); err != nil {
+			return err
+		}
+		for _, res := range pending {
+			desres, err := DeserializeResource(res, decrypter, enc)
+			if err != nil {
+				return err
+			}
+			resources = append(resources, desres)
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	isNull, err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "manifest":
+			return dec.Decode(&manifest)
+		case "secrets_providers":
+			var providers *apitype.SecretsProvidersV1
+			if err := dec.Decode(&providers); err != nil {
+				return err
+			}
+			sm, err := deserializeSecretsProviders(providers, secretsProv)
+			if err != nil {
+				return err
+			}
+			secretsManager, haveSecretsManager = sm, true
+			return nil
+		case "resources":
+			return decodeArray(dec, func() error {
+				var res apitype.ResourceV3
+				if err := dec.Decode(&res); err != nil {
+					return err
+				}
+				pending = append(pending, res)
+				// Resources can only be deserialized once the secrets provider is known. It is written before the
+				// resources, but if it isn't, the resources are kept until the end of the deployment.
+				if haveSecretsManager && len(pending) >= decodeBatchSize {
+					return flush()
+				}
+				return nil
+			})
+		case "pending_operations":
+			return dec.Decode(&operations)
+		case "unstarted_operations":
+			return dec.Decode(&unstarted)
+		default:
+			_, err := decodeRaw(dec, nil, key)
+			return err
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if isNull {
+		return nil, nil
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	ops := make([]resource.Operation, 0, len(operations))
+	for _, op := range operations {
+		desop, err := DeserializeOperation(op, decrypter, enc)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, desop)
+	}
+
+	man, err := deploy.DeserializeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	snap := deploy.NewSnapshot(*man, secretsManager, resources, ops)
+	for _, op := range unstarted {
+		desop, err := DeserializeOperation(op, decrypter, enc)
+		if err != nil {
+			return nil, err
+		}
+		snap.UnstartedOperations = append(snap.UnstartedOperations, desop)
+	}
+	return snap, nil
+}
+
+// decodeObject reads a JSON object, calling member for each of its keys. member must decode the key's value. A null
+// is treated as an empty object, and reported by the returned bool. Keys are lower-cased before they are passed to
+// member, since encoding/json matches them case-insensitively and some writers, such as
+// MarshalUntypedDeploymentToVersionedCheckpoint, use Go field names as keys.
+func decodeObject(dec *json.Decoder, member func(key string) error) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return true, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return false, fmt.Errorf("expected an object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return false, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return false, fmt.Errorf("expected an object key, got %v", tok)
+		}
+		if err := member(strings.ToLower(key)); err != nil {
+			return false, err
+		}
+	}
+	_, err = dec.Token()
+	return false, err
+}
+
+// decodeArray reads a JSON array, calling elem for each of its elements. elem must decode the element. A null is
+// treated as an empty array.
+func decodeArray(dec *json.Decoder, elem func() error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array, got %v", tok)
+	}
+	for dec.More() {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeRaw reads the value of the given key, recording it in fields if fields is non-nil.
+func decodeRaw(dec *json.Decoder, fields map[string]json.RawMessage, key string) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if fields != nil {
+		fields[key] = raw
+	}
+	return raw, nil
+}
@@ -0,0 +1,372 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+func newStreamTestSnapshot(n int) *deploy.Snapshot {
+	resources := make([]*resource.State, 0, n)
+	for i := 0; i < n; i++ {
+		urn := resource.NewURN("stack", "proj", "", "test:index:Resource", tokens.QName(fmt.Sprintf("res%d", i)))
+		inputs := resource.PropertyMap{
+			"name":  resource.NewStringProperty(fmt.Sprintf("<res%d> & co", i)),
+			"count": resource.NewNumberProperty(float64(i)),
+		}
+		outputs := resource.PropertyMap{
+			"name":     resource.NewStringProperty(fmt.Sprintf("<res%d> & co", i)),
+			"password": resource.MakeSecret(resource.NewStringProperty(fmt.Sprintf("hunter%d", i))),
+			"tags": resource.NewObjectProperty(resource.PropertyMap{
+				"a": resource.NewArrayProperty([]resource.PropertyValue{resource.NewBoolProperty(true)}),
+			}),
+		}
+		resources = append(resources, &resource.State{
+			Type:    "test:index:Resource",
+			URN:     urn,
+			Custom:  true,
+			ID:      resource.ID(fmt.Sprintf("id%d", i)),
+			Inputs:  inputs,
+			Outputs: outputs,
+		})
+	}
+
+	var ops []resource.Operation
+	if n > 0 {
+		ops = append(ops, resource.NewOperation(resources[n-1], resource.OperationTypeUpdating))
+	}
+
+	manifest := deploy.Manifest{
+		Time:    time.Unix(1680000000, 0).UTC(),
+		Magic:   "magic",
+		Version: "v3.0.0",
+	}
+	return deploy.NewSnapshot(manifest, b64.NewBase64SecretsManager(), resources, ops)
+}
+
+func TestEncodeCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 1, 3} {
+		n := n
+		t.Run(fmt.Sprintf("%d resources", n), func(t *testing.T) {
+			t.Parallel()
+
+			snap := newStreamTestSnapshot(n)
+			chk, err := SerializeCheckpoint("stack", snap, nil, false /* showSecrets */)
+			require.NoError(t, err)
+			expected, err := encoding.JSON.Marshal(chk)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = EncodeCheckpoint(&buf, "stack", snap, nil, false /* showSecrets */)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+
+	t.Run("no snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		chk, err := SerializeCheckpoint("stack", nil, nil, false /* showSecrets */)
+		require.NoError(t, err)
+		expected, err := encoding.JSON.Marshal(chk)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = EncodeCheckpoint(&buf, "stack", nil, nil, false /* showSecrets */)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), buf.String())
+	})
+}
+
+func TestEncodeDeployment(t *testing.T) {
+	t.Parallel()
+
+	for _, showSecrets := range []bool{false, true} {
+		showSecrets := showSecrets
+		t.Run(fmt.Sprintf("showSecrets=%v", showSecrets), func(t *testing.T) {
+			t.Parallel()
+
+			snap := newStreamTestSnapshot(3)
+			dep, err := SerializeDeployment(snap, nil, showSecrets)
+			require.NoError(t, err)
+			data, err := encoding.JSON.Marshal(dep)
+			require.NoError(t, err)
+			expected, err := encoding.JSON.Marshal(apitype.UntypedDeployment{
+				Version:    apitype.DeploymentSchemaVersionCurrent,
+				Deployment: data,
+			})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = EncodeDeployment(&buf, snap, nil, showSecrets)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestDecodeCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// Enough resources to decode them in more than one batch.
+	snap := newStreamTestSnapshot(decodeBatchSize + 1)
+	var buf bytes.Buffer
+	err := EncodeCheckpoint(&buf, "stack", snap, nil, false /* showSecrets */)
+	require.NoError(t, err)
+
+	chk, err := UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, buf.Bytes())
+	require.NoError(t, err)
+	expected, err := DeserializeCheckpoint(ctx, DefaultSecretsProvider, chk)
+	require.NoError(t, err)
+
+	actual, err := DecodeCheckpoint(ctx, &buf, DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.NotNil(t, actual)
+	assert.Equal(t, expected.Manifest, actual.Manifest)
+	assert.Equal(t, b64.Type, actual.SecretsManager.Type())
+	assert.Equal(t, expected.Resources, actual.Resources)
+	assert.Equal(t, expected.PendingOperations, actual.PendingOperations)
+	assert.True(t, actual.Resources[decodeBatchSize].Outputs["password"].IsSecret())
+}
+
+func TestDecodeCheckpoint_empty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := EncodeCheckpoint(&buf, "stack", nil, nil, false /* showSecrets */)
+	require.NoError(t, err)
+
+	snap, err := DecodeCheckpoint(context.Background(), &buf, DefaultSecretsProvider)
+	require.NoError(t, err)
+	assert.Nil(t, snap)
+}
+
+// Checkpoints written by `pulumi stack import` use Go field names as keys, which encoding/json accepts.
+func TestDecodeCheckpoint_imported(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	snap := newStreamTestSnapshot(2)
+	sdep, err := SerializeDeployment(snap, nil, false /* showSecrets */)
+	require.NoError(t, err)
+	deployment, err := encoding.JSON.Marshal(sdep)
+	require.NoError(t, err)
+	chk, err := MarshalUntypedDeploymentToVersionedCheckpoint("stack", &apitype.UntypedDeployment{
+		Version:    apitype.DeploymentSchemaVersionCurrent,
+		Deployment: deployment,
+	})
+	require.NoError(t, err)
+	data, err := encoding.JSON.Marshal(chk)
+	require.NoError(t, err)
+
+	actual, err := DecodeCheckpoint(ctx, bytes.NewReader(data), DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.NotNil(t, actual)
+	assert.Len(t, actual.Resources, 2)
+}
+
+func TestDecodeCheckpoint_oldVersions(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{"checkpoint-v0.json", "checkpoint-v1.json", "checkpoint-v3.json"} {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/" + file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			snap, err := DecodeCheckpoint(context.Background(), f, DefaultSecretsProvider)
+			require.NoError(t, err)
+			require.NotNil(t, snap)
+			assert.Len(t, snap.Resources, 30)
+		})
+	}
+}
+
+func TestDecodeCheckpoint_secretsProvidersLast(t *testing.T) {
+	t.Parallel()
+
+	// The secrets provider is normally written before the resources, but doesn't have to be.
+	const chk = `{
+    "checkpoint": {
+        "latest": {
+            "resources": [{
+                "urn": "urn:pulumi:stack::proj::test:index:Resource::res",
+                "custom": true,
+                "id": "id",
+                "type": "test:index:Resource",
+                "outputs": {
+                    "password": {
+                        "4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270",
+                        "ciphertext": "Imh1bnRlciI="
+                    }
+                }
+            }],
+            "secrets_providers": {"type": "b64"},
+            "manifest": {"time": "2023-01-01T00:00:00Z", "magic": "", "version": ""}
+        }
+    },
+    "version": 3
+}`
+
+	snap, err := DecodeCheckpoint(context.Background(), strings.NewReader(chk), DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 1)
+	password := snap.Resources[0].Outputs["password"]
+	require.True(t, password.IsSecret())
+	assert.Equal(t, "hunter", password.SecretValue().Element.StringValue())
+}
+
+func TestDecodeDeployment(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	snap := newStreamTestSnapshot(3)
+	var buf bytes.Buffer
+	err := EncodeDeployment(&buf, snap, nil, true /* showSecrets */)
+	require.NoError(t, err)
+
+	var deployment apitype.UntypedDeployment
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &deployment))
+	expected, err := DeserializeUntypedDeployment(ctx, &deployment, DefaultSecretsProvider)
+	require.NoError(t, err)
+
+	actual, err := DecodeDeployment(ctx, &buf, DefaultSecretsProvider)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Resources, actual.Resources)
+	assert.Equal(t, expected.PendingOperations, actual.PendingOperations)
+}
+
+func TestDecodeDeployment_oldVersion(t *testing.T) {
+	t.Parallel()
+
+	const dep = `{
+    "deployment": {
+        "manifest": {"time": "2023-01-01T00:00:00Z", "magic": "", "version": ""},
+        "resources": [{
+            "urn": "urn:pulumi:stack::proj::test:index:Resource::res",
+            "custom": true,
+            "id": "id",
+            "type": "test:index:Resource"
+        }]
+    },
+    "version": 2
+}`
+
+	snap, err := DecodeDeployment(context.Background(), strings.NewReader(dep), DefaultSecretsProvider)
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 1)
+	assert.Equal(t, resource.ID("id"), snap.Resources[0].ID)
+
+	_, err = DecodeDeployment(context.Background(), strings.NewReader(`{"version": 100, "deployment": {}}`), nil)
+	assert.ErrorIs(t, err, ErrDeploymentSchemaVersionTooNew)
+}
+
+func TestExportCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	// exportCheckpoint returns the checkpoint's deployment as `pulumi stack export` wrote it before it was streamed.
+	exportCheckpoint := func(t *testing.T, data []byte) string {
+		chk, err := UnmarshalVersionedCheckpointToLatestCheckpoint(encoding.JSON, data)
+		require.NoError(t, err)
+		deployment, err := encoding.JSON.Marshal(chk.Latest)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "    ")
+		err = enc.Encode(apitype.UntypedDeployment{
+			Version:    apitype.DeploymentSchemaVersionCurrent,
+			Deployment: deployment,
+		})
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	for _, n := range []int{0, 1, 3} {
+		n := n
+		t.Run(fmt.Sprintf("%d resources", n), func(t *testing.T) {
+			t.Parallel()
+
+			var chk bytes.Buffer
+			err := EncodeCheckpoint(&chk, "stack", newStreamTestSnapshot(n), nil, false /* showSecrets */)
+			require.NoError(t, err)
+			expected := exportCheckpoint(t, chk.Bytes())
+
+			var buf bytes.Buffer
+			err = ExportCheckpoint(&chk, &buf)
+			require.NoError(t, err)
+			assert.Equal(t, expected, buf.String())
+			// Secrets are left encrypted.
+			assert.NotContains(t, buf.String(), "hunter")
+		})
+	}
+
+	t.Run("no snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		var chk bytes.Buffer
+		err := EncodeCheckpoint(&chk, "stack", nil, nil, false /* showSecrets */)
+		require.NoError(t, err)
+		expected := exportCheckpoint(t, chk.Bytes())
+
+		var buf bytes.Buffer
+		err = ExportCheckpoint(&chk, &buf)
+		require.NoError(t, err)
+		assert.Equal(t, expected, buf.String())
+	})
+
+	for _, file := range []string{"checkpoint-v0.json", "checkpoint-v1.json", "checkpoint-v3.json"} {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile("testdata/" + file)
+			require.NoError(t, err)
+			expected := exportCheckpoint(t, data)
+
+			var buf bytes.Buffer
+			err = ExportCheckpoint(bytes.NewReader(data), &buf)
+			require.NoError(t, err)
+			// These files weren't written by EncodeCheckpoint, so only their content is the same.
+			assert.JSONEq(t, expected, buf.String())
+		})
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/authhelpers"
 	"github.com/pulumi/pulumi/pkg/v3/secrets"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
@@ -45,7 +46,9 @@ type cloudSecretsManagerState struct {
 	EncryptedKey []byte `json:"encryptedkey"`
 }
 
-// openKeeper opens the keeper, handling pulumi-specifc cases in the URL.
+// openKeeper opens the keeper, handling pulumi-specifc cases in the URL. URLs with a scheme that isn't supported
+// natively are handled by the secrets provider plugin of the same name, e.g. "vault-transit://..." by the
+// "vault-transit" secrets plugin.
 func openKeeper(ctx context.Context, url string) (*gosecrets.Keeper, error) {
 	u, err := netUrl.Parse(url)
 	if err != nil {
@@ -69,6 +72,9 @@ func openKeeper(ctx context.Context, url string) (*gosecrets.Keeper, error) {
 
 		return opener.OpenKeeperURL(ctx, u)
 	default:
+		if !gosecrets.DefaultURLMux().ValidKeeperScheme(u.Scheme) {
+			return openPluginKeeper(url, u.Scheme)
+		}
 		return gosecrets.OpenKeeper(ctx, url)
 	}
 }
@@ -85,6 +91,7 @@ func generateNewDataKey(url string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer contract.IgnoreClose(keeper)
 	return keeper.Encrypt(context.Background(), plaintextDataKey)
 }
 
@@ -95,6 +102,7 @@ func newCloudSecretsManager(url string, encryptedDataKey []byte) (*Manager, erro
 	if err != nil {
 		return nil, err
 	}
+	defer contract.IgnoreClose(keeper)
 	plaintextDataKey, err := keeper.Decrypt(context.Background(), encryptedDataKey)
 	if err != nil {
 		return nil, err
@@ -294,3 +294,7 @@ func (k dummySecretsKeeper) Decrypt(ctx context.Context, ciphertext []byte) ([]b
 func (k dummySecretsKeeper) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
 	return plaintext, nil
 }
+
+func (k dummySecretsKeeper) Close() error {
+	return nil
+}
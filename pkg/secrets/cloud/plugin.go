@@ -0,0 +1,103 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gocloud.dev/gcerrors"
+	gosecrets "gocloud.dev/secrets"
+	"gocloud.dev/secrets/driver"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// newSecretsProvider loads the secrets provider plugin of the given name. It is a variable so that tests can replace
+// it with an in-process provider.
+var newSecretsProvider = func(ctx *plugin.Context, name string) (plugin.SecretsProvider, error) {
+	return plugin.NewSecretsProvider(ctx, name, nil)
+}
+
+// pluginKeeper is a keeper that delegates to a secrets provider plugin. It is used for secrets provider URLs whose
+// scheme isn't supported natively, using the plugin named after the scheme.
+type pluginKeeper struct {
+	url      string
+	ctx      *plugin.Context
+	provider plugin.SecretsProvider
+}
+
+var _ driver.Keeper = (*pluginKeeper)(nil)
+
+// openPluginKeeper opens a keeper for the given URL backed by the secrets provider plugin named after its scheme.
+func openPluginKeeper(url, scheme string) (*gosecrets.Keeper, error) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := plugin.NewContext(nil, nil, nil, nil, pwd, nil, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := newSecretsProvider(ctx, scheme)
+	if err != nil {
+		contract.IgnoreClose(ctx)
+		return nil, fmt.Errorf("loading secrets provider plugin for %s://: %w", scheme, err)
+	}
+	return gosecrets.NewKeeper(&pluginKeeper{url: url, ctx: ctx, provider: provider}), nil
+}
+
+func (k *pluginKeeper) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	return k.provider.Encrypt(ctx, k.url, plaintext)
+}
+
+func (k *pluginKeeper) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return k.provider.Decrypt(ctx, k.url, ciphertext)
+}
+
+func (k *pluginKeeper) Close() error {
+	err := k.provider.Close()
+	if ctxErr := k.ctx.Close(); err == nil {
+		err = ctxErr
+	}
+	return err
+}
+
+func (k *pluginKeeper) ErrorAs(err error, i interface{}) bool {
+	return false
+}
+
+func (k *pluginKeeper) ErrorCode(err error) gcerrors.ErrorCode {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return gcerrors.NotFound
+	case codes.InvalidArgument:
+		return gcerrors.InvalidArgument
+	case codes.PermissionDenied:
+		return gcerrors.PermissionDenied
+	case codes.Unimplemented:
+		return gcerrors.Unimplemented
+	case codes.DeadlineExceeded:
+		return gcerrors.DeadlineExceeded
+	case codes.Canceled:
+		return gcerrors.Canceled
+	default:
+		return gcerrors.Unknown
+	}
+}
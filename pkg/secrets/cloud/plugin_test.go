@@ -0,0 +1,65 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloud
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/secrets/filekey"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+type recordingSecretsProvider struct {
+	plugin.SecretsProvider
+
+	names  []string
+	closed int
+}
+
+func (p *recordingSecretsProvider) Close() error {
+	p.closed++
+	return nil
+}
+
+//nolint:paralleltest // replaces newSecretsProvider
+func TestPluginKeeper(t *testing.T) {
+	provider := &recordingSecretsProvider{SecretsProvider: filekey.NewProvider()}
+	old := newSecretsProvider
+	newSecretsProvider = func(ctx *plugin.Context, name string) (plugin.SecretsProvider, error) {
+		provider.names = append(provider.names, name)
+		return provider, nil
+	}
+	t.Cleanup(func() { newSecretsProvider = old })
+
+	// filekey isn't a scheme gocloud knows about, so it's handled by the plugin of that name.
+	url := "filekey://" + filepath.ToSlash(filepath.Join(t.TempDir(), "secrets.key"))
+	testURL(context.Background(), t, url)
+	assert.Equal(t, []string{"filekey", "filekey"}, provider.names)
+	assert.Equal(t, 2, provider.closed, "expected the plugin to be closed after each use")
+
+	// A data key can't be decrypted with a different key.
+	dataKey, err := generateNewDataKey(url)
+	require.NoError(t, err)
+	other := "filekey://" + filepath.ToSlash(filepath.Join(t.TempDir(), "other.key"))
+	_, err = generateNewDataKey(other)
+	require.NoError(t, err)
+	_, err = newCloudSecretsManager(other, dataKey)
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filekey implements a secrets provider plugin that encrypts with an AES-256-GCM key kept in a local file.
+// It is useful to develop and test against the secrets provider plugin protocol, but offers no more protection than
+// the file system permissions of the key file.
+package filekey
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	netUrl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// Scheme is the URL scheme of the provider, e.g. "filekey:///home/me/.pulumi/secrets.key" or
+// "filekey://./secrets.key" for a path relative to the working directory.
+const Scheme = "filekey"
+
+// keySize is the size of the keys, in bytes, which makes them AES-256 keys.
+const keySize = 32
+
+// Provider is a plugin.SecretsProvider that encrypts with the key in the file named by the URL. The key file holds a
+// base64-encoded key and is created by the first encryption if it does not exist.
+type Provider struct{}
+
+var _ plugin.SecretsProvider = (*Provider)(nil)
+
+// NewProvider returns a new file key secrets provider.
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Close() error {
+	return nil
+}
+
+func (p *Provider) Encrypt(ctx context.Context, url string, plaintext []byte) ([]byte, error) {
+	path, err := keyPath(url)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := openKey(path, true /* create */)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (p *Provider) Decrypt(ctx context.Context, url string, ciphertext []byte) ([]byte, error) {
+	path, err := keyPath(url)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := openKey(path, false /* create */)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting with the key in %s: %w", path, err)
+	}
+	return plaintext, nil
+}
+
+// keyPath returns the path of the key file named by a secrets provider URL.
+func keyPath(url string) (string, error) {
+	u, err := netUrl.Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the secrets provider URL: %w", err)
+	}
+	if u.Scheme != Scheme {
+		return "", fmt.Errorf("unexpected secrets provider URL scheme %q, expected %q", u.Scheme, Scheme)
+	}
+	path := filepath.FromSlash(u.Host + u.Path)
+	if path == "" {
+		return "", fmt.Errorf("secrets provider URL %q does not name a key file", url)
+	}
+	return path, nil
+}
+
+// openKey reads the key in the given file, optionally creating it if it does not exist, and returns a cipher that
+// uses it.
+func openKey(path string, create bool) (cipher.AEAD, error) {
+	text, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		text = []byte(base64.StdEncoding.EncodeToString(key) + "\n")
+		if err := os.WriteFile(path, text, 0o600); err != nil {
+			return nil, fmt.Errorf("creating key file: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(text)))
+	if err != nil {
+		return nil, fmt.Errorf("reading key file %s: %w", path, err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key in %s is %d bytes long, expected %d", path, len(key), keySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,97 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filekey
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "secrets.key")
+	url := "filekey://" + filepath.ToSlash(path)
+	p := NewProvider()
+
+	// Decrypting doesn't create a key.
+	_, err := p.Decrypt(ctx, url, []byte("ciphertext"))
+	assert.Error(t, err)
+	assert.NoFileExists(t, path)
+
+	ciphertext, err := p.Encrypt(ctx, url, []byte("hunter2"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "hunter2")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	plaintext, err := p.Decrypt(ctx, url, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+
+	// Tampered ciphertexts are rejected.
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = p.Decrypt(ctx, url, ciphertext)
+	assert.Error(t, err)
+
+	_, err = p.Encrypt(ctx, "file:///"+filepath.ToSlash(path), []byte("hunter2"))
+	assert.ErrorContains(t, err, "unexpected secrets provider URL scheme")
+}
+
+func TestProvider_server(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cancel := make(chan bool)
+	handle, err := rpcutil.ServeWithOptions(rpcutil.ServeOptions{
+		Cancel: cancel,
+		Init: func(srv *grpc.Server) error {
+			pulumirpc.RegisterSecretsProviderServer(srv, plugin.NewSecretsProviderServer(NewProvider()))
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		close(cancel)
+		assert.NoError(t, <-handle.Done)
+	})
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", handle.Port),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+	client := pulumirpc.NewSecretsProviderClient(conn)
+
+	url := "filekey://" + filepath.ToSlash(filepath.Join(t.TempDir(), "secrets.key"))
+	encrypted, err := client.Encrypt(ctx, &pulumirpc.EncryptRequest{Url: url, Plaintext: []byte("hunter2")})
+	require.NoError(t, err)
+	decrypted, err := client.Decrypt(ctx, &pulumirpc.DecryptRequest{Url: url, Ciphertext: encrypted.Ciphertext})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(decrypted.Plaintext))
+}
@@ -16,6 +16,7 @@ package cancel
 
 import (
 	"context"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 )
@@ -23,9 +24,13 @@ import (
 // Context provides the ability to observe cancellation and termination requests from a Source. A termination request
 // automatically triggers a corresponding cancellation request. This can be used to implement cancellation with two
 // priority levels.
+//
+// A context may also carry a deadline, by which the work it governs should wrap up. Unlike cancellation, the deadline
+// isn't a request to stop: it is up to the work to stop starting anything new once it is reached.
 type Context struct {
 	terminate context.Context
 	cancel    context.Context
+	deadline  time.Time
 }
 
 // Source provides the ability to deliver cancellation and termination requests to a Context. A termination request
@@ -61,6 +66,37 @@ func NewContext(ctx context.Context) (*Context, *Source) {
 	return c, s
 }
 
+// NewContextWithDeadline creates a new cancellation context and source parented to the given context, like NewContext,
+// and gives the cancellation context the given deadline.
+func NewContextWithDeadline(ctx context.Context, deadline time.Time) (*Context, *Source) {
+	c, s := NewContext(ctx)
+	c.deadline = deadline
+	return c, s
+}
+
+// NewChildContext creates a new cancellation context and source parented to the given cancellation context. The
+// returned context is canceled and terminated along with its parent, and has the same deadline. Canceling the returned
+// context releases the resources associated with it.
+func NewChildContext(parent *Context) (*Context, *Source) {
+	contract.Requiref(parent != nil, "parent", "must not be nil")
+
+	c, s := NewContext(parent.terminate)
+	c.deadline = parent.deadline
+	go func() {
+		select {
+		case <-parent.cancel.Done():
+			s.Cancel()
+		case <-c.cancel.Done():
+		}
+	}()
+	return c, s
+}
+
+// Deadline returns the deadline of the context, and false if it doesn't have one.
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.deadline, !c.deadline.IsZero()
+}
+
 // Canceled returns a channel that will be closed when the context is canceled or terminated.
 func (c *Context) Canceled() <-chan struct{} {
 	return c.cancel.Done()
@@ -1047,6 +1047,15 @@ func ExampleStack_History() {
 	fmt.Println(hist[0].StartTime)
 }
 
+func ExampleStack_Restore() {
+	ctx := context.Background()
+	stackName := FullyQualifiedStackName("org", "project", "stack")
+	stack, _ := SelectStackLocalSource(ctx, stackName, filepath.Join(".", "program"))
+	hist, _ := stack.History(ctx, 0 /*pageSize*/, 0 /*page*/)
+	// roll the stack's state back to before the last operation
+	_ = stack.Restore(ctx, hist[1].Version)
+}
+
 func ExampleStack_Info() {
 	ctx := context.Background()
 	stackName := FullyQualifiedStackName("org", "project", "stack")
@@ -73,6 +73,28 @@ func TargetDependents() Option {
 	})
 }
 
+// Exclude specifies a list of resource URNs to leave as they are
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also leaves as they are the resources that depend on the ones in the Exclude list
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// AutoAlias treats new resources whose inputs match a resource that would be deleted as renames of that resource
+// instead of replacing it.
+func AutoAlias() Option {
+	return optionFunc(func(opts *Options) {
+		opts.AutoAlias = true
+	})
+}
+
 // DebugLogging provides options for verbose logging to standard error, and enabling plugin logs.
 func DebugLogging(debugOpts debug.LoggingOptions) Option {
 	return optionFunc(func(opts *Options) {
@@ -139,6 +161,12 @@ type Options struct {
 	Target []string
 	// Allows updating of dependent targets discovered but not specified in the Target list
 	TargetDependents bool
+	// Specify a list of resource URNs to leave as they are
+	Exclude []string
+	// Also leave as they are the resources that depend on the ones in the Exclude list
+	ExcludeDependents bool
+	// Treats new resources whose inputs match a resource that would be deleted as renames of it
+	AutoAlias bool
 	// DebugLogOpts specifies additional settings for debug logging
 	DebugLogOpts debug.LoggingOptions
 	// ProgressStreams allows specifying one or more io.Writers to redirect incremental preview stdout
@@ -52,6 +52,20 @@ func Target(urns []string) Option {
 	})
 }
 
+// Exclude specifies a list of resource URNs to not refresh
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also doesn't refresh the resources that depend on the ones in the Exclude list
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
 // ProgressStreams allows specifying one or more io.Writers to redirect incremental refresh stdout
 func ProgressStreams(writers ...io.Writer) Option {
 	return optionFunc(func(opts *Options) {
@@ -112,6 +126,10 @@ type Options struct {
 	ExpectNoChanges bool
 	// Specify an exclusive list of resource URNs to re
 	Target []string
+	// Specify a list of resource URNs to not refresh
+	Exclude []string
+	// Also don't refresh the resources that depend on the ones in the Exclude list
+	ExcludeDependents bool
 	// ProgressStreams allows specifying one or more io.Writers to redirect incremental refresh stdout
 	ProgressStreams []io.Writer
 	// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental refresh stderr
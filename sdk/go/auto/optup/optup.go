@@ -18,6 +18,7 @@ package optup
 
 import (
 	"io"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
@@ -73,6 +74,52 @@ func TargetDependents() Option {
 	})
 }
 
+// Exclude specifies a list of resource URNs to leave as they are
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also leaves as they are the resources that depend on the ones in the Exclude list
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ContinueOnError keeps updating resources after an error is encountered, skipping only the resources affected by the
+// resources that failed. The operation still returns an error.
+func ContinueOnError() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ContinueOnError = true
+	})
+}
+
+// AutoAlias treats new resources whose inputs match a resource that would be deleted as renames of that resource
+// instead of replacing it.
+func AutoAlias() Option {
+	return optionFunc(func(opts *Options) {
+		opts.AutoAlias = true
+	})
+}
+
+// Retry retries resource operations that fail with transient provider errors, such as throttling, up to the given
+// number of attempts in total.
+func Retry(attempts int) Option {
+	return optionFunc(func(opts *Options) {
+		opts.RetryAttempts = attempts
+	})
+}
+
+// Deadline gives the update a deadline: once the given time has elapsed, no new resource operations are started, the
+// operations in flight are allowed to finish and the checkpoint records what was left undone.
+func Deadline(timeout time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Deadline = timeout
+	})
+}
+
 // ProgressStreams allows specifying one or more io.Writers to redirect incremental update stdout
 func ProgressStreams(writers ...io.Writer) Option {
 	return optionFunc(func(opts *Options) {
@@ -146,6 +193,18 @@ type Options struct {
 	Target []string
 	// Allows updating of dependent targets discovered but not specified in the Target list
 	TargetDependents bool
+	// Specify a list of resource URNs to leave as they are
+	Exclude []string
+	// Also leave as they are the resources that depend on the ones in the Exclude list
+	ExcludeDependents bool
+	// Keeps updating resources after an error is encountered, skipping only the resources affected by the failures
+	ContinueOnError bool
+	// Treats new resources whose inputs match a resource that would be deleted as renames of it
+	AutoAlias bool
+	// The maximum number of attempts at resource operations that fail with transient provider errors
+	RetryAttempts int
+	// The time the update may take, after which it stops starting new resource operations
+	Deadline time.Duration
 	// DebugLogOpts specifies additional settings for debug logging
 	DebugLogOpts debug.LoggingOptions
 	// ProgressStreams allows specifying one or more io.Writers to redirect incremental update stdout
@@ -105,6 +105,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -121,6 +122,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/constant"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
@@ -242,6 +244,15 @@ func (s *Stack) Preview(ctx context.Context, opts ...optpreview.Option) (Preview
 	if preOpts.TargetDependents {
 		sharedArgs = append(sharedArgs, "--target-dependents")
 	}
+	for _, eURN := range preOpts.Exclude {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if preOpts.ExcludeDependents {
+		sharedArgs = append(sharedArgs, "--exclude-dependents")
+	}
+	if preOpts.AutoAlias {
+		sharedArgs = append(sharedArgs, "--auto-alias")
+	}
 	if preOpts.Parallel > 0 {
 		sharedArgs = append(sharedArgs, fmt.Sprintf("--parallel=%d", preOpts.Parallel))
 	}
@@ -364,6 +375,24 @@ func (s *Stack) Up(ctx context.Context, opts ...optup.Option) (UpResult, error)
 	if upOpts.TargetDependents {
 		sharedArgs = append(sharedArgs, "--target-dependents")
 	}
+	for _, eURN := range upOpts.Exclude {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if upOpts.ExcludeDependents {
+		sharedArgs = append(sharedArgs, "--exclude-dependents")
+	}
+	if upOpts.ContinueOnError {
+		sharedArgs = append(sharedArgs, "--continue-on-error")
+	}
+	if upOpts.AutoAlias {
+		sharedArgs = append(sharedArgs, "--auto-alias")
+	}
+	if upOpts.RetryAttempts > 0 {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--retry-attempts=%d", upOpts.RetryAttempts))
+	}
+	if upOpts.Deadline > 0 {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--deadline=%v", upOpts.Deadline))
+	}
 	if upOpts.Parallel > 0 {
 		sharedArgs = append(sharedArgs, fmt.Sprintf("--parallel=%d", upOpts.Parallel))
 	}
@@ -463,6 +492,12 @@ func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) (Refresh
 	for _, tURN := range refreshOpts.Target {
 		args = append(args, fmt.Sprintf("--target=%s", tURN))
 	}
+	for _, eURN := range refreshOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if refreshOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
 	if refreshOpts.Parallel > 0 {
 		args = append(args, fmt.Sprintf("--parallel=%d", refreshOpts.Parallel))
 	}
@@ -529,6 +564,81 @@ func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) (Refresh
 	return res, nil
 }
 
+// DetectDrift compares the current stack’s resource state with the state known to exist in the actual
+// cloud provider without changing the stack's state, and reports which resources have drifted.
+// Drift is not an error: use DriftResult.HasDrift to check whether any resource has drifted.
+func (s *Stack) DetectDrift(ctx context.Context, opts ...optrefresh.Option) (DriftResult, error) {
+	var res DriftResult
+
+	if s.isRemote() {
+		return res, errors.New("drift detection is not supported for remote workspaces")
+	}
+
+	refreshOpts := &optrefresh.Options{}
+	for _, o := range opts {
+		o.ApplyOption(refreshOpts)
+	}
+
+	args := make([]string, 0, len(refreshOpts.Target))
+
+	args = debug.AddArgs(&refreshOpts.DebugLogOpts, args)
+	args = append(args, "refresh", "--detect-drift", "--json")
+	for _, tURN := range refreshOpts.Target {
+		args = append(args, fmt.Sprintf("--target=%s", tURN))
+	}
+	for _, eURN := range refreshOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if refreshOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if refreshOpts.Parallel > 0 {
+		args = append(args, fmt.Sprintf("--parallel=%d", refreshOpts.Parallel))
+	}
+	if refreshOpts.UserAgent != "" {
+		args = append(args, fmt.Sprintf("--exec-agent=%s", refreshOpts.UserAgent))
+	}
+	execKind := constant.ExecKindAutoLocal
+	if s.Workspace().Program() != nil {
+		execKind = constant.ExecKindAutoInline
+	}
+	args = append(args, fmt.Sprintf("--exec-kind=%s", execKind))
+
+	if len(refreshOpts.EventStreams) > 0 {
+		eventChannels := refreshOpts.EventStreams
+		t, err := tailLogs("refresh", eventChannels)
+		if err != nil {
+			return res, fmt.Errorf("failed to tail logs: %w", err)
+		}
+		defer t.Close()
+		args = append(args, "--event-log", t.Filename)
+	}
+
+	stdout, stderr, code, err := s.runPulumiCmdSync(
+		ctx,
+		refreshOpts.ProgressStreams,      /* additionalOutputs */
+		refreshOpts.ErrorProgressStreams, /* additionalErrorOutputs */
+		args...,
+	)
+	// The CLI exits with a distinct code when drift is detected, which is not a failure.
+	if err != nil && code != driftExitCode {
+		return res, newAutoError(fmt.Errorf("failed to detect drift: %w", err), stdout, stderr, code)
+	}
+
+	var report display.DriftReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		return res, newAutoError(fmt.Errorf("unable to unmarshal drift report: %w", err), stdout, stderr, code)
+	}
+
+	res = DriftResult{
+		StdOut: stdout,
+		StdErr: stderr,
+		Report: report,
+	}
+
+	return res, nil
+}
+
 // Destroy deletes all resources in a stack, leaving all history and configuration intact.
 func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (DestroyResult, error) {
 	var res DestroyResult
@@ -551,6 +661,21 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 	if destroyOpts.TargetDependents {
 		args = append(args, "--target-dependents")
 	}
+	for _, eURN := range destroyOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if destroyOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if destroyOpts.ContinueOnError {
+		args = append(args, "--continue-on-error")
+	}
+	if destroyOpts.RetryAttempts > 0 {
+		args = append(args, fmt.Sprintf("--retry-attempts=%d", destroyOpts.RetryAttempts))
+	}
+	if destroyOpts.Deadline > 0 {
+		args = append(args, fmt.Sprintf("--deadline=%v", destroyOpts.Deadline))
+	}
 	if destroyOpts.Parallel > 0 {
 		args = append(args, fmt.Sprintf("--parallel=%d", destroyOpts.Parallel))
 	}
@@ -783,6 +908,22 @@ func (s *Stack) Cancel(ctx context.Context) error {
 	return nil
 }
 
+// Restore replaces the current state of the stack with the state it had after the given version, as reported by
+// Stack.History. Resources are not changed; run Stack.Refresh afterwards to bring the restored state in line with
+// them.
+func (s *Stack) Restore(ctx context.Context, version int) error {
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		nil, /* additionalErrorOutput */
+		"stack", "restore", "--version", strconv.Itoa(version), "--yes")
+	if err != nil {
+		return newAutoError(fmt.Errorf("failed to restore stack: %w", err), stdout, stderr, errCode)
+	}
+
+	return nil
+}
+
 // Export exports the deployment state of the stack.
 // This can be combined with Stack.Import to edit a stack's state (such as recovery from failed deployments).
 func (s *Stack) Export(ctx context.Context) (apitype.UntypedDeployment, error) {
@@ -911,6 +1052,22 @@ func (rr *RefreshResult) GetPermalink() (string, error) {
 	return GetPermalink(rr.StdOut)
 }
 
+// driftExitCode is the exit code of `pulumi refresh --detect-drift` when drift is detected.
+const driftExitCode = 2
+
+// DriftResult contains information about a Stack.DetectDrift operation,
+// including the drift status of each resource.
+type DriftResult struct {
+	StdOut string
+	StdErr string
+	Report display.DriftReport
+}
+
+// HasDrift returns true if any resource has drifted or was deleted outside of Pulumi.
+func (dr *DriftResult) HasDrift() bool {
+	return dr.Report.HasDrift()
+}
+
 // DestroyResult is the output of a successful Stack.Destroy operation
 type DestroyResult struct {
 	StdOut  string
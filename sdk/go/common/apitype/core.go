@@ -149,6 +149,8 @@ type DeploymentV3 struct {
 	Resources []ResourceV3 `json:"resources,omitempty" yaml:"resources,omitempty"`
 	// PendingOperations are all operations that were known by the engine to be currently executing.
 	PendingOperations []OperationV2 `json:"pending_operations,omitempty" yaml:"pending_operations,omitempty"`
+	// UnstartedOperations are the operations that the deployment didn't start because it reached its deadline.
+	UnstartedOperations []OperationV2 `json:"unstarted_operations,omitempty" yaml:"unstarted_operations,omitempty"`
 }
 
 type SecretsProvidersV1 struct {
@@ -333,6 +335,8 @@ type ResourceV3 struct {
 	Created *time.Time `json:"created,omitempty" yaml:"created,omitempty"`
 	// Modified tracks when the resource state was last altered. Checkpoints prior to early 2023 do not include this.
 	Modified *time.Time `json:"modified,omitempty" yaml:"modified,omitempty"`
+	// Hooks are the commands to run around operations on the resource, keyed by the point at which they run.
+	Hooks resource.ResourceHooks `json:"hooks,omitempty" yaml:"hooks,omitempty"`
 }
 
 // ManifestV1 captures meta-information about this checkpoint file, such as versions of binaries, etc.
@@ -181,6 +181,21 @@ type ResOpFailedEvent struct {
 	Steps    int               `json:"steps"`
 }
 
+// ResourceRetryEvent is emitted before a resource operation that failed with a transient error is tried again.
+type ResourceRetryEvent struct {
+	Metadata    StepEventMetadata `json:"metadata"`
+	Attempt     int               `json:"attempt"`
+	MaxAttempts int               `json:"maxAttempts"`
+	Message     string            `json:"message"`
+}
+
+// ResourceHookEvent is emitted before a hook of a resource runs.
+type ResourceHookEvent struct {
+	Metadata StepEventMetadata `json:"metadata"`
+	Hook     string            `json:"hook"`
+	Command  string            `json:"command"`
+}
+
 // EngineEvent describes a Pulumi engine event, such as a change to a resource or diagnostic
 // message. EngineEvent is a discriminated union of all possible event types, and exactly one
 // field will be non-nil.
@@ -197,15 +212,17 @@ type EngineEvent struct {
 	// Timestamp is a Unix timestamp (seconds) of when the event was emitted.
 	Timestamp int `json:"timestamp"`
 
-	CancelEvent      *CancelEvent       `json:"cancelEvent,omitempty"`
-	StdoutEvent      *StdoutEngineEvent `json:"stdoutEvent,omitempty"`
-	DiagnosticEvent  *DiagnosticEvent   `json:"diagnosticEvent,omitempty"`
-	PreludeEvent     *PreludeEvent      `json:"preludeEvent,omitempty"`
-	SummaryEvent     *SummaryEvent      `json:"summaryEvent,omitempty"`
-	ResourcePreEvent *ResourcePreEvent  `json:"resourcePreEvent,omitempty"`
-	ResOutputsEvent  *ResOutputsEvent   `json:"resOutputsEvent,omitempty"`
-	ResOpFailedEvent *ResOpFailedEvent  `json:"resOpFailedEvent,omitempty"`
-	PolicyEvent      *PolicyEvent       `json:"policyEvent,omitempty"`
+	CancelEvent        *CancelEvent        `json:"cancelEvent,omitempty"`
+	StdoutEvent        *StdoutEngineEvent  `json:"stdoutEvent,omitempty"`
+	DiagnosticEvent    *DiagnosticEvent    `json:"diagnosticEvent,omitempty"`
+	PreludeEvent       *PreludeEvent       `json:"preludeEvent,omitempty"`
+	SummaryEvent       *SummaryEvent       `json:"summaryEvent,omitempty"`
+	ResourcePreEvent   *ResourcePreEvent   `json:"resourcePreEvent,omitempty"`
+	ResOutputsEvent    *ResOutputsEvent    `json:"resOutputsEvent,omitempty"`
+	ResOpFailedEvent   *ResOpFailedEvent   `json:"resOpFailedEvent,omitempty"`
+	PolicyEvent        *PolicyEvent        `json:"policyEvent,omitempty"`
+	ResourceRetryEvent *ResourceRetryEvent `json:"resourceRetryEvent,omitempty"`
+	ResourceHookEvent  *ResourceHookEvent  `json:"resourceHookEvent,omitempty"`
 }
 
 // EngineEventBatch is a group of engine events.
@@ -37,6 +37,8 @@ const (
 	StackImportUpdate UpdateKind = "import"
 	// ResourceImportUpdate is an update that entails importing one or more resources.
 	ResourceImportUpdate = "resource-import"
+	// StackRestoreUpdate is an update that restores a previous version of a stack's checkpoint.
+	StackRestoreUpdate UpdateKind = "restore"
 )
 
 // UpdateResult is an enum for the result of the update.
@@ -0,0 +1,57 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitype
+
+import "github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+
+// JournalEntryKind is the kind of a journal entry.
+type JournalEntryKind string
+
+const (
+	// JournalEntryBegin records that a step has started.
+	JournalEntryBegin JournalEntryKind = "begin"
+	// JournalEntrySuccess records that a step has completed successfully.
+	JournalEntrySuccess JournalEntryKind = "success"
+	// JournalEntryFailure records that a step has failed.
+	JournalEntryFailure JournalEntryKind = "failure"
+	// JournalEntryOutputs records that the outputs of a step's resource have been registered.
+	JournalEntryOutputs JournalEntryKind = "outputs"
+)
+
+// JournalEntryV1 is a single entry of an update's journal. Replaying the entries of a journal in order on top of the
+// snapshot the update started from produces the snapshot the update has reached.
+type JournalEntryV1 struct {
+	// Sequence is the position of this entry in the journal, starting at 1.
+	Sequence int `json:"sequence"`
+	// Kind is the kind of this entry.
+	Kind JournalEntryKind `json:"kind"`
+	// Op is the operation performed by the entry's step.
+	Op string `json:"op"`
+	// URN is the URN of the resource the entry's step operates on.
+	URN resource.URN `json:"urn"`
+	// Old is the state of the resource before the step, if any.
+	Old *JournalResourceV1 `json:"old,omitempty"`
+	// New is the state of the resource after the step, if any.
+	New *JournalResourceV1 `json:"new,omitempty"`
+}
+
+// JournalResourceV1 is the state of a resource as recorded in a journal entry.
+type JournalResourceV1 struct {
+	// ID identifies the resource state within the journal. The states of the base snapshot are identified by their
+	// index in its resources; states introduced by the update are numbered after them in the order they first appear.
+	ID int `json:"id"`
+	// State is the contents of the resource state at the time of the entry.
+	State ResourceV3 `json:"state"`
+}
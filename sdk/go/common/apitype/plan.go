@@ -87,3 +87,24 @@ type DeploymentPlanV1 struct {
 	// The set of resource plans.
 	ResourcePlans map[resource.URN]ResourcePlanV1 `json:"resourcePlans,omitempty"`
 }
+
+// PlanFileVersionCurrent is the current version of the plan file format.
+const PlanFileVersionCurrent = 1
+
+// PlanFileV1 is the self-contained plan artifact written by `pulumi preview --save-plan`. Besides the plan itself it
+// records what the plan was computed against, so that `pulumi up --plan` can refuse to apply a plan that has gone
+// stale.
+type PlanFileV1 struct {
+	// Version is the version of the plan file format.
+	Version int `json:"version"`
+	// Stack is the fully qualified name of the stack the plan was computed for.
+	Stack string `json:"stack"`
+	// CheckpointDigest is the digest of the stack's checkpoint at the time the plan was computed.
+	CheckpointDigest string `json:"checkpointDigest"`
+	// ConfigDigest is the digest of the stack's configuration at the time the plan was computed.
+	ConfigDigest string `json:"configDigest"`
+	// Plan is the serialized DeploymentPlanV1.
+	Plan json.RawMessage `json:"plan"`
+	// Digest is the digest of all the other fields of the plan file, used to detect modifications.
+	Digest string `json:"digest"`
+}
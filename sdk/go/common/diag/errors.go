@@ -90,3 +90,17 @@ func GetDuplicateResourceAliasedError(urn resource.URN) *Diag {
 		"Duplicate resource URN '%v' conflicting with alias on resource with URN '%v'",
 	)
 }
+
+func GetResourceDependsOnExcludedResource(urn resource.URN) *Diag {
+	return newError(urn, 2017, `Resource '%v' depends on '%v' which was excluded and does not exist yet.
+Either stop excluding it or pass --exclude-dependents to proceed.`)
+}
+
+func GetResourceMayHaveBeenRenamed(urn resource.URN) *Diag {
+	return newError(urn, 2018, `Resource '%v' looks like a rename of '%v', which will be deleted.
+If they are the same resource, add the alias %v to it.`)
+}
+
+func GetResourceAutoAliased(urn resource.URN) *Diag {
+	return newError(urn, 2019, "Resource '%v' is treated as a rename of '%v' due to --auto-alias")
+}
@@ -82,3 +82,42 @@ type PreviewDiagnostic struct {
 	Message  string        `json:"message,omitempty"`
 	Severity diag.Severity `json:"severity,omitempty"`
 }
+
+// DriftStatus classifies how the actual state of a resource compares to the state recorded for it.
+type DriftStatus string
+
+const (
+	// DriftInSync indicates that the actual state of the resource matches its recorded state.
+	DriftInSync DriftStatus = "in-sync"
+	// DriftDrifted indicates that the actual state of the resource differs from its recorded state.
+	DriftDrifted DriftStatus = "drifted"
+	// DriftDeleted indicates that the resource was deleted outside of Pulumi.
+	DriftDeleted DriftStatus = "deleted"
+)
+
+// DriftReport is a JSON-serializable overview of a drift detection operation.
+type DriftReport struct {
+	// Resources contains the drift status of each resource that was checked.
+	Resources []DriftResource `json:"resources,omitempty"`
+	// Diagnostics contains a record of all warnings/errors that took place during drift detection. Note that
+	// ephemeral and debug messages are omitted from this list, as they are meant for display purposes only.
+	Diagnostics []PreviewDiagnostic `json:"diagnostics,omitempty"`
+	// Summary contains a count of resources per drift status.
+	Summary map[DriftStatus]int `json:"summary,omitempty"`
+}
+
+// HasDrift returns true if any resource in the report has drifted or was deleted.
+func (r DriftReport) HasDrift() bool {
+	return r.Summary[DriftDrifted] > 0 || r.Summary[DriftDeleted] > 0
+}
+
+// DriftResource is the drift status of a single resource.
+type DriftResource struct {
+	// URN is the resource that was checked.
+	URN resource.URN `json:"urn"`
+	// Status is the drift status of the resource.
+	Status DriftStatus `json:"status"`
+	// DetailedDiff indicates precise per-property differences between the recorded and actual outputs of a drifted
+	// resource.
+	DetailedDiff map[string]PropertyDiff `json:"detailedDiff,omitempty"`
+}
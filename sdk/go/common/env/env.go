@@ -66,6 +66,11 @@ that a --yes parameter has been set for non-interactive scenarios.
 This should NOT be used to bypass protections for destructive operations, such as those that will
 fail without a --force parameter.`)
 
+var DeploymentDeadline = env.String("DEPLOYMENT_DEADLINE",
+	"The time that an update or destroy may take, such as 45m, after which it stops starting new operations, waits for "+
+		"the ones in flight and saves its checkpoint. It is counted from the start of the update, after any preview and "+
+		"confirmation prompt. Overridden by the --deadline flag.")
+
 var DebugGRPC = env.String("DEBUG_GRPC", `Enables debug tracing of Pulumi gRPC internals.
 The variable should be set to the log file to which gRPC debug traces will be sent.`)
 
@@ -76,4 +81,12 @@ var (
 
 	SelfManagedStateLegacyLayout = env.Bool("SELF_MANAGED_STATE_LEGACY_LAYOUT",
 		"Uses the legacy layout for new buckets, which currently default to project-scoped stacks.")
+
+	SelfManagedStateLockLease = env.Int("SELF_MANAGED_STATE_LOCK_LEASE",
+		"The number of seconds a stack lock is leased for before it must be renewed. "+
+			"Locks that are not renewed in time may be taken over by other processes. Defaults to 300.")
+
+	SelfManagedStateJournal = env.Bool("SELF_MANAGED_STATE_JOURNAL",
+		"Records updates as an append-only journal that is compacted into the checkpoint when they complete, "+
+			"rather than rewriting the whole checkpoint after every step.")
 )
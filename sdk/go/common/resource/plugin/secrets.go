@@ -0,0 +1,32 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"io"
+)
+
+// SecretsProvider is a secrets provider plugin, which encrypts and decrypts the keys that protect the secrets in a
+// stack's state. The URL passed to each call is the full secrets provider URL the stack is configured with.
+type SecretsProvider interface {
+	io.Closer
+
+	// Encrypt encrypts plaintext with the key identified by url.
+	Encrypt(ctx context.Context, url string, plaintext []byte) ([]byte, error)
+
+	// Decrypt decrypts a ciphertext previously returned by Encrypt for the same url.
+	Decrypt(ctx context.Context, url string, ciphertext []byte) ([]byte, error)
+}
@@ -0,0 +1,51 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+
+	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
+)
+
+type secretsProviderServer struct {
+	pulumirpc.UnsafeSecretsProviderServer // opt out of forward compat
+
+	provider SecretsProvider
+}
+
+func NewSecretsProviderServer(provider SecretsProvider) pulumirpc.SecretsProviderServer {
+	return &secretsProviderServer{provider: provider}
+}
+
+func (s *secretsProviderServer) Encrypt(ctx context.Context,
+	req *pulumirpc.EncryptRequest,
+) (*pulumirpc.EncryptResponse, error) {
+	ciphertext, err := s.provider.Encrypt(ctx, req.Url, req.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.EncryptResponse{Ciphertext: ciphertext}, nil
+}
+
+func (s *secretsProviderServer) Decrypt(ctx context.Context,
+	req *pulumirpc.DecryptRequest,
+) (*pulumirpc.DecryptResponse, error) {
+	plaintext, err := s.provider.Decrypt(ctx, req.Url, req.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return &pulumirpc.DecryptResponse{Plaintext: plaintext}, nil
+}
@@ -43,6 +43,10 @@ type Goal struct {
 	// if set, the providers Delete method will not be called for this resource
 	// if specified resource is being deleted as well.
 	DeletedWith URN
+	// the maximum number of concurrent operations on the resources managed by this provider resource, if not zero.
+	MaxConcurrency int
+	// the commands to run around operations on this resource.
+	Hooks ResourceHooks
 }
 
 // NewGoal allocates a new resource goal state.
@@ -0,0 +1,65 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HookType identifies the point in an operation on a resource at which a resource hook runs.
+type HookType string
+
+const (
+	BeforeCreate HookType = "before-create" // runs before the resource is created.
+	AfterCreate  HookType = "after-create"  // runs after the resource has been created.
+	BeforeUpdate HookType = "before-update" // runs before the resource is updated.
+	AfterUpdate  HookType = "after-update"  // runs after the resource has been updated.
+	BeforeDelete HookType = "before-delete" // runs before the resource is deleted.
+	AfterDelete  HookType = "after-delete"  // runs after the resource has been deleted.
+)
+
+// ResourceHooks are the commands that the engine runs around operations on a resource, by the point at which they
+// run. Each command is run by the system shell.
+type ResourceHooks map[HookType][]string
+
+// Copy returns a deep copy of the hooks.
+func (h ResourceHooks) Copy() ResourceHooks {
+	if h == nil {
+		return nil
+	}
+	c := make(ResourceHooks, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// Validate returns an error if the hooks have a type that isn't known or an empty command.
+func (h ResourceHooks) Validate() error {
+	for typ, commands := range h {
+		switch typ {
+		case BeforeCreate, AfterCreate, BeforeUpdate, AfterUpdate, BeforeDelete, AfterDelete:
+		default:
+			return fmt.Errorf("unknown hook type %q", typ)
+		}
+		for _, command := range commands {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("%s hook has an empty command", typ)
+			}
+		}
+	}
+	return nil
+}
@@ -124,9 +124,18 @@ func RunResultFunc(run func(cmd *cobra.Command, args []string) result.Result) fu
 				return
 			}
 
-			// If there is a stack trace, and logging is enabled, append it.  Otherwise, debug logging it.
+			// If the command asked for a specific exit code, use it, printing a message only if there is one.
 			err := res.Error()
+			code := -1
+			var exitCodeErr *ExitCodeError
+			if errors.As(err, &exitCodeErr) {
+				if exitCodeErr.Err == nil {
+					os.Exit(exitCodeErr.Code)
+				}
+				code = exitCodeErr.Code
+			}
 
+			// If there is a stack trace, and logging is enabled, append it.  Otherwise, debug logging it.
 			var msg string
 			if logging.LogToStderr {
 				msg = DetailedError(err)
@@ -135,11 +144,29 @@ func RunResultFunc(run func(cmd *cobra.Command, args []string) result.Result) fu
 				logging.V(3).Infof(DetailedError(err))
 			}
 
-			ExitError(msg)
+			exitErrorCodef(code, strings.ReplaceAll(msg, "%", "%%"))
 		}
 	}
 }
 
+// ExitCodeError is an error that causes a command wrapped in [RunFunc] or [RunResultFunc] to exit with a specific
+// exit code instead of the standard error exit code.
+type ExitCodeError struct {
+	Code int   // the exit code to use.
+	Err  error // the error to report, if any.
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
 // Exit exits with a given error.
 func Exit(err error) {
 	ExitError(errorMessage(err))
@@ -370,6 +370,9 @@ func newGithubSource(url *url.URL, name string, kind PluginKind) (*githubSource,
 			// should go away and be replaced with a registry lookup.
 			repository = "pulumi-yaml"
 		}
+	} else if kind == SecretsPlugin {
+		// Likewise secrets plugins are expected at e.g. github.com/pulumi/pulumi-secrets-vault.
+		repository = "pulumi-secrets-" + name
 	}
 	if len(parts) == 2 {
 		repository = parts[1]
@@ -1501,12 +1504,14 @@ const (
 	ResourcePlugin PluginKind = "resource"
 	// ConverterPlugin is a plugin that can be used to convert from other ecosystems to Pulumi.
 	ConverterPlugin PluginKind = "converter"
+	// SecretsPlugin is a plugin that can be used as a secrets provider for encrypting and decrypting state.
+	SecretsPlugin PluginKind = "secrets"
 )
 
 // IsPluginKind returns true if k is a valid plugin kind, and false otherwise.
 func IsPluginKind(k string) bool {
 	switch PluginKind(k) {
-	case AnalyzerPlugin, LanguagePlugin, ResourcePlugin, ConverterPlugin:
+	case AnalyzerPlugin, LanguagePlugin, ResourcePlugin, ConverterPlugin, SecretsPlugin:
 		return true
 	default:
 		return false
@@ -91,6 +91,9 @@ type ProjectBackend struct {
 type ProjectOptions struct {
 	// Refresh is the ability to always run a refresh as part of a pulumi update / preview / destroy
 	Refresh string `json:"refresh,omitempty" yaml:"refresh,omitempty"`
+	// ProviderConcurrency caps the number of concurrent operations on the resources of providers, keyed by package
+	// name (e.g. "aws") or by the URN of a provider resource, which may contain wildcards.
+	ProviderConcurrency map[string]int `json:"providerConcurrency,omitempty" yaml:"providerConcurrency,omitempty"`
 }
 
 type PluginOptions struct {
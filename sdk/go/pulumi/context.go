@@ -796,6 +796,10 @@ func (ctx *Context) registerResource(
 
 	options := merge(opts...)
 
+	if options.MaxConcurrency != 0 && !strings.HasPrefix(t, "pulumi:providers:") {
+		return errors.New("the MaxConcurrency option only applies to provider resources")
+	}
+
 	if parent := options.Parent; parent != nil && parent.URN().getState() == nil {
 		// Guard against uninitialized parent resources to prevent
 		// panics from invalid state further down the line.
@@ -938,6 +942,13 @@ func (ctx *Context) registerResource(
 				ReplaceOnChanges:        inputs.replaceOnChanges,
 				RetainOnDelete:          inputs.retainOnDelete,
 				DeletedWith:             inputs.deletedWith,
+				MaxConcurrency:          inputs.maxConcurrency,
+				BeforeCreateHooks:       inputs.hooks.BeforeCreate,
+				AfterCreateHooks:        inputs.hooks.AfterCreate,
+				BeforeUpdateHooks:       inputs.hooks.BeforeUpdate,
+				AfterUpdateHooks:        inputs.hooks.AfterUpdate,
+				BeforeDeleteHooks:       inputs.hooks.BeforeDelete,
+				AfterDeleteHooks:        inputs.hooks.AfterDelete,
 			})
 			if err != nil {
 				logging.V(9).Infof("RegisterResource(%s, %s): error: %v", t, name, err)
@@ -1336,6 +1347,8 @@ type resourceInputs struct {
 	replaceOnChanges        []string
 	retainOnDelete          bool
 	deletedWith             string
+	maxConcurrency          int32
+	hooks                   ResourceHooks
 }
 
 func (ctx *Context) resolveAliasParent(alias Alias, spec *pulumirpc.Alias_Spec) error {
@@ -1581,6 +1594,11 @@ func (ctx *Context) prepareResourceInputs(res Resource, props Input, t string, o
 		deletedWithURN = urn
 	}
 
+	var hooks ResourceHooks
+	if opts.Hooks != nil {
+		hooks = *opts.Hooks
+	}
+
 	return &resourceInputs{
 		parent:                  string(resOpts.parentURN),
 		deps:                    deps,
@@ -1601,6 +1619,8 @@ func (ctx *Context) prepareResourceInputs(res Resource, props Input, t string, o
 		replaceOnChanges:        resOpts.replaceOnChanges,
 		retainOnDelete:          opts.RetainOnDelete,
 		deletedWith:             string(deletedWithURN),
+		maxConcurrency:          int32(opts.MaxConcurrency),
+		hooks:                   hooks,
 	}, nil
 }
 
@@ -286,6 +286,22 @@ type CustomTimeouts struct {
 	Delete string
 }
 
+// ResourceHooks are shell commands that the engine runs around operations on a resource.
+// Each command reads a JSON description of the resource and its old and new state from its standard input,
+// in which secret values are objects with the same secret signature as in an exported stack,
+// and any secret strings in its output are masked.
+// A failed "before" command fails the operation, which is then not attempted,
+// and a failed "after" command fails the deployment once the operation has completed.
+// Hooks don't run during previews.
+type ResourceHooks struct {
+	BeforeCreate []string
+	AfterCreate  []string
+	BeforeUpdate []string
+	AfterUpdate  []string
+	BeforeDelete []string
+	AfterDelete  []string
+}
+
 // ResourceOptions is a snapshot of one or more [ResourceOption]s.
 //
 // It provides a preview of the collective effect of options
@@ -374,6 +390,14 @@ type ResourceOptions struct {
 	// DeletedWith holds a container resource that, if deleted,
 	// also deletes this resource.
 	DeletedWith Resource
+
+	// MaxConcurrency caps the number of concurrent operations
+	// on the resources managed by this provider resource.
+	// This will be zero if the operations are not capped.
+	MaxConcurrency int
+
+	// Hooks holds the commands to run around operations on the resource.
+	Hooks *ResourceHooks
 }
 
 // NewResourceOptions builds a preview of the effect of the provided options.
@@ -408,6 +432,8 @@ type resourceOptions struct {
 	PluginDownloadURL       string
 	RetainOnDelete          bool
 	DeletedWith             Resource
+	MaxConcurrency          int
+	Hooks                   *ResourceHooks
 }
 
 func resourceOptionsSnapshot(ro *resourceOptions) *ResourceOptions {
@@ -468,6 +494,8 @@ func resourceOptionsSnapshot(ro *resourceOptions) *ResourceOptions {
 		PluginDownloadURL:       ro.PluginDownloadURL,
 		RetainOnDelete:          ro.RetainOnDelete,
 		DeletedWith:             ro.DeletedWith,
+		MaxConcurrency:          ro.MaxConcurrency,
+		Hooks:                   ro.Hooks,
 	}
 }
 
@@ -881,3 +909,21 @@ func DeletedWith(r Resource) ResourceOption {
 		ro.DeletedWith = r
 	})
 }
+
+// MaxConcurrency caps the number of operations that the engine runs concurrently
+// on the resources managed by a provider resource, for example to stay within an API's rate limits.
+// It only applies to provider resources.
+func MaxConcurrency(n int) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.MaxConcurrency = n
+	})
+}
+
+// Hooks sets shell commands that the engine runs before and after the resource is created, updated or deleted.
+// Hooks are saved in the state of the resource. When the resource is removed from the program or destroyed,
+// its delete hooks are read from the state, and only run if the update or destroy is given --run-state-hooks.
+func Hooks(h *ResourceHooks) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.Hooks = h
+	})
+}
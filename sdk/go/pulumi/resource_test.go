@@ -1128,6 +1128,11 @@ func TestNewResourceOptions(t *testing.T) {
 			give: DeletedWith(&testRes{foo: "a"}),
 			want: ResourceOptions{DeletedWith: &testRes{foo: "a"}},
 		},
+		{
+			desc: "Hooks",
+			give: Hooks(&ResourceHooks{BeforeDelete: []string{"./backup.sh"}}),
+			want: ResourceOptions{Hooks: &ResourceHooks{BeforeDelete: []string{"./backup.sh"}}},
+		},
 	}
 
 	for _, tt := range tests {
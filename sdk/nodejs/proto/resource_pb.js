@@ -1238,7 +1238,7 @@ proto.pulumirpc.ReadResourceResponse.prototype.hasProperties = function() {
  * @private {!Array<number>}
  * @const
  */
-proto.pulumirpc.RegisterResourceRequest.repeatedFields_ = [7,12,14,15,23,26];
+proto.pulumirpc.RegisterResourceRequest.repeatedFields_ = [7,12,14,15,23,26,29,30,31,32,33,34];
 
 
 
@@ -1298,7 +1298,14 @@ proto.pulumirpc.RegisterResourceRequest.toObject = function(includeInstance, msg
     retainondelete: jspb.Message.getBooleanFieldWithDefault(msg, 25, false),
     aliasesList: jspb.Message.toObjectList(msg.getAliasesList(),
     pulumi_alias_pb.Alias.toObject, includeInstance),
-    deletedwith: jspb.Message.getFieldWithDefault(msg, 27, "")
+    deletedwith: jspb.Message.getFieldWithDefault(msg, 27, ""),
+    maxconcurrency: jspb.Message.getFieldWithDefault(msg, 28, 0),
+    beforecreatehooksList: (f = jspb.Message.getRepeatedField(msg, 29)) == null ? undefined : f,
+    aftercreatehooksList: (f = jspb.Message.getRepeatedField(msg, 30)) == null ? undefined : f,
+    beforeupdatehooksList: (f = jspb.Message.getRepeatedField(msg, 31)) == null ? undefined : f,
+    afterupdatehooksList: (f = jspb.Message.getRepeatedField(msg, 32)) == null ? undefined : f,
+    beforedeletehooksList: (f = jspb.Message.getRepeatedField(msg, 33)) == null ? undefined : f,
+    afterdeletehooksList: (f = jspb.Message.getRepeatedField(msg, 34)) == null ? undefined : f
   };
 
   if (includeInstance) {
@@ -1450,6 +1457,34 @@ proto.pulumirpc.RegisterResourceRequest.deserializeBinaryFromReader = function(m
       var value = /** @type {string} */ (reader.readString());
       msg.setDeletedwith(value);
       break;
+    case 28:
+      var value = /** @type {number} */ (reader.readInt32());
+      msg.setMaxconcurrency(value);
+      break;
+    case 29:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addBeforecreatehooks(value);
+      break;
+    case 30:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addAftercreatehooks(value);
+      break;
+    case 31:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addBeforeupdatehooks(value);
+      break;
+    case 32:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addAfterupdatehooks(value);
+      break;
+    case 33:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addBeforedeletehooks(value);
+      break;
+    case 34:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addAfterdeletehooks(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -1665,6 +1700,55 @@ proto.pulumirpc.RegisterResourceRequest.serializeBinaryToWriter = function(messa
       f
     );
   }
+  f = message.getMaxconcurrency();
+  if (f !== 0) {
+    writer.writeInt32(
+      28,
+      f
+    );
+  }
+  f = message.getBeforecreatehooksList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      29,
+      f
+    );
+  }
+  f = message.getAftercreatehooksList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      30,
+      f
+    );
+  }
+  f = message.getBeforeupdatehooksList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      31,
+      f
+    );
+  }
+  f = message.getAfterupdatehooksList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      32,
+      f
+    );
+  }
+  f = message.getBeforedeletehooksList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      33,
+      f
+    );
+  }
+  f = message.getAfterdeletehooksList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      34,
+      f
+    );
+  }
 };
 
 
@@ -2661,6 +2745,246 @@ proto.pulumirpc.RegisterResourceRequest.prototype.setDeletedwith = function(valu
 };
 
 
+/**
+ * optional int32 maxConcurrency = 28;
+ * @return {number}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getMaxconcurrency = function() {
+  return /** @type {number} */ (jspb.Message.getFieldWithDefault(this, 28, 0));
+};
+
+
+/**
+ * @param {number} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setMaxconcurrency = function(value) {
+  return jspb.Message.setProto3IntField(this, 28, value);
+};
+
+
+/**
+ * repeated string beforeCreateHooks = 29;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getBeforecreatehooksList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 29));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setBeforecreatehooksList = function(value) {
+  return jspb.Message.setField(this, 29, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addBeforecreatehooks = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 29, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearBeforecreatehooksList = function() {
+  return this.setBeforecreatehooksList([]);
+};
+
+
+/**
+ * repeated string afterCreateHooks = 30;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getAftercreatehooksList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 30));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setAftercreatehooksList = function(value) {
+  return jspb.Message.setField(this, 30, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addAftercreatehooks = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 30, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearAftercreatehooksList = function() {
+  return this.setAftercreatehooksList([]);
+};
+
+
+/**
+ * repeated string beforeUpdateHooks = 31;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getBeforeupdatehooksList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 31));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setBeforeupdatehooksList = function(value) {
+  return jspb.Message.setField(this, 31, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addBeforeupdatehooks = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 31, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearBeforeupdatehooksList = function() {
+  return this.setBeforeupdatehooksList([]);
+};
+
+
+/**
+ * repeated string afterUpdateHooks = 32;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getAfterupdatehooksList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 32));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setAfterupdatehooksList = function(value) {
+  return jspb.Message.setField(this, 32, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addAfterupdatehooks = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 32, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearAfterupdatehooksList = function() {
+  return this.setAfterupdatehooksList([]);
+};
+
+
+/**
+ * repeated string beforeDeleteHooks = 33;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getBeforedeletehooksList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 33));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setBeforedeletehooksList = function(value) {
+  return jspb.Message.setField(this, 33, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addBeforedeletehooks = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 33, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearBeforedeletehooksList = function() {
+  return this.setBeforedeletehooksList([]);
+};
+
+
+/**
+ * repeated string afterDeleteHooks = 34;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getAfterdeletehooksList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 34));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setAfterdeletehooksList = function(value) {
+  return jspb.Message.setField(this, 34, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addAfterdeletehooks = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 34, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearAfterdeletehooksList = function() {
+  return this.setAfterdeletehooksList([]);
+};
+
+
 
 /**
  * List of repeated fields within this message type.
@@ -0,0 +1,97 @@
+// GENERATED CODE -- DO NOT EDIT!
+
+// Original file comments:
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+'use strict';
+var grpc = require('@grpc/grpc-js');
+var pulumi_secrets_pb = require('./secrets_pb.js');
+
+function serialize_pulumirpc_DecryptRequest(arg) {
+  if (!(arg instanceof pulumi_secrets_pb.DecryptRequest)) {
+    throw new Error('Expected argument of type pulumirpc.DecryptRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_pulumirpc_DecryptRequest(buffer_arg) {
+  return pulumi_secrets_pb.DecryptRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_pulumirpc_DecryptResponse(arg) {
+  if (!(arg instanceof pulumi_secrets_pb.DecryptResponse)) {
+    throw new Error('Expected argument of type pulumirpc.DecryptResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_pulumirpc_DecryptResponse(buffer_arg) {
+  return pulumi_secrets_pb.DecryptResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_pulumirpc_EncryptRequest(arg) {
+  if (!(arg instanceof pulumi_secrets_pb.EncryptRequest)) {
+    throw new Error('Expected argument of type pulumirpc.EncryptRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_pulumirpc_EncryptRequest(buffer_arg) {
+  return pulumi_secrets_pb.EncryptRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_pulumirpc_EncryptResponse(arg) {
+  if (!(arg instanceof pulumi_secrets_pb.EncryptResponse)) {
+    throw new Error('Expected argument of type pulumirpc.EncryptResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_pulumirpc_EncryptResponse(buffer_arg) {
+  return pulumi_secrets_pb.EncryptResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+
+// SecretsProvider is a service for encrypting and decrypting the keys that protect the secrets in a stack's state.
+// A secrets provider plugin is used for any secrets provider URL whose scheme isn't natively supported, and is named
+// after that scheme. This is currently unstable and experimental.
+var SecretsProviderService = exports.SecretsProviderService = {
+  // Encrypt encrypts a plaintext with the key identified by the given URL.
+encrypt: {
+    path: '/pulumirpc.SecretsProvider/Encrypt',
+    requestStream: false,
+    responseStream: false,
+    requestType: pulumi_secrets_pb.EncryptRequest,
+    responseType: pulumi_secrets_pb.EncryptResponse,
+    requestSerialize: serialize_pulumirpc_EncryptRequest,
+    requestDeserialize: deserialize_pulumirpc_EncryptRequest,
+    responseSerialize: serialize_pulumirpc_EncryptResponse,
+    responseDeserialize: deserialize_pulumirpc_EncryptResponse,
+  },
+  // Decrypt decrypts a ciphertext previously returned by Encrypt for the same URL.
+decrypt: {
+    path: '/pulumirpc.SecretsProvider/Decrypt',
+    requestStream: false,
+    responseStream: false,
+    requestType: pulumi_secrets_pb.DecryptRequest,
+    responseType: pulumi_secrets_pb.DecryptResponse,
+    requestSerialize: serialize_pulumirpc_DecryptRequest,
+    requestDeserialize: deserialize_pulumirpc_DecryptRequest,
+    responseSerialize: serialize_pulumirpc_DecryptResponse,
+    responseDeserialize: deserialize_pulumirpc_DecryptResponse,
+  },
+};
+
+exports.SecretsProviderClient = grpc.makeGenericClientConstructor(SecretsProviderService);
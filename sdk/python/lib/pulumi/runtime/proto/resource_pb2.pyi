@@ -272,6 +272,13 @@ class RegisterResourceRequest(google.protobuf.message.Message):
     RETAINONDELETE_FIELD_NUMBER: builtins.int
     ALIASES_FIELD_NUMBER: builtins.int
     DELETEDWITH_FIELD_NUMBER: builtins.int
+    MAXCONCURRENCY_FIELD_NUMBER: builtins.int
+    BEFORECREATEHOOKS_FIELD_NUMBER: builtins.int
+    AFTERCREATEHOOKS_FIELD_NUMBER: builtins.int
+    BEFOREUPDATEHOOKS_FIELD_NUMBER: builtins.int
+    AFTERUPDATEHOOKS_FIELD_NUMBER: builtins.int
+    BEFOREDELETEHOOKS_FIELD_NUMBER: builtins.int
+    AFTERDELETEHOOKS_FIELD_NUMBER: builtins.int
     type: builtins.str
     """the type of the object allocated."""
     name: builtins.str
@@ -336,6 +343,26 @@ class RegisterResourceRequest(google.protobuf.message.Message):
         """a list of additional aliases that should be considered the same."""
     deletedWith: builtins.str
     """if set the engine will not call the resource providers delete method for this resource when specified resource is deleted."""
+    maxConcurrency: builtins.int
+    """the maximum number of concurrent operations on the resources managed by this provider resource."""
+    @property
+    def beforeCreateHooks(self) -> google.protobuf.internal.containers.RepeatedScalarFieldContainer[builtins.str]:
+        """the commands to run before creating this resource."""
+    @property
+    def afterCreateHooks(self) -> google.protobuf.internal.containers.RepeatedScalarFieldContainer[builtins.str]:
+        """the commands to run after creating this resource."""
+    @property
+    def beforeUpdateHooks(self) -> google.protobuf.internal.containers.RepeatedScalarFieldContainer[builtins.str]:
+        """the commands to run before updating this resource."""
+    @property
+    def afterUpdateHooks(self) -> google.protobuf.internal.containers.RepeatedScalarFieldContainer[builtins.str]:
+        """the commands to run after updating this resource."""
+    @property
+    def beforeDeleteHooks(self) -> google.protobuf.internal.containers.RepeatedScalarFieldContainer[builtins.str]:
+        """the commands to run before deleting this resource."""
+    @property
+    def afterDeleteHooks(self) -> google.protobuf.internal.containers.RepeatedScalarFieldContainer[builtins.str]:
+        """the commands to run after deleting this resource."""
     def __init__(
         self,
         *,
@@ -366,9 +393,16 @@ class RegisterResourceRequest(google.protobuf.message.Message):
         retainOnDelete: builtins.bool = ...,
         aliases: collections.abc.Iterable[pulumi.alias_pb2.Alias] | None = ...,
         deletedWith: builtins.str = ...,
+        maxConcurrency: builtins.int = ...,
+        beforeCreateHooks: collections.abc.Iterable[builtins.str] | None = ...,
+        afterCreateHooks: collections.abc.Iterable[builtins.str] | None = ...,
+        beforeUpdateHooks: collections.abc.Iterable[builtins.str] | None = ...,
+        afterUpdateHooks: collections.abc.Iterable[builtins.str] | None = ...,
+        beforeDeleteHooks: collections.abc.Iterable[builtins.str] | None = ...,
+        afterDeleteHooks: collections.abc.Iterable[builtins.str] | None = ...,
     ) -> None: ...
     def HasField(self, field_name: typing_extensions.Literal["customTimeouts", b"customTimeouts", "object", b"object"]) -> builtins.bool: ...
-    def ClearField(self, field_name: typing_extensions.Literal["acceptResources", b"acceptResources", "acceptSecrets", b"acceptSecrets", "additionalSecretOutputs", b"additionalSecretOutputs", "aliasURNs", b"aliasURNs", "aliases", b"aliases", "custom", b"custom", "customTimeouts", b"customTimeouts", "deleteBeforeReplace", b"deleteBeforeReplace", "deleteBeforeReplaceDefined", b"deleteBeforeReplaceDefined", "deletedWith", b"deletedWith", "dependencies", b"dependencies", "ignoreChanges", b"ignoreChanges", "importId", b"importId", "name", b"name", "object", b"object", "parent", b"parent", "pluginDownloadURL", b"pluginDownloadURL", "propertyDependencies", b"propertyDependencies", "protect", b"protect", "provider", b"provider", "providers", b"providers", "remote", b"remote", "replaceOnChanges", b"replaceOnChanges", "retainOnDelete", b"retainOnDelete", "supportsPartialValues", b"supportsPartialValues", "type", b"type", "version", b"version"]) -> None: ...
+    def ClearField(self, field_name: typing_extensions.Literal["acceptResources", b"acceptResources", "acceptSecrets", b"acceptSecrets", "additionalSecretOutputs", b"additionalSecretOutputs", "afterCreateHooks", b"afterCreateHooks", "afterDeleteHooks", b"afterDeleteHooks", "afterUpdateHooks", b"afterUpdateHooks", "aliasURNs", b"aliasURNs", "aliases", b"aliases", "beforeCreateHooks", b"beforeCreateHooks", "beforeDeleteHooks", b"beforeDeleteHooks", "beforeUpdateHooks", b"beforeUpdateHooks", "custom", b"custom", "customTimeouts", b"customTimeouts", "deleteBeforeReplace", b"deleteBeforeReplace", "deleteBeforeReplaceDefined", b"deleteBeforeReplaceDefined", "deletedWith", b"deletedWith", "dependencies", b"dependencies", "ignoreChanges", b"ignoreChanges", "importId", b"importId", "maxConcurrency", b"maxConcurrency", "name", b"name", "object", b"object", "parent", b"parent", "pluginDownloadURL", b"pluginDownloadURL", "propertyDependencies", b"propertyDependencies", "protect", b"protect", "provider", b"provider", "providers", b"providers", "remote", b"remote", "replaceOnChanges", b"replaceOnChanges", "retainOnDelete", b"retainOnDelete", "supportsPartialValues", b"supportsPartialValues", "type", b"type", "version", b"version"]) -> None: ...
 
 global___RegisterResourceRequest = RegisterResourceRequest
 
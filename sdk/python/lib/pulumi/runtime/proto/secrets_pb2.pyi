@@ -0,0 +1,100 @@
+"""
+@generated by mypy-protobuf.  Do not edit manually!
+isort:skip_file
+Copyright 2016-2023, Pulumi Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+"""
+import builtins
+import google.protobuf.descriptor
+import google.protobuf.message
+import sys
+
+if sys.version_info >= (3, 8):
+    import typing as typing_extensions
+else:
+    import typing_extensions
+
+DESCRIPTOR: google.protobuf.descriptor.FileDescriptor
+
+@typing_extensions.final
+class EncryptRequest(google.protobuf.message.Message):
+    DESCRIPTOR: google.protobuf.descriptor.Descriptor
+
+    URL_FIELD_NUMBER: builtins.int
+    PLAINTEXT_FIELD_NUMBER: builtins.int
+    url: builtins.str
+    """the URL of the secrets provider, which identifies the key to encrypt with."""
+    plaintext: builtins.bytes
+    """the plaintext to encrypt."""
+    def __init__(
+        self,
+        *,
+        url: builtins.str = ...,
+        plaintext: builtins.bytes = ...,
+    ) -> None: ...
+    def ClearField(self, field_name: typing_extensions.Literal["plaintext", b"plaintext", "url", b"url"]) -> None: ...
+
+global___EncryptRequest = EncryptRequest
+
+@typing_extensions.final
+class EncryptResponse(google.protobuf.message.Message):
+    DESCRIPTOR: google.protobuf.descriptor.Descriptor
+
+    CIPHERTEXT_FIELD_NUMBER: builtins.int
+    ciphertext: builtins.bytes
+    """the encrypted plaintext."""
+    def __init__(
+        self,
+        *,
+        ciphertext: builtins.bytes = ...,
+    ) -> None: ...
+    def ClearField(self, field_name: typing_extensions.Literal["ciphertext", b"ciphertext"]) -> None: ...
+
+global___EncryptResponse = EncryptResponse
+
+@typing_extensions.final
+class DecryptRequest(google.protobuf.message.Message):
+    DESCRIPTOR: google.protobuf.descriptor.Descriptor
+
+    URL_FIELD_NUMBER: builtins.int
+    CIPHERTEXT_FIELD_NUMBER: builtins.int
+    url: builtins.str
+    """the URL of the secrets provider, which identifies the key to decrypt with."""
+    ciphertext: builtins.bytes
+    """the ciphertext to decrypt."""
+    def __init__(
+        self,
+        *,
+        url: builtins.str = ...,
+        ciphertext: builtins.bytes = ...,
+    ) -> None: ...
+    def ClearField(self, field_name: typing_extensions.Literal["ciphertext", b"ciphertext", "url", b"url"]) -> None: ...
+
+global___DecryptRequest = DecryptRequest
+
+@typing_extensions.final
+class DecryptResponse(google.protobuf.message.Message):
+    DESCRIPTOR: google.protobuf.descriptor.Descriptor
+
+    PLAINTEXT_FIELD_NUMBER: builtins.int
+    plaintext: builtins.bytes
+    """the decrypted ciphertext."""
+    def __init__(
+        self,
+        *,
+        plaintext: builtins.bytes = ...,
+    ) -> None: ...
+    def ClearField(self, field_name: typing_extensions.Literal["plaintext", b"plaintext"]) -> None: ...
+
+global___DecryptResponse = DecryptResponse
@@ -0,0 +1,63 @@
+"""
+@generated by mypy-protobuf.  Do not edit manually!
+isort:skip_file
+Copyright 2016-2023, Pulumi Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+"""
+import abc
+import grpc
+import grpc.aio
+import typing
+import pulumi.secrets_pb2
+
+class SecretsProviderStub:
+    """SecretsProvider is a service for encrypting and decrypting the keys that protect the secrets in a stack's state.
+    A secrets provider plugin is used for any secrets provider URL whose scheme isn't natively supported, and is named
+    after that scheme. This is currently unstable and experimental.
+    """
+
+    def __init__(self, channel: grpc.Channel) -> None: ...
+    Encrypt: grpc.UnaryUnaryMultiCallable[
+        pulumi.secrets_pb2.EncryptRequest,
+        pulumi.secrets_pb2.EncryptResponse,
+    ]
+    """Encrypt encrypts a plaintext with the key identified by the given URL."""
+    Decrypt: grpc.UnaryUnaryMultiCallable[
+        pulumi.secrets_pb2.DecryptRequest,
+        pulumi.secrets_pb2.DecryptResponse,
+    ]
+    """Decrypt decrypts a ciphertext previously returned by Encrypt for the same URL."""
+
+class SecretsProviderServicer(metaclass=abc.ABCMeta):
+    """SecretsProvider is a service for encrypting and decrypting the keys that protect the secrets in a stack's state.
+    A secrets provider plugin is used for any secrets provider URL whose scheme isn't natively supported, and is named
+    after that scheme. This is currently unstable and experimental.
+    """
+
+    
+    def Encrypt(
+        self,
+        request: pulumi.secrets_pb2.EncryptRequest,
+        context: grpc.ServicerContext,
+    ) -> pulumi.secrets_pb2.EncryptResponse:
+        """Encrypt encrypts a plaintext with the key identified by the given URL."""
+    
+    def Decrypt(
+        self,
+        request: pulumi.secrets_pb2.DecryptRequest,
+        context: grpc.ServicerContext,
+    ) -> pulumi.secrets_pb2.DecryptResponse:
+        """Decrypt decrypts a ciphertext previously returned by Encrypt for the same URL."""
+
+def add_SecretsProviderServicer_to_server(servicer: SecretsProviderServicer, server: typing.Union[grpc.Server, grpc.aio.Server]) -> None: ...